@@ -41,4 +41,36 @@ var (
 		Value: 1000,
 		Usage: "batch size for reading history record from the database",
 	}
+	AccountIndexFlag = &cli.Int64Flag{
+		Name:  "account",
+		Value: -1,
+		Usage: "account index",
+	}
+	AssetIdFlag = &cli.Int64Flag{
+		Name:  "asset",
+		Value: -1,
+		Usage: "asset id, all the assets with balances of the account if not set",
+	}
+	NftIndexFlag = &cli.Int64Flag{
+		Name:  "nft",
+		Value: -1,
+		Usage: "nft index",
+	}
+	AllFlag = &cli.BoolFlag{
+		Name:  "all",
+		Usage: "generate for all the accounts",
+	}
+	GasAssetsVersionFlag = &cli.Int64Flag{
+		Name:  "version",
+		Usage: "the new version of the gas assets, above the latest one",
+	}
+	GasAssetIdsFlag = &cli.Int64SliceFlag{
+		Name:  "assets",
+		Usage: "the asset ids of the new gas assets version, e.g. 0,1,2",
+	}
+	OutputFlag = &cli.StringFlag{
+		Name:    "output",
+		Aliases: []string{"o"},
+		Usage:   "the output file, or the output directory with --all",
+	}
 )
@@ -15,6 +15,9 @@ import (
 	"github.com/bnb-chain/zkbnb/service/sender"
 	"github.com/bnb-chain/zkbnb/service/witness"
 	"github.com/bnb-chain/zkbnb/tools/dbinitializer"
+	"github.com/bnb-chain/zkbnb/tools/exodus"
+	"github.com/bnb-chain/zkbnb/tools/gasassets"
+	"github.com/bnb-chain/zkbnb/tools/reconstruction"
 	"github.com/bnb-chain/zkbnb/tools/recovery"
 )
 
@@ -55,6 +58,22 @@ func main() {
 
 					return prover.Run(cCtx.String(flags.ConfigFlag.Name))
 				},
+				Subcommands: []*cli.Command{
+					{
+						Name:  "stats",
+						Usage: "Show the block witnesses claimed by each prover",
+						Flags: []cli.Flag{
+							flags.ConfigFlag,
+						},
+						Action: func(cCtx *cli.Context) error {
+							if !cCtx.IsSet(flags.ConfigFlag.Name) {
+								return cli.ShowSubcommandHelp(cCtx)
+							}
+
+							return prover.Stats(cCtx.String(flags.ConfigFlag.Name))
+						},
+					},
+				},
 			},
 			{
 				Name:  "witness",
@@ -186,6 +205,79 @@ func main() {
 					},
 				},
 			},
+			{
+				Name:  "exodus",
+				Usage: "Generate the exit packages from the last verified state for the desert mode",
+				Flags: []cli.Flag{
+					flags.ConfigFlag,
+					flags.AccountIndexFlag,
+					flags.AssetIdFlag,
+					flags.NftIndexFlag,
+					flags.AllFlag,
+					flags.OutputFlag,
+				},
+				Action: func(cCtx *cli.Context) error {
+					if !cCtx.IsSet(flags.ConfigFlag.Name) ||
+						(!cCtx.IsSet(flags.AccountIndexFlag.Name) &&
+							!cCtx.IsSet(flags.NftIndexFlag.Name) &&
+							!cCtx.IsSet(flags.AllFlag.Name)) {
+						return cli.ShowSubcommandHelp(cCtx)
+					}
+
+					return exodus.Exodus(
+						cCtx.String(flags.ConfigFlag.Name),
+						exodus.Options{
+							AccountIndex: cCtx.Int64(flags.AccountIndexFlag.Name),
+							AssetId:      cCtx.Int64(flags.AssetIdFlag.Name),
+							NftIndex:     cCtx.Int64(flags.NftIndexFlag.Name),
+							All:          cCtx.Bool(flags.AllFlag.Name),
+							Output:       cCtx.String(flags.OutputFlag.Name),
+						},
+					)
+				},
+			},
+			{
+				Name:  "gasassets",
+				Usage: "Manage the versions of the gas assets",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "add",
+						Usage: "Append a new gas assets version, the prover config must have its circuit keys",
+						Flags: []cli.Flag{
+							flags.ConfigFlag,
+							flags.GasAssetsVersionFlag,
+							flags.GasAssetIdsFlag,
+						},
+						Action: func(cCtx *cli.Context) error {
+							if !cCtx.IsSet(flags.ConfigFlag.Name) ||
+								!cCtx.IsSet(flags.GasAssetsVersionFlag.Name) ||
+								!cCtx.IsSet(flags.GasAssetIdsFlag.Name) {
+								return cli.ShowSubcommandHelp(cCtx)
+							}
+
+							return gasassets.AddGasAssets(
+								cCtx.String(flags.ConfigFlag.Name),
+								cCtx.Int64(flags.GasAssetsVersionFlag.Name),
+								cCtx.Int64Slice(flags.GasAssetIdsFlag.Name),
+							)
+						},
+					},
+				},
+			},
+			{
+				Name:  "reconstruct",
+				Usage: "Reconstruct the state from the blocks committed on L1 into a fresh database",
+				Flags: []cli.Flag{
+					flags.ConfigFlag,
+				},
+				Action: func(cCtx *cli.Context) error {
+					if !cCtx.IsSet(flags.ConfigFlag.Name) {
+						return cli.ShowSubcommandHelp(cCtx)
+					}
+
+					return reconstruction.Reconstruct(cCtx.String(flags.ConfigFlag.Name))
+				},
+			},
 		},
 	}
 	if err := app.Run(os.Args); err != nil {
@@ -0,0 +1,394 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package chain
+
+import (
+	"fmt"
+	"math/big"
+
+	cryptoTypes "github.com/bnb-chain/zkbnb-crypto/circuit/types"
+	"github.com/bnb-chain/zkbnb-crypto/wasm/txtypes"
+	"github.com/consensys/gnark-crypto/ecc/bn254/twistededwards/eddsa"
+	"github.com/ethereum/go-ethereum/common"
+
+	common2 "github.com/bnb-chain/zkbnb/common"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+const (
+	chunkSize          = 32
+	TxPubDataBytesSize = chunkSize * cryptoTypes.PubDataSizePerTx
+)
+
+// ParseBlockPubData decodes the pub data of a block committed on L1 into the tx infos, the
+// empty txs padding the block are skipped. The tx infos only contain the fields in the pub
+// data, e.g. the nonces and the signatures are not available, and the amounts are the
+// unpacked ones.
+func ParseBlockPubData(pubData []byte) ([]txtypes.TxInfo, error) {
+	if len(pubData)%TxPubDataBytesSize != 0 {
+		return nil, fmt.Errorf("invalid block pub data size %d", len(pubData))
+	}
+	txInfos := make([]txtypes.TxInfo, 0, len(pubData)/TxPubDataBytesSize)
+	for offset := 0; offset < len(pubData); offset += TxPubDataBytesSize {
+		txPubData := pubData[offset : offset+TxPubDataBytesSize]
+		txType := int(txPubData[0])
+		if txType == types.TxTypeEmpty {
+			continue
+		}
+		txInfo, err := ParseTxPubData(txPubData)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pub data of tx %d: %v", offset/TxPubDataBytesSize, err)
+		}
+		txInfos = append(txInfos, txInfo)
+	}
+	return txInfos, nil
+}
+
+// ParseTxPubData decodes the pub data of a tx in the block, which is generated by the tx
+// executor of the tx type.
+func ParseTxPubData(pubData []byte) (txtypes.TxInfo, error) {
+	if len(pubData) != TxPubDataBytesSize {
+		return nil, fmt.Errorf("invalid tx pub data size %d", len(pubData))
+	}
+	switch int(pubData[0]) {
+	case types.TxTypeRegisterZns:
+		return parseRegisterZnsTxPubData(pubData), nil
+	case types.TxTypeDeposit:
+		return parseDepositTxPubData(pubData), nil
+	case types.TxTypeDepositNft:
+		return parseDepositNftTxPubData(pubData), nil
+	case types.TxTypeTransfer:
+		return parseTransferTxPubData(pubData), nil
+	case types.TxTypeWithdraw:
+		return parseWithdrawTxPubData(pubData), nil
+	case types.TxTypeCreateCollection:
+		return parseCreateCollectionTxPubData(pubData), nil
+	case types.TxTypeMintNft:
+		return parseMintNftTxPubData(pubData), nil
+	case types.TxTypeTransferNft:
+		return parseTransferNftTxPubData(pubData), nil
+	case types.TxTypeAtomicMatch:
+		return parseAtomicMatchTxPubData(pubData), nil
+	case types.TxTypeCancelOffer:
+		return parseCancelOfferTxPubData(pubData), nil
+	case types.TxTypeWithdrawNft:
+		return parseWithdrawNftTxPubData(pubData), nil
+	case types.TxTypeFullExit:
+		return parseFullExitTxPubData(pubData), nil
+	case types.TxTypeFullExitNft:
+		return parseFullExitNftTxPubData(pubData), nil
+	default:
+		return nil, fmt.Errorf("unknown tx type %d", pubData[0])
+	}
+}
+
+// chunkOffset returns the offset of the data with the size, which is aligned to the end of
+// the chunk.
+func chunkOffset(chunk, size int) int {
+	return (chunk+1)*chunkSize - size
+}
+
+func readPackedAmount(pubData []byte, offset int) (int, *big.Int) {
+	offset, packedAmount := common2.ReadUint40(pubData, offset)
+	return offset, common2.FromPackedAmount(packedAmount)
+}
+
+func readPackedFee(pubData []byte, offset int) (int, *big.Int) {
+	offset, packedFee := common2.ReadUint16(pubData, offset)
+	return offset, common2.FromPackedFee(int64(packedFee))
+}
+
+func parseRegisterZnsTxPubData(pubData []byte) *txtypes.RegisterZnsTxInfo {
+	_, accountIndex := common2.ReadUint32(pubData, 1)
+	_, accountName := common2.ReadBytes32(pubData, chunkSize)
+	_, accountNameHash := common2.ReadBytes32(pubData, 2*chunkSize)
+	_, pubKeyX := common2.ReadBytes32(pubData, 3*chunkSize)
+	_, pubKeyY := common2.ReadBytes32(pubData, 4*chunkSize)
+	pk := new(eddsa.PublicKey)
+	pk.A.X.SetBytes(pubKeyX)
+	pk.A.Y.SetBytes(pubKeyY)
+	return &txtypes.RegisterZnsTxInfo{
+		TxType:          types.TxTypeRegisterZns,
+		AccountIndex:    int64(accountIndex),
+		AccountName:     common2.CleanAccountName(common2.SerializeAccountName(accountName)),
+		AccountNameHash: accountNameHash,
+		PubKey:          common.Bytes2Hex(pk.Bytes()),
+	}
+}
+
+func parseDepositTxPubData(pubData []byte) *txtypes.DepositTxInfo {
+	offset, accountIndex := common2.ReadUint32(pubData, 1)
+	offset, assetId := common2.ReadUint16(pubData, offset)
+	_, amount := common2.ReadUint128(pubData, offset)
+	_, accountNameHash := common2.ReadBytes32(pubData, chunkSize)
+	return &txtypes.DepositTxInfo{
+		TxType:          types.TxTypeDeposit,
+		AccountIndex:    int64(accountIndex),
+		AccountNameHash: accountNameHash,
+		AssetId:         int64(assetId),
+		AssetAmount:     amount,
+	}
+}
+
+func parseDepositNftTxPubData(pubData []byte) *txtypes.DepositNftTxInfo {
+	offset, accountIndex := common2.ReadUint32(pubData, 1)
+	offset, nftIndex := common2.ReadUint40(pubData, offset)
+	_, nftL1Address := common2.ReadAddress(pubData, offset)
+	offset, creatorAccountIndex := common2.ReadUint32(pubData, chunkOffset(1, 8))
+	offset, creatorTreasuryRate := common2.ReadUint16(pubData, offset)
+	_, collectionId := common2.ReadUint16(pubData, offset)
+	_, nftContentHash := common2.ReadBytes32(pubData, 2*chunkSize)
+	_, nftL1TokenId := common2.ReadUint256(pubData, 3*chunkSize)
+	_, accountNameHash := common2.ReadBytes32(pubData, 4*chunkSize)
+	return &txtypes.DepositNftTxInfo{
+		TxType:              types.TxTypeDepositNft,
+		AccountIndex:        int64(accountIndex),
+		NftIndex:            nftIndex,
+		NftL1Address:        nftL1Address,
+		CreatorAccountIndex: int64(creatorAccountIndex),
+		CreatorTreasuryRate: int64(creatorTreasuryRate),
+		NftContentHash:      nftContentHash,
+		NftL1TokenId:        nftL1TokenId,
+		AccountNameHash:     accountNameHash,
+		CollectionId:        int64(collectionId),
+	}
+}
+
+func parseTransferTxPubData(pubData []byte) *txtypes.TransferTxInfo {
+	offset, fromAccountIndex := common2.ReadUint32(pubData, 1)
+	offset, toAccountIndex := common2.ReadUint32(pubData, offset)
+	offset, assetId := common2.ReadUint16(pubData, offset)
+	offset, assetAmount := readPackedAmount(pubData, offset)
+	offset, gasAccountIndex := common2.ReadUint32(pubData, offset)
+	offset, gasFeeAssetId := common2.ReadUint16(pubData, offset)
+	_, gasFeeAssetAmount := readPackedFee(pubData, offset)
+	_, callDataHash := common2.ReadBytes32(pubData, chunkSize)
+	return &txtypes.TransferTxInfo{
+		FromAccountIndex:  int64(fromAccountIndex),
+		ToAccountIndex:    int64(toAccountIndex),
+		AssetId:           int64(assetId),
+		AssetAmount:       assetAmount,
+		GasAccountIndex:   int64(gasAccountIndex),
+		GasFeeAssetId:     int64(gasFeeAssetId),
+		GasFeeAssetAmount: gasFeeAssetAmount,
+		CallDataHash:      callDataHash,
+	}
+}
+
+func parseWithdrawTxPubData(pubData []byte) *txtypes.WithdrawTxInfo {
+	offset, fromAccountIndex := common2.ReadUint32(pubData, 1)
+	offset, toAddress := common2.ReadAddress(pubData, offset)
+	_, assetId := common2.ReadUint16(pubData, offset)
+	offset, assetAmount := common2.ReadUint128(pubData, chunkOffset(1, 24))
+	offset, gasAccountIndex := common2.ReadUint32(pubData, offset)
+	offset, gasFeeAssetId := common2.ReadUint16(pubData, offset)
+	_, gasFeeAssetAmount := readPackedFee(pubData, offset)
+	return &txtypes.WithdrawTxInfo{
+		FromAccountIndex:  int64(fromAccountIndex),
+		ToAddress:         toAddress,
+		AssetId:           int64(assetId),
+		AssetAmount:       assetAmount,
+		GasAccountIndex:   int64(gasAccountIndex),
+		GasFeeAssetId:     int64(gasFeeAssetId),
+		GasFeeAssetAmount: gasFeeAssetAmount,
+	}
+}
+
+func parseCreateCollectionTxPubData(pubData []byte) *txtypes.CreateCollectionTxInfo {
+	offset, accountIndex := common2.ReadUint32(pubData, 1)
+	offset, collectionId := common2.ReadUint16(pubData, offset)
+	offset, gasAccountIndex := common2.ReadUint32(pubData, offset)
+	offset, gasFeeAssetId := common2.ReadUint16(pubData, offset)
+	_, gasFeeAssetAmount := readPackedFee(pubData, offset)
+	return &txtypes.CreateCollectionTxInfo{
+		AccountIndex:      int64(accountIndex),
+		CollectionId:      int64(collectionId),
+		GasAccountIndex:   int64(gasAccountIndex),
+		GasFeeAssetId:     int64(gasFeeAssetId),
+		GasFeeAssetAmount: gasFeeAssetAmount,
+	}
+}
+
+func parseMintNftTxPubData(pubData []byte) *txtypes.MintNftTxInfo {
+	offset, creatorAccountIndex := common2.ReadUint32(pubData, 1)
+	offset, toAccountIndex := common2.ReadUint32(pubData, offset)
+	offset, nftIndex := common2.ReadUint40(pubData, offset)
+	offset, gasAccountIndex := common2.ReadUint32(pubData, offset)
+	offset, gasFeeAssetId := common2.ReadUint16(pubData, offset)
+	offset, gasFeeAssetAmount := readPackedFee(pubData, offset)
+	offset, creatorTreasuryRate := common2.ReadUint16(pubData, offset)
+	_, collectionId := common2.ReadUint16(pubData, offset)
+	_, nftContentHash := common2.ReadBytes32(pubData, chunkSize)
+	return &txtypes.MintNftTxInfo{
+		CreatorAccountIndex: int64(creatorAccountIndex),
+		ToAccountIndex:      int64(toAccountIndex),
+		NftIndex:            nftIndex,
+		NftContentHash:      common.Bytes2Hex(nftContentHash),
+		NftCollectionId:     int64(collectionId),
+		CreatorTreasuryRate: int64(creatorTreasuryRate),
+		GasAccountIndex:     int64(gasAccountIndex),
+		GasFeeAssetId:       int64(gasFeeAssetId),
+		GasFeeAssetAmount:   gasFeeAssetAmount,
+	}
+}
+
+func parseTransferNftTxPubData(pubData []byte) *txtypes.TransferNftTxInfo {
+	offset, fromAccountIndex := common2.ReadUint32(pubData, 1)
+	offset, toAccountIndex := common2.ReadUint32(pubData, offset)
+	offset, nftIndex := common2.ReadUint40(pubData, offset)
+	offset, gasAccountIndex := common2.ReadUint32(pubData, offset)
+	offset, gasFeeAssetId := common2.ReadUint16(pubData, offset)
+	_, gasFeeAssetAmount := readPackedFee(pubData, offset)
+	_, callDataHash := common2.ReadBytes32(pubData, chunkSize)
+	return &txtypes.TransferNftTxInfo{
+		FromAccountIndex:  int64(fromAccountIndex),
+		ToAccountIndex:    int64(toAccountIndex),
+		NftIndex:          nftIndex,
+		GasAccountIndex:   int64(gasAccountIndex),
+		GasFeeAssetId:     int64(gasFeeAssetId),
+		GasFeeAssetAmount: gasFeeAssetAmount,
+		CallDataHash:      callDataHash,
+	}
+}
+
+// parseAtomicMatchTxPubData decodes the atomic match, the offers share the nft and the asset.
+func parseAtomicMatchTxPubData(pubData []byte) *txtypes.AtomicMatchTxInfo {
+	offset, accountIndex := common2.ReadUint32(pubData, 1)
+	offset, buyAccountIndex := common2.ReadUint32(pubData, offset)
+	offset, buyOfferId := common2.ReadUint24(pubData, offset)
+	offset, sellAccountIndex := common2.ReadUint32(pubData, offset)
+	offset, sellOfferId := common2.ReadUint24(pubData, offset)
+	offset, nftIndex := common2.ReadUint40(pubData, offset)
+	_, assetId := common2.ReadUint16(pubData, offset)
+	offset, assetAmount := readPackedAmount(pubData, chunkOffset(1, 23))
+	offset, creatorAmount := readPackedAmount(pubData, offset)
+	offset, treasuryAmount := readPackedAmount(pubData, offset)
+	offset, gasAccountIndex := common2.ReadUint32(pubData, offset)
+	offset, gasFeeAssetId := common2.ReadUint16(pubData, offset)
+	_, gasFeeAssetAmount := readPackedFee(pubData, offset)
+	return &txtypes.AtomicMatchTxInfo{
+		AccountIndex: int64(accountIndex),
+		BuyOffer: &txtypes.OfferTxInfo{
+			Type:         types.BuyOfferType,
+			OfferId:      buyOfferId,
+			AccountIndex: int64(buyAccountIndex),
+			NftIndex:     nftIndex,
+			AssetId:      int64(assetId),
+			AssetAmount:  assetAmount,
+		},
+		SellOffer: &txtypes.OfferTxInfo{
+			Type:         types.SellOfferType,
+			OfferId:      sellOfferId,
+			AccountIndex: int64(sellAccountIndex),
+			NftIndex:     nftIndex,
+			AssetId:      int64(assetId),
+			AssetAmount:  assetAmount,
+		},
+		GasAccountIndex:   int64(gasAccountIndex),
+		GasFeeAssetId:     int64(gasFeeAssetId),
+		GasFeeAssetAmount: gasFeeAssetAmount,
+		CreatorAmount:     creatorAmount,
+		TreasuryAmount:    treasuryAmount,
+	}
+}
+
+func parseCancelOfferTxPubData(pubData []byte) *txtypes.CancelOfferTxInfo {
+	offset, accountIndex := common2.ReadUint32(pubData, 1)
+	offset, offerId := common2.ReadUint24(pubData, offset)
+	offset, gasAccountIndex := common2.ReadUint32(pubData, offset)
+	offset, gasFeeAssetId := common2.ReadUint16(pubData, offset)
+	_, gasFeeAssetAmount := readPackedFee(pubData, offset)
+	return &txtypes.CancelOfferTxInfo{
+		AccountIndex:      int64(accountIndex),
+		OfferId:           offerId,
+		GasAccountIndex:   int64(gasAccountIndex),
+		GasFeeAssetId:     int64(gasFeeAssetId),
+		GasFeeAssetAmount: gasFeeAssetAmount,
+	}
+}
+
+func parseWithdrawNftTxPubData(pubData []byte) *txtypes.WithdrawNftTxInfo {
+	offset, accountIndex := common2.ReadUint32(pubData, 1)
+	offset, creatorAccountIndex := common2.ReadUint32(pubData, offset)
+	offset, creatorTreasuryRate := common2.ReadUint16(pubData, offset)
+	offset, nftIndex := common2.ReadUint40(pubData, offset)
+	_, collectionId := common2.ReadUint16(pubData, offset)
+	_, nftL1Address := common2.ReadAddress(pubData, chunkOffset(1, types.AddressBytesSize))
+	offset, toAddress := common2.ReadAddress(pubData, chunkOffset(2, 28))
+	offset, gasAccountIndex := common2.ReadUint32(pubData, offset)
+	offset, gasFeeAssetId := common2.ReadUint16(pubData, offset)
+	_, gasFeeAssetAmount := readPackedFee(pubData, offset)
+	_, nftContentHash := common2.ReadBytes32(pubData, 3*chunkSize)
+	_, nftL1TokenId := common2.ReadUint256(pubData, 4*chunkSize)
+	_, creatorAccountNameHash := common2.ReadBytes32(pubData, 5*chunkSize)
+	return &txtypes.WithdrawNftTxInfo{
+		AccountIndex:           int64(accountIndex),
+		CreatorAccountIndex:    int64(creatorAccountIndex),
+		CreatorAccountNameHash: creatorAccountNameHash,
+		CreatorTreasuryRate:    int64(creatorTreasuryRate),
+		NftIndex:               nftIndex,
+		NftContentHash:         nftContentHash,
+		NftL1Address:           nftL1Address,
+		NftL1TokenId:           nftL1TokenId,
+		CollectionId:           int64(collectionId),
+		ToAddress:              toAddress,
+		GasAccountIndex:        int64(gasAccountIndex),
+		GasFeeAssetId:          int64(gasFeeAssetId),
+		GasFeeAssetAmount:      gasFeeAssetAmount,
+	}
+}
+
+func parseFullExitTxPubData(pubData []byte) *txtypes.FullExitTxInfo {
+	offset, accountIndex := common2.ReadUint32(pubData, 1)
+	offset, assetId := common2.ReadUint16(pubData, offset)
+	_, assetAmount := common2.ReadUint128(pubData, offset)
+	_, accountNameHash := common2.ReadBytes32(pubData, chunkSize)
+	return &txtypes.FullExitTxInfo{
+		TxType:          types.TxTypeFullExit,
+		AccountIndex:    int64(accountIndex),
+		AccountNameHash: accountNameHash,
+		AssetId:         int64(assetId),
+		AssetAmount:     assetAmount,
+	}
+}
+
+func parseFullExitNftTxPubData(pubData []byte) *txtypes.FullExitNftTxInfo {
+	offset, accountIndex := common2.ReadUint32(pubData, 1)
+	offset, creatorAccountIndex := common2.ReadUint32(pubData, offset)
+	offset, creatorTreasuryRate := common2.ReadUint16(pubData, offset)
+	offset, nftIndex := common2.ReadUint40(pubData, offset)
+	_, collectionId := common2.ReadUint16(pubData, offset)
+	_, nftL1Address := common2.ReadAddress(pubData, chunkOffset(1, types.AddressBytesSize))
+	_, accountNameHash := common2.ReadBytes32(pubData, 2*chunkSize)
+	_, creatorAccountNameHash := common2.ReadBytes32(pubData, 3*chunkSize)
+	_, nftContentHash := common2.ReadBytes32(pubData, 4*chunkSize)
+	_, nftL1TokenId := common2.ReadUint256(pubData, 5*chunkSize)
+	return &txtypes.FullExitNftTxInfo{
+		TxType:                 types.TxTypeFullExitNft,
+		AccountIndex:           int64(accountIndex),
+		CreatorAccountIndex:    int64(creatorAccountIndex),
+		CreatorTreasuryRate:    int64(creatorTreasuryRate),
+		NftIndex:               nftIndex,
+		CollectionId:           int64(collectionId),
+		NftL1Address:           nftL1Address,
+		AccountNameHash:        accountNameHash,
+		CreatorAccountNameHash: creatorAccountNameHash,
+		NftContentHash:         nftContentHash,
+		NftL1TokenId:           nftL1TokenId,
+	}
+}
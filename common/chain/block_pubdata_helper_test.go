@@ -0,0 +1,173 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package chain
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/bnb-chain/zkbnb-crypto/wasm/txtypes"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	common2 "github.com/bnb-chain/zkbnb/common"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+func padTxPubData(chunks ...[]byte) []byte {
+	var buf bytes.Buffer
+	for _, chunk := range chunks {
+		buf.Write(chunk)
+	}
+	for i := len(chunks); i < 6; i++ {
+		buf.Write(common2.PrefixPaddingBufToChunkSize([]byte{}))
+	}
+	return buf.Bytes()
+}
+
+func transferTxPubData(t *testing.T) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte(uint8(types.TxTypeTransfer))
+	buf.Write(common2.Uint32ToBytes(2))
+	buf.Write(common2.Uint32ToBytes(3))
+	buf.Write(common2.Uint16ToBytes(1))
+	packedAmount, err := common2.AmountToPackedAmountBytes(big.NewInt(123400000))
+	require.NoError(t, err)
+	buf.Write(packedAmount)
+	buf.Write(common2.Uint32ToBytes(uint32(types.GasAccount)))
+	buf.Write(common2.Uint16ToBytes(0))
+	packedFee, err := common2.FeeToPackedFeeBytes(big.NewInt(5000))
+	require.NoError(t, err)
+	buf.Write(packedFee)
+	return padTxPubData(
+		common2.SuffixPaddingBufToChunkSize(buf.Bytes()),
+		common2.PrefixPaddingBufToChunkSize(bytes.Repeat([]byte{0xab}, 32)),
+	)
+}
+
+func atomicMatchTxPubData(t *testing.T) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte(uint8(types.TxTypeAtomicMatch))
+	buf.Write(common2.Uint32ToBytes(4))
+	buf.Write(common2.Uint32ToBytes(2))
+	buf.Write(common2.Uint24ToBytes(129))
+	buf.Write(common2.Uint32ToBytes(3))
+	buf.Write(common2.Uint24ToBytes(7))
+	buf.Write(common2.Uint40ToBytes(11))
+	buf.Write(common2.Uint16ToBytes(1))
+	chunk1 := common2.SuffixPaddingBufToChunkSize(buf.Bytes())
+	buf.Reset()
+	for _, amount := range []int64{10000, 300, 200} {
+		packedAmount, err := common2.AmountToPackedAmountBytes(big.NewInt(amount))
+		require.NoError(t, err)
+		buf.Write(packedAmount)
+	}
+	buf.Write(common2.Uint32ToBytes(uint32(types.GasAccount)))
+	buf.Write(common2.Uint16ToBytes(0))
+	packedFee, err := common2.FeeToPackedFeeBytes(big.NewInt(12))
+	require.NoError(t, err)
+	buf.Write(packedFee)
+	return padTxPubData(chunk1, common2.PrefixPaddingBufToChunkSize(buf.Bytes()))
+}
+
+func withdrawNftTxPubData(t *testing.T) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte(uint8(types.TxTypeWithdrawNft))
+	buf.Write(common2.Uint32ToBytes(3))
+	buf.Write(common2.Uint32ToBytes(2))
+	buf.Write(common2.Uint16ToBytes(30))
+	buf.Write(common2.Uint40ToBytes(11))
+	buf.Write(common2.Uint16ToBytes(1))
+	chunk1 := common2.SuffixPaddingBufToChunkSize(buf.Bytes())
+	buf.Reset()
+	chunk2 := common2.PrefixPaddingBufToChunkSize(common2.AddressStrToBytes("0x1111111111111111111111111111111111111111"))
+	buf.Write(common2.AddressStrToBytes("0x2222222222222222222222222222222222222222"))
+	buf.Write(common2.Uint32ToBytes(uint32(types.GasAccount)))
+	buf.Write(common2.Uint16ToBytes(0))
+	packedFee, err := common2.FeeToPackedFeeBytes(big.NewInt(5000))
+	require.NoError(t, err)
+	buf.Write(packedFee)
+	chunk3 := common2.PrefixPaddingBufToChunkSize(buf.Bytes())
+	return padTxPubData(chunk1, chunk2, chunk3,
+		common2.PrefixPaddingBufToChunkSize(bytes.Repeat([]byte{0xcd}, 32)),
+		common2.Uint256ToBytes(big.NewInt(42)),
+		common2.PrefixPaddingBufToChunkSize(bytes.Repeat([]byte{0xef}, 32)),
+	)
+}
+
+func TestParseBlockPubData(t *testing.T) {
+	var pubData []byte
+	pubData = append(pubData, transferTxPubData(t)...)
+	pubData = append(pubData, atomicMatchTxPubData(t)...)
+	pubData = append(pubData, withdrawNftTxPubData(t)...)
+	pubData = append(pubData, padTxPubData()...)
+
+	txInfos, err := ParseBlockPubData(pubData)
+	require.NoError(t, err)
+	require.Len(t, txInfos, 3)
+
+	transfer, ok := txInfos[0].(*txtypes.TransferTxInfo)
+	require.True(t, ok)
+	assert.Equal(t, int64(2), transfer.FromAccountIndex)
+	assert.Equal(t, int64(3), transfer.ToAccountIndex)
+	assert.Equal(t, int64(1), transfer.AssetId)
+	assert.Equal(t, "123400000", transfer.AssetAmount.String())
+	assert.Equal(t, types.GasAccount, transfer.GasAccountIndex)
+	assert.Equal(t, "5000", transfer.GasFeeAssetAmount.String())
+	assert.Equal(t, bytes.Repeat([]byte{0xab}, 32), transfer.CallDataHash)
+
+	atomicMatch, ok := txInfos[1].(*txtypes.AtomicMatchTxInfo)
+	require.True(t, ok)
+	assert.Equal(t, int64(4), atomicMatch.AccountIndex)
+	assert.Equal(t, int64(2), atomicMatch.BuyOffer.AccountIndex)
+	assert.Equal(t, int64(129), atomicMatch.BuyOffer.OfferId)
+	assert.Equal(t, int64(3), atomicMatch.SellOffer.AccountIndex)
+	assert.Equal(t, int64(7), atomicMatch.SellOffer.OfferId)
+	assert.Equal(t, int64(11), atomicMatch.BuyOffer.NftIndex)
+	assert.Equal(t, int64(1), atomicMatch.SellOffer.AssetId)
+	assert.Equal(t, "10000", atomicMatch.BuyOffer.AssetAmount.String())
+	assert.Equal(t, "300", atomicMatch.CreatorAmount.String())
+	assert.Equal(t, "200", atomicMatch.TreasuryAmount.String())
+	assert.Equal(t, "12", atomicMatch.GasFeeAssetAmount.String())
+
+	withdrawNft, ok := txInfos[2].(*txtypes.WithdrawNftTxInfo)
+	require.True(t, ok)
+	assert.Equal(t, int64(3), withdrawNft.AccountIndex)
+	assert.Equal(t, int64(2), withdrawNft.CreatorAccountIndex)
+	assert.Equal(t, int64(30), withdrawNft.CreatorTreasuryRate)
+	assert.Equal(t, int64(11), withdrawNft.NftIndex)
+	assert.Equal(t, int64(1), withdrawNft.CollectionId)
+	assert.Equal(t, common.HexToAddress("0x1111111111111111111111111111111111111111").Hex(), withdrawNft.NftL1Address)
+	assert.Equal(t, common.HexToAddress("0x2222222222222222222222222222222222222222").Hex(), withdrawNft.ToAddress)
+	assert.Equal(t, "5000", withdrawNft.GasFeeAssetAmount.String())
+	assert.Equal(t, bytes.Repeat([]byte{0xcd}, 32), withdrawNft.NftContentHash)
+	assert.Equal(t, "42", withdrawNft.NftL1TokenId.String())
+	assert.Equal(t, bytes.Repeat([]byte{0xef}, 32), withdrawNft.CreatorAccountNameHash)
+}
+
+func TestParseBlockPubDataInvalid(t *testing.T) {
+	_, err := ParseBlockPubData(make([]byte, TxPubDataBytesSize+1))
+	assert.Error(t, err)
+
+	pubData := padTxPubData()
+	pubData[0] = 0xff
+	_, err = ParseBlockPubData(pubData)
+	assert.Error(t, err)
+}
@@ -0,0 +1,130 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package chain
+
+import (
+	"math"
+	"math/big"
+
+	cryptoTypes "github.com/bnb-chain/zkbnb-crypto/circuit/types"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+const (
+	// CalldataGasPerByte is the L1 gas of a calldata byte, the padding zero bytes of the pub
+	// data are charged at the same rate to keep the fee simple.
+	CalldataGasPerByte = 16
+	// VerifyGasPerTx is the share of a tx in the L1 gas to verify the block proof.
+	VerifyGasPerTx = 10000
+
+	bnbDecimals = 18
+)
+
+var (
+	// GasTxTypes are the layer2 tx types which pay gas fees.
+	GasTxTypes = []int{
+		types.TxTypeTransfer,
+		types.TxTypeWithdraw,
+		types.TxTypeCreateCollection,
+		types.TxTypeMintNft,
+		types.TxTypeTransferNft,
+		types.TxTypeAtomicMatch,
+		types.TxTypeCancelOffer,
+		types.TxTypeWithdrawNft,
+	}
+
+	// onChainOperationGas is the L1 gas to execute the onchain operations of the tx types.
+	onChainOperationGas = map[int]int64{
+		types.TxTypeWithdraw:    50000,
+		types.TxTypeWithdrawNft: 150000,
+	}
+)
+
+// GasFeeAsset is a gas asset with its price in USD, the price is unknown if it's not positive.
+type GasFeeAsset struct {
+	AssetId  int64
+	Decimals uint32
+	Price    float64
+}
+
+type GasFeeParams struct {
+	// L1 gas price in wei
+	L1GasPrice     *big.Int
+	PendingTxCount int64
+	// The recommended fees are raised by the ratio of the pending txs to the threshold, and
+	// capped by the max multiplier.
+	CongestionThreshold     int64
+	MaxCongestionMultiplier float64
+	BNBPrice                float64
+	Assets                  []*GasFeeAsset
+}
+
+// ComputeL1GasPerTx returns the L1 gas consumed by a tx of the given type, including the gas
+// of its pub data, its share of the proof verification and its onchain operation.
+func ComputeL1GasPerTx(txType int) int64 {
+	pubDataBytes := int64(32 * cryptoTypes.PubDataSizePerTx)
+	return pubDataBytes*CalldataGasPerByte + VerifyGasPerTx + onChainOperationGas[txType]
+}
+
+// ComputeGasFees computes the minimum and the recommended fees of the gas tx types in the
+// gas assets, i.e. asset id -> (tx type -> fee) in the smallest unit of the asset. The
+// minimum fees cover the L1 cost of the txs, while the recommended fees are raised when the
+// tx pool is congested. The assets whose prices are unknown are not included.
+func ComputeGasFees(params *GasFeeParams) (minFees, recommendedFees map[uint32]map[int]int64) {
+	minFees = make(map[uint32]map[int]int64)
+	recommendedFees = make(map[uint32]map[int]int64)
+
+	multiplier := 1.0
+	if params.CongestionThreshold > 0 {
+		multiplier += float64(params.PendingTxCount) / float64(params.CongestionThreshold)
+	}
+	if params.MaxCongestionMultiplier >= 1 && multiplier > params.MaxCongestionMultiplier {
+		multiplier = params.MaxCongestionMultiplier
+	}
+
+	for _, asset := range params.Assets {
+		// asset amount per wei of BNB
+		rate := new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(asset.Decimals)), nil))
+		rate.Quo(rate, new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(bnbDecimals), nil)))
+		if asset.AssetId != types.BNBAssetId {
+			if asset.Price <= 0 || params.BNBPrice <= 0 {
+				continue
+			}
+			rate.Mul(rate, big.NewFloat(params.BNBPrice/asset.Price))
+		}
+
+		assetMinFees := make(map[int]int64, len(GasTxTypes))
+		assetRecommendedFees := make(map[int]int64, len(GasTxTypes))
+		for _, txType := range GasTxTypes {
+			feeInWei := new(big.Int).Mul(big.NewInt(ComputeL1GasPerTx(txType)), params.L1GasPrice)
+			fee, _ := new(big.Float).Mul(new(big.Float).SetInt(feeInWei), rate).Float64()
+			assetMinFees[txType] = ceilFee(fee)
+			assetRecommendedFees[txType] = ceilFee(fee * multiplier)
+		}
+		minFees[uint32(asset.AssetId)] = assetMinFees
+		recommendedFees[uint32(asset.AssetId)] = assetRecommendedFees
+	}
+	return minFees, recommendedFees
+}
+
+func ceilFee(fee float64) int64 {
+	if fee >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return int64(math.Ceil(fee))
+}
@@ -0,0 +1,63 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package chain
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+func TestComputeL1GasPerTx(t *testing.T) {
+	assert.Equal(t, int64(13072), ComputeL1GasPerTx(types.TxTypeTransfer))
+	assert.Equal(t, int64(63072), ComputeL1GasPerTx(types.TxTypeWithdraw))
+	assert.Equal(t, int64(163072), ComputeL1GasPerTx(types.TxTypeWithdrawNft))
+}
+
+func TestComputeGasFees(t *testing.T) {
+	params := &GasFeeParams{
+		L1GasPrice:              big.NewInt(5000000000),
+		PendingTxCount:          50,
+		CongestionThreshold:     100,
+		MaxCongestionMultiplier: 4,
+		BNBPrice:                300,
+		Assets: []*GasFeeAsset{
+			{AssetId: types.BNBAssetId, Decimals: 18},
+			{AssetId: 1, Decimals: 6, Price: 1},
+			{AssetId: 2, Decimals: 18},
+		},
+	}
+
+	minFees, recommendedFees := ComputeGasFees(params)
+	assert.Equal(t, int64(65360000000000), minFees[types.BNBAssetId][types.TxTypeTransfer])
+	assert.Equal(t, int64(98040000000000), recommendedFees[types.BNBAssetId][types.TxTypeTransfer])
+	assert.InDelta(t, 19608, minFees[1][types.TxTypeTransfer], 1)
+	assert.InDelta(t, 29412, recommendedFees[1][types.TxTypeTransfer], 1)
+	assert.Len(t, minFees[1], len(GasTxTypes))
+	// the fees of the asset without price are unknown
+	_, ok := minFees[2]
+	assert.False(t, ok)
+
+	// the recommended fees are capped
+	params.PendingTxCount = 1000
+	_, recommendedFees = ComputeGasFees(params)
+	assert.Equal(t, int64(261440000000000), recommendedFees[types.BNBAssetId][types.TxTypeTransfer])
+}
@@ -0,0 +1,160 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package chain
+
+import (
+	"container/heap"
+	"math/big"
+	"sort"
+
+	"github.com/bnb-chain/zkbnb-crypto/ffmath"
+	"github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+// ComputeGasFeeValue converts the gas fee into the BNB unit with the rates implied by the gas
+// config, i.e. a fee paid in an asset is worth fee * bnbGasFee / assetGasFee of the tx type.
+// The fee paid in an asset which is not configured is worth nothing.
+func ComputeGasFeeValue(gasConfig map[uint32]map[int]int64, txType int64, gasFeeAssetId int64, gasFee string) *big.Int {
+	fee, ok := new(big.Int).SetString(gasFee, 10)
+	if !ok || fee.Sign() <= 0 {
+		return big.NewInt(0)
+	}
+	if gasFeeAssetId == types.BNBAssetId {
+		return fee
+	}
+	assetGasFee := gasConfig[uint32(gasFeeAssetId)][int(txType)]
+	bnbGasFee := gasConfig[types.BNBAssetId][int(txType)]
+	if assetGasFee <= 0 || bnbGasFee <= 0 {
+		return big.NewInt(0)
+	}
+	return ffmath.Div(ffmath.Multiply(fee, big.NewInt(bnbGasFee)), big.NewInt(assetGasFee))
+}
+
+// SortPoolTxs orders the pending pool txs for execution. The priority operations come first
+// in their original order, which is the order of the request ids. The layer2 txs follow in
+// the order of the gas fee value, while the txs of the same account are kept in nonce order.
+// The txs with the same fee value are kept in their original order.
+func SortPoolTxs(poolTxs []*tx.Tx, gasConfig map[uint32]map[int]int64) []*tx.Tx {
+	sortedTxs := make([]*tx.Tx, 0, len(poolTxs))
+	accountTxs := make(map[int64][]*poolTxWithValue)
+	for index, poolTx := range poolTxs {
+		if types.IsPriorityOperationTx(poolTx.TxType) {
+			sortedTxs = append(sortedTxs, poolTx)
+			continue
+		}
+		accountTxs[poolTx.AccountIndex] = append(accountTxs[poolTx.AccountIndex], &poolTxWithValue{
+			poolTx: poolTx,
+			value:  ComputeGasFeeValue(gasConfig, poolTx.TxType, poolTx.GasFeeAssetId, poolTx.GasFee),
+			index:  index,
+		})
+	}
+
+	heads := make(poolTxHeap, 0, len(accountTxs))
+	for accountIndex, txs := range accountTxs {
+		sort.SliceStable(txs, func(i, j int) bool {
+			return txs[i].poolTx.Nonce < txs[j].poolTx.Nonce
+		})
+		heads = append(heads, txs[0])
+		accountTxs[accountIndex] = txs[1:]
+	}
+	heap.Init(&heads)
+	for heads.Len() > 0 {
+		head := heap.Pop(&heads).(*poolTxWithValue)
+		sortedTxs = append(sortedTxs, head.poolTx)
+		if txs := accountTxs[head.poolTx.AccountIndex]; len(txs) > 0 {
+			heap.Push(&heads, txs[0])
+			accountTxs[head.poolTx.AccountIndex] = txs[1:]
+		}
+	}
+	return sortedTxs
+}
+
+// SelectEvictedTxs selects the pending layer2 txs to be evicted from the tx pool and assigns
+// their fail reasons. The expired txs and the txs with the nonces lower than the committed
+// ones are evicted, then the txs exceeding the pending limit of each account are evicted
+// from the highest nonce. The priority operations are never evicted.
+func SelectEvictedTxs(poolTxs []*tx.Tx, committedNonces map[int64]int64, now int64, maxPendingTxsPerAccount int) []*tx.Tx {
+	evictedTxs := make([]*tx.Tx, 0)
+	accountTxs := make(map[int64][]*tx.Tx)
+	for _, poolTx := range poolTxs {
+		if types.IsPriorityOperationTx(poolTx.TxType) {
+			continue
+		}
+		if poolTx.ExpiredAt < now {
+			poolTx.FailReason = tx.FailReasonExpired
+			evictedTxs = append(evictedTxs, poolTx)
+			continue
+		}
+		if nonce, ok := committedNonces[poolTx.AccountIndex]; ok && poolTx.Nonce < nonce {
+			poolTx.FailReason = tx.FailReasonNonceTooLow
+			evictedTxs = append(evictedTxs, poolTx)
+			continue
+		}
+		accountTxs[poolTx.AccountIndex] = append(accountTxs[poolTx.AccountIndex], poolTx)
+	}
+
+	if maxPendingTxsPerAccount <= 0 {
+		return evictedTxs
+	}
+	for _, txs := range accountTxs {
+		if len(txs) <= maxPendingTxsPerAccount {
+			continue
+		}
+		sort.SliceStable(txs, func(i, j int) bool {
+			return txs[i].Nonce < txs[j].Nonce
+		})
+		for _, poolTx := range txs[maxPendingTxsPerAccount:] {
+			poolTx.FailReason = tx.FailReasonAccountLimit
+			evictedTxs = append(evictedTxs, poolTx)
+		}
+	}
+	return evictedTxs
+}
+
+type poolTxWithValue struct {
+	poolTx *tx.Tx
+	value  *big.Int
+	index  int
+}
+
+// poolTxHeap is a max heap of the gas fee values, the earlier tx wins when the values are equal.
+type poolTxHeap []*poolTxWithValue
+
+func (h poolTxHeap) Len() int { return len(h) }
+
+func (h poolTxHeap) Less(i, j int) bool {
+	if c := h[i].value.Cmp(h[j].value); c != 0 {
+		return c > 0
+	}
+	return h[i].index < h[j].index
+}
+
+func (h poolTxHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *poolTxHeap) Push(x interface{}) {
+	*h = append(*h, x.(*poolTxWithValue))
+}
+
+func (h *poolTxHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package chain
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+func TestComputeGasFeeValue(t *testing.T) {
+	gasConfig := map[uint32]map[int]int64{
+		types.BNBAssetId: {types.TxTypeTransfer: 100},
+		1:                {types.TxTypeTransfer: 1000},
+	}
+
+	assert.Equal(t, big.NewInt(200), ComputeGasFeeValue(gasConfig, types.TxTypeTransfer, types.BNBAssetId, "200"))
+	assert.Equal(t, big.NewInt(20), ComputeGasFeeValue(gasConfig, types.TxTypeTransfer, 1, "200"))
+	assert.Equal(t, big.NewInt(0), ComputeGasFeeValue(gasConfig, types.TxTypeTransfer, 2, "200"))
+	assert.Equal(t, big.NewInt(0), ComputeGasFeeValue(gasConfig, types.TxTypeWithdraw, 1, "200"))
+	assert.Equal(t, big.NewInt(0), ComputeGasFeeValue(gasConfig, types.TxTypeTransfer, types.BNBAssetId, "invalid"))
+}
+
+func TestSortPoolTxs(t *testing.T) {
+	gasConfig := map[uint32]map[int]int64{
+		types.BNBAssetId: {types.TxTypeTransfer: 100},
+		1:                {types.TxTypeTransfer: 1000},
+	}
+	newTx := func(hash string, txType int64, accountIndex, nonce, gasFeeAssetId int64, gasFee string) *tx.Tx {
+		return &tx.Tx{
+			TxHash:        hash,
+			TxType:        txType,
+			AccountIndex:  accountIndex,
+			Nonce:         nonce,
+			GasFeeAssetId: gasFeeAssetId,
+			GasFee:        gasFee,
+		}
+	}
+	poolTxs := []*tx.Tx{
+		newTx("a0", types.TxTypeTransfer, 1, 0, types.BNBAssetId, "100"),
+		newTx("deposit0", types.TxTypeDeposit, types.NilAccountIndex, 0, types.NilAssetId, types.NilAssetAmount),
+		newTx("b1", types.TxTypeTransfer, 2, 1, types.BNBAssetId, "500"),
+		newTx("b0", types.TxTypeTransfer, 2, 0, types.BNBAssetId, "100"),
+		newTx("c0", types.TxTypeTransfer, 3, 0, 1, "3000"),
+		newTx("deposit1", types.TxTypeDeposit, types.NilAccountIndex, 0, types.NilAssetId, types.NilAssetAmount),
+		newTx("a1", types.TxTypeTransfer, 1, 1, types.BNBAssetId, "200"),
+	}
+
+	sortedTxs := SortPoolTxs(poolTxs, gasConfig)
+	hashes := make([]string, 0, len(sortedTxs))
+	for _, sortedTx := range sortedTxs {
+		hashes = append(hashes, sortedTx.TxHash)
+	}
+	assert.Equal(t, []string{"deposit0", "deposit1", "c0", "a0", "a1", "b0", "b1"}, hashes)
+}
+
+func TestSelectEvictedTxs(t *testing.T) {
+	newTx := func(hash string, txType int64, accountIndex, nonce, expiredAt int64) *tx.Tx {
+		return &tx.Tx{
+			TxHash:       hash,
+			TxType:       txType,
+			AccountIndex: accountIndex,
+			Nonce:        nonce,
+			ExpiredAt:    expiredAt,
+		}
+	}
+	poolTxs := []*tx.Tx{
+		newTx("deposit", types.TxTypeDeposit, types.NilAccountIndex, 0, 0),
+		newTx("a0", types.TxTypeTransfer, 1, 0, 100),
+		newTx("a1", types.TxTypeTransfer, 1, 1, 1000),
+		newTx("b3", types.TxTypeTransfer, 2, 3, 1000),
+		newTx("b1", types.TxTypeTransfer, 2, 1, 1000),
+		newTx("b2", types.TxTypeTransfer, 2, 2, 1000),
+		newTx("c0", types.TxTypeTransfer, 3, 0, 1000),
+		newTx("c1", types.TxTypeTransfer, 3, 1, 1000),
+	}
+	committedNonces := map[int64]int64{2: 1, 3: 1}
+
+	evictedTxs := SelectEvictedTxs(poolTxs, committedNonces, 500, 1)
+	reasons := make(map[string]string)
+	for _, evictedTx := range evictedTxs {
+		reasons[evictedTx.TxHash] = evictedTx.FailReason
+	}
+	assert.Equal(t, map[string]string{
+		"a0": tx.FailReasonExpired,
+		"c0": tx.FailReasonNonceTooLow,
+		"b2": tx.FailReasonAccountLimit,
+		"b3": tx.FailReasonAccountLimit,
+	}, reasons)
+}
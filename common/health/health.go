@@ -0,0 +1,159 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/threading"
+	"gorm.io/gorm"
+)
+
+const (
+	LivenessPath  = "/healthz"
+	ReadinessPath = "/readyz"
+
+	StatusOk   = "ok"
+	StatusFail = "fail"
+)
+
+// Config is the listener of the health endpoints, they are not served if the host is empty.
+type Config struct {
+	Host string `json:",optional"`
+	Port int    `json:",default=9102"`
+}
+
+// Check returns an error describing why the dependency is unhealthy, or nil.
+type Check func() error
+
+// Result is the response of the health endpoints, with the result of each check.
+type Result struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Server serves the liveness and the readiness of a service, the endpoints respond 503 with
+// the failed checks if any check fails.
+type Server struct {
+	config Config
+
+	mu              sync.RWMutex
+	livenessChecks  []namedCheck
+	readinessChecks []namedCheck
+}
+
+func NewServer(c Config) *Server {
+	return &Server{config: c}
+}
+
+// AddLivenessCheck adds a check of the liveness, the service should be restarted if it fails.
+func (s *Server) AddLivenessCheck(name string, check Check) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.livenessChecks = append(s.livenessChecks, namedCheck{name: name, check: check})
+}
+
+// AddReadinessCheck adds a check of the readiness, the service is working if it passes.
+func (s *Server) AddReadinessCheck(name string, check Check) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.readinessChecks = append(s.readinessChecks, namedCheck{name: name, check: check})
+}
+
+func (s *Server) Liveness() *Result {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return runChecks(s.livenessChecks)
+}
+
+func (s *Server) Readiness() *Result {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return runChecks(s.readinessChecks)
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(LivenessPath, func(w http.ResponseWriter, r *http.Request) {
+		writeResult(w, s.Liveness())
+	})
+	mux.HandleFunc(ReadinessPath, func(w http.ResponseWriter, r *http.Request) {
+		writeResult(w, s.Readiness())
+	})
+	return mux
+}
+
+// Start serves the health endpoints in the background.
+func (s *Server) Start() {
+	if len(s.config.Host) == 0 {
+		return
+	}
+	threading.GoSafe(func() {
+		addr := fmt.Sprintf("%s:%d", s.config.Host, s.config.Port)
+		logx.Infof("Starting health server at %s", addr)
+		if err := http.ListenAndServe(addr, s.Handler()); err != nil {
+			logx.Error(err)
+		}
+	})
+}
+
+// DatabaseCheck pings the database.
+func DatabaseCheck(db *gorm.DB) Check {
+	return func() error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.Ping()
+	}
+}
+
+func runChecks(checks []namedCheck) *Result {
+	result := &Result{
+		Status: StatusOk,
+		Checks: make(map[string]string, len(checks)),
+	}
+	for _, c := range checks {
+		if err := c.check(); err != nil {
+			result.Status = StatusFail
+			result.Checks[c.name] = err.Error()
+			continue
+		}
+		result.Checks[c.name] = StatusOk
+	}
+	return result
+}
+
+func writeResult(w http.ResponseWriter, result *Result) {
+	w.Header().Set("Content-Type", "application/json")
+	if result.Status != StatusOk {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		logx.Errorf("write health result failed, err: %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package health
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer(t *testing.T) {
+	s := NewServer(Config{})
+	s.AddLivenessCheck("loop", func() error { return nil })
+	s.AddReadinessCheck("database", func() error { return nil })
+	s.AddReadinessCheck("l1_rpc", func() error { return errors.New("connection refused") })
+
+	get := func(path string) (int, *Result) {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var result Result
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+		return rec.Code, &result
+	}
+
+	code, result := get(LivenessPath)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, &Result{Status: StatusOk, Checks: map[string]string{"loop": StatusOk}}, result)
+
+	code, result = get(ReadinessPath)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, &Result{
+		Status: StatusFail,
+		Checks: map[string]string{"database": StatusOk, "l1_rpc": "connection refused"},
+	}, result)
+}
+
+func TestServerWithoutChecks(t *testing.T) {
+	s := NewServer(Config{})
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, ReadinessPath, nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,171 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package l1
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+
+	zkbnb "github.com/bnb-chain/zkbnb-eth-rpc/core"
+	"github.com/bnb-chain/zkbnb-eth-rpc/rpc"
+)
+
+var errReadOnly = errors.New("the provider has no private key to send txs")
+
+// Provider is the access to the L1 chain and the rollup contracts, which is used by the
+// monitor and the sender.
+type Provider interface {
+	GetHeight() (uint64, error)
+	// GetBlockHeaderByNumber returns the latest header if the number is nil.
+	GetBlockHeaderByNumber(number *big.Int) (*ethTypes.Header, error)
+	FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]ethTypes.Log, error)
+	GetTransactionReceipt(txHash string) (*ethTypes.Receipt, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	GetERC20Info(assetAddress string) (*ERC20Info, error)
+
+	// CommitBlocks and VerifyAndExecuteBlocks send the txs to the rollup contract, and
+	// return the sent txs.
+	CommitBlocks(lastBlock zkbnb.StorageStoredBlockInfo, commitBlocks []zkbnb.OldZkBNBCommitBlockInfo,
+		opts *TxOptions) (*ethTypes.Transaction, error)
+	VerifyAndExecuteBlocks(blocks []zkbnb.OldZkBNBVerifyAndExecuteBlockInfo, proofs []*big.Int,
+		opts *TxOptions) (*ethTypes.Transaction, error)
+}
+
+// TxOptions are the nonce and the fees of the txs to the rollup contract.
+type TxOptions struct {
+	// Nonce is set to replace the pending tx with the nonce, the next nonce of the sender
+	// is used if it is nil.
+	Nonce *uint64
+	// GasPrice is the gas price of the legacy tx, or the fee cap of the EIP-1559 tx if the
+	// tip cap is set.
+	GasPrice  *big.Int
+	GasTipCap *big.Int
+	GasLimit  uint64
+}
+
+type ERC20Info struct {
+	Name     string
+	Symbol   string
+	Decimals uint8
+}
+
+type rpcProvider struct {
+	*rpc.ProviderClient
+
+	authCli       *rpc.AuthClient
+	zkbnbInstance *zkbnb.ZkBNB
+}
+
+// NewRpcProvider connects to the L1 node. The private key signs the txs to the rollup
+// contract, it is empty if the provider only reads the chain.
+func NewRpcProvider(networkRpc string, zkbnbAddress string, privateKey string) (Provider, error) {
+	cli, err := rpc.NewClient(networkRpc)
+	if err != nil {
+		return nil, err
+	}
+	p := &rpcProvider{ProviderClient: cli}
+	if privateKey == "" {
+		return p, nil
+	}
+
+	chainId, err := cli.ChainID(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	p.authCli, err = rpc.NewAuthClient(privateKey, chainId)
+	if err != nil {
+		return nil, err
+	}
+	p.zkbnbInstance, err = zkbnb.LoadZkBNBInstance(cli, zkbnbAddress)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *rpcProvider) GetERC20Info(assetAddress string) (*ERC20Info, error) {
+	erc20Instance, err := zkbnb.LoadERC20(p.ProviderClient, assetAddress)
+	if err != nil {
+		return nil, err
+	}
+	name, err := erc20Instance.Name(&bind.CallOpts{})
+	if err != nil {
+		return nil, err
+	}
+	symbol, err := erc20Instance.Symbol(&bind.CallOpts{})
+	if err != nil {
+		return nil, err
+	}
+	decimals, err := erc20Instance.Decimals(&bind.CallOpts{})
+	if err != nil {
+		return nil, err
+	}
+	return &ERC20Info{Name: name, Symbol: symbol, Decimals: decimals}, nil
+}
+
+func (p *rpcProvider) CommitBlocks(lastBlock zkbnb.StorageStoredBlockInfo, commitBlocks []zkbnb.OldZkBNBCommitBlockInfo,
+	opts *TxOptions) (*ethTypes.Transaction, error) {
+	transactOpts, err := p.transactOpts(opts)
+	if err != nil {
+		return nil, err
+	}
+	return p.zkbnbInstance.CommitBlocks(transactOpts, lastBlock, commitBlocks)
+}
+
+func (p *rpcProvider) VerifyAndExecuteBlocks(blocks []zkbnb.OldZkBNBVerifyAndExecuteBlockInfo, proofs []*big.Int,
+	opts *TxOptions) (*ethTypes.Transaction, error) {
+	transactOpts, err := p.transactOpts(opts)
+	if err != nil {
+		return nil, err
+	}
+	return p.zkbnbInstance.VerifyAndExecuteBlocks(transactOpts, blocks, proofs)
+}
+
+func (p *rpcProvider) transactOpts(opts *TxOptions) (*bind.TransactOpts, error) {
+	if p.authCli == nil {
+		return nil, errReadOnly
+	}
+	transactOpts, err := bind.NewKeyedTransactorWithChainID(p.authCli.PrivateKey, p.authCli.ChainId)
+	if err != nil {
+		return nil, err
+	}
+	nonce := opts.Nonce
+	if nonce == nil {
+		pendingNonce, err := p.PendingNonceAt(context.Background(), p.authCli.Address)
+		if err != nil {
+			return nil, err
+		}
+		nonce = &pendingNonce
+	}
+	transactOpts.Nonce = new(big.Int).SetUint64(*nonce)
+	if opts.GasTipCap != nil {
+		transactOpts.GasFeeCap = opts.GasPrice
+		transactOpts.GasTipCap = opts.GasTipCap
+	} else {
+		transactOpts.GasPrice = opts.GasPrice
+	}
+	transactOpts.GasLimit = opts.GasLimit
+	transactOpts.Value = big.NewInt(0)
+	return transactOpts, nil
+}
@@ -0,0 +1,412 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package l1
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	zkbnb "github.com/bnb-chain/zkbnb-eth-rpc/core"
+)
+
+const (
+	simulatedBlockInterval = 3 * time.Second
+	simulatedGasPrice      = 1e9
+	// the percent the fees of a replacement tx must be higher by, as geth
+	replacementPriceBump = 10
+	// the number of L1 blocks before the priority requests expire, as the contract
+	priorityExpirationPeriod = 40320
+)
+
+var (
+	zkbnbContractAbi, _      = abi.JSON(strings.NewReader(zkbnb.ZkBNBMetaData.ABI))
+	governanceContractAbi, _ = abi.JSON(strings.NewReader(zkbnb.GovernanceMetaData.ABI))
+)
+
+// SimulatedProvider is an in-memory L1 chain with the rollup and the governance contracts.
+// It mines one block for each tx, emits the same events as the contracts, and checks the
+// committed and verified blocks as the rollup contract does, except that the proofs are
+// not verified. The txs failing the checks are rejected, they are never mined.
+//
+// The rollup txs are mined in the order of the nonces, the ones priced below the min gas
+// price stay pending until they are replaced, so the state of the rollup contract changes
+// when the tx is sent, but the events are emitted when it is mined.
+type SimulatedProvider struct {
+	mu sync.Mutex
+
+	zkbnbAddress      common.Address
+	governanceAddress common.Address
+
+	headers  []*ethTypes.Header
+	logs     [][]*ethTypes.Log
+	receipts map[common.Hash]*ethTypes.Receipt
+	txCount  uint64
+
+	assets           map[common.Address]*ERC20Info
+	failedRecipients map[common.Address]bool
+
+	// the nonce of the next rollup tx to mine, and the pending ones from the nonce
+	nonce       uint64
+	pendingTxs  []*pendingTx
+	minGasPrice *big.Int
+
+	rollup simulatedRollup
+}
+
+type pendingTx struct {
+	tx   *ethTypes.Transaction
+	logs []*ethTypes.Log
+}
+
+// NewSimulatedProvider creates the chain with the rollup contract initialized to the
+// genesis block, which the first committed block is built on.
+func NewSimulatedProvider(zkbnbAddress, governanceAddress common.Address, genesisBlock zkbnb.StorageStoredBlockInfo) *SimulatedProvider {
+	s := &SimulatedProvider{
+		zkbnbAddress:      zkbnbAddress,
+		governanceAddress: governanceAddress,
+		receipts:          make(map[common.Hash]*ethTypes.Receipt),
+		minGasPrice:       big.NewInt(0),
+		assets:            make(map[common.Address]*ERC20Info),
+		failedRecipients:  make(map[common.Address]bool),
+		rollup: simulatedRollup{
+			storedBlocks: []*storedBlock{{info: genesisBlock}},
+		},
+	}
+	s.mineBlock(nil)
+	return s
+}
+
+// Mine mines the empty blocks, so that the mined txs get the confirmations.
+func (s *SimulatedProvider) Mine(blocks int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < blocks; i++ {
+		s.mineBlock(nil)
+	}
+}
+
+// RequestPriorityOperation queues the priority request, as RegisterZNS, Deposit,
+// DepositNft, RequestFullExit and RequestFullExitNft of the rollup contract. The pub data
+// is in the format of the NewPriorityRequest event.
+func (s *SimulatedProvider) RequestPriorityOperation(sender common.Address, txType uint8, pubData []byte) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := parsePriorityRequest(txType, pubData); err != nil {
+		return "", err
+	}
+	serialId := uint64(len(s.rollup.priorityRequests))
+	expirationBlock := big.NewInt(int64(len(s.headers)) + priorityExpirationPeriod)
+	data, err := zkbnbContractAbi.Events["NewPriorityRequest"].Inputs.NonIndexed().
+		Pack(sender, serialId, txType, pubData, expirationBlock)
+	if err != nil {
+		return "", err
+	}
+	s.rollup.priorityRequests = append(s.rollup.priorityRequests, &priorityRequest{
+		sender:  sender,
+		txType:  txType,
+		pubData: pubData,
+	})
+	return s.sendTx([]*ethTypes.Log{s.newRollupLog("NewPriorityRequest", data)}).Hex(), nil
+}
+
+// AddAsset lists the ERC20 token in the governance contract, the asset id is returned.
+func (s *SimulatedProvider) AddAsset(assetAddress common.Address, info ERC20Info) (uint16, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.assets[assetAddress]; ok {
+		return 0, "", fmt.Errorf("asset %s is already added", assetAddress.Hex())
+	}
+	// the asset 0 is BNB
+	assetId := uint16(len(s.assets) + 1)
+	data, err := governanceContractAbi.Events["NewAsset"].Inputs.NonIndexed().Pack(assetAddress, assetId)
+	if err != nil {
+		return 0, "", err
+	}
+	s.assets[assetAddress] = &info
+	txHash := s.sendTx([]*ethTypes.Log{newLog(governanceContractAbi, s.governanceAddress, "NewAsset", data)})
+	return assetId, txHash.Hex(), nil
+}
+
+// SetMinGasPrice sets the min gas price, or the min tip cap of the EIP-1559 txs, of the
+// rollup txs to mine.
+func (s *SimulatedProvider) SetMinGasPrice(minGasPrice *big.Int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.minGasPrice = minGasPrice
+	s.minePendingTxs()
+}
+
+// FailTransfersTo makes the transfers of the withdrawals to the address fail, the funds
+// and the nfts are kept by the rollup contract to be claimed.
+func (s *SimulatedProvider) FailTransfersTo(address common.Address) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failedRecipients[address] = true
+}
+
+func (s *SimulatedProvider) GetHeight() (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return uint64(len(s.headers) - 1), nil
+}
+
+func (s *SimulatedProvider) GetBlockHeaderByNumber(number *big.Int) (*ethTypes.Header, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if number == nil {
+		return ethTypes.CopyHeader(s.headers[len(s.headers)-1]), nil
+	}
+	if !number.IsUint64() || number.Uint64() >= uint64(len(s.headers)) {
+		return nil, ethereum.NotFound
+	}
+	return ethTypes.CopyHeader(s.headers[number.Uint64()]), nil
+}
+
+func (s *SimulatedProvider) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]ethTypes.Log, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if query.BlockHash != nil {
+		return nil, fmt.Errorf("filter by block hash is not supported")
+	}
+	fromBlock, toBlock := uint64(0), uint64(len(s.headers)-1)
+	if query.FromBlock != nil {
+		fromBlock = query.FromBlock.Uint64()
+	}
+	if query.ToBlock != nil && query.ToBlock.Uint64() < toBlock {
+		toBlock = query.ToBlock.Uint64()
+	}
+
+	var logs []ethTypes.Log
+	for height := fromBlock; height <= toBlock && height < uint64(len(s.logs)); height++ {
+		for _, vlog := range s.logs[height] {
+			if matchLog(vlog, query) {
+				logs = append(logs, *vlog)
+			}
+		}
+	}
+	return logs, nil
+}
+
+func (s *SimulatedProvider) GetTransactionReceipt(txHash string) (*ethTypes.Receipt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	receipt, ok := s.receipts[common.HexToHash(txHash)]
+	if !ok {
+		return nil, ethereum.NotFound
+	}
+	return receipt, nil
+}
+
+func (s *SimulatedProvider) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(simulatedGasPrice), nil
+}
+
+func (s *SimulatedProvider) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(simulatedGasPrice), nil
+}
+
+func (s *SimulatedProvider) GetERC20Info(assetAddress string) (*ERC20Info, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info, ok := s.assets[common.HexToAddress(assetAddress)]
+	if !ok {
+		return nil, fmt.Errorf("no contract code at %s", assetAddress)
+	}
+	return info, nil
+}
+
+// sendRollupTx sends the call to the rollup contract, or replaces the pending one with the
+// nonce. The call is applied to the contract state when it is sent, so the replacement
+// must be the same call with higher fees.
+func (s *SimulatedProvider) sendRollupTx(opts *TxOptions, data []byte,
+	apply func() ([]*ethTypes.Log, error)) (*ethTypes.Transaction, error) {
+	nextNonce := s.nonce + uint64(len(s.pendingTxs))
+	nonce := nextNonce
+	if opts.Nonce != nil {
+		nonce = *opts.Nonce
+	}
+	if nonce < s.nonce {
+		return nil, fmt.Errorf("nonce too low: %d, the next nonce is %d", nonce, s.nonce)
+	}
+	if nonce > nextNonce {
+		return nil, fmt.Errorf("nonce too high: %d, the next nonce is %d", nonce, nextNonce)
+	}
+
+	tx := s.newRollupTx(nonce, opts, data)
+	if nonce < nextNonce {
+		pending := s.pendingTxs[nonce-s.nonce]
+		if !bytes.Equal(pending.tx.Data(), data) {
+			return nil, errors.New("the replacement tx is a different call")
+		}
+		if !isReplacementPriced(pending.tx, tx) {
+			return nil, errors.New("replacement transaction underpriced")
+		}
+		pending.tx = tx
+	} else {
+		logs, err := apply()
+		if err != nil {
+			return nil, err
+		}
+		s.pendingTxs = append(s.pendingTxs, &pendingTx{tx: tx, logs: logs})
+	}
+	s.minePendingTxs()
+	return tx, nil
+}
+
+func (s *SimulatedProvider) newRollupTx(nonce uint64, opts *TxOptions, data []byte) *ethTypes.Transaction {
+	if opts.GasTipCap != nil {
+		return ethTypes.NewTx(&ethTypes.DynamicFeeTx{
+			Nonce:     nonce,
+			GasTipCap: opts.GasTipCap,
+			GasFeeCap: opts.GasPrice,
+			Gas:       opts.GasLimit,
+			To:        &s.zkbnbAddress,
+			Data:      data,
+		})
+	}
+	return ethTypes.NewTx(&ethTypes.LegacyTx{
+		Nonce:    nonce,
+		GasPrice: opts.GasPrice,
+		Gas:      opts.GasLimit,
+		To:       &s.zkbnbAddress,
+		Data:     data,
+	})
+}
+
+func isReplacementPriced(old, tx *ethTypes.Transaction) bool {
+	bump := func(price *big.Int) *big.Int {
+		price = new(big.Int).Mul(price, big.NewInt(100+replacementPriceBump))
+		return price.Div(price, big.NewInt(100))
+	}
+	return tx.GasTipCapIntCmp(bump(old.GasTipCap())) >= 0 && tx.GasFeeCapIntCmp(bump(old.GasFeeCap())) >= 0
+}
+
+// minePendingTxs mines the pending rollup txs in the order of the nonces, until the one
+// priced below the min gas price.
+func (s *SimulatedProvider) minePendingTxs() {
+	for len(s.pendingTxs) > 0 && s.pendingTxs[0].tx.GasTipCapIntCmp(s.minGasPrice) >= 0 {
+		pending := s.pendingTxs[0]
+		s.pendingTxs = s.pendingTxs[1:]
+		s.nonce++
+		s.mineTx(pending.tx.Hash(), pending.logs)
+	}
+}
+
+// sendTx mines the tx emitting the logs, the hash of the tx is returned. It is sent by the
+// users, not the operator sending the rollup txs.
+func (s *SimulatedProvider) sendTx(logs []*ethTypes.Log) common.Hash {
+	s.txCount++
+	txHash := crypto.Keccak256Hash(new(big.Int).SetUint64(s.txCount).Bytes())
+	s.mineTx(txHash, logs)
+	return txHash
+}
+
+// mineTx mines a block with the tx emitting the logs.
+func (s *SimulatedProvider) mineTx(txHash common.Hash, logs []*ethTypes.Log) {
+	header := s.mineBlock(logs)
+	for i, vlog := range logs {
+		vlog.TxHash = txHash
+		vlog.Index = uint(i)
+	}
+	s.receipts[txHash] = &ethTypes.Receipt{
+		Status:      ethTypes.ReceiptStatusSuccessful,
+		Logs:        logs,
+		TxHash:      txHash,
+		BlockHash:   header.Hash(),
+		BlockNumber: header.Number,
+	}
+}
+
+func (s *SimulatedProvider) mineBlock(logs []*ethTypes.Log) *ethTypes.Header {
+	header := &ethTypes.Header{
+		Number:     big.NewInt(int64(len(s.headers))),
+		Difficulty: big.NewInt(0),
+		Time:       uint64(time.Now().Unix()),
+	}
+	if len(s.headers) > 0 {
+		parent := s.headers[len(s.headers)-1]
+		header.ParentHash = parent.Hash()
+		header.Time = parent.Time + uint64(simulatedBlockInterval.Seconds())
+	}
+	blockHash := header.Hash()
+	for _, vlog := range logs {
+		vlog.BlockNumber = header.Number.Uint64()
+		vlog.BlockHash = blockHash
+	}
+	s.headers = append(s.headers, header)
+	s.logs = append(s.logs, logs)
+	return header
+}
+
+func (s *SimulatedProvider) newRollupLog(event string, data []byte, indexed ...common.Hash) *ethTypes.Log {
+	return newLog(zkbnbContractAbi, s.zkbnbAddress, event, data, indexed...)
+}
+
+func newLog(contractAbi abi.ABI, address common.Address, event string, data []byte, indexed ...common.Hash) *ethTypes.Log {
+	return &ethTypes.Log{
+		Address: address,
+		Topics:  append([]common.Hash{contractAbi.Events[event].ID}, indexed...),
+		Data:    data,
+	}
+}
+
+func matchLog(vlog *ethTypes.Log, query ethereum.FilterQuery) bool {
+	if len(query.Addresses) > 0 {
+		matched := false
+		for _, address := range query.Addresses {
+			if address == vlog.Address {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(query.Topics) > len(vlog.Topics) {
+		return false
+	}
+	for i, topics := range query.Topics {
+		if len(topics) == 0 {
+			continue
+		}
+		matched := false
+		for _, topic := range topics {
+			if topic == vlog.Topics[i] {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,378 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package l1
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/bnb-chain/zkbnb-crypto/wasm/txtypes"
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+
+	zkbnb "github.com/bnb-chain/zkbnb-eth-rpc/core"
+	common2 "github.com/bnb-chain/zkbnb/common"
+	"github.com/bnb-chain/zkbnb/common/chain"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+// the proof of each block is made of A (2), B (4) and C (2)
+const proofSize = 8
+
+// simulatedRollup is the state of the rollup contract.
+type simulatedRollup struct {
+	// the committed blocks indexed by the block number, starting from the genesis block
+	storedBlocks        []*storedBlock
+	totalBlocksVerified uint32
+
+	// the priority requests indexed by the serial id
+	priorityRequests []*priorityRequest
+	// the number of the priority requests in the committed blocks, the next request to
+	// commit is the one with this serial id
+	committedPriorityRequests uint64
+}
+
+type storedBlock struct {
+	info zkbnb.StorageStoredBlockInfo
+	// the senders of the full exit requests of the block, which receive the funds and the nfts
+	exitOwners []common.Address
+}
+
+type priorityRequest struct {
+	sender  common.Address
+	txType  uint8
+	pubData []byte
+}
+
+func (s *SimulatedProvider) CommitBlocks(lastBlock zkbnb.StorageStoredBlockInfo, commitBlocks []zkbnb.OldZkBNBCommitBlockInfo,
+	opts *TxOptions) (*ethTypes.Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := zkbnbContractAbi.Pack("commitBlocks", lastBlock, commitBlocks)
+	if err != nil {
+		return nil, err
+	}
+	return s.sendRollupTx(opts, data, func() ([]*ethTypes.Log, error) {
+		return s.commitBlocks(lastBlock, commitBlocks)
+	})
+}
+
+func (s *SimulatedProvider) VerifyAndExecuteBlocks(blocks []zkbnb.OldZkBNBVerifyAndExecuteBlockInfo, proofs []*big.Int,
+	opts *TxOptions) (*ethTypes.Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := zkbnbContractAbi.Pack("verifyAndExecuteBlocks", blocks, proofs)
+	if err != nil {
+		return nil, err
+	}
+	return s.sendRollupTx(opts, data, func() ([]*ethTypes.Log, error) {
+		return s.verifyAndExecuteBlocks(blocks, proofs)
+	})
+}
+
+func (s *SimulatedProvider) commitBlocks(lastBlock zkbnb.StorageStoredBlockInfo,
+	commitBlocks []zkbnb.OldZkBNBCommitBlockInfo) ([]*ethTypes.Log, error) {
+	r := &s.rollup
+	if !storedBlockInfoEqual(lastBlock, r.storedBlocks[len(r.storedBlocks)-1].info) {
+		return nil, fmt.Errorf("block %d is not the last committed block", lastBlock.BlockNumber)
+	}
+
+	committedPriorityRequests := r.committedPriorityRequests
+	newBlocks := make([]*storedBlock, 0, len(commitBlocks))
+	logs := make([]*ethTypes.Log, 0, len(commitBlocks))
+	for _, commitBlock := range commitBlocks {
+		newBlock, err := r.commitOneBlock(lastBlock, commitBlock, committedPriorityRequests)
+		if err != nil {
+			return nil, fmt.Errorf("failed to commit block %d: %v", commitBlock.BlockNumber, err)
+		}
+		data, err := zkbnbContractAbi.Events["BlockCommit"].Inputs.NonIndexed().Pack(commitBlock.BlockNumber)
+		if err != nil {
+			return nil, err
+		}
+		committedPriorityRequests += newBlock.info.PriorityOperations
+		newBlocks = append(newBlocks, newBlock)
+		logs = append(logs, s.newRollupLog("BlockCommit", data))
+		lastBlock = newBlock.info
+	}
+
+	r.storedBlocks = append(r.storedBlocks, newBlocks...)
+	r.committedPriorityRequests = committedPriorityRequests
+	return logs, nil
+}
+
+func (s *SimulatedProvider) verifyAndExecuteBlocks(blocks []zkbnb.OldZkBNBVerifyAndExecuteBlockInfo,
+	proofs []*big.Int) ([]*ethTypes.Log, error) {
+	if len(proofs) != proofSize*len(blocks) {
+		return nil, fmt.Errorf("invalid proofs size %d for %d blocks", len(proofs), len(blocks))
+	}
+
+	r := &s.rollup
+	totalBlocksVerified := r.totalBlocksVerified
+	var logs []*ethTypes.Log
+	for _, block := range blocks {
+		totalBlocksVerified++
+		if int(totalBlocksVerified) >= len(r.storedBlocks) {
+			return nil, fmt.Errorf("block %d is not committed", totalBlocksVerified)
+		}
+		stored := r.storedBlocks[totalBlocksVerified]
+		if !storedBlockInfoEqual(block.BlockHeader, stored.info) {
+			return nil, fmt.Errorf("block %d doesn't match the committed block %d",
+				block.BlockHeader.BlockNumber, totalBlocksVerified)
+		}
+		blockLogs, err := s.executeBlock(stored, block.PendingOnchainOpsPubData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute block %d: %v", totalBlocksVerified, err)
+		}
+		data, err := zkbnbContractAbi.Events["BlockVerification"].Inputs.NonIndexed().Pack(totalBlocksVerified)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, blockLogs...)
+		logs = append(logs, s.newRollupLog("BlockVerification", data))
+	}
+
+	r.totalBlocksVerified = totalBlocksVerified
+	return logs, nil
+}
+
+// commitOneBlock checks the block against the previous one and the queued priority
+// requests, and returns the block to store.
+func (r *simulatedRollup) commitOneBlock(previous zkbnb.StorageStoredBlockInfo, newBlock zkbnb.OldZkBNBCommitBlockInfo,
+	committedPriorityRequests uint64) (*storedBlock, error) {
+	if newBlock.BlockNumber != previous.BlockNumber+1 {
+		return nil, fmt.Errorf("invalid block number, expected %d", previous.BlockNumber+1)
+	}
+	if newBlock.Timestamp.Cmp(previous.Timestamp) < 0 {
+		return nil, errors.New("the timestamp is earlier than the previous block")
+	}
+	pubData := newBlock.PublicData
+	if len(pubData) != int(newBlock.BlockSize)*chain.TxPubDataBytesSize {
+		return nil, fmt.Errorf("invalid pub data size %d for block size %d", len(pubData), newBlock.BlockSize)
+	}
+
+	var (
+		stored                = &storedBlock{}
+		priorityOperations    uint64
+		pendingOnchainOpsHash = common.FromHex(types.EmptyStringKeccak)
+		offsets               = newBlock.PublicDataOffsets
+	)
+	for offset := 0; offset < len(pubData); offset += chain.TxPubDataBytesSize {
+		txPubData := pubData[offset : offset+chain.TxPubDataBytesSize]
+		txType := int(txPubData[0])
+		if !isOnChainOperation(txType) {
+			continue
+		}
+		if len(offsets) == 0 || offsets[0] != uint32(offset) {
+			return nil, fmt.Errorf("the on-chain operation at offset %d is not in the offsets", offset)
+		}
+		offsets = offsets[1:]
+
+		var request *priorityRequest
+		if types.IsPriorityOperationTx(int64(txType)) {
+			serialId := committedPriorityRequests + priorityOperations
+			if serialId >= uint64(len(r.priorityRequests)) {
+				return nil, fmt.Errorf("priority request %d is not found", serialId)
+			}
+			request = r.priorityRequests[serialId]
+			if err := checkPriorityOperation(request, txPubData); err != nil {
+				return nil, fmt.Errorf("priority request %d: %v", serialId, err)
+			}
+			priorityOperations++
+		}
+		switch txType {
+		case types.TxTypeWithdraw, types.TxTypeWithdrawNft:
+			pendingOnchainOpsHash = common2.ConcatKeccakHash(pendingOnchainOpsHash, txPubData)
+		case types.TxTypeFullExit, types.TxTypeFullExitNft:
+			pendingOnchainOpsHash = common2.ConcatKeccakHash(pendingOnchainOpsHash, txPubData)
+			stored.exitOwners = append(stored.exitOwners, request.sender)
+		}
+	}
+	if len(offsets) > 0 {
+		return nil, fmt.Errorf("the offsets %v are not on-chain operations", offsets)
+	}
+
+	commitment := chain.CreateBlockCommitment(int64(newBlock.BlockNumber), newBlock.Timestamp.Int64(),
+		previous.StateRoot[:], newBlock.NewStateRoot[:], pubData, int64(len(newBlock.PublicDataOffsets)))
+	stored.info = zkbnb.StorageStoredBlockInfo{
+		BlockSize:          newBlock.BlockSize,
+		BlockNumber:        newBlock.BlockNumber,
+		PriorityOperations: priorityOperations,
+		Timestamp:          newBlock.Timestamp,
+		StateRoot:          newBlock.NewStateRoot,
+	}
+	copy(stored.info.PendingOnchainOperationsHash[:], pendingOnchainOpsHash)
+	copy(stored.info.Commitment[:], common.FromHex(commitment))
+	return stored, nil
+}
+
+// executeBlock checks the pending on-chain operations against the committed hash, and
+// sends the withdrawals to the recipients.
+func (s *SimulatedProvider) executeBlock(stored *storedBlock, pendingOnchainOpsPubData [][]byte) ([]*ethTypes.Log, error) {
+	var (
+		logs                  []*ethTypes.Log
+		exitOwners            = stored.exitOwners
+		pendingOnchainOpsHash = common.FromHex(types.EmptyStringKeccak)
+	)
+	for _, pubData := range pendingOnchainOpsPubData {
+		pendingOnchainOpsHash = common2.ConcatKeccakHash(pendingOnchainOpsHash, pubData)
+	}
+	if !bytes.Equal(pendingOnchainOpsHash, stored.info.PendingOnchainOperationsHash[:]) {
+		return nil, errors.New("the pending on-chain operations don't match the committed hash")
+	}
+
+	for _, pubData := range pendingOnchainOpsPubData {
+		txInfo, err := chain.ParseTxPubData(pubData)
+		if err != nil {
+			return nil, err
+		}
+		var vlog *ethTypes.Log
+		switch op := txInfo.(type) {
+		case *txtypes.WithdrawTxInfo:
+			vlog, err = s.withdrawLog(common.HexToAddress(op.ToAddress), op.AssetId, op.AssetAmount)
+		case *txtypes.WithdrawNftTxInfo:
+			vlog, err = s.withdrawNftLog(common.HexToAddress(op.ToAddress), op.AccountIndex, op.NftIndex,
+				op.NftL1Address, op.NftL1TokenId)
+		case *txtypes.FullExitTxInfo:
+			owner := exitOwners[0]
+			exitOwners = exitOwners[1:]
+			if op.AssetAmount.Sign() == 0 {
+				continue
+			}
+			vlog, err = s.withdrawLog(owner, op.AssetId, op.AssetAmount)
+		case *txtypes.FullExitNftTxInfo:
+			owner := exitOwners[0]
+			exitOwners = exitOwners[1:]
+			// the nft doesn't exist or isn't owned by the account
+			if bytes.Equal(op.NftContentHash, make([]byte, len(op.NftContentHash))) {
+				continue
+			}
+			vlog, err = s.withdrawNftLog(owner, op.AccountIndex, op.NftIndex, op.NftL1Address, op.NftL1TokenId)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if vlog != nil {
+			logs = append(logs, vlog)
+		}
+	}
+	return logs, nil
+}
+
+// withdrawLog emits Withdrawal, or nothing if the transfer to the recipient fails, the
+// contract only adds the amount to the pending balance then.
+func (s *SimulatedProvider) withdrawLog(to common.Address, assetId int64, amount *big.Int) (*ethTypes.Log, error) {
+	if s.failedRecipients[to] {
+		return nil, nil
+	}
+	data, err := zkbnbContractAbi.Events["Withdrawal"].Inputs.NonIndexed().Pack(uint16(assetId), amount)
+	if err != nil {
+		return nil, err
+	}
+	return s.newRollupLog("Withdrawal", data), nil
+}
+
+// withdrawNftLog emits WithdrawNft, or WithdrawalNFTPending if the transfer to the
+// recipient fails.
+func (s *SimulatedProvider) withdrawNftLog(to common.Address, accountIndex, nftIndex int64,
+	nftL1Address string, nftL1TokenId *big.Int) (*ethTypes.Log, error) {
+	if s.failedRecipients[to] {
+		return s.newRollupLog("WithdrawalNFTPending", nil, common.BigToHash(big.NewInt(nftIndex))), nil
+	}
+	data, err := zkbnbContractAbi.Events["WithdrawNft"].Inputs.NonIndexed().
+		Pack(uint32(accountIndex), common.HexToAddress(nftL1Address), to, nftL1TokenId)
+	if err != nil {
+		return nil, err
+	}
+	return s.newRollupLog("WithdrawNft", data), nil
+}
+
+// checkPriorityOperation checks the operation of the block against the fields of the
+// priority request, which are known on L1.
+func checkPriorityOperation(request *priorityRequest, opPubData []byte) error {
+	if int(request.txType) != int(opPubData[0]) {
+		return fmt.Errorf("the request is of type %d, but the operation is of type %d", request.txType, opPubData[0])
+	}
+	requestInfo, err := parsePriorityRequest(request.txType, request.pubData)
+	if err != nil {
+		return err
+	}
+	opInfo, err := chain.ParseTxPubData(opPubData)
+	if err != nil {
+		return err
+	}
+
+	var matched bool
+	switch r := requestInfo.(type) {
+	case *txtypes.RegisterZnsTxInfo:
+		op := opInfo.(*txtypes.RegisterZnsTxInfo)
+		matched = r.AccountIndex == op.AccountIndex && bytes.Equal(r.AccountNameHash, op.AccountNameHash) &&
+			r.PubKey == op.PubKey
+	case *txtypes.DepositTxInfo:
+		op := opInfo.(*txtypes.DepositTxInfo)
+		matched = bytes.Equal(r.AccountNameHash, op.AccountNameHash) && r.AssetId == op.AssetId &&
+			r.AssetAmount.Cmp(op.AssetAmount) == 0
+	case *txtypes.DepositNftTxInfo:
+		op := opInfo.(*txtypes.DepositNftTxInfo)
+		matched = bytes.Equal(r.AccountNameHash, op.AccountNameHash) && bytes.Equal(r.NftContentHash, op.NftContentHash) &&
+			r.NftL1Address == op.NftL1Address && r.NftL1TokenId.Cmp(op.NftL1TokenId) == 0
+	case *txtypes.FullExitTxInfo:
+		op := opInfo.(*txtypes.FullExitTxInfo)
+		matched = bytes.Equal(r.AccountNameHash, op.AccountNameHash) && r.AssetId == op.AssetId
+	case *txtypes.FullExitNftTxInfo:
+		op := opInfo.(*txtypes.FullExitNftTxInfo)
+		matched = bytes.Equal(r.AccountNameHash, op.AccountNameHash) && r.NftIndex == op.NftIndex
+	}
+	if !matched {
+		return errors.New("the operation doesn't match the request")
+	}
+	return nil
+}
+
+func parsePriorityRequest(txType uint8, pubData []byte) (txInfo txtypes.TxInfo, err error) {
+	switch int(txType) {
+	case types.TxTypeRegisterZns:
+		txInfo, err = chain.ParseRegisterZnsPubData(pubData)
+	case types.TxTypeDeposit:
+		txInfo, err = chain.ParseDepositPubData(pubData)
+	case types.TxTypeDepositNft:
+		txInfo, err = chain.ParseDepositNftPubData(pubData)
+	case types.TxTypeFullExit:
+		txInfo, err = chain.ParseFullExitPubData(pubData)
+	case types.TxTypeFullExitNft:
+		txInfo, err = chain.ParseFullExitNftPubData(pubData)
+	default:
+		return nil, fmt.Errorf("tx type %d is not a priority operation", txType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return txInfo, nil
+}
+
+func isOnChainOperation(txType int) bool {
+	return types.IsPriorityOperationTx(int64(txType)) || txType == types.TxTypeWithdraw || txType == types.TxTypeWithdrawNft
+}
+
+func storedBlockInfoEqual(a, b zkbnb.StorageStoredBlockInfo) bool {
+	return a.BlockSize == b.BlockSize && a.BlockNumber == b.BlockNumber &&
+		a.PriorityOperations == b.PriorityOperations &&
+		a.PendingOnchainOperationsHash == b.PendingOnchainOperationsHash &&
+		a.Timestamp.Cmp(b.Timestamp) == 0 && a.StateRoot == b.StateRoot && a.Commitment == b.Commitment
+}
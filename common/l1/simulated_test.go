@@ -0,0 +1,284 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package l1
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	zkbnb "github.com/bnb-chain/zkbnb-eth-rpc/core"
+	common2 "github.com/bnb-chain/zkbnb/common"
+	"github.com/bnb-chain/zkbnb/common/chain"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+var (
+	zkbnbAddress      = common.HexToAddress("0x1000000000000000000000000000000000000001")
+	governanceAddress = common.HexToAddress("0x1000000000000000000000000000000000000002")
+	depositor         = common.HexToAddress("0x2000000000000000000000000000000000000001")
+	recipient         = common.HexToAddress("0x2000000000000000000000000000000000000002")
+	accountNameHash   = common.FromHex("0x04b2a4e3e1b9de8b19b5fcb5f4ad4a5b6a3e9c3d0c55c1c1b1c0a19f4c1d2e3f")
+)
+
+func genesisBlock() zkbnb.StorageStoredBlockInfo {
+	genesis := zkbnb.StorageStoredBlockInfo{Timestamp: big.NewInt(0)}
+	copy(genesis.PendingOnchainOperationsHash[:], common.FromHex(types.EmptyStringKeccak))
+	return genesis
+}
+
+func padTxPubData(chunks ...[]byte) []byte {
+	var buf bytes.Buffer
+	for _, chunk := range chunks {
+		buf.Write(chunk)
+	}
+	for i := len(chunks); i < 6; i++ {
+		buf.Write(common2.PrefixPaddingBufToChunkSize([]byte{}))
+	}
+	return buf.Bytes()
+}
+
+// depositRequestPubData is the pub data of the NewPriorityRequest event.
+func depositRequestPubData(amount int64) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte(uint8(types.TxTypeDeposit))
+	buf.Write(common2.Uint32ToBytes(0))
+	buf.Write(accountNameHash)
+	buf.Write(common2.Uint16ToBytes(1))
+	buf.Write(common2.Uint128ToBytes(big.NewInt(amount)))
+	return buf.Bytes()
+}
+
+func depositTxPubData(amount int64) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte(uint8(types.TxTypeDeposit))
+	buf.Write(common2.Uint32ToBytes(2))
+	buf.Write(common2.Uint16ToBytes(1))
+	buf.Write(common2.Uint128ToBytes(big.NewInt(amount)))
+	return padTxPubData(
+		common2.SuffixPaddingBufToChunkSize(buf.Bytes()),
+		common2.PrefixPaddingBufToChunkSize(accountNameHash),
+	)
+}
+
+func withdrawTxPubData(t *testing.T, to common.Address, amount int64) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte(uint8(types.TxTypeWithdraw))
+	buf.Write(common2.Uint32ToBytes(2))
+	buf.Write(to.Bytes())
+	buf.Write(common2.Uint16ToBytes(1))
+	chunk1 := common2.SuffixPaddingBufToChunkSize(buf.Bytes())
+	buf.Reset()
+	buf.Write(common2.Uint128ToBytes(big.NewInt(amount)))
+	buf.Write(common2.Uint32ToBytes(uint32(types.GasAccount)))
+	buf.Write(common2.Uint16ToBytes(0))
+	packedFee, err := common2.FeeToPackedFeeBytes(big.NewInt(5000))
+	require.NoError(t, err)
+	buf.Write(packedFee)
+	return padTxPubData(chunk1, common2.PrefixPaddingBufToChunkSize(buf.Bytes()))
+}
+
+func commitBlockInfo(number uint32, txsPubData ...[]byte) zkbnb.OldZkBNBCommitBlockInfo {
+	block := zkbnb.OldZkBNBCommitBlockInfo{
+		NewStateRoot: common.BytesToHash([]byte{byte(number)}),
+		Timestamp:    big.NewInt(int64(number) * 1000),
+		BlockNumber:  number,
+		BlockSize:    uint16(len(txsPubData)),
+	}
+	for _, txPubData := range txsPubData {
+		if txPubData[0] == types.TxTypeDeposit || txPubData[0] == types.TxTypeWithdraw {
+			block.PublicDataOffsets = append(block.PublicDataOffsets, uint32(len(block.PublicData)))
+		}
+		block.PublicData = append(block.PublicData, txPubData...)
+	}
+	return block
+}
+
+// storedBlockInfo computes the stored block as the committer does.
+func storedBlockInfo(previous zkbnb.StorageStoredBlockInfo, block zkbnb.OldZkBNBCommitBlockInfo,
+	priorityOperations uint64, pendingOnchainOpsPubData [][]byte) zkbnb.StorageStoredBlockInfo {
+	info := zkbnb.StorageStoredBlockInfo{
+		BlockSize:          block.BlockSize,
+		BlockNumber:        block.BlockNumber,
+		PriorityOperations: priorityOperations,
+		Timestamp:          block.Timestamp,
+		StateRoot:          block.NewStateRoot,
+	}
+	pendingOnchainOpsHash := common.FromHex(types.EmptyStringKeccak)
+	for _, pubData := range pendingOnchainOpsPubData {
+		pendingOnchainOpsHash = common2.ConcatKeccakHash(pendingOnchainOpsHash, pubData)
+	}
+	copy(info.PendingOnchainOperationsHash[:], pendingOnchainOpsHash)
+	commitment := chain.CreateBlockCommitment(int64(block.BlockNumber), block.Timestamp.Int64(),
+		previous.StateRoot[:], block.NewStateRoot[:], block.PublicData, int64(len(block.PublicDataOffsets)))
+	copy(info.Commitment[:], common.FromHex(commitment))
+	return info
+}
+
+func txOptions(nonce *uint64, gasPrice int64) *TxOptions {
+	return &TxOptions{Nonce: nonce, GasPrice: big.NewInt(gasPrice), GasLimit: 20000000}
+}
+
+func eventNames(t *testing.T, logs []*ethTypes.Log) []string {
+	names := make([]string, 0, len(logs))
+	for _, vlog := range logs {
+		event, err := zkbnbContractAbi.EventByID(vlog.Topics[0])
+		require.NoError(t, err)
+		names = append(names, event.Name)
+	}
+	return names
+}
+
+func TestSimulatedProvider(t *testing.T) {
+	s := NewSimulatedProvider(zkbnbAddress, governanceAddress, genesisBlock())
+
+	_, err := s.RequestPriorityOperation(depositor, types.TxTypeDeposit, depositRequestPubData(100))
+	require.NoError(t, err)
+	logs, err := s.FilterLogs(context.Background(), ethereum.FilterQuery{
+		Addresses: []common.Address{zkbnbAddress},
+		Topics:    [][]common.Hash{{zkbnbContractAbi.Events["NewPriorityRequest"].ID}},
+	})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	var request zkbnb.ZkBNBNewPriorityRequest
+	require.NoError(t, zkbnbContractAbi.UnpackIntoInterface(&request, "NewPriorityRequest", logs[0].Data))
+	assert.Equal(t, depositor, request.Sender)
+	assert.Equal(t, uint64(0), request.SerialId)
+	assert.Equal(t, depositRequestPubData(100), request.PubData)
+
+	// the deposit doesn't match the request
+	block1 := commitBlockInfo(1, depositTxPubData(99))
+	_, err = s.CommitBlocks(genesisBlock(), []zkbnb.OldZkBNBCommitBlockInfo{block1}, txOptions(nil, simulatedGasPrice))
+	assert.Error(t, err)
+
+	withdrawPubData := withdrawTxPubData(t, recipient, 40)
+	block1 = commitBlockInfo(1, depositTxPubData(100))
+	block2 := commitBlockInfo(2, withdrawPubData, withdrawTxPubData(t, depositor, 50))
+	tx, err := s.CommitBlocks(genesisBlock(), []zkbnb.OldZkBNBCommitBlockInfo{block1, block2}, txOptions(nil, simulatedGasPrice))
+	require.NoError(t, err)
+	receipt, err := s.GetTransactionReceipt(tx.Hash().Hex())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"BlockCommit", "BlockCommit"}, eventNames(t, receipt.Logs))
+
+	// the blocks are committed already
+	_, err = s.CommitBlocks(genesisBlock(), []zkbnb.OldZkBNBCommitBlockInfo{block1}, txOptions(nil, simulatedGasPrice))
+	assert.Error(t, err)
+
+	stored1 := storedBlockInfo(genesisBlock(), block1, 1, nil)
+	pendingOnchainOpsPubData := [][]byte{withdrawPubData, withdrawTxPubData(t, depositor, 50)}
+	stored2 := storedBlockInfo(stored1, block2, 0, pendingOnchainOpsPubData)
+	blocks := []zkbnb.OldZkBNBVerifyAndExecuteBlockInfo{
+		{BlockHeader: stored1},
+		{BlockHeader: stored2, PendingOnchainOpsPubData: pendingOnchainOpsPubData[:1]},
+	}
+	proofs := make([]*big.Int, 2*proofSize)
+	for i := range proofs {
+		proofs[i] = big.NewInt(int64(i))
+	}
+	// the pending on-chain operations don't match the committed ones
+	_, err = s.VerifyAndExecuteBlocks(blocks, proofs, txOptions(nil, simulatedGasPrice))
+	assert.Error(t, err)
+	blocks[1].PendingOnchainOpsPubData = pendingOnchainOpsPubData
+	_, err = s.VerifyAndExecuteBlocks(blocks, proofs[:proofSize], txOptions(nil, simulatedGasPrice))
+	assert.Error(t, err)
+
+	s.FailTransfersTo(depositor)
+	tx, err = s.VerifyAndExecuteBlocks(blocks, proofs, txOptions(nil, simulatedGasPrice))
+	require.NoError(t, err)
+	receipt, err = s.GetTransactionReceipt(tx.Hash().Hex())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"BlockVerification", "Withdrawal", "BlockVerification"},
+		eventNames(t, receipt.Logs))
+
+	s.Mine(3)
+	height, err := s.GetHeight()
+	require.NoError(t, err)
+	assert.Equal(t, receipt.BlockNumber.Uint64()+3, height)
+	header, err := s.GetBlockHeaderByNumber(big.NewInt(int64(height)))
+	require.NoError(t, err)
+	parent, err := s.GetBlockHeaderByNumber(big.NewInt(int64(height - 1)))
+	require.NoError(t, err)
+	assert.Equal(t, parent.Hash(), header.ParentHash)
+	_, err = s.GetBlockHeaderByNumber(big.NewInt(int64(height + 1)))
+	assert.Equal(t, ethereum.NotFound, err)
+}
+
+func TestSimulatedProviderAddAsset(t *testing.T) {
+	s := NewSimulatedProvider(zkbnbAddress, governanceAddress, genesisBlock())
+	assetAddress := common.HexToAddress("0x3000000000000000000000000000000000000001")
+	assetId, _, err := s.AddAsset(assetAddress, ERC20Info{Name: "Binance USD", Symbol: "BUSD", Decimals: 18})
+	require.NoError(t, err)
+	assert.Equal(t, uint16(1), assetId)
+
+	logs, err := s.FilterLogs(context.Background(), ethereum.FilterQuery{
+		Addresses: []common.Address{governanceAddress},
+	})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	var event zkbnb.GovernanceNewAsset
+	require.NoError(t, governanceContractAbi.UnpackIntoInterface(&event, "NewAsset", logs[0].Data))
+	assert.Equal(t, assetAddress, event.AssetAddress)
+	assert.Equal(t, assetId, event.AssetId)
+
+	info, err := s.GetERC20Info(assetAddress.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, "BUSD", info.Symbol)
+	_, err = s.GetERC20Info(zkbnbAddress.Hex())
+	assert.Error(t, err)
+}
+
+func TestSimulatedProviderReplaceTx(t *testing.T) {
+	s := NewSimulatedProvider(zkbnbAddress, governanceAddress, genesisBlock())
+	s.SetMinGasPrice(big.NewInt(2 * simulatedGasPrice))
+	_, err := s.RequestPriorityOperation(depositor, types.TxTypeDeposit, depositRequestPubData(100))
+	require.NoError(t, err)
+
+	block1 := commitBlockInfo(1, depositTxPubData(100))
+	tx, err := s.CommitBlocks(genesisBlock(), []zkbnb.OldZkBNBCommitBlockInfo{block1}, txOptions(nil, simulatedGasPrice))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), tx.Nonce())
+	_, err = s.GetTransactionReceipt(tx.Hash().Hex())
+	assert.Equal(t, ethereum.NotFound, err)
+
+	nonce := tx.Nonce()
+	_, err = s.CommitBlocks(genesisBlock(), []zkbnb.OldZkBNBCommitBlockInfo{block1}, txOptions(&nonce, simulatedGasPrice*105/100))
+	assert.EqualError(t, err, "replacement transaction underpriced")
+	block1.Timestamp = big.NewInt(1001)
+	_, err = s.CommitBlocks(genesisBlock(), []zkbnb.OldZkBNBCommitBlockInfo{block1}, txOptions(&nonce, 2*simulatedGasPrice))
+	assert.Error(t, err)
+	block1.Timestamp = big.NewInt(1000)
+
+	replacement, err := s.CommitBlocks(genesisBlock(), []zkbnb.OldZkBNBCommitBlockInfo{block1}, txOptions(&nonce, 2*simulatedGasPrice))
+	require.NoError(t, err)
+	assert.Equal(t, nonce, replacement.Nonce())
+	receipt, err := s.GetTransactionReceipt(replacement.Hash().Hex())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"BlockCommit"}, eventNames(t, receipt.Logs))
+	_, err = s.GetTransactionReceipt(tx.Hash().Hex())
+	assert.Equal(t, ethereum.NotFound, err)
+
+	_, err = s.CommitBlocks(genesisBlock(), []zkbnb.OldZkBNBCommitBlockInfo{block1}, txOptions(&nonce, 3*simulatedGasPrice))
+	assert.Error(t, err)
+}
@@ -0,0 +1,180 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// Package merkle verifies the merkle inclusion proofs of the account, asset and nft leaves
+// served by the api server. A verified proof shows that the leaf is in the state of the
+// block whose state root is in the proof, the state root itself should be checked against
+// the one stored in the rollup contract for the block.
+package merkle
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/bnb-chain/zkbnb/tree"
+)
+
+var (
+	ErrInvalidProof = errors.New("invalid merkle proof")
+	ErrRootMismatch = errors.New("merkle root mismatch")
+)
+
+// AccountProof proves the leaf of an account in the account tree, the roots and the hashes
+// are hex encoded, and the siblings are ordered from the leaf to the root.
+type AccountProof struct {
+	BlockHeight     int64    `json:"block_height"`
+	StateRoot       string   `json:"state_root"`
+	NftRoot         string   `json:"nft_root"`
+	AccountIndex    int64    `json:"account_index"`
+	AccountNameHash string   `json:"account_name_hash"`
+	AccountPk       string   `json:"account_pk"`
+	Nonce           int64    `json:"nonce"`
+	CollectionNonce int64    `json:"collection_nonce"`
+	AssetRoot       string   `json:"asset_root"`
+	Siblings        []string `json:"siblings"`
+}
+
+// AssetProof proves the leaf of an asset in the asset tree of an account, together with the
+// proof of the account whose asset root is the root of the asset tree.
+type AssetProof struct {
+	AccountProof             *AccountProof `json:"account_proof"`
+	AssetId                  int64         `json:"asset_id"`
+	Balance                  string        `json:"balance"`
+	OfferCanceledOrFinalized string        `json:"offer_canceled_or_finalized"`
+	Siblings                 []string      `json:"siblings"`
+}
+
+// NftProof proves the leaf of a nft in the nft tree.
+type NftProof struct {
+	BlockHeight         int64    `json:"block_height"`
+	StateRoot           string   `json:"state_root"`
+	AccountRoot         string   `json:"account_root"`
+	NftIndex            int64    `json:"nft_index"`
+	CreatorAccountIndex int64    `json:"creator_account_index"`
+	OwnerAccountIndex   int64    `json:"owner_account_index"`
+	NftContentHash      string   `json:"nft_content_hash"`
+	NftL1Address        string   `json:"nft_l1_address"`
+	NftL1TokenId        string   `json:"nft_l1_token_id"`
+	CreatorTreasuryRate int64    `json:"creator_treasury_rate"`
+	CollectionId        int64    `json:"collection_id"`
+	Siblings            []string `json:"siblings"`
+}
+
+// VerifyAccountProof checks that the account leaf is in the state of the state root.
+func VerifyAccountProof(proof *AccountProof) error {
+	if proof == nil {
+		return ErrInvalidProof
+	}
+	leaf, err := tree.ComputeAccountLeafHash(proof.AccountNameHash, proof.AccountPk,
+		proof.Nonce, proof.CollectionNonce, common.FromHex(proof.AssetRoot))
+	if err != nil {
+		return err
+	}
+	accountRoot, err := ComputeRoot(leaf, proof.AccountIndex, proof.Siblings, tree.AccountTreeHeight)
+	if err != nil {
+		return err
+	}
+	stateRoot := tree.ComputeStateRootHash(accountRoot, common.FromHex(proof.NftRoot))
+	if !bytes.Equal(stateRoot, common.FromHex(proof.StateRoot)) {
+		return ErrRootMismatch
+	}
+	return nil
+}
+
+// VerifyAssetProof checks that the asset leaf is in the asset tree of the account, and the
+// account leaf is in the state of the state root.
+func VerifyAssetProof(proof *AssetProof) error {
+	if proof == nil || proof.AccountProof == nil {
+		return ErrInvalidProof
+	}
+	leaf, err := tree.ComputeAccountAssetLeafHash(proof.Balance, proof.OfferCanceledOrFinalized)
+	if err != nil {
+		return err
+	}
+	assetRoot, err := ComputeRoot(leaf, proof.AssetId, proof.Siblings, tree.AssetTreeHeight)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(assetRoot, common.FromHex(proof.AccountProof.AssetRoot)) {
+		return ErrRootMismatch
+	}
+	return VerifyAccountProof(proof.AccountProof)
+}
+
+// VerifyNftProof checks that the nft leaf is in the state of the state root.
+func VerifyNftProof(proof *NftProof) error {
+	if proof == nil {
+		return ErrInvalidProof
+	}
+	leaf, err := tree.ComputeNftAssetLeafHash(proof.CreatorAccountIndex, proof.OwnerAccountIndex,
+		proof.NftContentHash, proof.NftL1Address, proof.NftL1TokenId, proof.CreatorTreasuryRate, proof.CollectionId)
+	if err != nil {
+		return err
+	}
+	nftRoot, err := ComputeRoot(leaf, proof.NftIndex, proof.Siblings, tree.NftTreeHeight)
+	if err != nil {
+		return err
+	}
+	stateRoot := tree.ComputeStateRootHash(common.FromHex(proof.AccountRoot), nftRoot)
+	if !bytes.Equal(stateRoot, common.FromHex(proof.StateRoot)) {
+		return ErrRootMismatch
+	}
+	return nil
+}
+
+// FormatSiblings hex encodes the proof of a sparse merkle tree, which are the siblings from
+// the leaf to the root.
+func FormatSiblings(proof [][]byte) []string {
+	siblings := make([]string, 0, len(proof))
+	for _, sibling := range proof {
+		siblings = append(siblings, common.Bytes2Hex(sibling))
+	}
+	return siblings
+}
+
+// ComputeRoot hashes the leaf with the siblings from the leaf to the root of a tree of the
+// height, the bits of the index tell whether the node is the left or the right child.
+func ComputeRoot(leaf []byte, index int64, siblings []string, height int) ([]byte, error) {
+	if index < 0 || index >= 1<<height {
+		return nil, fmt.Errorf("%w: index %d out of range", ErrInvalidProof, index)
+	}
+	if len(siblings) != height {
+		return nil, fmt.Errorf("%w: expect %d siblings, got %d", ErrInvalidProof, height, len(siblings))
+	}
+	hFunc := mimc.NewMiMC()
+	node := leaf
+	for i := range siblings {
+		sibling := common.FromHex(siblings[i])
+		if len(sibling) != 32 {
+			return nil, fmt.Errorf("%w: invalid sibling %s", ErrInvalidProof, siblings[i])
+		}
+		hFunc.Reset()
+		if (index>>i)&1 == 0 {
+			hFunc.Write(node)
+			hFunc.Write(sibling)
+		} else {
+			hFunc.Write(sibling)
+			hFunc.Write(node)
+		}
+		node = hFunc.Sum(nil)
+	}
+	return node, nil
+}
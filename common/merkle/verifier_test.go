@@ -0,0 +1,202 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package merkle
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/twistededwards/eddsa"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bsmt "github.com/bnb-chain/zkbnb-smt"
+	"github.com/bnb-chain/zkbnb-smt/database/memory"
+	"github.com/bnb-chain/zkbnb/dao/nft"
+	"github.com/bnb-chain/zkbnb/tree"
+)
+
+type testState struct {
+	accountTree bsmt.SparseMerkleTree
+	assetTree   bsmt.SparseMerkleTree
+	nftTree     bsmt.SparseMerkleTree
+	pk          string
+	nft         *nft.L2NftHistory
+}
+
+func newTestState(t *testing.T) *testState {
+	sk, err := eddsa.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	s := &testState{
+		pk: common.Bytes2Hex(sk.PublicKey.Bytes()),
+		nft: &nft.L2NftHistory{
+			NftIndex:            5,
+			CreatorAccountIndex: 2,
+			OwnerAccountIndex:   3,
+			NftContentHash:      "1f2d3c4b5a69788796a5b4c3d2e1f00112233445566778899aabbccddeeff00",
+			NftL1Address:        "0",
+			NftL1TokenId:        "0",
+			CreatorTreasuryRate: 30,
+			CollectionId:        1,
+		},
+	}
+
+	s.assetTree, err = tree.NewMemAccountAssetTree()
+	require.NoError(t, err)
+	for assetId, balance := range []string{"100", "0", "2500"} {
+		leaf, err := tree.ComputeAccountAssetLeafHash(balance, "0")
+		require.NoError(t, err)
+		require.NoError(t, s.assetTree.Set(uint64(assetId), leaf))
+	}
+	_, err = s.assetTree.Commit(nil)
+	require.NoError(t, err)
+
+	s.accountTree, err = bsmt.NewBASSparseMerkleTree(bsmt.NewHasher(mimc.NewMiMC()),
+		memory.NewMemoryDB(), tree.AccountTreeHeight, tree.NilAccountNodeHash)
+	require.NoError(t, err)
+	for accountIndex := int64(0); accountIndex < 4; accountIndex++ {
+		leaf, err := tree.ComputeAccountLeafHash(s.nameHash(accountIndex), s.pk, accountIndex, 0, s.assetTree.Root())
+		require.NoError(t, err)
+		require.NoError(t, s.accountTree.Set(uint64(accountIndex), leaf))
+	}
+	_, err = s.accountTree.Commit(nil)
+	require.NoError(t, err)
+
+	s.nftTree, err = bsmt.NewBASSparseMerkleTree(bsmt.NewHasher(mimc.NewMiMC()),
+		memory.NewMemoryDB(), tree.NftTreeHeight, tree.NilNftNodeHash)
+	require.NoError(t, err)
+	leaf, err := tree.NftAssetToNode(s.nft)
+	require.NoError(t, err)
+	require.NoError(t, s.nftTree.Set(uint64(s.nft.NftIndex), leaf))
+	_, err = s.nftTree.Commit(nil)
+	require.NoError(t, err)
+	return s
+}
+
+func (s *testState) nameHash(accountIndex int64) string {
+	return common.Bytes2Hex(common.LeftPadBytes([]byte{byte(accountIndex + 1)}, 32))
+}
+
+func (s *testState) stateRoot() string {
+	return common.Bytes2Hex(tree.ComputeStateRootHash(s.accountTree.Root(), s.nftTree.Root()))
+}
+
+func (s *testState) accountProof(t *testing.T, accountIndex int64) *AccountProof {
+	proof, err := s.accountTree.GetProof(uint64(accountIndex))
+	require.NoError(t, err)
+	return &AccountProof{
+		BlockHeight:     1,
+		StateRoot:       s.stateRoot(),
+		NftRoot:         common.Bytes2Hex(s.nftTree.Root()),
+		AccountIndex:    accountIndex,
+		AccountNameHash: s.nameHash(accountIndex),
+		AccountPk:       s.pk,
+		Nonce:           accountIndex,
+		CollectionNonce: 0,
+		AssetRoot:       common.Bytes2Hex(s.assetTree.Root()),
+		Siblings:        FormatSiblings(proof),
+	}
+}
+
+func TestVerifyAccountProof(t *testing.T) {
+	s := newTestState(t)
+
+	for accountIndex := int64(0); accountIndex < 4; accountIndex++ {
+		assert.NoError(t, VerifyAccountProof(s.accountProof(t, accountIndex)))
+	}
+
+	proof := s.accountProof(t, 1)
+	proof.Nonce = 2
+	assert.ErrorIs(t, VerifyAccountProof(proof), ErrRootMismatch)
+
+	proof = s.accountProof(t, 1)
+	proof.AccountIndex = 2
+	assert.ErrorIs(t, VerifyAccountProof(proof), ErrRootMismatch)
+
+	proof = s.accountProof(t, 1)
+	proof.Siblings = proof.Siblings[1:]
+	assert.ErrorIs(t, VerifyAccountProof(proof), ErrInvalidProof)
+
+	proof = s.accountProof(t, 1)
+	proof.AccountIndex = 1 << tree.AccountTreeHeight
+	assert.ErrorIs(t, VerifyAccountProof(proof), ErrInvalidProof)
+}
+
+func TestVerifyAssetProof(t *testing.T) {
+	s := newTestState(t)
+
+	assetProof := func(assetId int64, balance string) *AssetProof {
+		proof, err := s.assetTree.GetProof(uint64(assetId))
+		require.NoError(t, err)
+		return &AssetProof{
+			AccountProof:             s.accountProof(t, 3),
+			AssetId:                  assetId,
+			Balance:                  balance,
+			OfferCanceledOrFinalized: "0",
+			Siblings:                 FormatSiblings(proof),
+		}
+	}
+
+	assert.NoError(t, VerifyAssetProof(assetProof(0, "100")))
+	assert.NoError(t, VerifyAssetProof(assetProof(2, "2500")))
+	assert.ErrorIs(t, VerifyAssetProof(assetProof(2, "2501")), ErrRootMismatch)
+
+	// the asset tree is fine but the account is not in the state
+	proof := assetProof(0, "100")
+	proof.AccountProof.StateRoot = common.Bytes2Hex(tree.NilStateRoot)
+	assert.ErrorIs(t, VerifyAssetProof(proof), ErrRootMismatch)
+
+	proof = assetProof(0, "100")
+	proof.AccountProof = nil
+	assert.ErrorIs(t, VerifyAssetProof(proof), ErrInvalidProof)
+}
+
+func TestVerifyNftProof(t *testing.T) {
+	s := newTestState(t)
+
+	nftProof := func() *NftProof {
+		proof, err := s.nftTree.GetProof(uint64(s.nft.NftIndex))
+		require.NoError(t, err)
+		return &NftProof{
+			BlockHeight:         1,
+			StateRoot:           s.stateRoot(),
+			AccountRoot:         common.Bytes2Hex(s.accountTree.Root()),
+			NftIndex:            s.nft.NftIndex,
+			CreatorAccountIndex: s.nft.CreatorAccountIndex,
+			OwnerAccountIndex:   s.nft.OwnerAccountIndex,
+			NftContentHash:      s.nft.NftContentHash,
+			NftL1Address:        s.nft.NftL1Address,
+			NftL1TokenId:        s.nft.NftL1TokenId,
+			CreatorTreasuryRate: s.nft.CreatorTreasuryRate,
+			CollectionId:        s.nft.CollectionId,
+			Siblings:            FormatSiblings(proof),
+		}
+	}
+
+	assert.NoError(t, VerifyNftProof(nftProof()))
+
+	proof := nftProof()
+	proof.OwnerAccountIndex = 2
+	assert.ErrorIs(t, VerifyNftProof(proof), ErrRootMismatch)
+
+	proof = nftProof()
+	proof.Siblings[0] = "0x1234"
+	assert.ErrorIs(t, VerifyNftProof(proof), ErrInvalidProof)
+}
@@ -22,6 +22,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/bnb-chain/zkbnb-crypto/util"
 )
 
 func TestToPackedAmount(t *testing.T) {
@@ -37,3 +39,25 @@ func TestToPackedFee(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, fee, int64(32011))
 }
+
+func TestFromPackedAmount(t *testing.T) {
+	for _, s := range []string{"0", "34359738361", "34359738368", "100000000000000000000", "123456789000000000000000"} {
+		a, _ := new(big.Int).SetString(s, 10)
+		packed, err := ToPackedAmount(a)
+		assert.NoError(t, err)
+		cleaned, err := util.CleanPackedAmount(a)
+		assert.NoError(t, err)
+		assert.Equal(t, cleaned.String(), FromPackedAmount(packed).String())
+	}
+}
+
+func TestFromPackedFee(t *testing.T) {
+	for _, s := range []string{"0", "2047", "100000000000000", "123000000000000000"} {
+		a, _ := new(big.Int).SetString(s, 10)
+		packed, err := ToPackedFee(a)
+		assert.NoError(t, err)
+		cleaned, err := util.CleanPackedFee(a)
+		assert.NoError(t, err)
+		assert.Equal(t, cleaned.String(), FromPackedFee(packed).String())
+	}
+}
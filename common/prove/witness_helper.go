@@ -639,12 +639,14 @@ func (w *WitnessHelper) constructSimpleWitnessInfo(oTx *tx.Tx) (
 	return accountKeys, accountWitnessInfo, nftWitnessInfo, nil
 }
 
-func (w *WitnessHelper) ConstructGasWitness(block *block.Block) (cryptoGas *GasWitness, err error) {
+// ConstructGasWitness constructs the witness of the gas account, the gas assets are the ones
+// which the block is committed with.
+func (w *WitnessHelper) ConstructGasWitness(block *block.Block, gasAssetIds []int64) (cryptoGas *GasWitness, err error) {
 	var gas *circuit.Gas
 
 	needGas := false
 	gasChanges := make(map[int64]*big.Int)
-	for _, assetId := range types.GasAssets {
+	for _, assetId := range gasAssetIds {
 		gasChanges[assetId] = types.ZeroBigInt
 	}
 	for _, tx := range block.Txs {
@@ -678,7 +680,7 @@ func (w *WitnessHelper) ConstructGasWitness(block *block.Block) (cryptoGas *GasW
 			return nil, err
 		}
 		merkleProofsAccountAssetsBefore := make([][AssetMerkleLevels][]byte, 0)
-		for _, assetId := range types.GasAssets {
+		for _, assetId := range gasAssetIds {
 			accountInfoBefore.AssetsInfo = append(accountInfoBefore.AssetsInfo, cryptoTypes.EmptyAccountAsset(assetId))
 			assetMerkleProof, err := emptyAssetTree.GetProof(uint64(assetId))
 			if err != nil {
@@ -691,7 +693,7 @@ func (w *WitnessHelper) ConstructGasWitness(block *block.Block) (cryptoGas *GasW
 			merkleProofsAccountAssetsBefore = append(merkleProofsAccountAssetsBefore, merkleProofsAccountAssetBefore)
 		}
 		gas = &circuit.Gas{
-			GasAssetCount:                   len(types.GasAssets),
+			GasAssetCount:                   len(gasAssetIds),
 			AccountInfoBefore:               accountInfoBefore,
 			MerkleProofsAccountBefore:       merkleProofsAccountBefore,
 			MerkleProofsAccountAssetsBefore: merkleProofsAccountAssetsBefore,
@@ -720,7 +722,7 @@ func (w *WitnessHelper) ConstructGasWitness(block *block.Block) (cryptoGas *GasW
 			return nil, err
 		}
 		merkleProofsAccountAssetsBefore := make([][AssetMerkleLevels][]byte, 0)
-		for _, assetId := range types.GasAssets {
+		for _, assetId := range gasAssetIds {
 			assetMerkleProof, err := w.assetTrees.Get(gasAccountIndex).GetProof(uint64(assetId))
 			if err != nil {
 				return nil, err
@@ -772,7 +774,7 @@ func (w *WitnessHelper) ConstructGasWitness(block *block.Block) (cryptoGas *GasW
 			return nil, err
 		}
 		gas = &circuit.Gas{
-			GasAssetCount:                   len(types.GasAssets),
+			GasAssetCount:                   len(gasAssetIds),
 			AccountInfoBefore:               accountInfoBefore,
 			MerkleProofsAccountBefore:       merkleProofsAccountBefore,
 			MerkleProofsAccountAssetsBefore: merkleProofsAccountAssetsBefore,
@@ -35,6 +35,7 @@ import (
 	"github.com/bnb-chain/zkbnb/dao/blockwitness"
 	"github.com/bnb-chain/zkbnb/dao/nft"
 	"github.com/bnb-chain/zkbnb/tree"
+	"github.com/bnb-chain/zkbnb/types"
 )
 
 var (
@@ -70,7 +71,7 @@ func TestConstructWitness(t *testing.T) {
 			actualBz, _ := json.Marshal(txWitness)
 			assert.Equal(t, string(actualBz), string(expectedBz), fmt.Sprintf("block %d, tx %d generate witness failed, tx type: %d", h, idx, tx.TxType))
 		}
-		gasWitness, err := witnessHelper.ConstructGasWitness(b[0])
+		gasWitness, err := witnessHelper.ConstructGasWitness(b[0], types.InitialGasAssetIds)
 		assert.NoError(t, err)
 		expectedBz, _ := json.Marshal(cBlock.Gas)
 		actualBz, _ := json.Marshal(gasWitness)
@@ -2,10 +2,14 @@ package core
 
 import (
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/zeromicro/go-zero/core/logx"
 
+	"github.com/bnb-chain/zkbnb/common/chain"
 	"github.com/bnb-chain/zkbnb/core/executor"
+	sdb "github.com/bnb-chain/zkbnb/core/statedb"
 	"github.com/bnb-chain/zkbnb/dao/tx"
 	"github.com/bnb-chain/zkbnb/types"
 )
@@ -14,6 +18,11 @@ type Processor interface {
 	Process(tx *tx.Tx) error
 }
 
+// BatchProcessor is implemented by the processors which could process the txs in batches.
+type BatchProcessor interface {
+	ProcessBatch(txs []*tx.Tx) []error
+}
+
 type CommitProcessor struct {
 	bc *BlockChain
 }
@@ -37,10 +46,162 @@ func (p *CommitProcessor) Process(tx *tx.Tx) error {
 	if err != nil {
 		return err
 	}
-	err = executor.VerifyInputs(true)
+	err = executor.VerifyInputs(false)
 	if err != nil {
 		return err
 	}
+	return p.execute(executor, tx)
+}
+
+// ProcessBatch processes the txs with the same results as processing them one by one in
+// order. The layer2 txs which don't affect the same accounts or nfts are verified and applied
+// concurrently, each on its own overlay of the state, and the overlays are merged in the order
+// of the txs. The pub data and the executed txs are generated one by one after merging, so the
+// tx indexes, the pub data and the state roots are the same as the serial execution. The errors
+// of the failed txs are returned at their positions.
+func (p *CommitProcessor) ProcessBatch(txs []*tx.Tx) []error {
+	p.bc.setCurrentBlockTimeStamp()
+	defer p.bc.resetCurrentBlockTimeStamp()
+
+	errs := make([]error, len(txs))
+	for i := 0; i < len(txs); {
+		// The priority operations are processed alone, since they could depend on the
+		// states which are not tracked by the executors, e.g. the next account index.
+		if types.IsPriorityOperationTx(txs[i].TxType) {
+			errs[i] = p.Process(txs[i])
+			i++
+			continue
+		}
+
+		batch := p.prepareBatch(txs[i:])
+		p.executeBatch(batch)
+		for j, item := range batch {
+			if item.err == nil {
+				p.mergeBatchItem(item, txs[i+j])
+			}
+			errs[i+j] = item.err
+		}
+		i += len(batch)
+	}
+	return errs
+}
+
+// overlayChain is the blockchain seen by the executor of a batch tx, the state is switched
+// to the overlay of the tx while the batch is executed concurrently.
+type overlayChain struct {
+	*BlockChain
+	statedb *sdb.StateDB
+}
+
+func (c *overlayChain) StateDB() *sdb.StateDB {
+	return c.statedb
+}
+
+type batchItem struct {
+	chain     *overlayChain
+	executor  executor.TxExecutor
+	txDetails []*tx.TxDetail
+	applyErr  error
+	err       error
+}
+
+// prepareBatch prepares the leading layer2 txs until a tx conflicts with the previous ones.
+// A conflicting tx is left to the next batch and prepared again, since it could depend on
+// the states changed by the previous txs, e.g. the next nft index of the minted nfts.
+// The gas fees paid to the gas account don't make the txs conflict, since they are kept in
+// the pending gas of the block and credited to the gas account only when the block is
+// finalized. But the tx details of the txs paying the gas fees show the balances of the gas
+// account, so they conflict with the txs changing the gas account, e.g. a transfer from it.
+func (p *CommitProcessor) prepareBatch(txs []*tx.Tx) []*batchItem {
+	batch := make([]*batchItem, 0)
+	affectedAccounts := make(map[int64]bool)
+	affectedNfts := make(map[int64]bool)
+	gasAccounts := make(map[int64]bool)
+	for _, poolTx := range txs {
+		if types.IsPriorityOperationTx(poolTx.TxType) {
+			break
+		}
+
+		chain := &overlayChain{BlockChain: p.bc, statedb: p.bc.Statedb}
+		executor, err := executor.NewTxExecutor(chain, poolTx)
+		if err != nil {
+			batch = append(batch, &batchItem{err: fmt.Errorf("new tx executor failed")})
+			continue
+		}
+		err = executor.Prepare()
+		if err != nil {
+			batch = append(batch, &batchItem{err: err})
+			continue
+		}
+
+		accounts, nfts := executor.AffectedStates()
+		paidGasAccounts := executor.GasAccounts()
+		if len(batch) > 0 && (containsAny(affectedAccounts, accounts) || containsAny(gasAccounts, accounts) ||
+			containsAny(affectedAccounts, paidGasAccounts) || containsAny(affectedNfts, nfts)) {
+			break
+		}
+		for _, accountIndex := range accounts {
+			affectedAccounts[accountIndex] = true
+		}
+		for _, nftIndex := range nfts {
+			affectedNfts[nftIndex] = true
+		}
+		for _, gasAccountIndex := range paidGasAccounts {
+			gasAccounts[gasAccountIndex] = true
+		}
+		batch = append(batch, &batchItem{chain: chain, executor: executor})
+	}
+	return batch
+}
+
+// executeBatch verifies the prepared txs and applies them to their overlays concurrently, the
+// current state is read only until the overlays are merged.
+func (p *CommitProcessor) executeBatch(batch []*batchItem) {
+	var wg sync.WaitGroup
+	for _, item := range batch {
+		if item.err != nil {
+			continue
+		}
+		item := item
+		item.chain.statedb = p.bc.Statedb.NewOverlay()
+		wg.Add(1)
+		err := p.bc.taskPool.Submit(func() {
+			defer wg.Done()
+			item.execute()
+		})
+		if err != nil {
+			wg.Done()
+			item.execute()
+		}
+	}
+	wg.Wait()
+}
+
+func (item *batchItem) execute() {
+	item.err = item.executor.VerifyInputs(false)
+	if item.err != nil {
+		return
+	}
+	item.txDetails, item.err = item.executor.GenerateTxDetails()
+	if item.err != nil {
+		return
+	}
+	item.applyErr = item.executor.ApplyTransaction()
+}
+
+// mergeBatchItem merges the overlay of the executed tx into the current state, and generates
+// the pub data and the executed tx on the current state.
+func (p *CommitProcessor) mergeBatchItem(item *batchItem, tx *tx.Tx) {
+	if item.applyErr != nil {
+		panic(item.applyErr)
+	}
+	p.bc.Statedb.Merge(item.chain.statedb)
+	item.chain.statedb = p.bc.Statedb
+	tx.TxDetails = item.txDetails
+	p.finish(item.executor)
+}
+
+func (p *CommitProcessor) execute(executor executor.TxExecutor, tx *tx.Tx) error {
 	txDetails, err := executor.GenerateTxDetails()
 	if err != nil {
 		return err
@@ -50,27 +211,47 @@ func (p *CommitProcessor) Process(tx *tx.Tx) error {
 	if err != nil {
 		panic(err)
 	}
-	err = executor.GeneratePubData()
+	p.finish(executor)
+	return nil
+}
+
+// finish generates the pub data and the executed tx after the tx is applied to the state.
+func (p *CommitProcessor) finish(executor executor.TxExecutor) {
+	err := executor.GeneratePubData()
 	if err != nil {
 		panic(err)
 	}
-	tx, err = executor.GetExecutedTx()
+	tx, err := executor.GetExecutedTx()
 	if err != nil {
 		panic(err)
 	}
 
 	p.bc.Statedb.Txs = append(p.bc.Statedb.Txs, tx)
+}
 
-	return nil
+func containsAny(set map[int64]bool, keys []int64) bool {
+	for _, key := range keys {
+		if set[key] {
+			return true
+		}
+	}
+	return false
 }
 
 type APIProcessor struct {
 	bc *BlockChain
+
+	// In the speculative mode, the pending txs of the sender in the tx pool are replayed
+	// before its first tx is verified, so the checks match what the committer will see.
+	speculative      bool
+	replayedAccounts map[int64]bool
 }
 
-func NewAPIProcessor(bc *BlockChain) Processor {
+func NewAPIProcessor(bc *BlockChain, speculative bool) Processor {
 	return &APIProcessor{
-		bc: bc,
+		bc:               bc,
+		speculative:      speculative,
+		replayedAccounts: make(map[int64]bool),
 	}
 }
 
@@ -80,15 +261,203 @@ func (p *APIProcessor) Process(tx *tx.Tx) error {
 		return fmt.Errorf("new tx executor failed")
 	}
 
-	err = executor.Prepare()
+	err = p.prepare(executor, tx)
+	if err != nil {
+		return err
+	}
+	err = p.prepareReplacement(tx)
+	if err != nil {
+		return err
+	}
+	err = executor.VerifyInputs(false)
+	if err != nil {
+		return types.AppErrInvalidTxField.RefineError(err.Error())
+	}
+
+	// Apply the layer2 tx to the dry-run state, so that the following txs verified by
+	// the same blockchain could depend on it, e.g. the txs with consecutive nonces.
+	if types.IsPriorityOperationTx(tx.TxType) {
+		return nil
+	}
+	err = p.applyTransaction(executor, tx)
+	if err != nil {
+		logx.Error("fail to apply transaction:", err)
+		return types.AppErrInternal
+	}
+	return nil
+}
+
+func (p *APIProcessor) prepare(executor executor.TxExecutor, tx *tx.Tx) error {
+	err := executor.Prepare()
 	if err != nil {
 		logx.Error("fail to prepare:", err)
 		return types.AppErrInternal
 	}
+	if p.speculative && !types.IsPriorityOperationTx(tx.TxType) && !p.replayedAccounts[tx.AccountIndex] {
+		p.replayedAccounts[tx.AccountIndex] = true
+		err = p.replayPendingTxs(tx.AccountIndex, tx.Nonce)
+		if err != nil {
+			logx.Error("fail to replay pending txs:", err)
+			return types.AppErrInternal
+		}
+		// Prepare again since the states could be changed by the replayed txs.
+		err = executor.Prepare()
+		if err != nil {
+			logx.Error("fail to prepare:", err)
+			return types.AppErrInternal
+		}
+	}
+	return nil
+}
+
+// prepareReplacement checks whether the tx replaces the pending tx of the same account and
+// nonce in the tx pool, the replacing tx must pay a higher gas fee value than the replaced one.
+func (p *APIProcessor) prepareReplacement(tx *tx.Tx) error {
+	if types.IsPriorityOperationTx(tx.TxType) {
+		return nil
+	}
+	replacedTx, err := p.bc.TxPoolModel.GetPendingTxByAccountIndexAndNonce(tx.AccountIndex, tx.Nonce)
+	if err != nil {
+		if err == types.DbErrNotFound {
+			return nil
+		}
+		logx.Error("fail to get pending tx:", err)
+		return types.AppErrInternal
+	}
+
+	gasConfig, err := p.bc.StateDB().GetMinGasFees(time.Time{})
+	if err != nil {
+		logx.Error("fail to get gas fees:", err)
+		return types.AppErrInternal
+	}
+	gasFeeValue := chain.ComputeGasFeeValue(gasConfig, tx.TxType, tx.GasFeeAssetId, tx.GasFee)
+	replacedGasFeeValue := chain.ComputeGasFeeValue(gasConfig, replacedTx.TxType, replacedTx.GasFeeAssetId, replacedTx.GasFee)
+	if gasFeeValue.Cmp(replacedGasFeeValue) <= 0 {
+		return types.AppErrTxUnderpriced
+	}
+
+	// Verify the nonce as if the replaced tx is not in the tx pool.
+	if !p.bc.StateDB().HasPendingNonce(tx.AccountIndex) {
+		err = p.bc.StateDB().SetPendingNonce(tx.AccountIndex, tx.Nonce)
+		if err != nil {
+			logx.Error("fail to get account:", err)
+			return types.AppErrInternal
+		}
+	}
+	p.bc.replacedTxs = append(p.bc.replacedTxs, replacedTx)
+	return nil
+}
+
+// replayPendingTxs applies the pending txs of the account before the nonce in the tx pool to
+// the dry-run state in order, the ones which would fail in the committer are skipped.
+func (p *APIProcessor) replayPendingTxs(accountIndex int64, nonce int64) error {
+	poolTxs, err := p.bc.TxPoolModel.GetPendingTxsByAccountIndex(accountIndex)
+	if err != nil {
+		if err == types.DbErrNotFound {
+			return nil
+		}
+		return err
+	}
+
+	// The pending nonce starts from the nonce of the account state instead of the tx pool.
+	fromAccount, err := p.bc.StateDB().GetFormatAccount(accountIndex)
+	if err != nil {
+		return err
+	}
+	err = p.bc.StateDB().SetPendingNonce(accountIndex, fromAccount.Nonce)
+	if err != nil {
+		return err
+	}
+
+	for _, poolTx := range poolTxs {
+		if poolTx.Nonce >= nonce {
+			continue
+		}
+		executor, err := executor.NewTxExecutor(p.bc, poolTx)
+		if err != nil {
+			logx.Infof("skip pending tx %s, err: %v", poolTx.TxHash, err)
+			continue
+		}
+		err = executor.Prepare()
+		if err != nil {
+			return err
+		}
+		err = executor.VerifyInputs(false)
+		if err != nil {
+			logx.Infof("skip pending tx %s, err: %v", poolTx.TxHash, err)
+			continue
+		}
+		err = p.applyTransaction(executor, poolTx)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *APIProcessor) applyTransaction(executor executor.TxExecutor, tx *tx.Tx) error {
+	err := executor.ApplyTransaction()
+	if err != nil {
+		return err
+	}
+	// The nonce of the dry-run state starts from the committed one, align it with
+	// the nonce of the tx instead.
+	return p.bc.StateDB().SetPendingNonce(tx.AccountIndex, tx.Nonce+1)
+}
+
+// SimulateProcessor runs the whole executor pipeline of the txs on the dry-run state, the
+// generated tx details and the executed tx are kept in the tx for previewing its effects.
+type SimulateProcessor struct {
+	*APIProcessor
+}
+
+func NewSimulateProcessor(bc *BlockChain, speculative bool) Processor {
+	return &SimulateProcessor{
+		APIProcessor: &APIProcessor{
+			bc:               bc,
+			speculative:      speculative,
+			replayedAccounts: make(map[int64]bool),
+		},
+	}
+}
+
+func (p *SimulateProcessor) Process(tx *tx.Tx) error {
+	if types.IsPriorityOperationTx(tx.TxType) {
+		return types.AppErrInvalidTxType
+	}
+
+	executor, err := executor.NewTxExecutor(p.bc, tx)
+	if err != nil {
+		return fmt.Errorf("new tx executor failed")
+	}
+
+	err = p.prepare(executor, tx)
+	if err != nil {
+		return err
+	}
+	err = p.prepareReplacement(tx)
+	if err != nil {
+		return err
+	}
 	err = executor.VerifyInputs(false)
 	if err != nil {
 		return types.AppErrInvalidTxField.RefineError(err.Error())
 	}
-
+	txDetails, err := executor.GenerateTxDetails()
+	if err != nil {
+		logx.Error("fail to generate tx details:", err)
+		return types.AppErrInternal
+	}
+	tx.TxDetails = txDetails
+	err = p.applyTransaction(executor, tx)
+	if err != nil {
+		logx.Error("fail to apply transaction:", err)
+		return types.AppErrInternal
+	}
+	_, err = executor.GetExecutedTx()
+	if err != nil {
+		logx.Error("fail to get executed tx:", err)
+		return types.AppErrInternal
+	}
 	return nil
 }
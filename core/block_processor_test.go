@@ -0,0 +1,463 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/panjf2000/ants/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	curve "github.com/bnb-chain/zkbnb-crypto/ecc/ztwistededwards/tebn254"
+	"github.com/bnb-chain/zkbnb-crypto/wasm/txtypes"
+	"github.com/bnb-chain/zkbnb/core/executor"
+	sdb "github.com/bnb-chain/zkbnb/core/statedb"
+	"github.com/bnb-chain/zkbnb/dao/account"
+	"github.com/bnb-chain/zkbnb/dao/block"
+	"github.com/bnb-chain/zkbnb/dao/dbcache"
+	"github.com/bnb-chain/zkbnb/dao/gasfee"
+	"github.com/bnb-chain/zkbnb/dao/nft"
+	"github.com/bnb-chain/zkbnb/dao/sysconfig"
+	"github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/tree"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+const testGasFee = 100
+
+type testAccountModel struct {
+	account.AccountModel
+	accounts map[int64]*account.Account
+}
+
+func (m *testAccountModel) GetAccountByIndex(accountIndex int64) (*account.Account, error) {
+	a, ok := m.accounts[accountIndex]
+	if !ok {
+		return nil, types.DbErrNotFound
+	}
+	copied := *a
+	return &copied, nil
+}
+
+func (m *testAccountModel) GetAccountByNameHash(nameHash string) (*account.Account, error) {
+	for accountIndex, a := range m.accounts {
+		if a.AccountNameHash == nameHash {
+			return m.GetAccountByIndex(accountIndex)
+		}
+	}
+	return nil, types.DbErrNotFound
+}
+
+type testAccountHistoryModel struct {
+	account.AccountHistoryModel
+}
+
+func (m *testAccountHistoryModel) GetValidAccountCount(int64) (int64, error) {
+	return 0, nil
+}
+
+type testNftModel struct {
+	nft.L2NftModel
+}
+
+func (m *testNftModel) GetLatestNftIndex() (int64, error) {
+	return -1, nil
+}
+
+func (m *testNftModel) GetNft(int64) (*nft.L2Nft, error) {
+	return nil, types.DbErrNotFound
+}
+
+type testNftHistoryModel struct {
+	nft.L2NftHistoryModel
+}
+
+func (m *testNftHistoryModel) GetLatestNftsCountByBlockHeight(int64) (int64, error) {
+	return 0, nil
+}
+
+type testSysConfigModel struct {
+	sysconfig.SysConfigModel
+	configs map[string]string
+}
+
+func (m *testSysConfigModel) GetSysConfigByName(name string) (*sysconfig.SysConfig, error) {
+	value, ok := m.configs[name]
+	if !ok {
+		return nil, types.DbErrNotFound
+	}
+	return &sysconfig.SysConfig{Name: name, Value: value}, nil
+}
+
+type testGasFeeModel struct {
+	gasfee.GasFeeModel
+}
+
+func (m *testGasFeeModel) GetLatestGasFee() (*gasfee.GasFee, error) {
+	return nil, types.DbErrNotFound
+}
+
+// testRedisCache misses all the keys, the states are read from the models.
+type testRedisCache struct {
+	dbcache.Cache
+}
+
+func (c *testRedisCache) Get(context.Context, string, interface{}) (interface{}, error) {
+	return nil, errors.New("not found")
+}
+
+func (c *testRedisCache) Set(context.Context, string, interface{}) error {
+	return nil
+}
+
+type testAccount struct {
+	index    int64
+	nameHash string
+	sk       *txtypes.PrivateKey
+}
+
+var testAccountNames = []string{"gas", "a", "b", "c", "d"}
+
+// newTestAccounts creates the gas account and the accounts a, b, c and d, each of them could
+// mint the nfts of its collection 0.
+func newTestAccounts(t testing.TB) (map[string]*testAccount, *testAccountModel) {
+	return newTestAccountsOf(t, testAccountNames)
+}
+
+// newTestAccountsOf creates the accounts of the names from the gas account index in order.
+func newTestAccountsOf(t testing.TB, names []string) (map[string]*testAccount, *testAccountModel) {
+	accounts := make(map[string]*testAccount)
+	accountModel := &testAccountModel{accounts: make(map[int64]*account.Account)}
+	for i, name := range names {
+		sk, err := curve.GenerateEddsaPrivateKey(name)
+		require.NoError(t, err)
+		a := &testAccount{
+			index:    types.GasAccount + int64(i),
+			nameHash: common.Bytes2Hex(common.BigToHash(big.NewInt(int64(i + 1))).Bytes()),
+			sk:       sk,
+		}
+		accounts[name] = a
+		assetInfo, err := json.Marshal(map[int64]*types.AccountAsset{
+			types.BNBAssetId: {AssetId: types.BNBAssetId, Balance: big.NewInt(1000000), OfferCanceledOrFinalized: big.NewInt(0)},
+		})
+		require.NoError(t, err)
+		accountModel.accounts[a.index] = &account.Account{
+			AccountIndex:    a.index,
+			AccountName:     name,
+			PublicKey:       common.Bytes2Hex(sk.PublicKey.Bytes()),
+			AccountNameHash: a.nameHash,
+			CollectionNonce: 1,
+			AssetInfo:       string(assetInfo),
+		}
+	}
+	return accounts, accountModel
+}
+
+// newTestChain creates a committer chain of the accounts on the empty trees.
+func newTestChain(t testing.TB, accountModel *testAccountModel) *BlockChain {
+	gasAssets, err := json.Marshal([]*types.GasAssets{{Version: types.InitialGasAssetsVersion, AssetIds: []int64{types.BNBAssetId}}})
+	require.NoError(t, err)
+	chainDb := &sdb.ChainDB{
+		AccountModel:        accountModel,
+		AccountHistoryModel: &testAccountHistoryModel{},
+		L2NftModel:          &testNftModel{},
+		L2NftHistoryModel:   &testNftHistoryModel{},
+		SysConfigModel: &testSysConfigModel{configs: map[string]string{
+			types.GasAccountIndex: fmt.Sprintf("%d", types.GasAccount),
+			types.SysGasFee: fmt.Sprintf(`{"%d":{"%d":%d,"%d":%d,"%d":%d}}`, types.BNBAssetId,
+				types.TxTypeTransfer, testGasFee, types.TxTypeMintNft, testGasFee, types.TxTypeAtomicMatch, testGasFee),
+			types.SysGasAssets: string(gasAssets),
+		}},
+		GasFeeModel: &testGasFeeModel{},
+	}
+	stateRoot := common.Bytes2Hex(tree.NilStateRoot)
+	treeCtx := &tree.Context{Name: t.Name(), Driver: tree.MemoryDB}
+	statedb, err := sdb.NewStateDB(treeCtx, chainDb, &testRedisCache{}, &sdb.DefaultCacheConfig, 100, stateRoot, 0)
+	require.NoError(t, err)
+	taskPool, err := ants.NewPool(defaultTaskPoolSize)
+	require.NoError(t, err)
+	t.Cleanup(taskPool.Release)
+	bc := &BlockChain{
+		ChainDB:      chainDb,
+		Statedb:      statedb,
+		currentBlock: &block.Block{BlockHeight: 0, StateRoot: stateRoot, BlockStatus: block.StatusPending},
+		taskPool:     taskPool,
+	}
+	bc.processor = NewCommitProcessor(bc)
+	_, err = bc.ProposeNewBlock()
+	require.NoError(t, err)
+	return bc
+}
+
+func newTestTransfer(t testing.TB, accounts map[string]*testAccount, from, to string, amount, nonce, expiredAt int64) *tx.Tx {
+	segment, err := json.Marshal(&txtypes.TransferSegmentFormat{
+		FromAccountIndex:  accounts[from].index,
+		ToAccountIndex:    accounts[to].index,
+		ToAccountNameHash: accounts[to].nameHash,
+		AssetId:           types.BNBAssetId,
+		AssetAmount:       fmt.Sprintf("%d", amount),
+		GasAccountIndex:   types.GasAccount,
+		GasFeeAssetId:     types.BNBAssetId,
+		GasFeeAssetAmount: fmt.Sprintf("%d", testGasFee),
+		ExpiredAt:         expiredAt,
+		Nonce:             nonce,
+	})
+	require.NoError(t, err)
+	txInfo, err := txtypes.ConstructTransferTxInfo(accounts[from].sk, string(segment))
+	require.NoError(t, err)
+	txInfoBytes, err := json.Marshal(txInfo)
+	require.NoError(t, err)
+	return &tx.Tx{TxType: types.TxTypeTransfer, TxInfo: string(txInfoBytes)}
+}
+
+func newTestMintNft(t testing.TB, accounts map[string]*testAccount, creator string, nonce, expiredAt int64) *tx.Tx {
+	segment, err := json.Marshal(&txtypes.MintNftSegmentFormat{
+		CreatorAccountIndex: accounts[creator].index,
+		ToAccountIndex:      accounts[creator].index,
+		ToAccountNameHash:   accounts[creator].nameHash,
+		NftContentHash:      common.Bytes2Hex(common.BigToHash(big.NewInt(nonce + 100)).Bytes()),
+		NftCollectionId:     0,
+		GasAccountIndex:     types.GasAccount,
+		GasFeeAssetId:       types.BNBAssetId,
+		GasFeeAssetAmount:   fmt.Sprintf("%d", testGasFee),
+		ExpiredAt:           expiredAt,
+		Nonce:               nonce,
+	})
+	require.NoError(t, err)
+	txInfo, err := txtypes.ConstructMintNftTxInfo(accounts[creator].sk, string(segment))
+	require.NoError(t, err)
+	txInfoBytes, err := json.Marshal(txInfo)
+	require.NoError(t, err)
+	return &tx.Tx{TxType: types.TxTypeMintNft, TxInfo: string(txInfoBytes)}
+}
+
+func TestProcessBatch(t *testing.T) {
+	accounts, accountModel := newTestAccounts(t)
+	expiredAt := time.Now().Add(time.Hour).UnixMilli()
+	transfer := func(from, to string, amount, nonce int64) *tx.Tx {
+		return newTestTransfer(t, accounts, from, to, amount, nonce, expiredAt)
+	}
+	mint := func(creator string, nonce int64) *tx.Tx {
+		return newTestMintNft(t, accounts, creator, nonce, expiredAt)
+	}
+	deposit := func(to string, amount int64) *tx.Tx {
+		txInfoBytes, err := json.Marshal(&txtypes.DepositTxInfo{
+			TxType:          types.TxTypeDeposit,
+			AccountNameHash: common.FromHex(accounts[to].nameHash),
+			AssetId:         types.BNBAssetId,
+			AssetAmount:     big.NewInt(amount),
+		})
+		require.NoError(t, err)
+		return &tx.Tx{TxType: types.TxTypeDeposit, TxInfo: string(txInfoBytes)}
+	}
+	newTxs := func() []*tx.Tx {
+		txs := []*tx.Tx{
+			transfer("a", "b", 100, 0),
+			transfer("c", "d", 100, 0),
+			// conflicts with the transfer of a
+			mint("a", 1),
+			// takes the next nft index after the mint of a
+			mint("c", 1),
+			// fails in the middle of the batch
+			transfer("b", "a", 100, 5),
+			transfer("d", "b", 50, 0),
+			deposit("b", 1000),
+			// depends on all the txs received by b
+			transfer("b", "c", 1001000, 0),
+			transfer("a", "c", 1000000, 2),
+			transfer("a", "c", 100, 2),
+			// the gas fees of the block are not credited to the gas account yet
+			transfer("gas", "a", 1000000, 0),
+			transfer("gas", "d", 100, 0),
+			// the tx details show the balance of the gas account after the transfer from it
+			transfer("c", "b", 10, 2),
+		}
+		for i, poolTx := range txs {
+			poolTx.TxHash = fmt.Sprintf("pool-tx-%d", i)
+			poolTx.TxStatus = tx.StatusPending
+		}
+		return txs
+	}
+
+	serialChain := newTestChain(t, accountModel)
+	serialTxs := newTxs()
+	serialErrs := make([]error, len(serialTxs))
+	for i, poolTx := range serialTxs {
+		serialErrs[i] = serialChain.processor.Process(poolTx)
+	}
+	batchChain := newTestChain(t, accountModel)
+	batchTxs := newTxs()
+	batchErrs := batchChain.ApplyTransactions(batchTxs)
+
+	for i := range serialErrs {
+		if i == 4 || i == 8 || i == 10 {
+			assert.Error(t, serialErrs[i], "tx %d", i)
+		} else {
+			assert.NoError(t, serialErrs[i], "tx %d", i)
+		}
+		assert.Equal(t, fmt.Sprint(serialErrs[i]), fmt.Sprint(batchErrs[i]), "tx %d", i)
+		assert.Equal(t, serialTxs[i].TxDetails, batchTxs[i].TxDetails, "tx %d", i)
+	}
+
+	require.Len(t, batchChain.Statedb.Txs, len(serialChain.Statedb.Txs))
+	for i, executedTx := range serialChain.Statedb.Txs {
+		assert.Equal(t, executedTx.TxHash, batchChain.Statedb.Txs[i].TxHash)
+		assert.Equal(t, executedTx.TxIndex, batchChain.Statedb.Txs[i].TxIndex)
+		assert.Equal(t, executedTx.NftIndex, batchChain.Statedb.Txs[i].NftIndex)
+		assert.Equal(t, executedTx.TxInfo, batchChain.Statedb.Txs[i].TxInfo)
+	}
+	assert.Equal(t, []int64{0, 1}, []int64{serialTxs[2].NftIndex, serialTxs[3].NftIndex})
+
+	require.NoError(t, serialChain.Statedb.IntermediateRoot(true))
+	require.NoError(t, batchChain.Statedb.IntermediateRoot(true))
+	assert.Equal(t, serialChain.Statedb.StateRoot, batchChain.Statedb.StateRoot)
+	assert.Equal(t, serialChain.Statedb.PubData, batchChain.Statedb.PubData)
+	assert.Equal(t, serialChain.Statedb.PubDataOffset, batchChain.Statedb.PubDataOffset)
+	assert.Equal(t, serialChain.Statedb.PriorityOperations, batchChain.Statedb.PriorityOperations)
+	assert.Equal(t, int64(1), batchChain.Statedb.PriorityOperations)
+}
+
+// BenchmarkProcessBatch compares the batch processing with the serial processing of the
+// transfers between the distinct accounts, e.g. an airdrop. Only the processing is timed, each
+// iteration runs on a fresh chain. The speedup is bounded by the cores, e.g. run it with -cpu 1,4,8.
+func BenchmarkProcessBatch(b *testing.B) {
+	const txCount = 256
+	names := []string{"gas"}
+	for i := 0; i < 2*txCount; i++ {
+		names = append(names, fmt.Sprintf("account-%d", i))
+	}
+	accounts, accountModel := newTestAccountsOf(b, names)
+	expiredAt := time.Now().Add(time.Hour).UnixMilli()
+	newTxs := func() []*tx.Tx {
+		txs := make([]*tx.Tx, 0, txCount)
+		for i := 0; i < txCount; i++ {
+			poolTx := newTestTransfer(b, accounts, names[2*i+1], names[2*i+2], 100, 0, expiredAt)
+			poolTx.TxHash = fmt.Sprintf("pool-tx-%d", i)
+			poolTx.TxStatus = tx.StatusPending
+			txs = append(txs, poolTx)
+		}
+		return txs
+	}
+
+	run := func(b *testing.B, process func(bc *BlockChain, txs []*tx.Tx) []error) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			bc := newTestChain(b, accountModel)
+			txs := newTxs()
+			b.StartTimer()
+			for j, err := range process(bc, txs) {
+				if err != nil {
+					b.Fatalf("tx %d: %v", j, err)
+				}
+			}
+		}
+	}
+	b.Run("serial", func(b *testing.B) {
+		run(b, func(bc *BlockChain, txs []*tx.Tx) []error {
+			errs := make([]error, len(txs))
+			for i, poolTx := range txs {
+				errs[i] = bc.processor.Process(poolTx)
+			}
+			return errs
+		})
+	})
+	b.Run("batch", func(b *testing.B) {
+		run(b, func(bc *BlockChain, txs []*tx.Tx) []error {
+			return bc.ApplyTransactions(txs)
+		})
+	})
+}
+
+func TestGetGasAssetsConcurrently(t *testing.T) {
+	_, accountModel := newTestAccounts(t)
+	bc := newTestChain(t, accountModel)
+
+	// The gas assets are loaded lazily by the concurrent verifications after the purge.
+	bc.Statedb.PurgeCache(bc.Statedb.StateRoot)
+	results := make([]*types.GasAssets, 8)
+	done := make(chan struct{})
+	for i := range results {
+		i := i
+		go func() {
+			defer func() { done <- struct{}{} }()
+			gasAssets, err := bc.Statedb.GetGasAssets()
+			assert.NoError(t, err)
+			results[i] = gasAssets
+		}()
+	}
+	for range results {
+		<-done
+	}
+	for _, gasAssets := range results {
+		require.NotNil(t, gasAssets)
+		assert.Same(t, results[0], gasAssets)
+	}
+}
+
+func TestVerifyOfferSignatures(t *testing.T) {
+	accounts, accountModel := newTestAccounts(t)
+	bc := newTestChain(t, accountModel)
+	expiredAt := time.Now().Add(time.Hour).UnixMilli()
+	require.NoError(t, bc.ApplyTransaction(newTestMintNft(t, accounts, "b", 0, expiredAt)))
+
+	offer := func(offerType int64, account, signer string) string {
+		segment, err := json.Marshal(&txtypes.OfferSegmentFormat{
+			Type:         offerType,
+			OfferId:      0,
+			AccountIndex: accounts[account].index,
+			NftIndex:     0,
+			AssetId:      types.BNBAssetId,
+			AssetAmount:  "10000",
+			ListedAt:     time.Now().UnixMilli(),
+			ExpiredAt:    expiredAt,
+		})
+		require.NoError(t, err)
+		offerInfo, err := txtypes.ConstructOfferTxInfo(accounts[signer].sk, string(segment))
+		require.NoError(t, err)
+		offerBytes, err := json.Marshal(offerInfo)
+		require.NoError(t, err)
+		return string(offerBytes)
+	}
+	atomicMatch := func(buyOffer string) *txtypes.AtomicMatchTxInfo {
+		segment, err := json.Marshal(&txtypes.AtomicMatchSegmentFormat{
+			AccountIndex:      accounts["c"].index,
+			BuyOffer:          buyOffer,
+			SellOffer:         offer(types.SellOfferType, "b", "b"),
+			GasAccountIndex:   types.GasAccount,
+			GasFeeAssetId:     types.BNBAssetId,
+			GasFeeAssetAmount: fmt.Sprintf("%d", testGasFee),
+			Nonce:             0,
+			ExpiredAt:         expiredAt,
+		})
+		require.NoError(t, err)
+		txInfo, err := txtypes.ConstructAtomicMatchTxInfo(accounts["c"].sk, string(segment))
+		require.NoError(t, err)
+		return txInfo
+	}
+	verify := func(txInfo *txtypes.AtomicMatchTxInfo) error {
+		txInfoBytes, err := json.Marshal(txInfo)
+		require.NoError(t, err)
+		e, err := executor.NewTxExecutor(bc, &tx.Tx{TxType: types.TxTypeAtomicMatch, TxInfo: string(txInfoBytes)})
+		require.NoError(t, err)
+		require.NoError(t, e.Prepare())
+		return e.VerifyInputs(false)
+	}
+	require.NoError(t, verify(atomicMatch(offer(types.BuyOfferType, "a", "a"))))
+
+	// The buy offer of a is signed by c.
+	txInfo := atomicMatch(offer(types.BuyOfferType, "a", "c"))
+	err := verify(txInfo)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid signature")
+
+	// The unsigned tx is simulated without verifying the signatures of the offers either.
+	bc.skipSignatureChk = true
+	txInfo.Sig = nil
+	require.NoError(t, verify(txInfo))
+}
@@ -9,12 +9,14 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/panjf2000/ants/v2"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/zeromicro/go-zero/core/logx"
 	"github.com/zeromicro/go-zero/core/stores/cache"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 
 	"github.com/bnb-chain/zkbnb/common/chain"
+	"github.com/bnb-chain/zkbnb/core/executor"
 	"github.com/bnb-chain/zkbnb/core/statedb"
 	sdb "github.com/bnb-chain/zkbnb/core/statedb"
 	"github.com/bnb-chain/zkbnb/dao/account"
@@ -22,16 +24,29 @@ import (
 	"github.com/bnb-chain/zkbnb/dao/block"
 	"github.com/bnb-chain/zkbnb/dao/compressedblock"
 	"github.com/bnb-chain/zkbnb/dao/dbcache"
+	"github.com/bnb-chain/zkbnb/dao/gasfee"
 	"github.com/bnb-chain/zkbnb/dao/nft"
 	"github.com/bnb-chain/zkbnb/dao/sysconfig"
 	"github.com/bnb-chain/zkbnb/dao/tx"
 	"github.com/bnb-chain/zkbnb/tree"
+	"github.com/bnb-chain/zkbnb/types"
 )
 
 const (
 	defaultTaskPoolSize = 1000
 )
 
+// TreeCommitLatencyMetric observes the time to commit the trees of a new block, it is not
+// registered by the chain since the chain is also used in the dry-run mode, the committer
+// registers it.
+var TreeCommitLatencyMetric = prometheus.NewHistogram(prometheus.HistogramOpts{
+	Namespace: "zkbnb",
+	Subsystem: "committer",
+	Name:      "tree_commit_seconds",
+	Help:      "Time to commit the trees of a new block.",
+	Buckets:   prometheus.ExponentialBuckets(0.01, 2, 12),
+})
+
 type ChainConfig struct {
 	Postgres struct {
 		DataSource string
@@ -55,6 +70,10 @@ type BlockChain struct {
 
 	chainConfig *ChainConfig
 	dryRun      bool //dryRun mode is used for verifying user inputs, is not for execution
+	// skipSignatureChk is used for simulating the unsigned txs
+	skipSignatureChk bool
+	// replacedTxs are the pending pool txs replaced by the dry-run txs
+	replacedTxs []*tx.Tx
 
 	currentBlock *block.Block
 	processor    Processor
@@ -97,6 +116,12 @@ func NewBlockChain(config *ChainConfig, moduleName string) (*BlockChain, error)
 	if err != nil {
 		return nil, err
 	}
+	if bc.currentBlock.BlockStatus == block.StatusProposing {
+		err = bc.Statedb.SetGasAssetsVersion(bc.currentBlock.GasAssetsVersion)
+		if err != nil {
+			return nil, err
+		}
+	}
 	bc.processor = NewCommitProcessor(bc)
 	taskPool, err := ants.NewPool(defaultTaskPoolSize)
 	if err != nil {
@@ -108,16 +133,18 @@ func NewBlockChain(config *ChainConfig, moduleName string) (*BlockChain, error)
 }
 
 // NewBlockChainForDryRun - for dry run mode, we can reuse existing models for quick creation
-// , e.g., for sending tx, we can create blockchain for each request quickly
+// , e.g., for sending tx, we can create blockchain for each request quickly.
+// In the speculative mode, the pending txs of the sender are replayed before its tx is verified.
 func NewBlockChainForDryRun(accountModel account.AccountModel,
 	nftModel nft.L2NftModel, txPoolModel tx.TxPoolModel, assetModel asset.AssetModel,
-	sysConfigModel sysconfig.SysConfigModel, redisCache dbcache.Cache) (*BlockChain, error) {
+	sysConfigModel sysconfig.SysConfigModel, gasFeeModel gasfee.GasFeeModel, redisCache dbcache.Cache, speculative bool) (*BlockChain, error) {
 	chainDb := &sdb.ChainDB{
 		AccountModel:     accountModel,
 		L2NftModel:       nftModel,
 		TxPoolModel:      txPoolModel,
 		L2AssetInfoModel: assetModel,
 		SysConfigModel:   sysConfigModel,
+		GasFeeModel:      gasFeeModel,
 	}
 	statedb, err := sdb.NewStateDBForDryRun(redisCache, &statedb.DefaultCacheConfig, chainDb)
 	if err != nil {
@@ -128,7 +155,24 @@ func NewBlockChainForDryRun(accountModel account.AccountModel,
 		dryRun:  true,
 		Statedb: statedb,
 	}
-	bc.processor = NewAPIProcessor(bc)
+	bc.processor = NewAPIProcessor(bc, speculative)
+	return bc, nil
+}
+
+// NewBlockChainForSimulation creates a dry-run blockchain which runs the whole executor
+// pipeline of the txs, including generating the tx details and applying them to the state.
+// The signatures are not verified if skipSignatureChk is set, e.g., for the unsigned txs.
+func NewBlockChainForSimulation(accountModel account.AccountModel,
+	nftModel nft.L2NftModel, txPoolModel tx.TxPoolModel, assetModel asset.AssetModel,
+	sysConfigModel sysconfig.SysConfigModel, gasFeeModel gasfee.GasFeeModel, redisCache dbcache.Cache, speculative, skipSignatureChk bool) (*BlockChain, error) {
+	bc, err := NewBlockChainForDryRun(accountModel, nftModel, txPoolModel, assetModel, sysConfigModel, gasFeeModel, redisCache, speculative)
+	if err != nil {
+		return nil, err
+	}
+	bc.skipSignatureChk = skipSignatureChk
+	// The simulated txs are not packed into any block.
+	bc.currentBlock = &block.Block{BlockHeight: types.NilBlockHeight}
+	bc.processor = NewSimulateProcessor(bc, speculative)
 	return bc, nil
 }
 
@@ -136,6 +180,25 @@ func (bc *BlockChain) ApplyTransaction(tx *tx.Tx) error {
 	return bc.processor.Process(tx)
 }
 
+// ApplyTransactions applies the txs in order and returns the errors of the failed txs at
+// their positions. The txs are processed in batches if the processor supports it.
+func (bc *BlockChain) ApplyTransactions(txs []*tx.Tx) []error {
+	if processor, ok := bc.processor.(BatchProcessor); ok {
+		return processor.ProcessBatch(txs)
+	}
+	errs := make([]error, len(txs))
+	for i, tx := range txs {
+		errs[i] = bc.processor.Process(tx)
+	}
+	return errs
+}
+
+// ReplacedTxs returns the pending pool txs replaced by the txs verified in the dry-run mode,
+// which have the same accounts and nonces but pay higher gas fees.
+func (bc *BlockChain) ReplacedTxs() []*tx.Tx {
+	return bc.replacedTxs
+}
+
 func (bc *BlockChain) ProposeNewBlock() (*block.Block, error) {
 	newBlock := &block.Block{
 		Model: gorm.Model{
@@ -147,11 +210,44 @@ func (bc *BlockChain) ProposeNewBlock() (*block.Block, error) {
 		BlockStatus: block.StatusProposing,
 	}
 
+	bc.Statedb.PurgeCache(newBlock.StateRoot)
+	// The new block is committed with the latest gas assets.
+	gasAssets, err := bc.Statedb.GetGasAssets()
+	if err != nil {
+		return nil, err
+	}
+	newBlock.GasAssetsVersion = gasAssets.Version
+
 	bc.currentBlock = newBlock
-	bc.Statedb.PurgeCache(bc.currentBlock.StateRoot)
 	return newBlock, nil
 }
 
+// RollBack resets the chain to the given block after the blocks above it are reverted,
+// the database should have been rolled back before calling it.
+func (bc *BlockChain) RollBack(blockHeight int64, accountIndexes, assetIds, nftIndexes []int64) error {
+	curBlock, err := bc.BlockModel.GetBlockByHeight(blockHeight)
+	if err != nil {
+		return err
+	}
+
+	err = bc.Statedb.RollBack(bc.taskPool, blockHeight, curBlock.StateRoot, accountIndexes, assetIds, nftIndexes)
+	if err != nil {
+		return err
+	}
+	bc.currentBlock = curBlock
+	return nil
+}
+
+// DiscardPendingStates resets the proposing block to the states before its txs are
+// applied, the txs which are kept in the block should be applied again.
+func (bc *BlockChain) DiscardPendingStates() error {
+	err := bc.Statedb.DiscardPendingStates(bc.currentBlock.StateRoot)
+	if err != nil {
+		return err
+	}
+	return bc.Statedb.SetGasAssetsVersion(bc.currentBlock.GasAssetsVersion)
+}
+
 func (bc *BlockChain) CurrentBlock() *block.Block {
 	return bc.currentBlock
 }
@@ -164,10 +260,18 @@ func (bc *BlockChain) CommitNewBlock(blockSize int, createdAt int64) (*block.Blo
 
 	currentHeight := bc.currentBlock.BlockHeight
 
-	err = tree.CommitTrees(bc.taskPool, uint64(currentHeight), bc.Statedb.AccountTree, bc.Statedb.AccountAssetTrees, bc.Statedb.NftTree)
+	// Keep the tree versions since the latest verified block, so that the trees could be
+	// rolled back when the committed blocks are reverted on L1.
+	latestVerifiedHeight, err := bc.BlockModel.GetLatestVerifiedHeight()
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	err = tree.CommitTrees(bc.taskPool, uint64(latestVerifiedHeight), bc.Statedb.AccountTree, bc.Statedb.AccountAssetTrees, bc.Statedb.NftTree)
 	if err != nil {
 		return nil, err
 	}
+	TreeCommitLatencyMetric.Observe(time.Since(start).Seconds())
 
 	pendingAccount, pendingAccountHistory, err := bc.Statedb.GetPendingAccount(currentHeight)
 	if err != nil {
@@ -205,6 +309,11 @@ func (bc *BlockChain) commitNewBlock(blockSize int, createdAt int64) (*block.Blo
 			StateRoot:   bc.currentBlock.StateRoot,
 			BlockStatus: block.StatusProposing,
 		}
+		gasAssets, err := s.GetGasAssets()
+		if err != nil {
+			return nil, nil, err
+		}
+		newBlock.GasAssetsVersion = gasAssets.Version
 	}
 
 	// Intermediate state root.
@@ -289,7 +398,10 @@ func (bc *BlockChain) VerifyNonce(accountIndex int64, nonce int64) error {
 	return nil
 }
 
-func (bc *BlockChain) VerifyGas(gasAccountIndex, gasFeeAssetId int64, txType int, gasFeeAmount *big.Int, skipGasAmtChk bool) error {
+// VerifyGas checks the gas account and the gas asset of the tx, and that the gas fee is not
+// less than the minimum fee. A pool tx is accepted as long as it pays the minimum fee which
+// was in effect when it entered the tx pool, even if the fees rise afterwards.
+func (bc *BlockChain) VerifyGas(gasAccountIndex, gasFeeAssetId int64, txType int, gasFeeAmount *big.Int, pooledAt time.Time, skipGasAmtChk bool) error {
 	cfgGasAccountIndex, err := bc.Statedb.GetGasAccountIndex()
 	if err != nil {
 		return err
@@ -298,12 +410,20 @@ func (bc *BlockChain) VerifyGas(gasAccountIndex, gasFeeAssetId int64, txType int
 		return errors.New("invalid gas fee account")
 	}
 
-	cfgGasFee, err := bc.Statedb.GetGasConfig()
+	gasAssets, err := bc.Statedb.GetGasAssets()
+	if err != nil {
+		return err
+	}
+	if !gasAssets.Contains(gasFeeAssetId) {
+		return errors.New("invalid gas fee asset")
+	}
+
+	minGasFees, err := bc.Statedb.GetMinGasFees(time.Time{})
 	if err != nil {
 		return err
 	}
 
-	gasAsset, ok := cfgGasFee[uint32(gasFeeAssetId)]
+	gasAsset, ok := minGasFees[uint32(gasFeeAssetId)]
 	if !ok {
 		logx.Errorf("cannot find gas config for asset id: %d", gasFeeAssetId)
 		return errors.New("invalid gas fee asset")
@@ -314,13 +434,31 @@ func (bc *BlockChain) VerifyGas(gasAccountIndex, gasFeeAssetId int64, txType int
 		if !ok {
 			return errors.New("invalid tx type")
 		}
-		if gasFeeAmount.Cmp(big.NewInt(gasFee)) < 0 {
+		if gasFeeAmount.Cmp(big.NewInt(gasFee)) >= 0 {
+			return nil
+		}
+		if pooledAt.IsZero() {
+			return errors.New("invalid gas fee amount")
+		}
+		pooledGasFees, err := bc.Statedb.GetMinGasFees(pooledAt)
+		if err != nil {
+			return err
+		}
+		pooledGasFee, ok := pooledGasFees[uint32(gasFeeAssetId)][txType]
+		if !ok || gasFeeAmount.Cmp(big.NewInt(pooledGasFee)) < 0 {
 			return errors.New("invalid gas fee amount")
 		}
 	}
 	return nil
 }
 
+func (bc *BlockChain) VerifySignature(signedInfo executor.SignedInfo, pubKey string) error {
+	if bc.skipSignatureChk {
+		return nil
+	}
+	return signedInfo.VerifySignature(pubKey)
+}
+
 func (bc *BlockChain) StateDB() *sdb.StateDB {
 	return bc.Statedb
 }
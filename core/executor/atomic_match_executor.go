@@ -64,7 +64,7 @@ func (e *AtomicMatchExecutor) Prepare() error {
 	e.MarkAccountAssetsDirty(txInfo.BuyOffer.AccountIndex, []int64{txInfo.BuyOffer.AssetId, e.buyOfferAssetId})
 	e.MarkAccountAssetsDirty(txInfo.SellOffer.AccountIndex, []int64{txInfo.SellOffer.AssetId, e.sellOfferAssetId})
 	e.MarkAccountAssetsDirty(matchNft.CreatorAccountIndex, []int64{txInfo.BuyOffer.AssetId})
-	e.MarkAccountAssetsDirty(txInfo.GasAccountIndex, []int64{txInfo.BuyOffer.AssetId, txInfo.GasFeeAssetId})
+	e.MarkGasAccountAssetsDirty(txInfo.GasAccountIndex, []int64{txInfo.BuyOffer.AssetId, txInfo.GasFeeAssetId})
 	return e.BaseExecutor.Prepare()
 }
 
@@ -92,13 +92,11 @@ func (e *AtomicMatchExecutor) VerifyInputs(skipGasAmtChk bool) error {
 	}
 
 	// only gas assets are allowed for atomic match
-	found := false
-	for _, assetId := range types.GasAssets {
-		if assetId == txInfo.SellOffer.AssetId {
-			found = true
-		}
+	gasAssets, err := bc.StateDB().GetGasAssets()
+	if err != nil {
+		return err
 	}
-	if !found {
+	if !gasAssets.Contains(txInfo.SellOffer.AssetId) {
 		return errors.New("invalid asset of offer")
 	}
 
@@ -158,12 +156,13 @@ func (e *AtomicMatchExecutor) VerifyInputs(skipGasAmtChk bool) error {
 		return errors.New("seller is not owner")
 	}
 
-	// Verify offer signature.
-	err = txInfo.BuyOffer.VerifySignature(buyAccount.PublicKey)
+	// Verify offer signature, it's skipped along with the signature of the tx, e.g. when
+	// simulating the unsigned txs.
+	err = bc.VerifySignature(txInfo.BuyOffer, buyAccount.PublicKey)
 	if err != nil {
 		return err
 	}
-	err = txInfo.SellOffer.VerifySignature(sellAccount.PublicKey)
+	err = bc.VerifySignature(txInfo.SellOffer, sellAccount.PublicKey)
 	if err != nil {
 		return err
 	}
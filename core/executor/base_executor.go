@@ -24,6 +24,9 @@ type BaseExecutor struct {
 	// Affected states.
 	dirtyAccountsAndAssetsMap map[int64]map[int64]bool
 	dirtyNftMap               map[int64]bool
+	// The gas account only receives the gas, which is accumulated in the block rather
+	// than applied by the txs, so it is kept aside from the affected accounts.
+	dirtyGasAccountAssetsMap map[int64]map[int64]bool
 }
 
 func NewBaseExecutor(bc IBlockchain, tx *tx.Tx, txInfo txtypes.TxInfo) BaseExecutor {
@@ -34,6 +37,7 @@ func NewBaseExecutor(bc IBlockchain, tx *tx.Tx, txInfo txtypes.TxInfo) BaseExecu
 
 		dirtyAccountsAndAssetsMap: make(map[int64]map[int64]bool, 0),
 		dirtyNftMap:               make(map[int64]bool, 0),
+		dirtyGasAccountAssetsMap:  make(map[int64]map[int64]bool, 0),
 	}
 }
 
@@ -50,6 +54,12 @@ func (e *BaseExecutor) Prepare() error {
 		e.tx.AccountIndex = e.iTxInfo.GetFromAccountIndex()
 		e.tx.Nonce = e.iTxInfo.GetNonce()
 		e.tx.ExpiredAt = e.iTxInfo.GetExpiredAt()
+		// The gas fee is used for ordering the txs in the tx pool.
+		_, gasFeeAssetId, gasFeeAmount := e.iTxInfo.GetGas()
+		if gasFeeAmount != nil {
+			e.tx.GasFeeAssetId = gasFeeAssetId
+			e.tx.GasFee = gasFeeAmount.String()
+		}
 	}
 
 	err := e.bc.StateDB().PrepareAccountsAndAssets(e.dirtyAccountsAndAssetsMap)
@@ -57,6 +67,11 @@ func (e *BaseExecutor) Prepare() error {
 		logx.Errorf("prepare accounts and assets failed: %s", err.Error())
 		return errors.New("internal error")
 	}
+	err = e.bc.StateDB().PrepareAccountsAndAssets(e.dirtyGasAccountAssetsMap)
+	if err != nil {
+		logx.Errorf("prepare gas account and assets failed: %s", err.Error())
+		return errors.New("internal error")
+	}
 	return nil
 }
 
@@ -80,7 +95,7 @@ func (e *BaseExecutor) VerifyInputs(skipGasAmtChk bool) error {
 		}
 
 		gasAccountIndex, gasFeeAssetId, gasFeeAmount := txInfo.GetGas()
-		err = e.bc.VerifyGas(gasAccountIndex, gasFeeAssetId, txInfo.GetTxType(), gasFeeAmount, skipGasAmtChk)
+		err = e.bc.VerifyGas(gasAccountIndex, gasFeeAssetId, txInfo.GetTxType(), gasFeeAmount, e.tx.CreatedAt, skipGasAmtChk)
 		if err != nil {
 			return err
 		}
@@ -89,7 +104,7 @@ func (e *BaseExecutor) VerifyInputs(skipGasAmtChk bool) error {
 		if err != nil {
 			return err
 		}
-		err = txInfo.VerifySignature(fromAccount.PublicKey)
+		err = e.bc.VerifySignature(txInfo, fromAccount.PublicKey)
 		if err != nil {
 			return err
 		}
@@ -119,38 +134,74 @@ func (e *BaseExecutor) GenerateTxDetails() ([]*tx.TxDetail, error) {
 }
 
 func (e *BaseExecutor) MarkAccountAssetsDirty(accountIndex int64, assets []int64) {
-	if accountIndex < 0 {
-		return
-	}
-
-	_, ok := e.dirtyAccountsAndAssetsMap[accountIndex]
-	if !ok {
-		e.dirtyAccountsAndAssetsMap[accountIndex] = make(map[int64]bool, 0)
-	}
+	markAccountAssetsDirty(e.dirtyAccountsAndAssetsMap, accountIndex, assets)
+}
 
-	for _, assetIndex := range assets {
-		// Should never happen, but protect here.
-		if assetIndex < 0 {
-			continue
-		}
-		e.dirtyAccountsAndAssetsMap[accountIndex][assetIndex] = true
-	}
+// MarkGasAccountAssetsDirty marks the gas assets paid to the gas account. The gas account
+// is not an affected account of the tx unless it is marked by MarkAccountAssetsDirty too.
+func (e *BaseExecutor) MarkGasAccountAssetsDirty(gasAccountIndex int64, assets []int64) {
+	markAccountAssetsDirty(e.dirtyGasAccountAssetsMap, gasAccountIndex, assets)
 }
 
 func (e *BaseExecutor) MarkNftDirty(nftIndex int64) {
 	e.dirtyNftMap[nftIndex] = true
 }
 
+// AffectedStates returns the accounts and nfts read or written by the tx, the txs with
+// disjoint affected states could be verified independently.
+func (e *BaseExecutor) AffectedStates() (accounts []int64, nfts []int64) {
+	accounts = make([]int64, 0, len(e.dirtyAccountsAndAssetsMap))
+	for accountIndex := range e.dirtyAccountsAndAssetsMap {
+		accounts = append(accounts, accountIndex)
+	}
+	nfts = make([]int64, 0, len(e.dirtyNftMap))
+	for nftIndex := range e.dirtyNftMap {
+		nfts = append(nfts, nftIndex)
+	}
+	return accounts, nfts
+}
+
+// GasAccounts returns the gas accounts which the tx pays the gas fees to, their balances are
+// read by the tx details but not changed by the tx.
+func (e *BaseExecutor) GasAccounts() []int64 {
+	gasAccounts := make([]int64, 0, len(e.dirtyGasAccountAssetsMap))
+	for accountIndex := range e.dirtyGasAccountAssetsMap {
+		gasAccounts = append(gasAccounts, accountIndex)
+	}
+	return gasAccounts
+}
+
 func (e *BaseExecutor) SyncDirtyToStateCache() {
-	for accountIndex, assetsMap := range e.dirtyAccountsAndAssetsMap {
-		assets := make([]int64, 0, len(assetsMap))
-		for assetIndex := range assetsMap {
-			assets = append(assets, assetIndex)
+	for _, dirtyMap := range []map[int64]map[int64]bool{e.dirtyAccountsAndAssetsMap, e.dirtyGasAccountAssetsMap} {
+		for accountIndex, assetsMap := range dirtyMap {
+			assets := make([]int64, 0, len(assetsMap))
+			for assetIndex := range assetsMap {
+				assets = append(assets, assetIndex)
+			}
+			e.bc.StateDB().MarkAccountAssetsDirty(accountIndex, assets)
 		}
-		e.bc.StateDB().MarkAccountAssetsDirty(accountIndex, assets)
 	}
 
 	for nftIndex := range e.dirtyNftMap {
 		e.bc.StateDB().MarkNftDirty(nftIndex)
 	}
 }
+
+func markAccountAssetsDirty(dirtyMap map[int64]map[int64]bool, accountIndex int64, assets []int64) {
+	if accountIndex < 0 {
+		return
+	}
+
+	_, ok := dirtyMap[accountIndex]
+	if !ok {
+		dirtyMap[accountIndex] = make(map[int64]bool, 0)
+	}
+
+	for _, assetIndex := range assets {
+		// Should never happen, but protect here.
+		if assetIndex < 0 {
+			continue
+		}
+		dirtyMap[accountIndex][assetIndex] = true
+	}
+}
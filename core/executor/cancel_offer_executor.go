@@ -40,7 +40,7 @@ func (e *CancelOfferExecutor) Prepare() error {
 	// Mark the tree states that would be affected in this executor.
 	offerAssetId := txInfo.OfferId / OfferPerAsset
 	e.MarkAccountAssetsDirty(txInfo.AccountIndex, []int64{txInfo.GasFeeAssetId, offerAssetId})
-	e.MarkAccountAssetsDirty(txInfo.GasAccountIndex, []int64{txInfo.GasFeeAssetId})
+	e.MarkGasAccountAssetsDirty(txInfo.GasAccountIndex, []int64{txInfo.GasFeeAssetId})
 	return e.BaseExecutor.Prepare()
 }
 
@@ -39,7 +39,7 @@ func (e *CreateCollectionExecutor) Prepare() error {
 
 	// Mark the tree states that would be affected in this executor.
 	e.MarkAccountAssetsDirty(txInfo.AccountIndex, []int64{txInfo.GasFeeAssetId})
-	e.MarkAccountAssetsDirty(txInfo.GasAccountIndex, []int64{txInfo.GasFeeAssetId})
+	e.MarkGasAccountAssetsDirty(txInfo.GasAccountIndex, []int64{txInfo.GasFeeAssetId})
 	err := e.BaseExecutor.Prepare()
 	if err != nil {
 		return err
@@ -3,6 +3,7 @@ package executor
 import (
 	"errors"
 	"math/big"
+	"time"
 
 	sdb "github.com/bnb-chain/zkbnb/core/statedb"
 	"github.com/bnb-chain/zkbnb/dao/block"
@@ -13,12 +14,18 @@ import (
 type IBlockchain interface {
 	VerifyExpiredAt(expiredAt int64) error
 	VerifyNonce(accountIndex int64, nonce int64) error
-	VerifyGas(gasAccountIndex, gasFeeAssetId int64, txType int, gasFeeAmount *big.Int, skipGasAmtChk bool) error
+	VerifyGas(gasAccountIndex, gasFeeAssetId int64, txType int, gasFeeAmount *big.Int, pooledAt time.Time, skipGasAmtChk bool) error
+	VerifySignature(signedInfo SignedInfo, pubKey string) error
 	StateDB() *sdb.StateDB
 	DB() *sdb.ChainDB
 	CurrentBlock() *block.Block
 }
 
+// SignedInfo is implemented by the tx infos and the offers signed by the layer2 accounts.
+type SignedInfo interface {
+	VerifySignature(pubKey string) error
+}
+
 type TxExecutor interface {
 	Prepare() error
 	VerifyInputs(skipGasAmtChk bool) error
@@ -26,6 +33,8 @@ type TxExecutor interface {
 	GeneratePubData() error
 	GetExecutedTx() (*tx.Tx, error)
 	GenerateTxDetails() ([]*tx.TxDetail, error)
+	AffectedStates() (accounts []int64, nfts []int64)
+	GasAccounts() []int64
 }
 
 func NewTxExecutor(bc IBlockchain, tx *tx.Tx) (TxExecutor, error) {
@@ -48,7 +48,7 @@ func (e *WithdrawNftExecutor) Prepare() error {
 	// Mark the tree states that would be affected in this executor.
 	e.MarkNftDirty(txInfo.NftIndex)
 	e.MarkAccountAssetsDirty(txInfo.AccountIndex, []int64{txInfo.GasFeeAssetId})
-	e.MarkAccountAssetsDirty(txInfo.GasAccountIndex, []int64{txInfo.GasFeeAssetId})
+	e.MarkGasAccountAssetsDirty(txInfo.GasAccountIndex, []int64{txInfo.GasFeeAssetId})
 	if nftInfo.CreatorAccountIndex != types.NilAccountIndex {
 		e.MarkAccountAssetsDirty(nftInfo.CreatorAccountIndex, []int64{})
 	}
@@ -8,8 +8,11 @@ import (
 	"github.com/bnb-chain/zkbnb/dao/asset"
 	"github.com/bnb-chain/zkbnb/dao/block"
 	"github.com/bnb-chain/zkbnb/dao/compressedblock"
+	"github.com/bnb-chain/zkbnb/dao/gasfee"
 	"github.com/bnb-chain/zkbnb/dao/nft"
+	"github.com/bnb-chain/zkbnb/dao/offer"
 	"github.com/bnb-chain/zkbnb/dao/priorityrequest"
+	"github.com/bnb-chain/zkbnb/dao/rollback"
 	"github.com/bnb-chain/zkbnb/dao/sysconfig"
 	"github.com/bnb-chain/zkbnb/dao/tx"
 )
@@ -21,6 +24,7 @@ type ChainDB struct {
 	CompressedBlockModel compressedblock.CompressedBlockModel
 	TxModel              tx.TxModel
 	PriorityRequestModel priorityrequest.PriorityRequestModel
+	RollbackModel        rollback.RollbackModel
 
 	// State DB
 	AccountModel        account.AccountModel
@@ -30,8 +34,12 @@ type ChainDB struct {
 	L2NftHistoryModel   nft.L2NftHistoryModel
 	TxPoolModel         tx.TxPoolModel
 
+	// Off-chain offer book
+	OfferModel offer.OfferModel
+
 	// Sys config
 	SysConfigModel sysconfig.SysConfigModel
+	GasFeeModel    gasfee.GasFeeModel
 }
 
 func NewChainDB(db *gorm.DB) *ChainDB {
@@ -41,6 +49,7 @@ func NewChainDB(db *gorm.DB) *ChainDB {
 		CompressedBlockModel: compressedblock.NewCompressedBlockModel(db),
 		TxModel:              tx.NewTxModel(db),
 		PriorityRequestModel: priorityrequest.NewPriorityRequestModel(db),
+		RollbackModel:        rollback.NewRollbackModel(db),
 
 		AccountModel:        account.NewAccountModel(db),
 		AccountHistoryModel: account.NewAccountHistoryModel(db),
@@ -49,7 +58,10 @@ func NewChainDB(db *gorm.DB) *ChainDB {
 		L2NftHistoryModel:   nft.NewL2NftHistoryModel(db),
 		TxPoolModel:         tx.NewTxPoolModel(db),
 
+		OfferModel: offer.NewOfferModel(db),
+
 		SysConfigModel: sysconfig.NewSysConfigModel(db),
+		GasFeeModel:    gasfee.NewGasFeeModel(db),
 	}
 }
 
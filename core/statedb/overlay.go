@@ -0,0 +1,85 @@
+package statedb
+
+import (
+	"github.com/bnb-chain/zkbnb/dao/nft"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+// NewOverlay returns a state on top of the current one for executing a tx in isolation. The
+// accounts and nfts read through the overlay are copies of the current ones, and the states
+// written by the tx are kept in the overlay until it's merged back by Merge. The overlays of
+// the txs which don't affect the same states could be executed concurrently, as long as the
+// current state isn't changed until they are merged.
+func (s *StateDB) NewOverlay() *StateDB {
+	return &StateDB{
+		dryRun:       s.dryRun,
+		StateCache:   NewStateCache(s.StateRoot),
+		chainDb:      s.chainDb,
+		redisCache:   s.redisCache,
+		AccountCache: s.AccountCache,
+		NftCache:     s.NftCache,
+
+		AccountTree:       s.AccountTree,
+		NftTree:           s.NftTree,
+		AccountAssetTrees: s.AccountAssetTrees,
+		TreeCtx:           s.TreeCtx,
+
+		parent:         s,
+		copiedAccounts: make(map[int64]*types.AccountInfo),
+		copiedNfts:     make(map[int64]*nft.L2Nft),
+	}
+}
+
+// Merge applies the states written in the overlay to the current state. The overlays must be
+// merged in the order of their txs, the pub data and the executed txs are not merged, they are
+// generated on the current state after merging.
+func (s *StateDB) Merge(overlay *StateDB) {
+	for accountIndex, account := range overlay.PendingAccountMap {
+		s.SetPendingAccount(accountIndex, account)
+	}
+	for nftIndex, nft := range overlay.PendingNftMap {
+		s.SetPendingNft(nftIndex, nft)
+	}
+	for assetId, delta := range overlay.PendingGasMap {
+		s.SetPendingUpdateGas(assetId, delta)
+	}
+	for accountIndex, assetsMap := range overlay.dirtyAccountsAndAssetsMap {
+		assets := make([]int64, 0, len(assetsMap))
+		for assetId := range assetsMap {
+			assets = append(assets, assetId)
+		}
+		s.MarkAccountAssetsDirty(accountIndex, assets)
+	}
+	for nftIndex := range overlay.dirtyNftMap {
+		s.MarkNftDirty(nftIndex)
+	}
+}
+
+// copyParentAccount returns the copy of the account in the parent state, the same copy is
+// returned for the following reads, so the changes applied to it could be set as pending.
+func (s *StateDB) copyParentAccount(accountIndex int64) (*types.AccountInfo, error) {
+	if copied, exist := s.copiedAccounts[accountIndex]; exist {
+		return copied, nil
+	}
+	account, err := s.parent.GetFormatAccount(accountIndex)
+	if err != nil {
+		return nil, err
+	}
+	copied := account.DeepCopy()
+	s.copiedAccounts[accountIndex] = copied
+	return copied, nil
+}
+
+// copyParentNft returns the copy of the nft in the parent state, see copyParentAccount.
+func (s *StateDB) copyParentNft(nftIndex int64) (*nft.L2Nft, error) {
+	if copied, exist := s.copiedNfts[nftIndex]; exist {
+		return copied, nil
+	}
+	parentNft, err := s.parent.GetNft(nftIndex)
+	if err != nil {
+		return nil, err
+	}
+	copied := *parentNft
+	s.copiedNfts[nftIndex] = &copied
+	return &copied, nil
+}
@@ -6,10 +6,13 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
 	"github.com/ethereum/go-ethereum/common"
 	lru "github.com/hashicorp/golang-lru"
+	"github.com/panjf2000/ants/v2"
 	"github.com/zeromicro/go-zero/core/logx"
 
 	"github.com/bnb-chain/zkbnb-crypto/ffmath"
@@ -17,6 +20,7 @@ import (
 	"github.com/bnb-chain/zkbnb/common/chain"
 	"github.com/bnb-chain/zkbnb/dao/account"
 	"github.com/bnb-chain/zkbnb/dao/dbcache"
+	"github.com/bnb-chain/zkbnb/dao/gasfee"
 	"github.com/bnb-chain/zkbnb/dao/nft"
 	"github.com/bnb-chain/zkbnb/tree"
 	"github.com/bnb-chain/zkbnb/types"
@@ -62,6 +66,22 @@ type StateDB struct {
 	NftTree           bsmt.SparseMerkleTree
 	AccountAssetTrees *tree.AssetTreeCache
 	TreeCtx           *tree.Context
+
+	// The gas assets of the current block, they could be loaded by the concurrent verifications.
+	gasAssetsMu sync.Mutex
+	gasAssets   *types.GasAssets
+	// The latest minimum gas fees, they could be loaded by the concurrent verifications.
+	minGasFeesMu sync.Mutex
+	minGasFees   map[uint32]map[int]int64
+
+	// The nonces of the accounts which send the txs applied to the dry-run state, the other
+	// pending accounts, e.g. the receivers, keep the nonces of their committed states.
+	pendingNonces map[int64]int64
+
+	// The state which the overlay is on top of, and the states copied from it, see NewOverlay.
+	parent         *StateDB
+	copiedAccounts map[int64]*types.AccountInfo
+	copiedNfts     map[int64]*nft.L2Nft
 }
 
 func NewStateDB(treeCtx *tree.Context, chainDb *ChainDB,
@@ -131,12 +151,13 @@ func NewStateDBForDryRun(redisCache dbcache.Cache, cacheConfig *CacheConfig, cha
 		return nil, err
 	}
 	return &StateDB{
-		dryRun:       true,
-		redisCache:   redisCache,
-		chainDb:      chainDb,
-		AccountCache: accountCache,
-		NftCache:     nftCache,
-		StateCache:   NewStateCache(""),
+		dryRun:        true,
+		redisCache:    redisCache,
+		chainDb:       chainDb,
+		AccountCache:  accountCache,
+		NftCache:      nftCache,
+		StateCache:    NewStateCache(""),
+		pendingNonces: make(map[int64]int64),
 	}, nil
 }
 
@@ -145,6 +166,9 @@ func (s *StateDB) GetFormatAccount(accountIndex int64) (*types.AccountInfo, erro
 	if exist {
 		return pending, nil
 	}
+	if s.parent != nil {
+		return s.copyParentAccount(accountIndex)
+	}
 
 	cached, exist := s.AccountCache.Get(accountIndex)
 	if exist {
@@ -247,6 +271,9 @@ func (s *StateDB) GetNft(nftIndex int64) (*nft.L2Nft, error) {
 	if exist {
 		return pending, nil
 	}
+	if s.parent != nil {
+		return s.copyParentNft(nftIndex)
+	}
 	cached, exist := s.NftCache.Get(nftIndex)
 	if exist {
 		return cached.(*nft.L2Nft), nil
@@ -319,6 +346,74 @@ func (s *StateDB) SyncStateCacheToRedis() error {
 
 func (s *StateDB) PurgeCache(stateRoot string) {
 	s.StateCache = NewStateCache(stateRoot)
+	s.gasAssetsMu.Lock()
+	s.gasAssets = nil
+	s.gasAssetsMu.Unlock()
+	s.minGasFeesMu.Lock()
+	s.minGasFees = nil
+	s.minGasFeesMu.Unlock()
+}
+
+// DiscardPendingStates drops the states changed by the txs applied since the last block,
+// including the ones synced to the caches, so the txs could be applied again on the state
+// root of the last block.
+func (s *StateDB) DiscardPendingStates(stateRoot string) error {
+	for accountIndex := range s.PendingAccountMap {
+		s.AccountCache.Remove(accountIndex)
+		err := s.redisCache.Delete(context.Background(), dbcache.AccountKeyByIndex(accountIndex))
+		if err != nil {
+			return err
+		}
+	}
+	for nftIndex := range s.PendingNftMap {
+		s.NftCache.Remove(nftIndex)
+		err := s.redisCache.Delete(context.Background(), dbcache.NftKeyByIndex(nftIndex))
+		if err != nil {
+			return err
+		}
+	}
+	s.PurgeCache(stateRoot)
+	return nil
+}
+
+// RollBack reverts the trees and purges the caches to the state of the given block after
+// the blocks above it are reverted, the accounts and nfts updated by the reverted blocks
+// are removed from the caches.
+func (s *StateDB) RollBack(pool *ants.Pool, blockHeight int64, stateRoot string,
+	accountIndexes, assetIds, nftIndexes []int64) error {
+	err := tree.RollBackTreesToHeight(pool, blockHeight, accountIndexes, assetIds,
+		s.chainDb.AccountHistoryModel, s.AccountTree, s.AccountAssetTrees, s.NftTree)
+	if err != nil {
+		return err
+	}
+
+	accountNums, err := s.chainDb.AccountHistoryModel.GetValidAccountCount(blockHeight)
+	if err != nil {
+		return err
+	}
+	s.AccountAssetTrees.RollBack(accountNums-1, blockHeight)
+
+	root := common.Bytes2Hex(tree.ComputeStateRootHash(s.AccountTree.Root(), s.NftTree.Root()))
+	if root != stateRoot {
+		return fmt.Errorf("state root mismatch after rollback, expected: %s, actual: %s", stateRoot, root)
+	}
+
+	for _, accountIndex := range accountIndexes {
+		s.AccountCache.Remove(accountIndex)
+		err = s.redisCache.Delete(context.Background(), dbcache.AccountKeyByIndex(accountIndex))
+		if err != nil {
+			return err
+		}
+	}
+	for _, nftIndex := range nftIndexes {
+		s.NftCache.Remove(nftIndex)
+		err = s.redisCache.Delete(context.Background(), dbcache.NftKeyByIndex(nftIndex))
+		if err != nil {
+			return err
+		}
+	}
+	s.PurgeCache(stateRoot)
+	return nil
 }
 
 func (s *StateDB) GetPendingAccount(blockHeight int64) ([]*account.Account, []*account.AccountHistory, error) {
@@ -531,17 +626,15 @@ func (s *StateDB) updateAccountTree(accountIndex int64, assets []int64) error {
 	if err != nil {
 		return err
 	}
-	isGasAccount := accountIndex == types.GasAccount
-	for _, assetId := range assets {
-		isGasAsset := false
-		if isGasAccount {
-			for _, gasAssetId := range types.GasAssets {
-				if assetId == gasAssetId {
-					isGasAsset = true
-					break
-				}
-			}
+	var gasAssets *types.GasAssets
+	if accountIndex == types.GasAccount {
+		gasAssets, err = s.GetGasAssets()
+		if err != nil {
+			return err
 		}
+	}
+	for _, assetId := range assets {
+		isGasAsset := gasAssets != nil && gasAssets.Contains(assetId)
 		balance := account.AssetInfo[assetId].Balance
 		if isGasAsset {
 			balance = ffmath.Add(balance, s.GetPendingUpdateGas(assetId))
@@ -611,7 +704,30 @@ func (s *StateDB) GetCommittedNonce(accountIndex int64) (int64, error) {
 	return account.Nonce, nil
 }
 
+// SetPendingNonce sets the nonce of the sender in the dry-run state, it's the pending nonce
+// of the account from then on, since the txs applied to the dry-run state are not in the tx
+// pool yet.
+func (s *StateDB) SetPendingNonce(accountIndex int64, nonce int64) error {
+	account, err := s.GetFormatAccount(accountIndex)
+	if err != nil {
+		return err
+	}
+	account.Nonce = nonce
+	s.SetPendingAccount(accountIndex, account)
+	s.pendingNonces[accountIndex] = nonce
+	return nil
+}
+
+// HasPendingNonce returns whether the nonce of the account is set in the dry-run state.
+func (s *StateDB) HasPendingNonce(accountIndex int64) bool {
+	_, exist := s.pendingNonces[accountIndex]
+	return exist
+}
+
 func (s *StateDB) GetPendingNonce(accountIndex int64) (int64, error) {
+	if nonce, exist := s.pendingNonces[accountIndex]; exist {
+		return nonce, nil
+	}
 	nonce, err := s.chainDb.TxPoolModel.GetMaxNonceByAccountIndex(accountIndex)
 	if err == nil {
 		return nonce + 1, nil
@@ -692,6 +808,98 @@ func (s *StateDB) GetGasConfig() (map[uint32]map[int]int64, error) {
 	return m, nil
 }
 
+// GetMinGasFees returns the minimum gas fees computed by the gas fee oracle which were in
+// effect at the given time, the latest ones are returned and kept until the cache is purged
+// if the time is zero. The static gas config is used if the oracle hasn't computed any fees.
+func (s *StateDB) GetMinGasFees(at time.Time) (map[uint32]map[int]int64, error) {
+	if !at.IsZero() {
+		return s.loadMinGasFees(at)
+	}
+
+	s.minGasFeesMu.Lock()
+	defer s.minGasFeesMu.Unlock()
+	if s.minGasFees != nil {
+		return s.minGasFees, nil
+	}
+	minGasFees, err := s.loadMinGasFees(at)
+	if err != nil {
+		return nil, err
+	}
+	s.minGasFees = minGasFees
+	return minGasFees, nil
+}
+
+func (s *StateDB) loadMinGasFees(at time.Time) (map[uint32]map[int]int64, error) {
+	var gasFee *gasfee.GasFee
+	var err error
+	if at.IsZero() {
+		gasFee, err = s.chainDb.GasFeeModel.GetLatestGasFee()
+	} else {
+		gasFee, err = s.chainDb.GasFeeModel.GetGasFeeAt(at)
+	}
+	if err != nil {
+		if err == types.DbErrNotFound {
+			return s.GetGasConfig()
+		}
+		logx.Errorf("fail to get gas fee, err: %s", err.Error())
+		return nil, errors.New("internal error")
+	}
+
+	m := make(map[uint32]map[int]int64)
+	err = json.Unmarshal([]byte(gasFee.MinFees), &m)
+	if err != nil {
+		logx.Errorf("fail to unmarshal min gas fees, err: %s", err.Error())
+		return nil, errors.New("internal error")
+	}
+	return m, nil
+}
+
+// GetGasAssets returns the gas assets of the current block. The latest version is loaded
+// for a new block, and it's kept until the cache is purged for the next block.
+func (s *StateDB) GetGasAssets() (*types.GasAssets, error) {
+	if s.parent != nil {
+		return s.parent.GetGasAssets()
+	}
+	s.gasAssetsMu.Lock()
+	defer s.gasAssetsMu.Unlock()
+	if s.gasAssets != nil {
+		return s.gasAssets, nil
+	}
+	gasAssets, err := s.loadGasAssets(types.NilGasAssetsVersion)
+	if err != nil {
+		return nil, err
+	}
+	s.gasAssets = gasAssets
+	return gasAssets, nil
+}
+
+// SetGasAssetsVersion sets the gas assets of the current block to the given version, e.g.
+// the proposing block is restored after restarting.
+func (s *StateDB) SetGasAssetsVersion(version int64) error {
+	gasAssets, err := s.loadGasAssets(version)
+	if err != nil {
+		return err
+	}
+	s.gasAssetsMu.Lock()
+	s.gasAssets = gasAssets
+	s.gasAssetsMu.Unlock()
+	return nil
+}
+
+func (s *StateDB) loadGasAssets(version int64) (*types.GasAssets, error) {
+	cfgGasAssets, err := s.chainDb.SysConfigModel.GetSysConfigByName(types.SysGasAssets)
+	if err != nil {
+		logx.Errorf("cannot find config for: %s", types.SysGasAssets)
+		return nil, errors.New("internal error")
+	}
+	gasAssets, err := types.ParseGasAssets(cfgGasAssets.Value, version)
+	if err != nil {
+		logx.Errorf("invalid gas assets: %s, version: %d, err: %s", cfgGasAssets.Value, version, err.Error())
+		return nil, errors.New("internal error")
+	}
+	return gasAssets, nil
+}
+
 func (s *StateDB) Close() {
 	sqlDB, err := s.chainDb.DB.DB()
 	if err == nil && sqlDB != nil {
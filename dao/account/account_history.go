@@ -34,6 +34,9 @@ type (
 		GetValidAccountCount(height int64) (accounts int64, err error)
 		CreateAccountHistoriesInTransact(tx *gorm.DB, histories []*AccountHistory) error
 		GetLatestAccountHistory(accountIndex, height int64) (accountHistory *AccountHistory, err error)
+		GetAccountHistoriesForHeightGreaterThan(height int64) (accountHistories []*AccountHistory, err error)
+		GetAccountHistoriesBetween(start, end int64) (accountHistories []*AccountHistory, err error)
+		DeleteAccountHistoriesForHeightGreaterThanInTransact(tx *gorm.DB, height int64) error
 	}
 
 	defaultAccountHistoryModel struct {
@@ -131,3 +134,33 @@ func (m *defaultAccountHistoryModel) GetLatestAccountHistory(accountIndex, heigh
 	}
 	return accountHistory, nil
 }
+
+func (m *defaultAccountHistoryModel) GetAccountHistoriesForHeightGreaterThan(height int64) (accountHistories []*AccountHistory, err error) {
+	dbTx := m.DB.Table(m.table).Where("l2_block_height > ?", height).Order("l2_block_height, id").Find(&accountHistories)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	} else if dbTx.RowsAffected == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return accountHistories, nil
+}
+
+// GetAccountHistoriesBetween returns the account histories of the blocks in (start, end].
+func (m *defaultAccountHistoryModel) GetAccountHistoriesBetween(start, end int64) (accountHistories []*AccountHistory, err error) {
+	dbTx := m.DB.Table(m.table).Where("l2_block_height > ? AND l2_block_height <= ?", start, end).
+		Order("l2_block_height, id").Find(&accountHistories)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	} else if dbTx.RowsAffected == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return accountHistories, nil
+}
+
+func (m *defaultAccountHistoryModel) DeleteAccountHistoriesForHeightGreaterThanInTransact(tx *gorm.DB, height int64) error {
+	dbTx := tx.Table(m.table).Unscoped().Where("l2_block_height > ?", height).Delete(&AccountHistory{})
+	if dbTx.Error != nil {
+		return dbTx.Error
+	}
+	return nil
+}
@@ -42,7 +42,6 @@ type (
 		GetAssetById(assetId int64) (asset *Asset, err error)
 		GetAssetBySymbol(symbol string) (asset *Asset, err error)
 		GetAssetByAddress(address string) (asset *Asset, err error)
-		GetGasAssets() (assets []*Asset, err error)
 		GetMaxAssetId() (max int64, err error)
 		CreateAssetsInTransact(tx *gorm.DB, assets []*Asset) error
 		UpdateAssetsInTransact(tx *gorm.DB, assets []*Asset) error
@@ -61,7 +60,9 @@ type (
 		L1Address   string
 		Decimals    uint32
 		Status      uint32
-		IsGasAsset  uint32
+		// Deprecated: kept for the existing schema only, the gas assets are read from the
+		// versions in the SysGasAssets sysconfig.
+		IsGasAsset uint32
 	}
 )
 
@@ -148,16 +149,6 @@ func (m *defaultAssetModel) GetAssetByAddress(address string) (asset *Asset, err
 	return asset, nil
 }
 
-func (m *defaultAssetModel) GetGasAssets() (assets []*Asset, err error) {
-	dbTx := m.DB.Table(m.table).Where("is_gas_asset = ?", IsGasAsset).Find(&assets)
-	if dbTx.Error != nil {
-		return nil, types.DbErrSqlOperation
-	} else if dbTx.RowsAffected == 0 {
-		return nil, types.DbErrNotFound
-	}
-	return assets, nil
-}
-
 func (m *defaultAssetModel) GetMaxAssetId() (max int64, err error) {
 	dbTx := m.DB.Table(m.table).Select("id").Order("id desc").Limit(1).Find(&max)
 	if dbTx.Error != nil {
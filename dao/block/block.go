@@ -49,6 +49,7 @@ type (
 		GetBlocksBetween(start int64, end int64) (blocks []*Block, err error)
 		GetBlockByHeight(blockHeight int64) (block *Block, err error)
 		GetBlockByHeightWithoutTx(blockHeight int64) (block *Block, err error)
+		GetBlocksWithoutTxsBetween(start int64, end int64) (blocks []*Block, err error)
 		GetCommittedBlocksCount() (count int64, err error)
 		GetVerifiedBlocksCount() (count int64, err error)
 		GetLatestVerifiedHeight() (height int64, err error)
@@ -60,6 +61,7 @@ type (
 		CreateBlockInTransact(tx *gorm.DB, oBlock *Block) error
 		UpdateBlocksWithoutTxsInTransact(tx *gorm.DB, blocks []*Block) (err error)
 		UpdateBlockInTransact(tx *gorm.DB, block *Block) (err error)
+		DeleteBlocksForHeightGreaterThanInTransact(tx *gorm.DB, height int64) (err error)
 	}
 
 	defaultBlockModel struct {
@@ -83,6 +85,8 @@ type (
 		VerifiedAt                      int64
 		Txs                             []*tx.Tx `gorm:"foreignKey:BlockId"`
 		BlockStatus                     int64
+		// version of the gas assets which the block is committed with
+		GasAssetsVersion int64 `gorm:"default:1"`
 	}
 
 	BlockStates struct {
@@ -239,6 +243,18 @@ func (m *defaultBlockModel) GetBlockByHeightWithoutTx(blockHeight int64) (block
 	return block, nil
 }
 
+func (m *defaultBlockModel) GetBlocksWithoutTxsBetween(start int64, end int64) (blocks []*Block, err error) {
+	dbTx := m.DB.Table(m.table).Where("block_height >= ? AND block_height <= ?", start, end).
+		Order("block_height").
+		Find(&blocks)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	} else if dbTx.RowsAffected == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return blocks, nil
+}
+
 func (m *defaultBlockModel) GetCommittedBlocksCount() (count int64, err error) {
 	dbTx := m.DB.Table(m.table).Where("block_status >= ? and deleted_at is NULL", StatusCommitted).Count(&count)
 	if dbTx.Error != nil {
@@ -371,3 +387,11 @@ func (m *defaultBlockModel) UpdateBlockInTransact(tx *gorm.DB, block *Block) (er
 	}
 	return nil
 }
+
+func (m *defaultBlockModel) DeleteBlocksForHeightGreaterThanInTransact(tx *gorm.DB, height int64) (err error) {
+	dbTx := tx.Table(m.table).Unscoped().Where("block_height > ?", height).Delete(&Block{})
+	if dbTx.Error != nil {
+		return dbTx.Error
+	}
+	return nil
+}
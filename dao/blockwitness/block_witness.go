@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/bnb-chain/zkbnb/types"
 )
@@ -29,6 +30,7 @@ import (
 const (
 	StatusPublished = iota
 	StatusReceived
+	StatusProved
 )
 
 const (
@@ -41,9 +43,14 @@ type (
 		DropBlockWitnessTable() error
 		GetLatestBlockWitnessHeight() (height int64, err error)
 		GetBlockWitnessByHeight(height int64) (witness *BlockWitness, err error)
-		UpdateBlockWitnessStatus(witness *BlockWitness, status int64) error
-		GetLatestBlockWitness() (witness *BlockWitness, err error)
 		CreateBlockWitness(witness *BlockWitness) error
+		ClaimBlockWitness(prover string, gasAssetsVersions []int64, leaseDuration time.Duration) (witness *BlockWitness, err error)
+		RenewBlockWitnessLease(witness *BlockWitness, leaseDuration time.Duration) error
+		ReleaseBlockWitness(witness *BlockWitness) error
+		CompleteBlockWitness(witness *BlockWitness) error
+		GetProverStats() (stats []*ProverStat, err error)
+		GetBlockWitnessesCountByStatus(status int64) (count int64, err error)
+		DeleteBlockWitnessesForHeightGreaterThanInTransact(tx *gorm.DB, height int64) error
 	}
 
 	defaultBlockWitnessModel struct {
@@ -56,6 +63,22 @@ type (
 		Height      int64 `gorm:"index:idx_height,unique"`
 		WitnessData string
 		Status      int64
+		// The version of the gas assets, the block is proved by the circuit of the version.
+		GasAssetsVersion int64 `gorm:"default:1"`
+		// The prover which claims the block witness, and the unix milliseconds when its
+		// lease expires. The witness could be claimed by other provers after that.
+		Prover         string `gorm:"index"`
+		LeaseExpiredAt int64
+	}
+
+	ProverStat struct {
+		Prover string
+		Status int64
+		Count  int64
+		// The highest height of the block witnesses in the status.
+		MaxHeight int64
+		// The last time the prover claims, renews or completes the block witnesses.
+		LastActiveAt time.Time
 	}
 )
 
@@ -89,16 +112,6 @@ func (m *defaultBlockWitnessModel) GetLatestBlockWitnessHeight() (blockNumber in
 	return row.Height, nil
 }
 
-func (m *defaultBlockWitnessModel) GetLatestBlockWitness() (witness *BlockWitness, err error) {
-	dbTx := m.DB.Table(m.table).Where("status = ?", StatusPublished).Order("height asc").Limit(1).Find(&witness)
-	if dbTx.Error != nil {
-		return nil, types.DbErrSqlOperation
-	} else if dbTx.RowsAffected == 0 {
-		return nil, types.DbErrNotFound
-	}
-	return witness, nil
-}
-
 func (m *defaultBlockWitnessModel) GetBlockWitnessByHeight(height int64) (witness *BlockWitness, err error) {
 	dbTx := m.DB.Table(m.table).Where("height = ?", height).Limit(1).Find(&witness)
 	if dbTx.Error != nil {
@@ -124,12 +137,119 @@ func (m *defaultBlockWitnessModel) CreateBlockWitness(witness *BlockWitness) err
 	return nil
 }
 
-func (m *defaultBlockWitnessModel) UpdateBlockWitnessStatus(witness *BlockWitness, status int64) error {
-	witness.Status = status
-	witness.UpdatedAt = time.Now()
-	dbTx := m.DB.Table(m.table).Save(witness)
+// ClaimBlockWitness claims the lowest block witness which is not claimed or whose lease is
+// expired, among the ones of the given gas assets versions. The rows locked by the other
+// provers are skipped, so the provers claim different block witnesses concurrently.
+func (m *defaultBlockWitnessModel) ClaimBlockWitness(prover string, gasAssetsVersions []int64, leaseDuration time.Duration) (witness *BlockWitness, err error) {
+	now := time.Now()
+	err = m.DB.Transaction(func(tx *gorm.DB) error {
+		dbTx := tx.Table(m.table).Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? OR (status = ? AND lease_expired_at < ?)", StatusPublished, StatusReceived, now.UnixMilli()).
+			Where("gas_assets_version IN ?", gasAssetsVersions).
+			Order("height asc").Limit(1).Find(&witness)
+		if dbTx.Error != nil {
+			return types.DbErrSqlOperation
+		} else if dbTx.RowsAffected == 0 {
+			return types.DbErrNotFound
+		}
+
+		witness.Status = StatusReceived
+		witness.Prover = prover
+		witness.LeaseExpiredAt = now.Add(leaseDuration).UnixMilli()
+		witness.UpdatedAt = now
+		dbTx = tx.Table(m.table).Where("id = ?", witness.ID).Updates(map[string]interface{}{
+			"status":           witness.Status,
+			"prover":           witness.Prover,
+			"lease_expired_at": witness.LeaseExpiredAt,
+			"updated_at":       witness.UpdatedAt,
+		})
+		if dbTx.Error != nil {
+			return types.DbErrSqlOperation
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return witness, nil
+}
+
+// RenewBlockWitnessLease extends the lease of the block witness claimed by the prover,
+// DbErrNotFound is returned if the block witness is not held by the prover any more.
+func (m *defaultBlockWitnessModel) RenewBlockWitnessLease(witness *BlockWitness, leaseDuration time.Duration) error {
+	now := time.Now()
+	dbTx := m.DB.Table(m.table).Where("id = ? AND status = ? AND prover = ?", witness.ID, StatusReceived, witness.Prover).
+		Updates(map[string]interface{}{
+			"lease_expired_at": now.Add(leaseDuration).UnixMilli(),
+			"updated_at":       now,
+		})
+	if dbTx.Error != nil {
+		return types.DbErrSqlOperation
+	} else if dbTx.RowsAffected == 0 {
+		return types.DbErrNotFound
+	}
+	witness.LeaseExpiredAt = now.Add(leaseDuration).UnixMilli()
+	witness.UpdatedAt = now
+	return nil
+}
+
+// ReleaseBlockWitness gives up the block witness claimed by the prover, so that it could be
+// claimed again immediately.
+func (m *defaultBlockWitnessModel) ReleaseBlockWitness(witness *BlockWitness) error {
+	dbTx := m.DB.Table(m.table).Where("id = ? AND status = ? AND prover = ?", witness.ID, StatusReceived, witness.Prover).
+		Updates(map[string]interface{}{
+			"status":           StatusPublished,
+			"prover":           "",
+			"lease_expired_at": 0,
+			"updated_at":       time.Now(),
+		})
+	if dbTx.Error != nil {
+		return types.DbErrSqlOperation
+	}
+	return nil
+}
+
+// CompleteBlockWitness marks the block witness as proved by the prover, DbErrNotFound is
+// returned if the block witness is not held by the prover any more, e.g. its lease expired
+// and it is claimed by another prover.
+func (m *defaultBlockWitnessModel) CompleteBlockWitness(witness *BlockWitness) error {
+	dbTx := m.DB.Table(m.table).Where("id = ? AND status = ? AND prover = ?", witness.ID, StatusReceived, witness.Prover).
+		Updates(map[string]interface{}{
+			"status":           StatusProved,
+			"lease_expired_at": 0,
+			"updated_at":       time.Now(),
+		})
 	if dbTx.Error != nil {
 		return types.DbErrSqlOperation
+	} else if dbTx.RowsAffected == 0 {
+		return types.DbErrNotFound
+	}
+	return nil
+}
+
+// GetProverStats counts the block witnesses of each prover by status.
+func (m *defaultBlockWitnessModel) GetProverStats() (stats []*ProverStat, err error) {
+	dbTx := m.DB.Table(m.table).
+		Select("prover, status, count(*) as count, max(height) as max_height, max(updated_at) as last_active_at").
+		Where("prover <> ''").Group("prover, status").Order("prover, status").Find(&stats)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	}
+	return stats, nil
+}
+
+func (m *defaultBlockWitnessModel) GetBlockWitnessesCountByStatus(status int64) (count int64, err error) {
+	dbTx := m.DB.Table(m.table).Where("status = ? AND deleted_at is NULL", status).Count(&count)
+	if dbTx.Error != nil {
+		return 0, types.DbErrSqlOperation
+	}
+	return count, nil
+}
+
+func (m *defaultBlockWitnessModel) DeleteBlockWitnessesForHeightGreaterThanInTransact(tx *gorm.DB, height int64) error {
+	dbTx := tx.Table(m.table).Unscoped().Where("height > ?", height).Delete(&BlockWitness{})
+	if dbTx.Error != nil {
+		return dbTx.Error
 	}
 	return nil
 }
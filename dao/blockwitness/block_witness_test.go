@@ -0,0 +1,193 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package blockwitness
+
+import (
+	"fmt"
+	"os/exec"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+var (
+	dsn             = "host=localhost user=postgres password=ZkBNB@123 dbname=zkbnb port=5435 sslmode=disable"
+	testDB          *gorm.DB
+	testVersions    = []int64{1}
+	testProverA     = "prover-a"
+	testProverB     = "prover-b"
+	testShortLease  = 100 * time.Millisecond
+	testLongLease   = time.Hour
+	testWitnessRows = 4
+)
+
+func TestBlockWitnessClaim(t *testing.T) {
+	testDBSetup()
+	defer testDBShutdown()
+
+	t.Run("claim after expiry", func(t *testing.T) {
+		m := newTestBlockWitnessModel(t)
+
+		witness, err := m.ClaimBlockWitness(testProverA, testVersions, testShortLease)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), witness.Height)
+
+		// The block witness is held by the prover until the lease expires.
+		witness, err = m.ClaimBlockWitness(testProverB, testVersions, testLongLease)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), witness.Height)
+
+		time.Sleep(2 * testShortLease)
+		witness, err = m.ClaimBlockWitness(testProverB, testVersions, testLongLease)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), witness.Height)
+		assert.Equal(t, testProverB, witness.Prover)
+		assert.Equal(t, int64(StatusReceived), witness.Status)
+
+		witness, err = m.GetBlockWitnessByHeight(1)
+		require.NoError(t, err)
+		assert.Equal(t, testProverB, witness.Prover)
+		assert.Greater(t, witness.LeaseExpiredAt, time.Now().Add(testLongLease/2).UnixMilli())
+
+		// The block witnesses of the other versions are not claimed.
+		_, err = m.ClaimBlockWitness(testProverA, []int64{2}, testLongLease)
+		assert.Equal(t, types.DbErrNotFound, err)
+	})
+
+	t.Run("skip locked", func(t *testing.T) {
+		m := newTestBlockWitnessModel(t)
+
+		// The row locked by a claimer in progress is skipped by the other one, rather than
+		// blocking it or being claimed twice.
+		tx := testDB.Begin()
+		var locked *BlockWitness
+		require.NoError(t, tx.Table(TableName).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("height = ?", 1).Find(&locked).Error)
+		witness, err := m.ClaimBlockWitness(testProverB, testVersions, testLongLease)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), witness.Height)
+		require.NoError(t, tx.Rollback().Error)
+
+		witness, err = m.ClaimBlockWitness(testProverB, testVersions, testLongLease)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), witness.Height)
+
+		// The concurrent claimers get different block witnesses.
+		var (
+			wg      sync.WaitGroup
+			mu      sync.Mutex
+			heights = make(map[int64]string)
+		)
+		for i := 0; i < testWitnessRows; i++ {
+			wg.Add(1)
+			go func(prover string) {
+				defer wg.Done()
+				witness, err := m.ClaimBlockWitness(prover, testVersions, testLongLease)
+				if err == types.DbErrNotFound {
+					return
+				}
+				assert.NoError(t, err)
+				mu.Lock()
+				defer mu.Unlock()
+				_, exist := heights[witness.Height]
+				assert.False(t, exist, "block witness %d is claimed twice", witness.Height)
+				heights[witness.Height] = prover
+			}(fmt.Sprintf("prover-%d", i))
+		}
+		wg.Wait()
+		assert.Len(t, heights, testWitnessRows-2)
+		for height, prover := range heights {
+			witness, err = m.GetBlockWitnessByHeight(height)
+			require.NoError(t, err)
+			assert.Equal(t, prover, witness.Prover)
+		}
+	})
+
+	t.Run("complete by stale prover", func(t *testing.T) {
+		m := newTestBlockWitnessModel(t)
+
+		stale, err := m.ClaimBlockWitness(testProverA, testVersions, testShortLease)
+		require.NoError(t, err)
+		time.Sleep(2 * testShortLease)
+		witness, err := m.ClaimBlockWitness(testProverB, testVersions, testLongLease)
+		require.NoError(t, err)
+		require.Equal(t, stale.Height, witness.Height)
+
+		// The stale prover can neither complete nor keep the block witness.
+		assert.Equal(t, types.DbErrNotFound, m.CompleteBlockWitness(stale))
+		assert.Equal(t, types.DbErrNotFound, m.RenewBlockWitnessLease(stale, testLongLease))
+		require.NoError(t, m.ReleaseBlockWitness(stale))
+		current, err := m.GetBlockWitnessByHeight(witness.Height)
+		require.NoError(t, err)
+		assert.Equal(t, int64(StatusReceived), current.Status)
+		assert.Equal(t, testProverB, current.Prover)
+
+		require.NoError(t, m.CompleteBlockWitness(witness))
+		current, err = m.GetBlockWitnessByHeight(witness.Height)
+		require.NoError(t, err)
+		assert.Equal(t, int64(StatusProved), current.Status)
+		assert.Equal(t, testProverB, current.Prover)
+		assert.Equal(t, types.DbErrNotFound, m.CompleteBlockWitness(witness))
+	})
+}
+
+func newTestBlockWitnessModel(t *testing.T) BlockWitnessModel {
+	m := NewBlockWitnessModel(testDB)
+	require.NoError(t, m.DropBlockWitnessTable())
+	require.NoError(t, m.CreateBlockWitnessTable())
+	for h := 1; h <= testWitnessRows; h++ {
+		require.NoError(t, m.CreateBlockWitness(&BlockWitness{
+			Height:           int64(h),
+			WitnessData:      "{}",
+			Status:           StatusPublished,
+			GasAssetsVersion: 1,
+		}))
+	}
+	return m
+}
+
+func testDBSetup() {
+	testDBShutdown()
+	time.Sleep(5 * time.Second)
+	cmd := exec.Command("docker", "run", "--name", "postgres-ut-blockwitness", "-p", "5435:5432",
+		"-e", "POSTGRES_PASSWORD=ZkBNB@123", "-e", "POSTGRES_USER=postgres", "-e", "POSTGRES_DB=zkbnb",
+		"-e", "PGDATA=/var/lib/postgresql/pgdata", "-d", "ghcr.io/bnb-chain/zkbnb/zkbnb-ut-postgres:0.0.2")
+	if err := cmd.Run(); err != nil {
+		panic(err)
+	}
+	time.Sleep(15 * time.Second)
+	testDB, _ = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+}
+
+func testDBShutdown() {
+	cmd := exec.Command("docker", "kill", "postgres-ut-blockwitness")
+	//nolint:errcheck
+	cmd.Run()
+	time.Sleep(time.Second)
+	cmd = exec.Command("docker", "rm", "postgres-ut-blockwitness")
+	//nolint:errcheck
+	cmd.Run()
+}
@@ -20,6 +20,7 @@ const (
 	NftKeyPrefix     = "cache:nft_"
 	GasAccountKey    = "cache:gasAccount"
 	GasConfigKey     = "cache:gasConfig"
+	GasAssetsKey     = "cache:gasAssets"
 )
 
 func AccountKeyByIndex(accountIndex int64) string {
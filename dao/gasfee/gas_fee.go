@@ -0,0 +1,127 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gasfee
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+const (
+	TableName = "gas_fee"
+)
+
+type (
+	GasFeeModel interface {
+		CreateGasFeeTable() error
+		DropGasFeeTable() error
+		CreateGasFee(gasFee *GasFee) error
+		GetLatestGasFee() (gasFee *GasFee, err error)
+		GetGasFeeAt(at time.Time) (gasFee *GasFee, err error)
+		DeleteGasFeesBefore(at time.Time) (count int64, err error)
+	}
+
+	defaultGasFeeModel struct {
+		table string
+		DB    *gorm.DB
+	}
+
+	/*
+		GasFee is a fee schedule computed by the gas fee oracle, it's in effect from its
+		creation until the next one is created. The fees are json of asset id -> (tx type ->
+		fee), in the smallest unit of the asset.
+	*/
+	GasFee struct {
+		gorm.Model
+		L1GasPrice      string
+		PendingTxCount  int64
+		MinFees         string
+		RecommendedFees string
+	}
+)
+
+func (*GasFee) TableName() string {
+	return TableName
+}
+
+func NewGasFeeModel(db *gorm.DB) GasFeeModel {
+	return &defaultGasFeeModel{
+		table: TableName,
+		DB:    db,
+	}
+}
+
+func (m *defaultGasFeeModel) CreateGasFeeTable() error {
+	return m.DB.AutoMigrate(GasFee{})
+}
+
+func (m *defaultGasFeeModel) DropGasFeeTable() error {
+	return m.DB.Migrator().DropTable(m.table)
+}
+
+func (m *defaultGasFeeModel) CreateGasFee(gasFee *GasFee) error {
+	dbTx := m.DB.Table(m.table).Create(gasFee)
+	if dbTx.Error != nil {
+		return types.DbErrSqlOperation
+	}
+	if dbTx.RowsAffected == 0 {
+		return types.DbErrFailToCreateGasFee
+	}
+	return nil
+}
+
+func (m *defaultGasFeeModel) GetLatestGasFee() (gasFee *GasFee, err error) {
+	dbTx := m.DB.Table(m.table).Order("id desc").Limit(1).Find(&gasFee)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	} else if dbTx.RowsAffected == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return gasFee, nil
+}
+
+// GetGasFeeAt returns the fee schedule in effect at the given time.
+func (m *defaultGasFeeModel) GetGasFeeAt(at time.Time) (gasFee *GasFee, err error) {
+	dbTx := m.DB.Table(m.table).Where("created_at <= ?", at).Order("created_at desc, id desc").Limit(1).Find(&gasFee)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	} else if dbTx.RowsAffected == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return gasFee, nil
+}
+
+// DeleteGasFeesBefore deletes the fee schedules which were replaced before the given time,
+// the one in effect at that time is kept.
+func (m *defaultGasFeeModel) DeleteGasFeesBefore(at time.Time) (count int64, err error) {
+	gasFee, err := m.GetGasFeeAt(at)
+	if err != nil {
+		if err == types.DbErrNotFound {
+			return 0, nil
+		}
+		return 0, err
+	}
+	dbTx := m.DB.Table(m.table).Unscoped().Where("id < ?", gasFee.ID).Delete(&GasFee{})
+	if dbTx.Error != nil {
+		return 0, types.DbErrSqlOperation
+	}
+	return dbTx.RowsAffected, nil
+}
@@ -30,6 +30,9 @@ const (
 
 	StatusPending = 1
 	StatusHandled = 2
+	// StatusReplaced means the tx is replaced by a tx with the same nonce and a higher gas
+	// price, it is still handled if it is mined instead of the replacement
+	StatusReplaced = 3
 
 	TxTypeCommit           = 1
 	TxTypeVerifyAndExecute = 2
@@ -44,8 +47,11 @@ type (
 		GetLatestPendingTx(txType int64) (tx *L1RollupTx, err error)
 		GetL1RollupTxsByStatus(txStatus int) (txs []*L1RollupTx, err error)
 		GetL1RollupTxsByHash(hash string) (txs []*L1RollupTx, err error)
+		GetL1RollupTxsByNonce(txType uint8, l2BlockHeight int64, nonce uint64) (txs []*L1RollupTx, err error)
+		CreateL1RollupTxInTransact(tx *gorm.DB, rollupTx *L1RollupTx) error
 		DeleteL1RollupTx(tx *L1RollupTx) error
 		UpdateL1RollupTxsInTransact(tx *gorm.DB, txs []*L1RollupTx) error
+		DeleteL1RollupTxsForHeightGreaterThanInTransact(tx *gorm.DB, height int64) error
 	}
 
 	defaultL1RollupTxModel struct {
@@ -57,12 +63,18 @@ type (
 		gorm.Model
 		// txVerification hash
 		L1TxHash string
-		// txVerification status, 1 - pending, 2 - handled
+		// txVerification status, 1 - pending, 2 - handled, 3 - replaced
 		TxStatus int
 		// txVerification type: commit / verify
 		TxType uint8
 		// layer-2 block height
 		L2BlockHeight int64
+		// the replacements of a stuck tx have the same nonce
+		Nonce uint64
+		// gas price of the legacy tx or fee cap of the EIP-1559 tx in wei, empty if unknown
+		GasPrice string
+		// tip cap of the EIP-1559 tx in wei, empty for the legacy tx
+		GasTipCap string
 	}
 )
 
@@ -95,6 +107,16 @@ func (m *defaultL1RollupTxModel) CreateL1RollupTx(tx *L1RollupTx) error {
 	return nil
 }
 
+func (m *defaultL1RollupTxModel) CreateL1RollupTxInTransact(tx *gorm.DB, rollupTx *L1RollupTx) error {
+	dbTx := tx.Table(m.table).Create(rollupTx)
+	if dbTx.Error != nil {
+		return dbTx.Error
+	} else if dbTx.RowsAffected == 0 {
+		return types.DbErrFailToCreateL1RollupTx
+	}
+	return nil
+}
+
 func (m *defaultL1RollupTxModel) GetL1RollupTxsByStatus(txStatus int) (txs []*L1RollupTx, err error) {
 	dbTx := m.DB.Table(m.table).Where("tx_status = ?", txStatus).Order("l2_block_height, tx_type").Find(&txs)
 	if dbTx.Error != nil {
@@ -115,6 +137,21 @@ func (m *defaultL1RollupTxModel) GetL1RollupTxsByHash(hash string) (txs []*L1Rol
 	return txs, nil
 }
 
+// GetL1RollupTxsByNonce returns the pending tx and the txs replaced by it, which are sent
+// with the same nonce.
+func (m *defaultL1RollupTxModel) GetL1RollupTxsByNonce(txType uint8, l2BlockHeight int64, nonce uint64) (txs []*L1RollupTx, err error) {
+	dbTx := m.DB.Table(m.table).
+		Where("tx_type = ? AND l2_block_height = ? AND nonce = ? AND tx_status IN ?",
+			txType, l2BlockHeight, nonce, []int{StatusPending, StatusReplaced}).
+		Order("id").Find(&txs)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	} else if dbTx.RowsAffected == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return txs, nil
+}
+
 func (m *defaultL1RollupTxModel) DeleteL1RollupTx(rollupTx *L1RollupTx) error {
 	return m.DB.Transaction(func(tx *gorm.DB) error {
 		dbTx := tx.Table(m.table).Where("id = ?", rollupTx.ID).Delete(&rollupTx)
@@ -166,3 +203,11 @@ func (m *defaultL1RollupTxModel) UpdateL1RollupTxsInTransact(tx *gorm.DB, txs []
 	}
 	return nil
 }
+
+func (m *defaultL1RollupTxModel) DeleteL1RollupTxsForHeightGreaterThanInTransact(tx *gorm.DB, height int64) error {
+	dbTx := tx.Table(m.table).Unscoped().Where("l2_block_height > ?", height).Delete(&L1RollupTx{})
+	if dbTx.Error != nil {
+		return dbTx.Error
+	}
+	return nil
+}
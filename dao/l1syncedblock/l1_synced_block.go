@@ -35,8 +35,10 @@ type (
 		CreateL1SyncedBlockTable() error
 		DropL1SyncedBlockTable() error
 		GetLatestL1SyncedBlockByType(blockType int) (blockInfo *L1SyncedBlock, err error)
+		GetL1SyncedBlocksByType(blockType int) (blocks []*L1SyncedBlock, err error)
 		DeleteL1SyncedBlocksForHeightLessThan(height int64) (err error)
 		CreateL1SyncedBlockInTransact(tx *gorm.DB, block *L1SyncedBlock) error
+		DeleteL1SyncedBlocksForHeightGreaterThanInTransact(tx *gorm.DB, blockType int, height int64) error
 	}
 
 	defaultL1EventModel struct {
@@ -48,6 +50,9 @@ type (
 		gorm.Model
 		// l1 block height
 		L1BlockHeight int64 `gorm:"index"`
+		// l1 block hash, the parent hash of the next synced block should match it, it is empty
+		// for the blocks synced before the hash is recorded
+		L1BlockHash string
 		// block info, array of hashes
 		BlockInfo string
 		Type      int `gorm:"index"`
@@ -84,6 +89,18 @@ func (m *defaultL1EventModel) GetLatestL1SyncedBlockByType(blockType int) (block
 	return blockInfo, nil
 }
 
+// GetL1SyncedBlocksByType returns the synced blocks of the type, the latest first.
+func (m *defaultL1EventModel) GetL1SyncedBlocksByType(blockType int) (blocks []*L1SyncedBlock, err error) {
+	dbTx := m.DB.Table(m.table).Where("type = ?", blockType).Order("l1_block_height desc").Find(&blocks)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	}
+	if dbTx.RowsAffected == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return blocks, nil
+}
+
 func (m *defaultL1EventModel) DeleteL1SyncedBlocksForHeightLessThan(height int64) (err error) {
 	dbTx := m.DB.Table(m.table).Unscoped().Where("l1_block_height < ?", height).Delete(&L1SyncedBlock{})
 	if dbTx.Error != nil {
@@ -102,3 +119,11 @@ func (m *defaultL1EventModel) CreateL1SyncedBlockInTransact(tx *gorm.DB, block *
 	}
 	return nil
 }
+
+func (m *defaultL1EventModel) DeleteL1SyncedBlocksForHeightGreaterThanInTransact(tx *gorm.DB, blockType int, height int64) error {
+	dbTx := tx.Table(m.table).Unscoped().Where("type = ? AND l1_block_height > ?", blockType, height).Delete(&L1SyncedBlock{})
+	if dbTx.Error != nil {
+		return dbTx.Error
+	}
+	return nil
+}
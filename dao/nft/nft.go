@@ -36,6 +36,7 @@ type (
 		GetNftsByAccountIndex(accountIndex, limit, offset int64) (nfts []*L2Nft, err error)
 		GetNftsCountByAccountIndex(accountIndex int64) (int64, error)
 		UpdateNftsInTransact(tx *gorm.DB, nfts []*L2Nft) error
+		DeleteNftsInTransact(tx *gorm.DB, nftIndexes []int64) error
 	}
 	defaultL2NftModel struct {
 		table string
@@ -134,3 +135,11 @@ func (m *defaultL2NftModel) UpdateNftsInTransact(tx *gorm.DB, nfts []*L2Nft) err
 	}
 	return nil
 }
+
+func (m *defaultL2NftModel) DeleteNftsInTransact(tx *gorm.DB, nftIndexes []int64) error {
+	dbTx := tx.Table(m.table).Unscoped().Where("nft_index IN ?", nftIndexes).Delete(&L2Nft{})
+	if dbTx.Error != nil {
+		return dbTx.Error
+	}
+	return nil
+}
@@ -36,6 +36,12 @@ type (
 			rowsAffected int64, nftAssets []*L2NftHistory, err error,
 		)
 		CreateNftHistoriesInTransact(tx *gorm.DB, histories []*L2NftHistory) error
+		GetLatestNftHistory(nftIndex, height int64) (nftHistory *L2NftHistory, err error)
+		GetNftsCountByAccountIndexAtHeight(accountIndex, height int64) (count int64, err error)
+		GetNftsByAccountIndexAtHeight(accountIndex, height, limit, offset int64) (nfts []*L2NftHistory, err error)
+		GetNftHistoriesForHeightGreaterThan(height int64) (nftHistories []*L2NftHistory, err error)
+		GetNftHistoriesBetween(start, end int64) (nftHistories []*L2NftHistory, err error)
+		DeleteNftHistoriesForHeightGreaterThanInTransact(tx *gorm.DB, height int64) error
 	}
 	defaultL2NftHistoryModel struct {
 		table string
@@ -44,9 +50,9 @@ type (
 
 	L2NftHistory struct {
 		gorm.Model
-		NftIndex            int64
+		NftIndex            int64 `gorm:"index"`
 		CreatorAccountIndex int64
-		OwnerAccountIndex   int64
+		OwnerAccountIndex   int64 `gorm:"index"`
 		NftContentHash      string
 		NftL1Address        string
 		NftL1TokenId        string
@@ -119,3 +125,73 @@ func (m *defaultL2NftHistoryModel) CreateNftHistoriesInTransact(tx *gorm.DB, his
 	}
 	return nil
 }
+
+func (m *defaultL2NftHistoryModel) GetLatestNftHistory(nftIndex, height int64) (nftHistory *L2NftHistory, err error) {
+	dbTx := m.DB.Table(m.table).Where("nft_index = ? and l2_block_height < ?", nftIndex, height).Order("l2_block_height desc").Limit(1).Find(&nftHistory)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	} else if dbTx.RowsAffected == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return nftHistory, nil
+}
+
+func (m *defaultL2NftHistoryModel) GetNftsCountByAccountIndexAtHeight(accountIndex, height int64) (count int64, err error) {
+	dbTx := m.accountNftsAtHeight(accountIndex, height).Count(&count)
+	if dbTx.Error != nil {
+		return 0, types.DbErrSqlOperation
+	}
+	return count, nil
+}
+
+// GetNftsByAccountIndexAtHeight returns the nfts owned by the account after the block of the
+// height is executed, the snapshots of the nfts at the height are returned.
+func (m *defaultL2NftHistoryModel) GetNftsByAccountIndexAtHeight(accountIndex, height, limit, offset int64) (nfts []*L2NftHistory, err error) {
+	dbTx := m.accountNftsAtHeight(accountIndex, height).Select("*").
+		Limit(int(limit)).Offset(int(offset)).Order("nft_index desc").Find(&nfts)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	} else if dbTx.RowsAffected == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return nfts, nil
+}
+
+func (m *defaultL2NftHistoryModel) accountNftsAtHeight(accountIndex, height int64) *gorm.DB {
+	subQuery := m.DB.Table(m.table).Select("*").
+		Where("nft_index = a.nft_index AND l2_block_height <= ? AND l2_block_height > a.l2_block_height", height)
+
+	return m.DB.Table(m.table+" as a").
+		Where("NOT EXISTS (?) AND l2_block_height <= ? AND owner_account_index = ? AND nft_content_hash <> ?",
+			subQuery, height, accountIndex, types.EmptyNftContentHash)
+}
+
+func (m *defaultL2NftHistoryModel) GetNftHistoriesForHeightGreaterThan(height int64) (nftHistories []*L2NftHistory, err error) {
+	dbTx := m.DB.Table(m.table).Where("l2_block_height > ?", height).Order("l2_block_height, id").Find(&nftHistories)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	} else if dbTx.RowsAffected == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return nftHistories, nil
+}
+
+// GetNftHistoriesBetween returns the nft histories of the blocks in (start, end].
+func (m *defaultL2NftHistoryModel) GetNftHistoriesBetween(start, end int64) (nftHistories []*L2NftHistory, err error) {
+	dbTx := m.DB.Table(m.table).Where("l2_block_height > ? AND l2_block_height <= ?", start, end).
+		Order("l2_block_height, id").Find(&nftHistories)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	} else if dbTx.RowsAffected == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return nftHistories, nil
+}
+
+func (m *defaultL2NftHistoryModel) DeleteNftHistoriesForHeightGreaterThanInTransact(tx *gorm.DB, height int64) error {
+	dbTx := tx.Table(m.table).Unscoped().Where("l2_block_height > ?", height).Delete(&L2NftHistory{})
+	if dbTx.Error != nil {
+		return dbTx.Error
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package offer
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+const (
+	TableName = "offer"
+)
+
+const (
+	StatusOpen = iota
+	// The offer is settled by an atomic match tx.
+	StatusFinalized
+	// The offer is canceled by a cancel offer tx.
+	StatusCanceled
+	StatusExpired
+	// The seller doesn't own the nft any more, the buyer owns it, or the balance of the buyer
+	// is not enough.
+	StatusInvalid
+)
+
+type (
+	OfferModel interface {
+		CreateOfferTable() error
+		DropOfferTable() error
+		CreateOffer(offer *Offer) error
+		GetOffer(accountIndex, offerId int64) (offer *Offer, err error)
+		GetOpenOffersByNftIndex(nftIndex, now, limit, offset int64) (offers []*Offer, err error)
+		GetOpenOffersCountByNftIndex(nftIndex, now int64) (count int64, err error)
+		GetOpenOffersByCollection(creatorAccountIndex, collectionId, now, limit, offset int64) (offers []*Offer, err error)
+		GetOpenOffersCountByCollection(creatorAccountIndex, collectionId, now int64) (count int64, err error)
+		GetOpenOffersByAccountIndex(accountIndex, now, limit, offset int64) (offers []*Offer, err error)
+		GetOpenOffersCountByAccountIndex(accountIndex, now int64) (count int64, err error)
+		ExpireOffers(now int64) (count int64, err error)
+		UpdateOfferStatusInTransact(tx *gorm.DB, accountIndex, offerId, status int64) error
+		InvalidateNftOffersInTransact(tx *gorm.DB, nftIndex, ownerAccountIndex int64) error
+		GetOpenBuyOffersInTransact(tx *gorm.DB, accountIndexes []int64) (offers []*Offer, err error)
+	}
+
+	defaultOfferModel struct {
+		table string
+		DB    *gorm.DB
+	}
+
+	/*
+		Offer is a signed buy or sell offer submitted to the offer book, it's settled
+		by an atomic match tx with a matching offer, or closed by the txs which make it
+		invalid.
+	*/
+	Offer struct {
+		gorm.Model
+		OfferType    int64
+		OfferId      int64 `gorm:"uniqueIndex:idx_account_offer"`
+		AccountIndex int64 `gorm:"uniqueIndex:idx_account_offer"`
+		NftIndex     int64 `gorm:"index"`
+		// the creator and the collection of the nft
+		CreatorAccountIndex int64 `gorm:"index:idx_collection"`
+		CollectionId        int64 `gorm:"index:idx_collection"`
+		AssetId             int64
+		AssetAmount         string
+		ListedAt            int64
+		ExpiredAt           int64
+		TreasuryRate        int64
+		// json of the signed offer tx info
+		OfferInfo string
+		Status    int64 `gorm:"index"`
+	}
+)
+
+func (*Offer) TableName() string {
+	return TableName
+}
+
+func NewOfferModel(db *gorm.DB) OfferModel {
+	return &defaultOfferModel{
+		table: TableName,
+		DB:    db,
+	}
+}
+
+func (m *defaultOfferModel) CreateOfferTable() error {
+	return m.DB.AutoMigrate(Offer{})
+}
+
+func (m *defaultOfferModel) DropOfferTable() error {
+	return m.DB.Migrator().DropTable(m.table)
+}
+
+// CreateOffer inserts the open offer. The expired offer with the same id is replaced, since
+// the offer id is not used on chain yet.
+func (m *defaultOfferModel) CreateOffer(offer *Offer) error {
+	return m.DB.Transaction(func(tx *gorm.DB) error {
+		dbTx := tx.Table(m.table).Unscoped().
+			Where("account_index = ? AND offer_id = ? AND status = ?", offer.AccountIndex, offer.OfferId, StatusExpired).
+			Delete(&Offer{})
+		if dbTx.Error != nil {
+			return types.DbErrSqlOperation
+		}
+		dbTx = tx.Table(m.table).Create(offer)
+		if dbTx.Error != nil {
+			return types.DbErrSqlOperation
+		}
+		if dbTx.RowsAffected == 0 {
+			return types.DbErrFailToCreateOffer
+		}
+		return nil
+	})
+}
+
+func (m *defaultOfferModel) GetOffer(accountIndex, offerId int64) (offer *Offer, err error) {
+	dbTx := m.DB.Table(m.table).Where("account_index = ? AND offer_id = ?", accountIndex, offerId).Limit(1).Find(&offer)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	} else if dbTx.RowsAffected == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return offer, nil
+}
+
+func (m *defaultOfferModel) GetOpenOffersByNftIndex(nftIndex, now, limit, offset int64) (offers []*Offer, err error) {
+	return m.getOpenOffers(m.DB.Where("nft_index = ?", nftIndex), now, limit, offset)
+}
+
+func (m *defaultOfferModel) GetOpenOffersCountByNftIndex(nftIndex, now int64) (count int64, err error) {
+	return m.getOpenOffersCount(m.DB.Where("nft_index = ?", nftIndex), now)
+}
+
+func (m *defaultOfferModel) GetOpenOffersByCollection(creatorAccountIndex, collectionId, now, limit, offset int64) (offers []*Offer, err error) {
+	return m.getOpenOffers(m.DB.Where("creator_account_index = ? AND collection_id = ?", creatorAccountIndex, collectionId),
+		now, limit, offset)
+}
+
+func (m *defaultOfferModel) GetOpenOffersCountByCollection(creatorAccountIndex, collectionId, now int64) (count int64, err error) {
+	return m.getOpenOffersCount(m.DB.Where("creator_account_index = ? AND collection_id = ?", creatorAccountIndex, collectionId), now)
+}
+
+func (m *defaultOfferModel) GetOpenOffersByAccountIndex(accountIndex, now, limit, offset int64) (offers []*Offer, err error) {
+	return m.getOpenOffers(m.DB.Where("account_index = ?", accountIndex), now, limit, offset)
+}
+
+func (m *defaultOfferModel) GetOpenOffersCountByAccountIndex(accountIndex, now int64) (count int64, err error) {
+	return m.getOpenOffersCount(m.DB.Where("account_index = ?", accountIndex), now)
+}
+
+// getOpenOffers lists the open offers matching the condition, the offers expired but not
+// closed yet are excluded.
+func (m *defaultOfferModel) getOpenOffers(condition *gorm.DB, now, limit, offset int64) (offers []*Offer, err error) {
+	dbTx := m.DB.Table(m.table).Where(condition).Where("status = ? AND expired_at >= ?", StatusOpen, now).
+		Limit(int(limit)).Offset(int(offset)).Order("id desc").Find(&offers)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	} else if dbTx.RowsAffected == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return offers, nil
+}
+
+func (m *defaultOfferModel) getOpenOffersCount(condition *gorm.DB, now int64) (count int64, err error) {
+	dbTx := m.DB.Table(m.table).Where(condition).Where("status = ? AND expired_at >= ? AND deleted_at is NULL", StatusOpen, now).
+		Count(&count)
+	if dbTx.Error != nil {
+		return 0, types.DbErrSqlOperation
+	}
+	return count, nil
+}
+
+func (m *defaultOfferModel) ExpireOffers(now int64) (count int64, err error) {
+	dbTx := m.DB.Table(m.table).Where("status = ? AND expired_at < ?", StatusOpen, now).
+		Update("status", StatusExpired)
+	if dbTx.Error != nil {
+		return 0, types.DbErrSqlOperation
+	}
+	return dbTx.RowsAffected, nil
+}
+
+// UpdateOfferStatusInTransact closes the open offer, it's fine that the offer is not in the
+// offer book.
+func (m *defaultOfferModel) UpdateOfferStatusInTransact(tx *gorm.DB, accountIndex, offerId, status int64) error {
+	dbTx := tx.Table(m.table).Where("account_index = ? AND offer_id = ? AND status = ?", accountIndex, offerId, StatusOpen).
+		Update("status", status)
+	if dbTx.Error != nil {
+		return dbTx.Error
+	}
+	return nil
+}
+
+// InvalidateNftOffersInTransact closes the open sell offers of the nft which are not from the
+// owner, and the open buy offers from the owner. All the open offers of the nft are closed
+// if the owner is types.NilAccountIndex, e.g. the nft is withdrawn.
+func (m *defaultOfferModel) InvalidateNftOffersInTransact(tx *gorm.DB, nftIndex, ownerAccountIndex int64) error {
+	dbTx := tx.Table(m.table).Where("nft_index = ? AND status = ?", nftIndex, StatusOpen)
+	if ownerAccountIndex != types.NilAccountIndex {
+		dbTx = dbTx.Where("(offer_type = ? AND account_index <> ?) OR (offer_type = ? AND account_index = ?)",
+			types.SellOfferType, ownerAccountIndex, types.BuyOfferType, ownerAccountIndex)
+	}
+	dbTx = dbTx.Update("status", StatusInvalid)
+	if dbTx.Error != nil {
+		return dbTx.Error
+	}
+	return nil
+}
+
+// GetOpenBuyOffersInTransact returns the open buy offers of the accounts, including the expired
+// ones which are not closed yet.
+func (m *defaultOfferModel) GetOpenBuyOffersInTransact(tx *gorm.DB, accountIndexes []int64) (offers []*Offer, err error) {
+	dbTx := tx.Table(m.table).Where("account_index IN ? AND offer_type = ? AND status = ?",
+		accountIndexes, types.BuyOfferType, StatusOpen).Order("id").Find(&offers)
+	if dbTx.Error != nil {
+		return nil, dbTx.Error
+	} else if dbTx.RowsAffected == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return offers, nil
+}
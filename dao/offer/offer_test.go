@@ -0,0 +1,175 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package offer
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+var (
+	dsn    = "host=localhost user=postgres password=ZkBNB@123 dbname=zkbnb port=5437 sslmode=disable"
+	testDB *gorm.DB
+)
+
+func TestOfferTransitions(t *testing.T) {
+	testDBSetup()
+	defer testDBShutdown()
+
+	m := NewOfferModel(testDB)
+	require.NoError(t, m.DropOfferTable())
+	require.NoError(t, m.CreateOfferTable())
+
+	const (
+		seller = int64(2)
+		buyer  = int64(3)
+		other  = int64(4)
+	)
+	now := time.Now().UnixMilli()
+	newOffer := func(offerType, accountIndex, offerId, nftIndex int64) *Offer {
+		o := &Offer{
+			OfferType:    offerType,
+			OfferId:      offerId,
+			AccountIndex: accountIndex,
+			NftIndex:     nftIndex,
+			AssetId:      types.BNBAssetId,
+			AssetAmount:  "100",
+			ExpiredAt:    now + time.Hour.Milliseconds(),
+			Status:       StatusOpen,
+		}
+		require.NoError(t, m.CreateOffer(o))
+		return o
+	}
+	status := func(accountIndex, offerId int64) int64 {
+		o, err := m.GetOffer(accountIndex, offerId)
+		require.NoError(t, err)
+		return o.Status
+	}
+	transact := func(f func(tx *gorm.DB) error) {
+		require.NoError(t, testDB.Transaction(f))
+	}
+
+	newOffer(types.SellOfferType, seller, 0, 1)
+	newOffer(types.BuyOfferType, buyer, 0, 1)
+	newOffer(types.BuyOfferType, other, 0, 1)
+	newOffer(types.SellOfferType, seller, 1, 2)
+	newOffer(types.SellOfferType, seller, 2, 3)
+	newOffer(types.BuyOfferType, buyer, 1, 3)
+	expiring := newOffer(types.BuyOfferType, other, 1, 2)
+	require.NoError(t, testDB.Model(expiring).Update("expired_at", now-1).Error)
+
+	// The offers are settled by an atomic match and the nft changes owner.
+	transact(func(tx *gorm.DB) error {
+		require.NoError(t, m.UpdateOfferStatusInTransact(tx, buyer, 0, StatusFinalized))
+		require.NoError(t, m.UpdateOfferStatusInTransact(tx, seller, 0, StatusFinalized))
+		return m.InvalidateNftOffersInTransact(tx, 1, buyer)
+	})
+	assert.Equal(t, int64(StatusFinalized), status(buyer, 0))
+	assert.Equal(t, int64(StatusFinalized), status(seller, 0))
+	assert.Equal(t, int64(StatusOpen), status(other, 0))
+
+	// The closed offers are not changed again.
+	transact(func(tx *gorm.DB) error {
+		return m.UpdateOfferStatusInTransact(tx, buyer, 0, StatusCanceled)
+	})
+	assert.Equal(t, int64(StatusFinalized), status(buyer, 0))
+
+	// The nft is sold back to the seller, whose buy offer and the sell offers of the others
+	// are invalid.
+	newOffer(types.BuyOfferType, seller, 3, 1)
+	newOffer(types.SellOfferType, buyer, 2, 1)
+	transact(func(tx *gorm.DB) error {
+		return m.InvalidateNftOffersInTransact(tx, 1, seller)
+	})
+	assert.Equal(t, int64(StatusInvalid), status(seller, 3))
+	assert.Equal(t, int64(StatusInvalid), status(buyer, 2))
+	assert.Equal(t, int64(StatusOpen), status(other, 0))
+
+	// All the offers of the withdrawn nft are invalid.
+	transact(func(tx *gorm.DB) error {
+		return m.InvalidateNftOffersInTransact(tx, 3, types.NilAccountIndex)
+	})
+	assert.Equal(t, int64(StatusInvalid), status(seller, 2))
+	assert.Equal(t, int64(StatusInvalid), status(buyer, 1))
+
+	// The canceled offer.
+	transact(func(tx *gorm.DB) error {
+		return m.UpdateOfferStatusInTransact(tx, seller, 1, StatusCanceled)
+	})
+	assert.Equal(t, int64(StatusCanceled), status(seller, 1))
+
+	// The expired offer is excluded from the listing before it's closed.
+	count, err := m.GetOpenOffersCountByAccountIndex(other, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	var buyOffers []*Offer
+	transact(func(tx *gorm.DB) error {
+		buyOffers, err = m.GetOpenBuyOffersInTransact(tx, []int64{buyer, other})
+		return err
+	})
+	require.Len(t, buyOffers, 2)
+	count, err = m.ExpireOffers(now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	assert.Equal(t, int64(StatusExpired), status(other, 1))
+
+	// The expired offer id could be used again.
+	reused := newOffer(types.BuyOfferType, other, 1, 2)
+	o, err := m.GetOffer(other, 1)
+	require.NoError(t, err)
+	assert.Equal(t, reused.ID, o.ID)
+	assert.Equal(t, int64(StatusOpen), o.Status)
+	// The other closed offer ids could not.
+	assert.Error(t, m.CreateOffer(&Offer{OfferType: types.SellOfferType, OfferId: 1, AccountIndex: seller}))
+
+	offers, err := m.GetOpenOffersByNftIndex(1, now, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, offers, 1)
+	assert.Equal(t, other, offers[0].AccountIndex)
+}
+
+func testDBSetup() {
+	testDBShutdown()
+	time.Sleep(5 * time.Second)
+	cmd := exec.Command("docker", "run", "--name", "postgres-ut-offer", "-p", "5437:5432",
+		"-e", "POSTGRES_PASSWORD=ZkBNB@123", "-e", "POSTGRES_USER=postgres", "-e", "POSTGRES_DB=zkbnb",
+		"-e", "PGDATA=/var/lib/postgresql/pgdata", "-d", "ghcr.io/bnb-chain/zkbnb/zkbnb-ut-postgres:0.0.2")
+	if err := cmd.Run(); err != nil {
+		panic(err)
+	}
+	time.Sleep(15 * time.Second)
+	testDB, _ = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+}
+
+func testDBShutdown() {
+	cmd := exec.Command("docker", "kill", "postgres-ut-offer")
+	//nolint:errcheck
+	cmd.Run()
+	time.Sleep(time.Second)
+	cmd = exec.Command("docker", "rm", "postgres-ut-offer")
+	//nolint:errcheck
+	cmd.Run()
+}
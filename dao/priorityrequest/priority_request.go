@@ -39,6 +39,8 @@ type (
 		UpdateHandledPriorityRequestsInTransact(tx *gorm.DB, requests []*PriorityRequest) (err error)
 		CreatePriorityRequestsInTransact(tx *gorm.DB, requests []*PriorityRequest) (err error)
 		GetPriorityRequestsByL2TxHash(txHash string) (tx *PriorityRequest, err error)
+		GetPriorityRequestsForL1HeightGreaterThan(height int64) (requests []*PriorityRequest, err error)
+		DeletePriorityRequestsForL1HeightGreaterThanInTransact(tx *gorm.DB, height int64) error
 	}
 
 	defaultPriorityRequestModel struct {
@@ -161,3 +163,22 @@ func (m *defaultPriorityRequestModel) GetPriorityRequestsByL2TxHash(txHash strin
 
 	return tx, nil
 }
+
+func (m *defaultPriorityRequestModel) GetPriorityRequestsForL1HeightGreaterThan(height int64) (requests []*PriorityRequest, err error) {
+	dbTx := m.DB.Table(m.table).Where("l1_block_height > ?", height).Order("request_id").Find(&requests)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	}
+	if dbTx.RowsAffected == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return requests, nil
+}
+
+func (m *defaultPriorityRequestModel) DeletePriorityRequestsForL1HeightGreaterThanInTransact(tx *gorm.DB, height int64) error {
+	dbTx := tx.Table(m.table).Unscoped().Where("l1_block_height > ?", height).Delete(&PriorityRequest{})
+	if dbTx.Error != nil {
+		return dbTx.Error
+	}
+	return nil
+}
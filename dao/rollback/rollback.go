@@ -0,0 +1,173 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package rollback
+
+import (
+	"encoding/json"
+	"sort"
+
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+const (
+	TableName = "rollback"
+
+	StatusPending = 0
+	StatusHandled = 1
+)
+
+type (
+	RollbackModel interface {
+		CreateRollbackTable() error
+		DropRollbackTable() error
+		GetCommitterPendingRollbacks() (rollbacks []*Rollback, err error)
+		GetWitnessPendingRollbacks() (rollbacks []*Rollback, err error)
+		GetPendingRollbacksCount() (count int64, err error)
+		CreateRollbackInTransact(tx *gorm.DB, rollback *Rollback) error
+		UpdateRollbacksInTransact(tx *gorm.DB, rollbacks []*Rollback) error
+	}
+
+	defaultRollbackModel struct {
+		table string
+		DB    *gorm.DB
+	}
+
+	/*
+		Rollback records a BlocksRevert event emitted by L1, the blocks above
+		BlockHeight are reverted. The committer rolls back the database and its
+		trees first, then the witness rolls back its trees.
+	*/
+	Rollback struct {
+		gorm.Model
+		// the latest kept block height
+		BlockHeight int64 `gorm:"index"`
+		// json of []int64, the accounts updated by the reverted blocks
+		AccountIndexes string
+		// json of []int64, the assets of the accounts updated by the reverted blocks
+		AssetIds string
+		// json of []int64, the nfts updated by the reverted blocks
+		NftIndexes      string
+		CommitterStatus int `gorm:"index"`
+		WitnessStatus   int `gorm:"index"`
+	}
+)
+
+func (*Rollback) TableName() string {
+	return TableName
+}
+
+func NewRollbackModel(db *gorm.DB) RollbackModel {
+	return &defaultRollbackModel{
+		table: TableName,
+		DB:    db,
+	}
+}
+
+func (m *defaultRollbackModel) CreateRollbackTable() error {
+	return m.DB.AutoMigrate(Rollback{})
+}
+
+func (m *defaultRollbackModel) DropRollbackTable() error {
+	return m.DB.Migrator().DropTable(m.table)
+}
+
+func (m *defaultRollbackModel) GetCommitterPendingRollbacks() (rollbacks []*Rollback, err error) {
+	dbTx := m.DB.Table(m.table).Where("committer_status = ?", StatusPending).Order("id").Find(&rollbacks)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	} else if dbTx.RowsAffected == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return rollbacks, nil
+}
+
+func (m *defaultRollbackModel) GetWitnessPendingRollbacks() (rollbacks []*Rollback, err error) {
+	dbTx := m.DB.Table(m.table).Where("committer_status = ? AND witness_status = ?", StatusHandled, StatusPending).
+		Order("id").Find(&rollbacks)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	} else if dbTx.RowsAffected == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return rollbacks, nil
+}
+
+func (m *defaultRollbackModel) GetPendingRollbacksCount() (count int64, err error) {
+	dbTx := m.DB.Table(m.table).Where("deleted_at is NULL AND (committer_status = ? OR witness_status = ?)", StatusPending, StatusPending).
+		Count(&count)
+	if dbTx.Error != nil {
+		return 0, types.DbErrSqlOperation
+	}
+	return count, nil
+}
+
+func (m *defaultRollbackModel) CreateRollbackInTransact(tx *gorm.DB, rollback *Rollback) error {
+	dbTx := tx.Table(m.table).Create(rollback)
+	if dbTx.Error != nil {
+		return dbTx.Error
+	}
+	if dbTx.RowsAffected == 0 {
+		return types.DbErrFailToCreateRollback
+	}
+	return nil
+}
+
+func (m *defaultRollbackModel) UpdateRollbacksInTransact(tx *gorm.DB, rollbacks []*Rollback) error {
+	for _, pendingUpdateRollback := range rollbacks {
+		dbTx := tx.Table(m.table).Where("id = ?", pendingUpdateRollback.ID).
+			Select("*").
+			Updates(&pendingUpdateRollback)
+		if dbTx.Error != nil {
+			return dbTx.Error
+		}
+		if dbTx.RowsAffected == 0 {
+			return types.DbErrFailToUpdateRollback
+		}
+	}
+	return nil
+}
+
+// UnmarshalIndexes adds the indexes of the json field, e.g. AccountIndexes, to the set.
+func UnmarshalIndexes(value string, m map[int64]bool) error {
+	if value == "" {
+		return nil
+	}
+	var indexes []int64
+	err := json.Unmarshal([]byte(value), &indexes)
+	if err != nil {
+		return types.JsonErrUnmarshal
+	}
+	for _, index := range indexes {
+		m[index] = true
+	}
+	return nil
+}
+
+// SortedIndexes returns the indexes of the set in ascending order.
+func SortedIndexes(m map[int64]bool) []int64 {
+	indexes := make([]int64, 0, len(m))
+	for index := range m {
+		indexes = append(indexes, index)
+	}
+	sort.Slice(indexes, func(i, j int) bool {
+		return indexes[i] < indexes[j]
+	})
+	return indexes
+}
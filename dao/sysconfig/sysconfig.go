@@ -19,6 +19,7 @@ package sysconfig
 
 import (
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/bnb-chain/zkbnb/types"
 )
@@ -32,9 +33,11 @@ type (
 		CreateSysConfigTable() error
 		DropSysConfigTable() error
 		GetSysConfigByName(name string) (info *SysConfig, err error)
+		GetSysConfigForUpdateInTransact(tx *gorm.DB, name string) (info *SysConfig, err error)
 		CreateSysConfigs(configs []*SysConfig) (rowsAffected int64, err error)
 		CreateSysConfigsInTransact(tx *gorm.DB, configs []*SysConfig) error
 		UpdateSysConfigsInTransact(tx *gorm.DB, configs []*SysConfig) error
+		UpsertSysConfig(config *SysConfig) error
 	}
 
 	defaultSysConfigModel struct {
@@ -80,6 +83,18 @@ func (m *defaultSysConfigModel) GetSysConfigByName(name string) (config *SysConf
 	return config, nil
 }
 
+// GetSysConfigForUpdateInTransact locks the config until the transaction ends, so the value
+// could be updated based on the current one.
+func (m *defaultSysConfigModel) GetSysConfigForUpdateInTransact(tx *gorm.DB, name string) (config *SysConfig, err error) {
+	dbTx := tx.Table(m.table).Clauses(clause.Locking{Strength: "UPDATE"}).Where("name = ?", name).Find(&config)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	} else if dbTx.RowsAffected == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return config, nil
+}
+
 func (m *defaultSysConfigModel) CreateSysConfigs(configs []*SysConfig) (rowsAffected int64, err error) {
 	dbTx := m.DB.Table(m.table).CreateInBatches(configs, len(configs))
 	if dbTx.Error != nil {
@@ -114,3 +129,23 @@ func (m *defaultSysConfigModel) UpdateSysConfigsInTransact(tx *gorm.DB, configs
 	}
 	return nil
 }
+
+// UpsertSysConfig updates the value of the config with the same name, or creates the config
+// if it doesn't exist.
+func (m *defaultSysConfigModel) UpsertSysConfig(config *SysConfig) error {
+	dbTx := m.DB.Table(m.table).Where("name = ?", config.Name).Update("value", config.Value)
+	if dbTx.Error != nil {
+		return types.DbErrSqlOperation
+	}
+	if dbTx.RowsAffected > 0 {
+		return nil
+	}
+	dbTx = m.DB.Table(m.table).Create(config)
+	if dbTx.Error != nil {
+		return types.DbErrSqlOperation
+	}
+	if dbTx.RowsAffected == 0 {
+		return types.DbErrFailToCreateSysConfig
+	}
+	return nil
+}
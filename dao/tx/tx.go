@@ -50,6 +50,8 @@ type (
 		GetTxsTotalCountBetween(from, to time.Time) (count int64, err error)
 		GetDistinctAccountsCountBetween(from, to time.Time) (count int64, err error)
 		UpdateTxsStatusInTransact(tx *gorm.DB, blockTxStatus map[int64]int) error
+		DeleteTxsForHeightGreaterThanInTransact(tx *gorm.DB, height int64) error
+		CreateFailedTxsInTransact(tx *gorm.DB, failedTxs []*Tx) error
 	}
 
 	defaultTxModel struct {
@@ -84,6 +86,8 @@ type (
 		BlockHeight int64 `gorm:"index"`
 		BlockId     int64 `gorm:"index"`
 		TxStatus    int   `gorm:"index"`
+		// Assigned when the pool tx is failed or evicted.
+		FailReason string
 	}
 )
 
@@ -106,8 +110,10 @@ func (m *defaultTxModel) DropTxTable() error {
 	return m.DB.Migrator().DropTable(m.table)
 }
 
+// GetTxsTotalCount counts the executed txs, the failed txs are only queried by the hashes and
+// the accounts.
 func (m *defaultTxModel) GetTxsTotalCount() (count int64, err error) {
-	dbTx := m.DB.Table(m.table).Where("deleted_at is NULL").Count(&count)
+	dbTx := m.DB.Table(m.table).Where("deleted_at is NULL AND tx_status <> ?", StatusFailed).Count(&count)
 	if dbTx.Error != nil {
 		if dbTx.Error == types.DbErrNotFound {
 			return 0, nil
@@ -118,7 +124,7 @@ func (m *defaultTxModel) GetTxsTotalCount() (count int64, err error) {
 }
 
 func (m *defaultTxModel) GetTxs(limit int64, offset int64) (txList []*Tx, err error) {
-	dbTx := m.DB.Table(m.table).Limit(int(limit)).Offset(int(offset)).Order("created_at desc").Find(&txList)
+	dbTx := m.DB.Table(m.table).Where("tx_status <> ?", StatusFailed).Limit(int(limit)).Offset(int(offset)).Order("created_at desc").Find(&txList)
 	if dbTx.Error != nil {
 		return nil, types.DbErrSqlOperation
 	} else if dbTx.RowsAffected == 0 {
@@ -159,7 +165,7 @@ func (m *defaultTxModel) GetTxByHash(txHash string) (tx *Tx, err error) {
 }
 
 func (m *defaultTxModel) GetTxsTotalCountBetween(from, to time.Time) (count int64, err error) {
-	dbTx := m.DB.Table(m.table).Where("created_at BETWEEN ? AND ?", from, to).Count(&count)
+	dbTx := m.DB.Table(m.table).Where("created_at BETWEEN ? AND ? AND tx_status <> ?", from, to, StatusFailed).Count(&count)
 	if dbTx.Error != nil {
 		return 0, types.DbErrSqlOperation
 	} else if dbTx.RowsAffected == 0 {
@@ -169,7 +175,7 @@ func (m *defaultTxModel) GetTxsTotalCountBetween(from, to time.Time) (count int6
 }
 
 func (m *defaultTxModel) GetDistinctAccountsCountBetween(from, to time.Time) (count int64, err error) {
-	dbTx := m.DB.Raw("SELECT count (distinct account_index) FROM tx WHERE created_at BETWEEN ? AND ? AND account_index != -1", from, to).Count(&count)
+	dbTx := m.DB.Raw("SELECT count (distinct account_index) FROM tx WHERE created_at BETWEEN ? AND ? AND account_index != -1 AND tx_status <> ?", from, to, StatusFailed).Count(&count)
 	if dbTx.Error != nil {
 		return 0, types.DbErrSqlOperation
 	} else if dbTx.RowsAffected == 0 {
@@ -180,7 +186,7 @@ func (m *defaultTxModel) GetDistinctAccountsCountBetween(from, to time.Time) (co
 
 func (m *defaultTxModel) UpdateTxsStatusInTransact(tx *gorm.DB, blockTxStatus map[int64]int) error {
 	for height, status := range blockTxStatus {
-		dbTx := tx.Table(m.table).Where("block_height = ?", height).Update("tx_status", status)
+		dbTx := tx.Table(m.table).Where("block_height = ? AND tx_status <> ?", height, StatusFailed).Update("tx_status", status)
 		if dbTx.Error != nil {
 			return dbTx.Error
 		}
@@ -190,3 +196,43 @@ func (m *defaultTxModel) UpdateTxsStatusInTransact(tx *gorm.DB, blockTxStatus ma
 	}
 	return nil
 }
+
+// DeleteTxsForHeightGreaterThanInTransact deletes the txs executed in the blocks above the height,
+// the failed txs are kept since they are not part of the blocks.
+func (m *defaultTxModel) DeleteTxsForHeightGreaterThanInTransact(tx *gorm.DB, height int64) error {
+	txIds := tx.Table(m.table).Select("id").Where("block_height > ? AND tx_status <> ?", height, StatusFailed)
+	dbTx := tx.Table(TxDetailTableName).Unscoped().Where("tx_id IN (?)", txIds).Delete(&TxDetail{})
+	if dbTx.Error != nil {
+		return dbTx.Error
+	}
+	dbTx = tx.Table(m.table).Unscoped().Where("block_height > ? AND tx_status <> ?", height, StatusFailed).Delete(&Tx{})
+	if dbTx.Error != nil {
+		return dbTx.Error
+	}
+	return nil
+}
+
+// CreateFailedTxsInTransact keeps the pool txs failed in the committer, so that they could be
+// queried along with the executed txs. The block height of a failed tx is the height of the
+// block in which it failed.
+func (m *defaultTxModel) CreateFailedTxsInTransact(tx *gorm.DB, failedTxs []*Tx) error {
+	if len(failedTxs) == 0 {
+		return nil
+	}
+	txs := make([]*Tx, 0, len(failedTxs))
+	for _, failedTx := range failedTxs {
+		newTx := *failedTx
+		newTx.ID = 0
+		newTx.DeletedAt = gorm.DeletedAt{}
+		newTx.TxDetails = nil
+		txs = append(txs, &newTx)
+	}
+	dbTx := tx.Table(m.table).CreateInBatches(txs, len(txs))
+	if dbTx.Error != nil {
+		return dbTx.Error
+	}
+	if dbTx.RowsAffected == 0 {
+		return types.DbErrFailToCreateTx
+	}
+	return nil
+}
@@ -19,6 +19,8 @@ package tx
 
 import (
 	"gorm.io/gorm"
+
+	"github.com/bnb-chain/zkbnb/types"
 )
 
 const TxDetailTableName = `tx_detail`
@@ -27,6 +29,8 @@ type (
 	TxDetailModel interface {
 		CreateTxDetailTable() error
 		DropTxDetailTable() error
+		GetAssetChangesCountByAccountIndex(accountIndex, assetId int64) (count int64, err error)
+		GetAssetChangesByAccountIndex(accountIndex, assetId, limit, offset int64) (changes []*AssetChange, err error)
 	}
 
 	defaultTxDetailModel struct {
@@ -49,6 +53,14 @@ type (
 		CollectionNonce int64
 		IsGas           bool `gorm:"default:false"`
 	}
+
+	// AssetChange is a tx detail of a fungible asset with the tx which causes it.
+	AssetChange struct {
+		TxDetail
+		TxHash      string
+		TxType      int64
+		BlockHeight int64
+	}
 )
 
 func NewTxDetailModel(db *gorm.DB) TxDetailModel {
@@ -69,3 +81,35 @@ func (m *defaultTxDetailModel) CreateTxDetailTable() error {
 func (m *defaultTxDetailModel) DropTxDetailTable() error {
 	return m.DB.Migrator().DropTable(m.table)
 }
+
+func (m *defaultTxDetailModel) GetAssetChangesCountByAccountIndex(accountIndex, assetId int64) (count int64, err error) {
+	dbTx := m.assetChanges(accountIndex, assetId).Count(&count)
+	if dbTx.Error != nil {
+		return 0, types.DbErrSqlOperation
+	}
+	return count, nil
+}
+
+// GetAssetChangesByAccountIndex lists the changes of the fungible assets of the account from
+// the latest, the changes of all the assets are listed if the asset id is types.NilAssetId.
+func (m *defaultTxDetailModel) GetAssetChangesByAccountIndex(accountIndex, assetId, limit, offset int64) (changes []*AssetChange, err error) {
+	dbTx := m.assetChanges(accountIndex, assetId).
+		Select("d.*, t.tx_hash, t.tx_type, t.block_height").
+		Limit(int(limit)).Offset(int(offset)).Order(`d.tx_id desc, d."order" desc`).Find(&changes)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	} else if dbTx.RowsAffected == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return changes, nil
+}
+
+func (m *defaultTxDetailModel) assetChanges(accountIndex, assetId int64) *gorm.DB {
+	dbTx := m.DB.Table(m.table+" as d").
+		Joins("JOIN "+TxTableName+" as t ON t.id = d.tx_id AND t.deleted_at IS NULL").
+		Where("d.account_index = ? AND d.asset_type = ? AND d.deleted_at IS NULL", accountIndex, types.FungibleAssetType)
+	if assetId != types.NilAssetId {
+		dbTx = dbTx.Where("d.asset_id = ?", assetId)
+	}
+	return dbTx
+}
@@ -18,6 +18,8 @@
 package tx
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 
 	"github.com/bnb-chain/zkbnb/types"
@@ -27,6 +29,14 @@ const (
 	PoolTxTableName = `pool_tx`
 )
 
+// The reasons of the pool txs which are failed without being executed.
+const (
+	FailReasonReplaced     = "replaced by a tx with higher gas fee"
+	FailReasonExpired      = "expired"
+	FailReasonNonceTooLow  = "nonce is lower than the committed one"
+	FailReasonAccountLimit = "too many pending txs of the account"
+)
+
 type (
 	TxPoolModel interface {
 		CreatePoolTxTable() error
@@ -37,11 +47,20 @@ type (
 		GetTxsByStatus(status int) (txs []*Tx, err error)
 		CreateTxs(txs []*Tx) error
 		GetPendingTxsByAccountIndex(accountIndex int64) (txs []*Tx, err error)
+		GetPendingTxByAccountIndexAndNonce(accountIndex int64, nonce int64) (tx *Tx, err error)
+		ReplaceTxs(replacedTxs []*Tx, newTxs []*Tx) error
+		EvictTxs(txs []*Tx) (count int64, err error)
 		GetMaxNonceByAccountIndex(accountIndex int64) (nonce int64, err error)
 		CreateTxsInTransact(tx *gorm.DB, txs []*Tx) error
-		UpdateTxsInTransact(tx *gorm.DB, txs []*Tx) error
+		UpdateTxsInTransact(tx *gorm.DB, txs []*Tx) (skippedTxs []*Tx, err error)
 		DeleteTxsInTransact(tx *gorm.DB, txs []*Tx) error
+		PurgePendingTxsInTransact(tx *gorm.DB, txs []*Tx) error
+		RevertTxsForHeightGreaterThanInTransact(tx *gorm.DB, height int64) error
 		GetLatestTx(txTypes []int64, statuses []int) (tx *Tx, err error)
+		GetLatestTxId() (id int64, err error)
+		GetTxsForIdGreaterThan(id int64, limit int) (txs []*Tx, err error)
+		GetFailedTxsDeletedAfter(deletedAt time.Time, id int64, limit int) (txs []*Tx, err error)
+		GetEarliestTxCreatedAt() (createdAt time.Time, err error)
 	}
 
 	defaultTxPoolModel struct {
@@ -99,8 +118,10 @@ func (m *defaultTxPoolModel) GetTxsTotalCount() (count int64, err error) {
 	return count, nil
 }
 
+// GetTxByTxHash returns the tx in the pool, including the deleted ones, e.g. the failed
+// txs with their fail reasons.
 func (m *defaultTxPoolModel) GetTxByTxHash(hash string) (tx *Tx, err error) {
-	dbTx := m.DB.Table(m.table).Where("tx_hash = ?", hash).Find(&tx)
+	dbTx := m.DB.Table(m.table).Unscoped().Where("tx_hash = ?", hash).Find(&tx)
 	if dbTx.Error != nil {
 		return nil, types.DbErrSqlOperation
 	} else if dbTx.RowsAffected == 0 {
@@ -133,6 +154,70 @@ func (m *defaultTxPoolModel) GetPendingTxsByAccountIndex(accountIndex int64) (tx
 	return txs, nil
 }
 
+func (m *defaultTxPoolModel) GetPendingTxByAccountIndexAndNonce(accountIndex int64, nonce int64) (tx *Tx, err error) {
+	dbTx := m.DB.Table(m.table).Where("tx_status = ? AND account_index = ? AND nonce = ?", StatusPending, accountIndex, nonce).
+		Order("id desc").Limit(1).Find(&tx)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	} else if dbTx.RowsAffected == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return tx, nil
+}
+
+// ReplaceTxs fails the replaced txs and creates the new txs in one transaction, the replaced
+// txs must be still pending, otherwise DbErrFailToReplacePoolTx is returned.
+func (m *defaultTxPoolModel) ReplaceTxs(replacedTxs []*Tx, newTxs []*Tx) error {
+	return m.DB.Transaction(func(tx *gorm.DB) error { // transact
+		for _, replacedTx := range replacedTxs {
+			dbTx := tx.Table(m.table).Where("id = ? AND tx_status = ?", replacedTx.ID, StatusPending).
+				Updates(map[string]interface{}{
+					"tx_status":   StatusFailed,
+					"fail_reason": FailReasonReplaced,
+					"deleted_at":  time.Now(),
+				})
+			if dbTx.Error != nil {
+				return dbTx.Error
+			}
+			if dbTx.RowsAffected == 0 {
+				return types.DbErrFailToReplacePoolTx
+			}
+		}
+		dbTx := tx.Table(m.table).Create(newTxs)
+		if dbTx.Error != nil {
+			return dbTx.Error
+		}
+		if dbTx.RowsAffected == 0 {
+			return types.DbErrFailToCreatePoolTx
+		}
+		return nil
+	})
+}
+
+// EvictTxs fails and deletes the pending txs with their fail reasons, the ones which are
+// no longer pending are skipped, and the count of the evicted txs is returned.
+func (m *defaultTxPoolModel) EvictTxs(txs []*Tx) (count int64, err error) {
+	err = m.DB.Transaction(func(tx *gorm.DB) error { // transact
+		for _, poolTx := range txs {
+			dbTx := tx.Table(m.table).Where("id = ? AND tx_status = ?", poolTx.ID, StatusPending).
+				Updates(map[string]interface{}{
+					"tx_status":   StatusFailed,
+					"fail_reason": poolTx.FailReason,
+					"deleted_at":  time.Now(),
+				})
+			if dbTx.Error != nil {
+				return dbTx.Error
+			}
+			count += dbTx.RowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
 func (m *defaultTxPoolModel) GetMaxNonceByAccountIndex(accountIndex int64) (nonce int64, err error) {
 	dbTx := m.DB.Table(m.table).Select("nonce").Where("deleted_at is null and account_index = ?", accountIndex).Order("nonce desc").Limit(1).Find(&nonce)
 	if dbTx.Error != nil {
@@ -154,23 +239,27 @@ func (m *defaultTxPoolModel) CreateTxsInTransact(tx *gorm.DB, txs []*Tx) error {
 	return nil
 }
 
-func (m *defaultTxPoolModel) UpdateTxsInTransact(tx *gorm.DB, txs []*Tx) error {
+// UpdateTxsInTransact updates the pending txs, the ones which are no longer pending are
+// skipped and returned, e.g. the txs replaced or evicted after they are read by the committer,
+// or the priority txs purged for the reorged L1 blocks, so that their failures are kept.
+func (m *defaultTxPoolModel) UpdateTxsInTransact(tx *gorm.DB, txs []*Tx) (skippedTxs []*Tx, err error) {
 	for _, poolTx := range txs {
 		// Don't write tx details when update tx pool.
 		txDetails := poolTx.TxDetails
 		poolTx.TxDetails = nil
-		dbTx := tx.Table(m.table).Where("id = ?", poolTx.ID).
+		dbTx := tx.Table(m.table).
+			Where("id = ? AND tx_status = ? AND deleted_at IS NULL", poolTx.ID, StatusPending).
 			Select("*").
 			Updates(&poolTx)
 		poolTx.TxDetails = txDetails
 		if dbTx.Error != nil {
-			return dbTx.Error
+			return nil, dbTx.Error
 		}
 		if dbTx.RowsAffected == 0 {
-			return types.DbErrFailToUpdatePoolTx
+			skippedTxs = append(skippedTxs, poolTx)
 		}
 	}
-	return nil
+	return skippedTxs, nil
 }
 
 func (m *defaultTxPoolModel) DeleteTxsInTransact(tx *gorm.DB, txs []*Tx) error {
@@ -186,6 +275,24 @@ func (m *defaultTxPoolModel) DeleteTxsInTransact(tx *gorm.DB, txs []*Tx) error {
 	return nil
 }
 
+// PurgePendingTxsInTransact deletes the pending txs permanently, so that the same txs could
+// be created again with the same hashes, e.g. the priority txs of the reorged L1 blocks.
+// DbErrFailToDeletePoolTx is returned if any of them is no longer pending.
+func (m *defaultTxPoolModel) PurgePendingTxsInTransact(tx *gorm.DB, txs []*Tx) error {
+	for _, poolTx := range txs {
+		dbTx := tx.Table(m.table).Unscoped().
+			Where("id = ? AND tx_status = ? AND deleted_at IS NULL", poolTx.ID, StatusPending).
+			Delete(&Tx{})
+		if dbTx.Error != nil {
+			return dbTx.Error
+		}
+		if dbTx.RowsAffected == 0 {
+			return types.DbErrFailToDeletePoolTx
+		}
+	}
+	return nil
+}
+
 func (m *defaultTxPoolModel) GetLatestTx(txTypes []int64, statuses []int) (tx *Tx, err error) {
 
 	dbTx := m.DB.Table(m.table).Where("tx_status IN ? AND tx_type IN ?", statuses, txTypes).Order("id DESC").Limit(1).Find(&tx)
@@ -197,3 +304,67 @@ func (m *defaultTxPoolModel) GetLatestTx(txTypes []int64, statuses []int) (tx *T
 
 	return tx, nil
 }
+
+func (m *defaultTxPoolModel) GetLatestTxId() (id int64, err error) {
+	dbTx := m.DB.Table(m.table).Unscoped().Select("id").Order("id desc").Limit(1).Find(&id)
+	if dbTx.Error != nil {
+		return 0, types.DbErrSqlOperation
+	} else if dbTx.RowsAffected == 0 {
+		return 0, types.DbErrNotFound
+	}
+	return id, nil
+}
+
+// GetTxsForIdGreaterThan returns the txs accepted into the pool after the tx of the id,
+// including the ones which are already executed.
+func (m *defaultTxPoolModel) GetTxsForIdGreaterThan(id int64, limit int) (txs []*Tx, err error) {
+	dbTx := m.DB.Table(m.table).Unscoped().Where("id > ?", id).Order("id").Limit(limit).Find(&txs)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	} else if dbTx.RowsAffected == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return txs, nil
+}
+
+// GetFailedTxsDeletedAfter returns the failed txs ordered by (deleted_at, id) after the given cursor, the txs
+// failed in the same batch share the deleted_at, so the id is needed to resume from the middle of them.
+func (m *defaultTxPoolModel) GetFailedTxsDeletedAfter(deletedAt time.Time, id int64, limit int) (txs []*Tx, err error) {
+	dbTx := m.DB.Table(m.table).Unscoped().
+		Where("tx_status = ? AND (deleted_at > ? OR (deleted_at = ? AND id > ?))", StatusFailed, deletedAt, deletedAt, id).
+		Order("deleted_at, id").Limit(limit).Find(&txs)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	} else if dbTx.RowsAffected == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return txs, nil
+}
+
+// GetEarliestTxCreatedAt returns when the earliest tx in the pool was accepted, including the
+// executed ones which are not deleted yet.
+func (m *defaultTxPoolModel) GetEarliestTxCreatedAt() (createdAt time.Time, err error) {
+	dbTx := m.DB.Table(m.table).Select("created_at").Where("deleted_at is null").Order("created_at").Limit(1).Find(&createdAt)
+	if dbTx.Error != nil {
+		return time.Time{}, types.DbErrSqlOperation
+	} else if dbTx.RowsAffected == 0 {
+		return time.Time{}, types.DbErrNotFound
+	}
+	return createdAt, nil
+}
+
+// RevertTxsForHeightGreaterThanInTransact puts the txs executed in the blocks above
+// the height back to the pool as pending txs, including the ones already deleted
+// from the pool after their blocks were committed.
+func (m *defaultTxPoolModel) RevertTxsForHeightGreaterThanInTransact(tx *gorm.DB, height int64) error {
+	dbTx := tx.Table(m.table).Unscoped().
+		Where("block_height > ? AND tx_status = ?", height, StatusExecuted).
+		Updates(map[string]interface{}{
+			"tx_status":  StatusPending,
+			"deleted_at": nil,
+		})
+	if dbTx.Error != nil {
+		return dbTx.Error
+	}
+	return nil
+}
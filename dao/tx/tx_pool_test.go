@@ -0,0 +1,148 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package tx
+
+import (
+	"fmt"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+var (
+	dsn    = "host=localhost user=postgres password=ZkBNB@123 dbname=zkbnb port=5436 sslmode=disable"
+	testDB *gorm.DB
+)
+
+func TestUpdateTxsInTransact(t *testing.T) {
+	testDBSetup()
+	defer testDBShutdown()
+
+	m := newTestTxPoolModel(t)
+	txs := make([]*Tx, 0)
+	for nonce := int64(0); nonce < 4; nonce++ {
+		txs = append(txs, newTestPoolTx(nonce))
+	}
+	require.NoError(t, m.CreateTxs(txs))
+
+	// The txs are read by the committer, then one is replaced and one is evicted.
+	executedTxs, err := m.GetTxsByStatus(StatusPending)
+	require.NoError(t, err)
+	require.Len(t, executedTxs, 4)
+	replacingTx := newTestPoolTx(1)
+	replacingTx.TxHash = "replacing"
+	require.NoError(t, m.ReplaceTxs([]*Tx{txs[1]}, []*Tx{replacingTx}))
+	expiredTx := *txs[2]
+	expiredTx.FailReason = FailReasonExpired
+	count, err := m.EvictTxs([]*Tx{&expiredTx})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	for _, executedTx := range executedTxs {
+		executedTx.TxStatus = StatusExecuted
+		executedTx.BlockHeight = 1
+	}
+	var skippedTxs []*Tx
+	err = testDB.Transaction(func(dbTx *gorm.DB) error {
+		skippedTxs, err = m.UpdateTxsInTransact(dbTx, executedTxs)
+		return err
+	})
+	require.NoError(t, err)
+	require.Len(t, skippedTxs, 2)
+	assert.Equal(t, txs[1].TxHash, skippedTxs[0].TxHash)
+	assert.Equal(t, txs[2].TxHash, skippedTxs[1].TxHash)
+
+	// The replaced and evicted txs keep their failures.
+	failedTxs, err := m.GetFailedTxsDeletedAfter(time.Time{}, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, failedTxs, 2)
+	failReasons := make(map[string]string)
+	for _, failedTx := range failedTxs {
+		assert.Equal(t, StatusFailed, failedTx.TxStatus)
+		failReasons[failedTx.TxHash] = failedTx.FailReason
+	}
+	assert.Equal(t, FailReasonReplaced, failReasons[txs[1].TxHash])
+	assert.Equal(t, FailReasonExpired, failReasons[txs[2].TxHash])
+
+	executedTxs, err = m.GetTxsByStatus(StatusExecuted)
+	require.NoError(t, err)
+	require.Len(t, executedTxs, 2)
+	assert.Equal(t, txs[0].TxHash, executedTxs[0].TxHash)
+	assert.Equal(t, txs[3].TxHash, executedTxs[1].TxHash)
+
+	pendingTxs, err := m.GetTxsByStatus(StatusPending)
+	require.NoError(t, err)
+	require.Len(t, pendingTxs, 1)
+	assert.Equal(t, replacingTx.TxHash, pendingTxs[0].TxHash)
+}
+
+func newTestTxPoolModel(t *testing.T) TxPoolModel {
+	m := NewTxPoolModel(testDB)
+	require.NoError(t, m.DropPoolTxTable())
+	require.NoError(t, m.CreatePoolTxTable())
+	return m
+}
+
+func newTestPoolTx(nonce int64) *Tx {
+	return &Tx{
+		TxHash:        fmt.Sprintf("tx-%d", nonce),
+		TxType:        types.TxTypeTransfer,
+		GasFeeAssetId: types.BNBAssetId,
+		GasFee:        "100",
+		NftIndex:      types.NilNftIndex,
+		CollectionId:  types.NilCollectionNonce,
+		AssetId:       types.BNBAssetId,
+		TxAmount:      "100",
+		NativeAddress: types.EmptyL1Address,
+		TxInfo:        "{}",
+		AccountIndex:  1,
+		Nonce:         nonce,
+		BlockHeight:   types.NilBlockHeight,
+		TxStatus:      StatusPending,
+	}
+}
+
+func testDBSetup() {
+	testDBShutdown()
+	time.Sleep(5 * time.Second)
+	cmd := exec.Command("docker", "run", "--name", "postgres-ut-txpool", "-p", "5436:5432",
+		"-e", "POSTGRES_PASSWORD=ZkBNB@123", "-e", "POSTGRES_USER=postgres", "-e", "POSTGRES_DB=zkbnb",
+		"-e", "PGDATA=/var/lib/postgresql/pgdata", "-d", "ghcr.io/bnb-chain/zkbnb/zkbnb-ut-postgres:0.0.2")
+	if err := cmd.Run(); err != nil {
+		panic(err)
+	}
+	time.Sleep(15 * time.Second)
+	testDB, _ = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+}
+
+func testDBShutdown() {
+	cmd := exec.Command("docker", "kill", "postgres-ut-txpool")
+	//nolint:errcheck
+	cmd.Run()
+	time.Sleep(time.Second)
+	cmd = exec.Command("docker", "rm", "postgres-ut-txpool")
+	//nolint:errcheck
+	cmd.Run()
+}
@@ -0,0 +1,125 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package withdrawal
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+const (
+	TableName = "withdrawal"
+
+	// StatusPaid means the funds or the nft are sent to the L1 address.
+	StatusPaid = 1
+	// StatusPending means the L1 transfer fails and the funds or the nft are stored in the
+	// rollup contract, which could be claimed by the owner.
+	StatusPending = 2
+)
+
+type (
+	WithdrawalModel interface {
+		CreateWithdrawalTable() error
+		DropWithdrawalTable() error
+		GetWithdrawalByL2TxHash(txHash string) (withdrawal *Withdrawal, err error)
+		CreateWithdrawalsInTransact(tx *gorm.DB, withdrawals []*Withdrawal) error
+		DeleteWithdrawalsByL1TxHashesInTransact(tx *gorm.DB, l1TxHashes []string) error
+	}
+
+	defaultWithdrawalModel struct {
+		table string
+		DB    *gorm.DB
+	}
+
+	/*
+		Withdrawal records how a Withdraw or WithdrawNft tx is handled on L1 when its block is
+		executed, it is taken from the Withdrawal, WithdrawNft and WithdrawalNFTPending events
+		of the rollup contract, a Withdraw without the Withdrawal event is pending.
+	*/
+	Withdrawal struct {
+		gorm.Model
+		L2TxHash     string `gorm:"uniqueIndex"`
+		TxType       int64
+		BlockHeight  int64 `gorm:"index"`
+		AccountIndex int64
+		ToAddress    string
+		// NilAssetId and NilAssetAmount for the nft withdrawals
+		AssetId     int64
+		AssetAmount string
+		// NilNftIndex for the asset withdrawals
+		NftIndex int64
+		// the tx executing the block on L1
+		L1TxHash string
+		Status   int
+	}
+)
+
+func (*Withdrawal) TableName() string {
+	return TableName
+}
+
+func NewWithdrawalModel(db *gorm.DB) WithdrawalModel {
+	return &defaultWithdrawalModel{
+		table: TableName,
+		DB:    db,
+	}
+}
+
+func (m *defaultWithdrawalModel) CreateWithdrawalTable() error {
+	return m.DB.AutoMigrate(Withdrawal{})
+}
+
+func (m *defaultWithdrawalModel) DropWithdrawalTable() error {
+	return m.DB.Migrator().DropTable(m.table)
+}
+
+func (m *defaultWithdrawalModel) GetWithdrawalByL2TxHash(txHash string) (withdrawal *Withdrawal, err error) {
+	dbTx := m.DB.Table(m.table).Where("l2_tx_hash = ?", txHash).Find(&withdrawal)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	} else if dbTx.RowsAffected == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return withdrawal, nil
+}
+
+func (m *defaultWithdrawalModel) CreateWithdrawalsInTransact(tx *gorm.DB, withdrawals []*Withdrawal) error {
+	if len(withdrawals) == 0 {
+		return nil
+	}
+	dbTx := tx.Table(m.table).CreateInBatches(withdrawals, len(withdrawals))
+	if dbTx.Error != nil {
+		return dbTx.Error
+	}
+	if dbTx.RowsAffected != int64(len(withdrawals)) {
+		return types.DbErrFailToCreateWithdrawal
+	}
+	return nil
+}
+
+func (m *defaultWithdrawalModel) DeleteWithdrawalsByL1TxHashesInTransact(tx *gorm.DB, l1TxHashes []string) error {
+	if len(l1TxHashes) == 0 {
+		return nil
+	}
+	dbTx := tx.Table(m.table).Unscoped().Where("l1_tx_hash IN ?", l1TxHashes).Delete(&Withdrawal{})
+	if dbTx.Error != nil {
+		return dbTx.Error
+	}
+	return nil
+}
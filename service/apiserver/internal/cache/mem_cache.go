@@ -9,12 +9,14 @@ import (
 	accdao "github.com/bnb-chain/zkbnb/dao/account"
 	assetdao "github.com/bnb-chain/zkbnb/dao/asset"
 	blockdao "github.com/bnb-chain/zkbnb/dao/block"
+	gasfeedao "github.com/bnb-chain/zkbnb/dao/gasfee"
 	"github.com/bnb-chain/zkbnb/dao/sysconfig"
 	"github.com/bnb-chain/zkbnb/dao/tx"
 )
 
 const (
 	cacheDefaultExpiration = time.Hour * 1 //gocache default expiration
+	gasFeeExpiration       = time.Second * 10
 
 	AccountIndexNameKeyPrefix  = "in:" //key for cache: accountIndex -> accountName
 	AccountIndexPkKeyPrefix    = "ip:" //key for cache: accountIndex -> accountPk
@@ -34,6 +36,7 @@ const (
 	AssetBySymbolKeyPrefix     = "S:"  //key for cache: assetSymbol -> asset
 	PriceKeyPrefix             = "p:"  //key for cache: symbol -> price
 	SysConfigKeyPrefix         = "s:"  //key for cache: configName -> sysconfig
+	GasFeeKey                  = "gf"  //key for cache: latest gas fee
 )
 
 type fallback func() (interface{}, error)
@@ -299,16 +302,32 @@ func (m *MemCache) GetAssetSymbolById(assetId int64) (string, error) {
 	return asset.AssetSymbol, nil
 }
 
-func (m *MemCache) GetPriceWithFallback(symbol string, f fallback) (float64, error) {
+// Price is the aggregated price of an asset in USD, the price is 0 if it's unknown.
+type Price struct {
+	Price float64
+	// The price sources which are aggregated
+	Source    string
+	UpdatedAt time.Time
+}
+
+func (m *MemCache) GetPriceWithFallback(symbol string, f fallback) (*Price, error) {
 	key := fmt.Sprintf("%s%s", PriceKeyPrefix, symbol)
 	price, err := m.getWithSet(key, m.priceExpiration, f)
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+	return price.(*Price), nil
+}
+
+func (m *MemCache) GetPrice(symbol string) (*Price, bool) {
+	price, found := m.goCache.Get(fmt.Sprintf("%s%s", PriceKeyPrefix, symbol))
+	if !found {
+		return nil, false
 	}
-	return price.(float64), nil
+	return price.(*Price), true
 }
 
-func (m *MemCache) SetPrice(symbol string, price float64) {
+func (m *MemCache) SetPrice(symbol string, price *Price) {
 	key := fmt.Sprintf("%s%s", PriceKeyPrefix, symbol)
 	m.goCache.SetWithTTL(key, price, int64(len(key)), m.priceExpiration)
 }
@@ -321,3 +340,11 @@ func (m *MemCache) GetSysConfigWithFallback(configName string, f fallback) (*sys
 	}
 	return c.(*sysconfig.SysConfig), nil
 }
+
+func (m *MemCache) GetGasFeeWithFallback(f fallback) (*gasfeedao.GasFee, error) {
+	g, err := m.getWithSet(GasFeeKey, gasFeeExpiration, f)
+	if err != nil {
+		return nil, err
+	}
+	return g.(*gasfeedao.GasFee), nil
+}
@@ -4,6 +4,10 @@ import (
 	"github.com/zeromicro/go-zero/core/logx"
 	"github.com/zeromicro/go-zero/core/stores/cache"
 	"github.com/zeromicro/go-zero/rest"
+
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/fetcher/gasfee"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/fetcher/price"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/fetcher/proof"
 )
 
 type Config struct {
@@ -13,13 +17,19 @@ type Config struct {
 	}
 	TxPool struct {
 		MaxPendingTxCount int
+		// Max pending txs of an account, the new txs over it are rejected, no limit if 0
+		MaxPendingTxsPerAccount int `json:",optional"`
+		// Replay the pending txs of the sender before verifying its new txs
+		SpeculativeDryRun bool `json:",optional"`
 	}
-	CacheRedis    cache.CacheConf
-	LogConf       logx.LogConf
+	CacheRedis cache.CacheConf
+	LogConf    logx.LogConf
+	// The CoinMarketCap is the only price source if the price oracle is not configured
+	//nolint:staticcheck
 	CoinMarketCap struct {
 		Url   string
 		Token string
-	}
+	} `json:",optional"`
 	MemCache struct {
 		AccountExpiration int
 		AssetExpiration   int
@@ -31,4 +41,9 @@ type Config struct {
 		MaxCounterNum int64
 		MaxKeyNum     int64
 	}
+	// The static gas fees are used if the oracle is not configured
+	GasFeeOracle gasfee.Config `json:",optional"`
+	PriceOracle  price.Config  `json:",optional"`
+	// The merkle proofs are not served if it's not configured
+	MerkleProof proof.Config `json:",optional"`
 }
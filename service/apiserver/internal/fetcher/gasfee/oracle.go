@@ -0,0 +1,258 @@
+package gasfee
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/stores/redis"
+
+	"github.com/bnb-chain/zkbnb/common/chain"
+	"github.com/bnb-chain/zkbnb/dao/asset"
+	gasfeedao "github.com/bnb-chain/zkbnb/dao/gasfee"
+	"github.com/bnb-chain/zkbnb/dao/sysconfig"
+	"github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/fetcher/price"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+const (
+	fetchTimeout = 3 * time.Second
+	lockKey      = "lock:gasFeeOracle"
+
+	defaultCongestionThreshold     = 1000
+	defaultMaxCongestionMultiplier = 3
+	defaultKeptHistoryHours        = 7 * 24
+)
+
+type Config struct {
+	// Seconds between the computations, the oracle is disabled if it's not positive.
+	Interval                int
+	CongestionThreshold     int64   `json:",optional"`
+	MaxCongestionMultiplier float64 `json:",optional"`
+	// Hours to keep the fee schedules after they are replaced, 168 by default. The ones in
+	// effect when the txs in the pool were accepted are kept until the txs are gone.
+	KeptHistoryHours int `json:",optional"`
+}
+
+// Oracle computes the minimum and the recommended gas fees periodically from the L1 gas
+// price published by the sender, the pub data size of the tx types, the depth of the tx
+// pool and the prices of the gas assets. A new fee schedule is stored whenever the fees
+// change, so that the committer could check the txs against the fees in effect when they
+// entered the tx pool. Only the apiserver replica holding the lock in redis computes the
+// fees, the others take over if it stops refreshing the lock.
+type Oracle interface {
+	Stop()
+}
+
+// locker is held by the only replica which updates the fees, acquiring it again refreshes it.
+type locker interface {
+	Acquire() (bool, error)
+}
+
+func NewOracle(config Config, redisConn *redis.Redis, sysConfigModel sysconfig.SysConfigModel, assetModel asset.AssetModel,
+	txPoolModel tx.TxPoolModel, gasFeeModel gasfeedao.GasFeeModel, priceFetcher price.Fetcher) Oracle {
+	if config.CongestionThreshold <= 0 {
+		config.CongestionThreshold = defaultCongestionThreshold
+	}
+	if config.MaxCongestionMultiplier < 1 {
+		config.MaxCongestionMultiplier = defaultMaxCongestionMultiplier
+	}
+	if config.KeptHistoryHours <= 0 {
+		config.KeptHistoryHours = defaultKeptHistoryHours
+	}
+	lock := redis.NewRedisLock(redisConn, lockKey)
+	// The lock survives a missed update of the holder.
+	lock.SetExpire(2 * config.Interval)
+	o := &oracle{
+		config:         config,
+		lock:           lock,
+		sysConfigModel: sysConfigModel,
+		assetModel:     assetModel,
+		txPoolModel:    txPoolModel,
+		gasFeeModel:    gasFeeModel,
+		priceFetcher:   priceFetcher,
+		quitCh:         make(chan struct{}),
+	}
+	if config.Interval > 0 {
+		go o.loop()
+	}
+	return o
+}
+
+type oracle struct {
+	config         Config
+	lock           locker
+	sysConfigModel sysconfig.SysConfigModel
+	assetModel     asset.AssetModel
+	txPoolModel    tx.TxPoolModel
+	gasFeeModel    gasfeedao.GasFeeModel
+	priceFetcher   price.Fetcher
+
+	quitCh chan struct{}
+}
+
+func (o *oracle) loop() {
+	ticker := time.NewTicker(time.Duration(o.config.Interval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			o.tick()
+		case <-o.quitCh:
+			return
+		}
+	}
+}
+
+func (o *oracle) Stop() {
+	close(o.quitCh)
+}
+
+func (o *oracle) tick() {
+	locked, err := o.lock.Acquire()
+	if err != nil {
+		logx.Errorf("failed to acquire gas fee oracle lock, err: %v", err)
+		return
+	}
+	if !locked {
+		return
+	}
+	err = o.update()
+	if err != nil {
+		logx.Errorf("failed to update gas fees, err: %v", err)
+	}
+	err = o.prune()
+	if err != nil {
+		logx.Errorf("failed to prune gas fees, err: %v", err)
+	}
+}
+
+func (o *oracle) update() error {
+	cfgL1GasPrice, err := o.sysConfigModel.GetSysConfigByName(types.L1GasPrice)
+	if err != nil {
+		if err == types.DbErrNotFound {
+			logx.Info("l1 gas price is not published yet")
+			return nil
+		}
+		return err
+	}
+	l1GasPrice, ok := new(big.Int).SetString(cfgL1GasPrice.Value, 10)
+	if !ok || l1GasPrice.Sign() <= 0 {
+		return fmt.Errorf("invalid l1 gas price: %s", cfgL1GasPrice.Value)
+	}
+	pendingTxCount, err := o.txPoolModel.GetTxsTotalCount()
+	if err != nil {
+		return err
+	}
+
+	cfgGasAssets, err := o.sysConfigModel.GetSysConfigByName(types.SysGasAssets)
+	if err != nil {
+		return err
+	}
+	gasAssets, err := types.ParseGasAssets(cfgGasAssets.Value, types.NilGasAssetsVersion)
+	if err != nil {
+		return err
+	}
+	bnbAsset, err := o.assetModel.GetAssetById(types.BNBAssetId)
+	if err != nil {
+		return err
+	}
+	params := &chain.GasFeeParams{
+		L1GasPrice:              l1GasPrice,
+		PendingTxCount:          pendingTxCount,
+		CongestionThreshold:     o.config.CongestionThreshold,
+		MaxCongestionMultiplier: o.config.MaxCongestionMultiplier,
+		BNBPrice:                o.getPrice(bnbAsset.AssetSymbol),
+	}
+	for _, assetId := range gasAssets.AssetIds {
+		gasAsset, err := o.assetModel.GetAssetById(assetId)
+		if err != nil {
+			return err
+		}
+		params.Assets = append(params.Assets, &chain.GasFeeAsset{
+			AssetId:  assetId,
+			Decimals: gasAsset.Decimals,
+			Price:    o.getPrice(gasAsset.AssetSymbol),
+		})
+	}
+	minFees, recommendedFees := chain.ComputeGasFees(params)
+
+	// The static fees are kept for the assets whose prices are unknown.
+	cfgGasFee, err := o.sysConfigModel.GetSysConfigByName(types.SysGasFee)
+	if err != nil {
+		return err
+	}
+	staticFees := make(map[uint32]map[int]int64)
+	err = json.Unmarshal([]byte(cfgGasFee.Value), &staticFees)
+	if err != nil {
+		return err
+	}
+	for _, assetId := range gasAssets.AssetIds {
+		if _, ok := minFees[uint32(assetId)]; ok {
+			continue
+		}
+		if fees, ok := staticFees[uint32(assetId)]; ok {
+			minFees[uint32(assetId)] = fees
+			recommendedFees[uint32(assetId)] = fees
+		}
+	}
+
+	minFeesBytes, err := json.Marshal(minFees)
+	if err != nil {
+		return err
+	}
+	recommendedFeesBytes, err := json.Marshal(recommendedFees)
+	if err != nil {
+		return err
+	}
+	latest, err := o.gasFeeModel.GetLatestGasFee()
+	if err != nil && err != types.DbErrNotFound {
+		return err
+	}
+	if err == nil && latest.MinFees == string(minFeesBytes) && latest.RecommendedFees == string(recommendedFeesBytes) {
+		return nil
+	}
+	return o.gasFeeModel.CreateGasFee(&gasfeedao.GasFee{
+		L1GasPrice:      l1GasPrice.String(),
+		PendingTxCount:  pendingTxCount,
+		MinFees:         string(minFeesBytes),
+		RecommendedFees: string(recommendedFeesBytes),
+	})
+}
+
+// getPrice returns the price of the asset in USD, zero is returned if it's unknown.
+func (o *oracle) getPrice(symbol string) float64 {
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+	assetPrice, err := o.priceFetcher.GetCurrencyPrice(ctx, symbol)
+	if err != nil {
+		logx.Errorf("failed to get price of %s, err: %v", symbol, err)
+		return 0
+	}
+	return assetPrice
+}
+
+// prune deletes the fee schedules replaced before KeptHistoryHours, unless the txs in the pool
+// could still be checked against them.
+func (o *oracle) prune() error {
+	before := time.Now().Add(-time.Duration(o.config.KeptHistoryHours) * time.Hour)
+	earliest, err := o.txPoolModel.GetEarliestTxCreatedAt()
+	if err != nil && err != types.DbErrNotFound {
+		return err
+	}
+	if err == nil && earliest.Before(before) {
+		before = earliest
+	}
+	count, err := o.gasFeeModel.DeleteGasFeesBefore(before)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		logx.Infof("pruned %d gas fee schedules replaced before %v", count, before)
+	}
+	return nil
+}
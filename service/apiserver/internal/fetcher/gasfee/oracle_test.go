@@ -0,0 +1,161 @@
+package gasfee
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/zkbnb/dao/asset"
+	gasfeedao "github.com/bnb-chain/zkbnb/dao/gasfee"
+	"github.com/bnb-chain/zkbnb/dao/sysconfig"
+	"github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/cache"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/fetcher/price"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+type testLock struct {
+	locked bool
+	err    error
+}
+
+func (l *testLock) Acquire() (bool, error) {
+	return l.locked, l.err
+}
+
+type testSysConfigModel struct {
+	sysconfig.SysConfigModel
+	configs map[string]string
+}
+
+func (m *testSysConfigModel) GetSysConfigByName(name string) (*sysconfig.SysConfig, error) {
+	value, ok := m.configs[name]
+	if !ok {
+		return nil, types.DbErrNotFound
+	}
+	return &sysconfig.SysConfig{Name: name, Value: value}, nil
+}
+
+type testAssetModel struct {
+	asset.AssetModel
+}
+
+func (m *testAssetModel) GetAssetById(assetId int64) (*asset.Asset, error) {
+	return &asset.Asset{AssetId: uint32(assetId), AssetSymbol: "BNB", Decimals: 18}, nil
+}
+
+type testTxPoolModel struct {
+	tx.TxPoolModel
+	earliest time.Time
+}
+
+func (m *testTxPoolModel) GetTxsTotalCount() (int64, error) {
+	return 0, nil
+}
+
+func (m *testTxPoolModel) GetEarliestTxCreatedAt() (time.Time, error) {
+	if m.earliest.IsZero() {
+		return time.Time{}, types.DbErrNotFound
+	}
+	return m.earliest, nil
+}
+
+type testGasFeeModel struct {
+	gasfeedao.GasFeeModel
+	gasFees []*gasfeedao.GasFee
+	deleted []time.Time
+}
+
+func (m *testGasFeeModel) CreateGasFee(gasFee *gasfeedao.GasFee) error {
+	m.gasFees = append(m.gasFees, gasFee)
+	return nil
+}
+
+func (m *testGasFeeModel) GetLatestGasFee() (*gasfeedao.GasFee, error) {
+	if len(m.gasFees) == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return m.gasFees[len(m.gasFees)-1], nil
+}
+
+func (m *testGasFeeModel) DeleteGasFeesBefore(at time.Time) (int64, error) {
+	m.deleted = append(m.deleted, at)
+	return 0, nil
+}
+
+type testPriceFetcher struct {
+	price.Fetcher
+}
+
+func (f *testPriceFetcher) GetCurrencyPrice(context.Context, string) (float64, error) {
+	return 300, nil
+}
+
+func (f *testPriceFetcher) GetPrice(context.Context, string) (*cache.Price, error) {
+	return &cache.Price{Price: 300}, nil
+}
+
+func newTestOracle(lock locker) (*oracle, *testGasFeeModel, *testTxPoolModel) {
+	gasFeeModel := &testGasFeeModel{}
+	txPoolModel := &testTxPoolModel{}
+	o := &oracle{
+		config: Config{
+			Interval:                30,
+			CongestionThreshold:     defaultCongestionThreshold,
+			MaxCongestionMultiplier: defaultMaxCongestionMultiplier,
+			KeptHistoryHours:        24,
+		},
+		lock: lock,
+		sysConfigModel: &testSysConfigModel{configs: map[string]string{
+			types.L1GasPrice:   "5000000000",
+			types.SysGasAssets: `[{"Version":1,"AssetIds":[0]}]`,
+			types.SysGasFee:    fmt.Sprintf(`{"0":{"%d":10}}`, types.TxTypeTransfer),
+		}},
+		assetModel:   &testAssetModel{},
+		txPoolModel:  txPoolModel,
+		gasFeeModel:  gasFeeModel,
+		priceFetcher: &testPriceFetcher{},
+		quitCh:       make(chan struct{}),
+	}
+	return o, gasFeeModel, txPoolModel
+}
+
+func TestOracleTickWithoutLock(t *testing.T) {
+	for _, lock := range []*testLock{{locked: false}, {err: errors.New("redis is down")}} {
+		o, gasFeeModel, _ := newTestOracle(lock)
+		o.tick()
+		assert.Empty(t, gasFeeModel.gasFees)
+		assert.Empty(t, gasFeeModel.deleted)
+	}
+}
+
+func TestOracleTick(t *testing.T) {
+	o, gasFeeModel, txPoolModel := newTestOracle(&testLock{locked: true})
+
+	o.tick()
+	require.Len(t, gasFeeModel.gasFees, 1)
+	assert.Equal(t, "5000000000", gasFeeModel.gasFees[0].L1GasPrice)
+	// The schedules replaced before the kept hours are pruned.
+	require.Len(t, gasFeeModel.deleted, 1)
+	assert.WithinDuration(t, time.Now().Add(-24*time.Hour), gasFeeModel.deleted[0], time.Minute)
+
+	// The same fees are not stored again.
+	o.tick()
+	assert.Len(t, gasFeeModel.gasFees, 1)
+
+	// The schedules the txs in the pool could be checked against are kept.
+	txPoolModel.earliest = time.Now().Add(-48 * time.Hour)
+	o.tick()
+	require.Len(t, gasFeeModel.deleted, 3)
+	assert.Equal(t, txPoolModel.earliest, gasFeeModel.deleted[2])
+
+	txPoolModel.earliest = time.Now().Add(-time.Hour)
+	o.tick()
+	require.Len(t, gasFeeModel.deleted, 4)
+	assert.WithinDuration(t, time.Now().Add(-24*time.Hour), gasFeeModel.deleted[3], time.Minute)
+}
@@ -0,0 +1,113 @@
+package price
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb-eth-rpc/rpc"
+)
+
+var (
+	// selectors of the AggregatorV3Interface
+	decimalsSelector        = common.FromHex("0x313ce567")
+	latestRoundDataSelector = common.FromHex("0xfeaf968c")
+)
+
+// chainlinkSource reads the latest round data of the Chainlink-style price feeds on chain,
+// the feeds are expected to be quoted in USD.
+type chainlinkSource struct {
+	cli   *rpc.ProviderClient
+	feeds map[string]common.Address
+
+	// the decimals of the feeds, they are immutable
+	decimalsMu sync.Mutex
+	decimals   map[common.Address]uint8
+}
+
+func newChainlinkSource(url string, feeds map[string]string) (*chainlinkSource, error) {
+	cli, err := rpc.NewClient(url)
+	if err != nil {
+		return nil, err
+	}
+	s := &chainlinkSource{
+		cli:      cli,
+		feeds:    make(map[string]common.Address, len(feeds)),
+		decimals: make(map[common.Address]uint8, len(feeds)),
+	}
+	for symbol, address := range feeds {
+		if !common.IsHexAddress(address) {
+			return nil, errors.New("invalid price feed address of " + symbol)
+		}
+		s.feeds[symbol] = common.HexToAddress(address)
+	}
+	return s, nil
+}
+
+func (s *chainlinkSource) Name() string {
+	return SourceTypeChainlink
+}
+
+func (s *chainlinkSource) GetPrices(ctx context.Context, symbols []string) (map[string]*SourcePrice, error) {
+	prices := make(map[string]*SourcePrice, len(symbols))
+	for _, symbol := range symbols {
+		feed, ok := s.feeds[symbol]
+		if !ok {
+			continue
+		}
+		price, err := s.getPrice(ctx, feed)
+		if err != nil {
+			logx.Errorf("failed to read price feed of %s, err: %v", symbol, err)
+			continue
+		}
+		prices[symbol] = price
+	}
+	return prices, nil
+}
+
+func (s *chainlinkSource) getPrice(ctx context.Context, feed common.Address) (*SourcePrice, error) {
+	s.decimalsMu.Lock()
+	decimals, ok := s.decimals[feed]
+	s.decimalsMu.Unlock()
+	if !ok {
+		output, err := s.cli.CallContract(ctx, ethereum.CallMsg{To: &feed, Data: decimalsSelector}, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(output) < 32 {
+			return nil, errors.New("invalid decimals output")
+		}
+		decimals = uint8(new(big.Int).SetBytes(output[:32]).Uint64())
+		s.decimalsMu.Lock()
+		s.decimals[feed] = decimals
+		s.decimalsMu.Unlock()
+	}
+
+	// (uint80 roundId, int256 answer, uint256 startedAt, uint256 updatedAt, uint80 answeredInRound)
+	output, err := s.cli.CallContract(ctx, ethereum.CallMsg{To: &feed, Data: latestRoundDataSelector}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(output) < 5*32 {
+		return nil, errors.New("invalid latest round data output")
+	}
+	answer := new(big.Int).SetBytes(output[32:64])
+	if output[32]&0x80 != 0 {
+		// negative answers are invalid prices
+		return nil, errors.New("negative price")
+	}
+	updatedAt := new(big.Int).SetBytes(output[96:128]).Int64()
+
+	price, _ := new(big.Float).Quo(new(big.Float).SetInt(answer),
+		new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))).Float64()
+	return &SourcePrice{
+		Price:     price,
+		UpdatedAt: time.Unix(updatedAt, 0),
+	}, nil
+}
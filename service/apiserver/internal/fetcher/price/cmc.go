@@ -0,0 +1,118 @@
+package price
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+// cmcSource reads the latest quotes from CoinMarketCap, the url ends with the symbol
+// parameter, e.g. https://pro-api.coinmarketcap.com/v1/cryptocurrency/quotes/latest?symbol=
+type cmcSource struct {
+	url   string
+	token string
+}
+
+func newCmcSource(url, token string) *cmcSource {
+	return &cmcSource{
+		url:   url,
+		token: token,
+	}
+}
+
+func (s *cmcSource) Name() string {
+	return SourceTypeCmc
+}
+
+func (s *cmcSource) GetPrices(ctx context.Context, symbols []string) (map[string]*SourcePrice, error) {
+	quoteMap, err := s.getLatestQuotes(ctx, symbols)
+	if err == types.CmcNotListedErr && len(symbols) > 1 {
+		// The whole request is rejected if any symbol is not listed, so the symbols are
+		// requested one by one then.
+		quoteMap = make(map[string]QuoteLatest, len(symbols))
+		for _, symbol := range symbols {
+			quotes, err := s.getLatestQuotes(ctx, []string{symbol})
+			if err != nil {
+				if err != types.CmcNotListedErr {
+					logx.Errorf("failed to get cmc quote of %s, err: %v", symbol, err)
+				}
+				continue
+			}
+			for k, q := range quotes {
+				quoteMap[k] = q
+			}
+		}
+	} else if err != nil {
+		if err == types.CmcNotListedErr {
+			return map[string]*SourcePrice{}, nil
+		}
+		return nil, err
+	}
+
+	prices := make(map[string]*SourcePrice, len(quoteMap))
+	for symbol, q := range quoteMap {
+		quote, ok := q.Quote["USD"]
+		if !ok {
+			continue
+		}
+		updatedAt, err := time.Parse(time.RFC3339, quote.LastUpdated)
+		if err != nil {
+			updatedAt = time.Now()
+		}
+		prices[symbol] = &SourcePrice{
+			Price:     quote.Price,
+			UpdatedAt: updatedAt,
+		}
+	}
+	return prices, nil
+}
+
+func (s *cmcSource) getLatestQuotes(ctx context.Context, symbols []string) (map[string]QuoteLatest, error) {
+	client := &http.Client{}
+	url := fmt.Sprintf("%s%s", s.url, strings.Join(symbols, ","))
+	request, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, types.HttpErrFailToRequest
+	}
+	request.Header.Add("X-CMC_PRO_API_KEY", s.token)
+	request.Header.Add("Accept", "application/json")
+	resp, err := client.Do(request)
+	if err != nil {
+		return nil, types.HttpErrClientDo
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, types.IoErrFailToRead
+	}
+	currencyPrice := &currencyPrice{}
+	if err = json.Unmarshal(body, &currencyPrice); err != nil {
+		return nil, types.JsonErrUnmarshal
+	}
+	dataMap, ok := currencyPrice.Data.(map[string]interface{})
+	if !ok { //the currency not listed on cmc
+		return nil, types.CmcNotListedErr
+	}
+	quotesLatest := make(map[string]QuoteLatest, 0)
+	for _, coinObj := range dataMap {
+		b, err := json.Marshal(coinObj)
+		if err != nil {
+			return nil, types.JsonErrMarshal
+		}
+		quoteLatest := &QuoteLatest{}
+		err = json.Unmarshal(b, quoteLatest)
+		if err != nil {
+			return nil, types.JsonErrUnmarshal
+		}
+		quotesLatest[quoteLatest.Symbol] = *quoteLatest
+	}
+	return quotesLatest, nil
+}
@@ -0,0 +1,95 @@
+package price
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+// coinGeckoSource reads the simple prices from CoinGecko, e.g. the url is
+// https://api.coingecko.com/api/v3/simple/price. The coins are identified by the ids of
+// CoinGecko instead of the symbols.
+type coinGeckoSource struct {
+	url   string
+	token string
+	ids   map[string]string
+}
+
+func newCoinGeckoSource(url, token string, ids map[string]string) *coinGeckoSource {
+	return &coinGeckoSource{
+		url:   url,
+		token: token,
+		ids:   ids,
+	}
+}
+
+type coinGeckoPrice struct {
+	Usd           float64 `json:"usd"`
+	LastUpdatedAt int64   `json:"last_updated_at"`
+}
+
+func (s *coinGeckoSource) Name() string {
+	return SourceTypeCoinGecko
+}
+
+func (s *coinGeckoSource) GetPrices(ctx context.Context, symbols []string) (map[string]*SourcePrice, error) {
+	symbolsById := make(map[string]string, len(symbols))
+	ids := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		id, ok := s.ids[symbol]
+		if !ok {
+			continue
+		}
+		symbolsById[id] = symbol
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return map[string]*SourcePrice{}, nil
+	}
+
+	client := &http.Client{}
+	url := fmt.Sprintf("%s?ids=%s&vs_currencies=usd&include_last_updated_at=true", s.url, strings.Join(ids, ","))
+	request, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, types.HttpErrFailToRequest
+	}
+	if s.token != "" {
+		request.Header.Add("x-cg-pro-api-key", s.token)
+	}
+	request.Header.Add("Accept", "application/json")
+	resp, err := client.Do(request)
+	if err != nil {
+		return nil, types.HttpErrClientDo
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, types.IoErrFailToRead
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("coingecko responds %d: %s", resp.StatusCode, string(body))
+	}
+	result := make(map[string]*coinGeckoPrice)
+	if err = json.Unmarshal(body, &result); err != nil {
+		return nil, types.JsonErrUnmarshal
+	}
+
+	prices := make(map[string]*SourcePrice, len(result))
+	for id, p := range result {
+		symbol, ok := symbolsById[id]
+		if !ok || p == nil {
+			continue
+		}
+		prices[symbol] = &SourcePrice{
+			Price:     p.Usd,
+			UpdatedAt: time.Unix(p.LastUpdatedAt, 0),
+		}
+	}
+	return prices, nil
+}
@@ -2,84 +2,111 @@ package price
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
 	"time"
 
 	"github.com/zeromicro/go-zero/core/logx"
 
 	"github.com/bnb-chain/zkbnb/dao/asset"
 	"github.com/bnb-chain/zkbnb/service/apiserver/internal/cache"
-	"github.com/bnb-chain/zkbnb/types"
 )
 
 const (
-	fetchTimeout  = 3 * time.Second
-	fetchLimit    = 100
-	fetchInterval = 60 * time.Minute
+	fetchTimeout           = 3 * time.Second
+	fetchLimit             = 100
+	defaultRefreshInterval = 60 * time.Minute
 )
 
+type Config struct {
+	// Seconds between the refreshes of all the asset prices, 3600 by default
+	RefreshInterval int `json:",optional"`
+	// Seconds after which the price of a source is stale and ignored, no limit if 0
+	MaxStaleness int `json:",optional"`
+	Sources      []SourceConfig
+}
+
 type Fetcher interface {
 	GetCurrencyPrice(ctx context.Context, l2Symbol string) (price float64, err error)
+	// GetPrice returns the aggregated price with its sources.
+	GetPrice(ctx context.Context, l2Symbol string) (price *cache.Price, err error)
 	Stop()
 }
 
-func NewFetcher(memCache *cache.MemCache, assetModel asset.AssetModel, cmcUrl, cmcToken string) Fetcher {
+// NewFetcher creates the fetcher which aggregates the prices of the sources with the median,
+// the prices of all the assets are refreshed in batches periodically.
+func NewFetcher(memCache *cache.MemCache, assetModel asset.AssetModel, config Config) (Fetcher, error) {
+	sources := make([]Source, 0, len(config.Sources))
+	for _, sourceConfig := range config.Sources {
+		source, err := NewSource(sourceConfig)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+	refreshInterval := defaultRefreshInterval
+	if config.RefreshInterval > 0 {
+		refreshInterval = time.Duration(config.RefreshInterval) * time.Second
+	}
 	f := &fetcher{
-		memCache:   memCache,
-		assetModel: assetModel,
-		cmcUrl:     cmcUrl,
-		cmcToken:   cmcToken,
-		quitCh:     make(chan struct{}),
+		memCache:        memCache,
+		assetModel:      assetModel,
+		sources:         sources,
+		refreshInterval: refreshInterval,
+		maxStaleness:    time.Duration(config.MaxStaleness) * time.Second,
+		quitCh:          make(chan struct{}),
 	}
 	go f.loop()
-	return f
+	return f, nil
 }
 
 type fetcher struct {
-	memCache   *cache.MemCache
-	assetModel asset.AssetModel
-	cmcUrl     string
-	cmcToken   string
+	memCache        *cache.MemCache
+	assetModel      asset.AssetModel
+	sources         []Source
+	refreshInterval time.Duration
+	maxStaleness    time.Duration
 
 	quitCh chan struct{}
 }
 
 func (f *fetcher) loop() {
-	ticker := time.NewTicker(fetchInterval)
+	ticker := time.NewTicker(f.refreshInterval)
 	defer ticker.Stop()
 	for {
 		select {
 		case <-ticker.C:
-			total, err := f.assetModel.GetAssetsTotalCount()
-			if err != nil {
-				logx.Errorf("failed to get all assets, err: %v", err)
-				continue
-			}
-			for i := 0; i < int(total); i += fetchLimit {
-				assets, err := f.assetModel.GetAssets(int64(fetchLimit), int64(i))
-				if err != nil {
-					logx.Errorf("failed to get all assets, err: %v", err)
-					continue
-				}
+			f.refresh()
+		case <-f.quitCh:
+			return
+		}
+	}
+}
 
-				for _, asset := range assets {
-					func() {
-						ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
-						defer cancel()
-						assetPrice, err := f.GetCurrencyPrice(ctx, asset.AssetSymbol)
-						if err != nil {
-							logx.Errorf("failed to get all assets, err: %v", err)
-							return
-						}
-						f.memCache.SetPrice(asset.AssetSymbol, assetPrice)
-					}()
+func (f *fetcher) refresh() {
+	total, err := f.assetModel.GetAssetsTotalCount()
+	if err != nil {
+		logx.Errorf("failed to get all assets, err: %v", err)
+		return
+	}
+	for i := 0; i < int(total); i += fetchLimit {
+		assets, err := f.assetModel.GetAssets(int64(fetchLimit), int64(i))
+		if err != nil {
+			logx.Errorf("failed to get all assets, err: %v", err)
+			continue
+		}
+		symbols := make([]string, 0, len(assets))
+		for _, asset := range assets {
+			symbols = append(symbols, asset.AssetSymbol)
+		}
+		now := time.Now()
+		for symbol, price := range f.fetchPrices(symbols) {
+			// The last price is kept until it's stale if no source could provide a fresh one.
+			if price.Price <= 0 {
+				last, ok := f.memCache.GetPrice(symbol)
+				if ok && last.Price > 0 && (f.maxStaleness <= 0 || now.Sub(last.UpdatedAt) <= f.maxStaleness) {
+					continue
 				}
 			}
-		case <-f.quitCh:
-			return
+			f.memCache.SetPrice(symbol, price)
 		}
 	}
 }
@@ -88,62 +115,43 @@ func (f *fetcher) Stop() {
 	close(f.quitCh)
 }
 
-func (f *fetcher) GetCurrencyPrice(_ context.Context, symbol string) (float64, error) {
+func (f *fetcher) GetCurrencyPrice(ctx context.Context, symbol string) (float64, error) {
+	price, err := f.GetPrice(ctx, symbol)
+	if err != nil {
+		return 0, err
+	}
+	return price.Price, nil
+}
+
+func (f *fetcher) GetPrice(_ context.Context, symbol string) (*cache.Price, error) {
 	return f.memCache.GetPriceWithFallback(symbol, func() (interface{}, error) {
-		quoteMap, err := f.getLatestQuotes(symbol)
-		if err != nil {
-			if err == types.CmcNotListedErr {
-				return 0.0, nil
-			}
-			return 0.0, err
-		}
-		q, ok := quoteMap[symbol]
-		if !ok {
-			return 0.0, nil
-		}
-		price := q.Quote["USD"].Price
-		return price, nil
+		return f.fetchPrices([]string{symbol})[symbol], nil
 	})
 }
 
-func (f *fetcher) getLatestQuotes(symbol string) (map[string]QuoteLatest, error) {
-	client := &http.Client{}
-	url := fmt.Sprintf("%s%s", f.cmcUrl, symbol)
-	request, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, types.HttpErrFailToRequest
-	}
-	request.Header.Add("X-CMC_PRO_API_KEY", f.cmcToken)
-	request.Header.Add("Accept", "application/json")
-	resp, err := client.Do(request)
-	if err != nil {
-		return nil, types.HttpErrClientDo
-	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, types.IoErrFailToRead
-	}
-	currencyPrice := &currencyPrice{}
-	if err = json.Unmarshal(body, &currencyPrice); err != nil {
-		return nil, types.JsonErrUnmarshal
-	}
-	dataMap, ok := currencyPrice.Data.(map[string]interface{})
-	if !ok { //the currency not listed on cmc
-		return nil, types.CmcNotListedErr
+// fetchPrices requests the prices of the symbols from all the sources in a batch, and
+// aggregates them. The failed sources are skipped, the price is 0 if no source lists it.
+func (f *fetcher) fetchPrices(symbols []string) map[string]*cache.Price {
+	sourcePrices := make(map[string][]*namedPrice, len(symbols))
+	for _, source := range f.sources {
+		func() {
+			ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+			defer cancel()
+			prices, err := source.GetPrices(ctx, symbols)
+			if err != nil {
+				logx.Errorf("failed to get prices from %s, err: %v", source.Name(), err)
+				return
+			}
+			for symbol, price := range prices {
+				sourcePrices[symbol] = append(sourcePrices[symbol], &namedPrice{source: source.Name(), SourcePrice: price})
+			}
+		}()
 	}
-	quotesLatest := make(map[string]QuoteLatest, 0)
-	for _, coinObj := range dataMap {
-		b, err := json.Marshal(coinObj)
-		if err != nil {
-			return nil, types.JsonErrMarshal
-		}
-		quoteLatest := &QuoteLatest{}
-		err = json.Unmarshal(b, quoteLatest)
-		if err != nil {
-			return nil, types.JsonErrUnmarshal
-		}
-		quotesLatest[quoteLatest.Symbol] = *quoteLatest
+
+	now := time.Now()
+	prices := make(map[string]*cache.Price, len(symbols))
+	for _, symbol := range symbols {
+		prices[symbol] = aggregatePrice(sourcePrices[symbol], now, f.maxStaleness)
 	}
-	return quotesLatest, nil
+	return prices
 }
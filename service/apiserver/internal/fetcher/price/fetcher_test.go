@@ -0,0 +1,117 @@
+package price
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/zkbnb/dao/asset"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/cache"
+)
+
+type testAssetModel struct {
+	asset.AssetModel
+	assets []*asset.Asset
+}
+
+func (m *testAssetModel) GetAssetsTotalCount() (int64, error) {
+	return int64(len(m.assets)), nil
+}
+
+func (m *testAssetModel) GetAssets(limit int64, offset int64) ([]*asset.Asset, error) {
+	if offset >= int64(len(m.assets)) {
+		return nil, nil
+	}
+	end := offset + limit
+	if end > int64(len(m.assets)) {
+		end = int64(len(m.assets))
+	}
+	return m.assets[offset:end], nil
+}
+
+type testSource struct {
+	name   string
+	prices map[string]*SourcePrice
+	err    error
+	calls  [][]string
+}
+
+func (s *testSource) Name() string {
+	return s.name
+}
+
+func (s *testSource) GetPrices(_ context.Context, symbols []string) (map[string]*SourcePrice, error) {
+	s.calls = append(s.calls, symbols)
+	if s.err != nil {
+		return nil, s.err
+	}
+	prices := make(map[string]*SourcePrice, len(symbols))
+	for _, symbol := range symbols {
+		if price, ok := s.prices[symbol]; ok {
+			prices[symbol] = price
+		}
+	}
+	return prices, nil
+}
+
+func TestFetcherRefresh(t *testing.T) {
+	assetModel := &testAssetModel{}
+	for _, symbol := range []string{"BNB", "ETH", "LEG"} {
+		assetModel.assets = append(assetModel.assets, &asset.Asset{AssetSymbol: symbol})
+	}
+	memCache := cache.MustNewMemCache(nil, assetModel, 10, 10, 10, 10, int(time.Hour/time.Millisecond), 100000, 10000)
+	now := time.Now()
+	cmc := &testSource{name: SourceTypeCmc, prices: map[string]*SourcePrice{
+		"BNB": {Price: 300, UpdatedAt: now},
+		"ETH": {Price: 1500, UpdatedAt: now},
+	}}
+	coinGecko := &testSource{name: SourceTypeCoinGecko, err: errors.New("coingecko is down")}
+	f := &fetcher{
+		memCache:     memCache,
+		assetModel:   assetModel,
+		sources:      []Source{cmc, coinGecko},
+		maxStaleness: time.Hour,
+	}
+	getPrice := func(symbol string) float64 {
+		price, ok := memCache.GetPrice(symbol)
+		if !ok {
+			return -1
+		}
+		return price.Price
+	}
+
+	// The failed source is skipped, and all the symbols are requested in a batch.
+	f.refresh()
+	assert.Equal(t, [][]string{{"BNB", "ETH", "LEG"}}, cmc.calls)
+	require.Eventually(t, func() bool {
+		return getPrice("BNB") == 300 && getPrice("ETH") == 1500 && getPrice("LEG") == 0
+	}, time.Second, 10*time.Millisecond)
+	price, _ := memCache.GetPrice("BNB")
+	assert.Equal(t, SourceTypeCmc, price.Source)
+
+	// The last good prices are kept if all the sources fail, until they are stale.
+	memCache.SetPrice("ETH", &cache.Price{Price: 1400, Source: SourceTypeCmc, UpdatedAt: now.Add(-2 * time.Hour)})
+	require.Eventually(t, func() bool {
+		return getPrice("ETH") == 1400
+	}, time.Second, 10*time.Millisecond)
+	cmc.err = errors.New("cmc is down")
+	f.refresh()
+	require.Eventually(t, func() bool {
+		return getPrice("ETH") == 0
+	}, time.Second, 10*time.Millisecond)
+	assert.Never(t, func() bool {
+		return getPrice("BNB") != 300
+	}, 100*time.Millisecond, 10*time.Millisecond)
+
+	// A fresh price replaces the last one.
+	cmc.err = nil
+	cmc.prices["BNB"] = &SourcePrice{Price: 310, UpdatedAt: time.Now()}
+	f.refresh()
+	require.Eventually(t, func() bool {
+		return getPrice("BNB") == 310
+	}, time.Second, 10*time.Millisecond)
+}
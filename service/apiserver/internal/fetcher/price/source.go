@@ -0,0 +1,101 @@
+package price
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/cache"
+)
+
+const (
+	SourceTypeCmc       = "cmc"
+	SourceTypeCoinGecko = "coingecko"
+	SourceTypeChainlink = "chainlink"
+	SourceTypeStatic    = "static"
+)
+
+type SourceConfig struct {
+	// One of cmc, coingecko, chainlink and static
+	Type string
+	// The api url of cmc and coingecko, or the rpc endpoint of the chain for chainlink
+	Url   string `json:",optional"`
+	Token string `json:",optional"`
+	// Symbol -> coin id of coingecko, or symbol -> address of the chainlink price feed,
+	// the symbols which are not listed are not supported by the source.
+	Ids map[string]string `json:",optional"`
+	// The json file of symbol -> price for the static source
+	Path string `json:",optional"`
+}
+
+// Source is a backend of the asset prices in USD.
+type Source interface {
+	Name() string
+	// GetPrices returns the prices of the symbols, the symbols not listed by the source are
+	// absent from the result.
+	GetPrices(ctx context.Context, symbols []string) (map[string]*SourcePrice, error)
+}
+
+type SourcePrice struct {
+	Price     float64
+	UpdatedAt time.Time
+}
+
+func NewSource(config SourceConfig) (Source, error) {
+	switch config.Type {
+	case SourceTypeCmc:
+		return newCmcSource(config.Url, config.Token), nil
+	case SourceTypeCoinGecko:
+		return newCoinGeckoSource(config.Url, config.Token, config.Ids), nil
+	case SourceTypeChainlink:
+		return newChainlinkSource(config.Url, config.Ids)
+	case SourceTypeStatic:
+		return newStaticSource(config.Path), nil
+	default:
+		return nil, fmt.Errorf("unknown price source: %s", config.Type)
+	}
+}
+
+type namedPrice struct {
+	source string
+	*SourcePrice
+}
+
+// aggregatePrice returns the median of the prices which are updated within maxStaleness,
+// the stale and the non-positive prices are ignored. The price is 0 if none is left.
+func aggregatePrice(prices []*namedPrice, now time.Time, maxStaleness time.Duration) *cache.Price {
+	fresh := make([]*namedPrice, 0, len(prices))
+	for _, p := range prices {
+		if p.Price <= 0 || (maxStaleness > 0 && now.Sub(p.UpdatedAt) > maxStaleness) {
+			continue
+		}
+		fresh = append(fresh, p)
+	}
+	if len(fresh) == 0 {
+		return &cache.Price{UpdatedAt: now}
+	}
+
+	sort.SliceStable(fresh, func(i, j int) bool {
+		return fresh[i].Price < fresh[j].Price
+	})
+	median := fresh[len(fresh)/2].Price
+	if len(fresh)%2 == 0 {
+		median = (fresh[len(fresh)/2-1].Price + median) / 2
+	}
+	updatedAt := fresh[0].UpdatedAt
+	sources := make([]string, 0, len(fresh))
+	for _, p := range fresh {
+		if p.UpdatedAt.Before(updatedAt) {
+			updatedAt = p.UpdatedAt
+		}
+		sources = append(sources, p.source)
+	}
+	sort.Strings(sources)
+	return &cache.Price{
+		Price:     median,
+		Source:    strings.Join(sources, ","),
+		UpdatedAt: updatedAt,
+	}
+}
@@ -0,0 +1,317 @@
+package price
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAggregatePrice(t *testing.T) {
+	now := time.Now()
+	price := func(source string, p float64, age time.Duration) *namedPrice {
+		return &namedPrice{source: source, SourcePrice: &SourcePrice{Price: p, UpdatedAt: now.Add(-age)}}
+	}
+
+	tests := []struct {
+		name         string
+		prices       []*namedPrice
+		maxStaleness time.Duration
+		price        float64
+		source       string
+		updatedAt    time.Time
+	}{
+		{
+			name:      "no source",
+			updatedAt: now,
+		},
+		{
+			name:      "single source",
+			prices:    []*namedPrice{price("cmc", 300, time.Minute)},
+			price:     300,
+			source:    "cmc",
+			updatedAt: now.Add(-time.Minute),
+		},
+		{
+			name: "odd count takes the middle",
+			prices: []*namedPrice{
+				price("static", 310, 0),
+				price("cmc", 290, 2*time.Minute),
+				price("coingecko", 300, time.Minute),
+			},
+			price:     300,
+			source:    "cmc,coingecko,static",
+			updatedAt: now.Add(-2 * time.Minute),
+		},
+		{
+			name: "even count averages the middle two",
+			prices: []*namedPrice{
+				price("static", 400, 0),
+				price("cmc", 290, 0),
+				price("chainlink", 310, 3*time.Minute),
+				price("coingecko", 100, 0),
+			},
+			price:     300,
+			source:    "chainlink,cmc,coingecko,static",
+			updatedAt: now.Add(-3 * time.Minute),
+		},
+		{
+			name: "stale prices are ignored",
+			prices: []*namedPrice{
+				price("cmc", 290, 2*time.Hour),
+				price("coingecko", 300, time.Minute),
+				price("chainlink", 10, time.Hour+time.Second),
+			},
+			maxStaleness: time.Hour,
+			price:        300,
+			source:       "coingecko",
+			updatedAt:    now.Add(-time.Minute),
+		},
+		{
+			name: "stale prices are kept without the limit",
+			prices: []*namedPrice{
+				price("cmc", 290, 2*time.Hour),
+				price("coingecko", 300, time.Minute),
+			},
+			price:     295,
+			source:    "cmc,coingecko",
+			updatedAt: now.Add(-2 * time.Hour),
+		},
+		{
+			name: "non-positive prices are ignored",
+			prices: []*namedPrice{
+				price("cmc", 0, 0),
+				price("coingecko", -1, 0),
+				price("static", 300, 0),
+			},
+			price:     300,
+			source:    "static",
+			updatedAt: now,
+		},
+		{
+			name: "all sources failing",
+			prices: []*namedPrice{
+				price("cmc", 0, 0),
+				price("coingecko", 300, 2*time.Hour),
+			},
+			maxStaleness: time.Hour,
+			updatedAt:    now,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := aggregatePrice(tt.prices, now, tt.maxStaleness)
+			assert.Equal(t, tt.price, result.Price)
+			assert.Equal(t, tt.source, result.Source)
+			assert.True(t, tt.updatedAt.Equal(result.UpdatedAt), "updated at %v, expected %v", result.UpdatedAt, tt.updatedAt)
+		})
+	}
+}
+
+func TestCmcSource(t *testing.T) {
+	listed := map[string]float64{"BNB": 300, "ETH": 1500}
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token", r.Header.Get("X-CMC_PRO_API_KEY"))
+		symbols := r.URL.Query().Get("symbol")
+		requests = append(requests, symbols)
+		data := make(map[string]interface{})
+		for _, symbol := range strings.Split(symbols, ",") {
+			price, ok := listed[symbol]
+			if !ok {
+				// cmc rejects the whole request if any symbol is not listed
+				_, _ = w.Write([]byte(`{"status":{"error_code":400,"error_message":"Invalid value for \"symbol\""}}`))
+				return
+			}
+			data[symbol] = map[string]interface{}{
+				"symbol": symbol,
+				"quote": map[string]interface{}{
+					"USD": map[string]interface{}{"price": price, "last_updated": "2022-10-01T00:00:00.000Z"},
+				},
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+	defer server.Close()
+	source := newCmcSource(server.URL+"?symbol=", "token")
+	updatedAt := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	prices, err := source.GetPrices(context.Background(), []string{"BNB", "ETH"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"BNB,ETH"}, requests)
+	assert.Equal(t, map[string]*SourcePrice{
+		"BNB": {Price: 300, UpdatedAt: updatedAt},
+		"ETH": {Price: 1500, UpdatedAt: updatedAt},
+	}, prices)
+
+	// The symbols are requested one by one if any of the batch is not listed.
+	requests = nil
+	prices, err = source.GetPrices(context.Background(), []string{"BNB", "LEG", "ETH"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"BNB,LEG,ETH", "BNB", "LEG", "ETH"}, requests)
+	assert.Equal(t, map[string]*SourcePrice{
+		"BNB": {Price: 300, UpdatedAt: updatedAt},
+		"ETH": {Price: 1500, UpdatedAt: updatedAt},
+	}, prices)
+
+	prices, err = source.GetPrices(context.Background(), []string{"LEG"})
+	require.NoError(t, err)
+	assert.Empty(t, prices)
+}
+
+func TestCoinGeckoSource(t *testing.T) {
+	var requests []string
+	failing := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing {
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"status":{"error_code":429}}`))
+			return
+		}
+		assert.Equal(t, "usd", r.URL.Query().Get("vs_currencies"))
+		assert.Equal(t, "true", r.URL.Query().Get("include_last_updated_at"))
+		ids := r.URL.Query().Get("ids")
+		requests = append(requests, ids)
+		result := make(map[string]interface{})
+		for _, id := range strings.Split(ids, ",") {
+			switch id {
+			case "binancecoin":
+				result[id] = map[string]interface{}{"usd": 300, "last_updated_at": 1664582400}
+			case "ethereum":
+				result[id] = map[string]interface{}{"usd": 1500, "last_updated_at": 1664582460}
+			}
+		}
+		_ = json.NewEncoder(w).Encode(result)
+	}))
+	defer server.Close()
+	source := newCoinGeckoSource(server.URL, "", map[string]string{
+		"BNB": "binancecoin",
+		"ETH": "ethereum",
+		"LEG": "legend",
+	})
+
+	prices, err := source.GetPrices(context.Background(), []string{"BNB", "ETH", "LEG", "USDT"})
+	require.NoError(t, err)
+	// The symbols without ids are not requested.
+	assert.Equal(t, []string{"binancecoin,ethereum,legend"}, requests)
+	assert.Equal(t, map[string]*SourcePrice{
+		"BNB": {Price: 300, UpdatedAt: time.Unix(1664582400, 0)},
+		"ETH": {Price: 1500, UpdatedAt: time.Unix(1664582460, 0)},
+	}, prices)
+
+	prices, err = source.GetPrices(context.Background(), []string{"USDT"})
+	require.NoError(t, err)
+	assert.Empty(t, prices)
+	assert.Len(t, requests, 1)
+
+	failing = true
+	_, err = source.GetPrices(context.Background(), []string{"BNB"})
+	assert.Error(t, err)
+}
+
+func TestChainlinkSource(t *testing.T) {
+	bnbFeed := common.HexToAddress("0x0567f2323251f0aab15c8dfb1967e4e8a7d42aee")
+	ethFeed := common.HexToAddress("0x9ef1b8c0e4f7dc8bf5719ea496883dc6401d5b2e")
+	negativeFeed := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	word := func(v *big.Int) []byte {
+		return common.LeftPadBytes(v.Bytes(), 32)
+	}
+	roundData := func(answer []byte, updatedAt int64) []byte {
+		var output []byte
+		output = append(output, word(big.NewInt(1))...)
+		output = append(output, answer...)
+		output = append(output, word(big.NewInt(updatedAt))...)
+		output = append(output, word(big.NewInt(updatedAt))...)
+		output = append(output, word(big.NewInt(1))...)
+		return output
+	}
+	negative := make([]byte, 32)
+	for i := range negative {
+		negative[i] = 0xff
+	}
+
+	decimalsCalls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		require.Equal(t, "eth_call", request.Method)
+		var call struct {
+			To   common.Address `json:"to"`
+			Data hexutil.Bytes  `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(request.Params[0], &call))
+
+		var output []byte
+		switch {
+		case common.Bytes2Hex(call.Data) == common.Bytes2Hex(decimalsSelector):
+			decimalsCalls++
+			output = word(big.NewInt(8))
+		case call.To == bnbFeed:
+			output = roundData(word(big.NewInt(30012345678)), 1664582400)
+		case call.To == ethFeed:
+			output = roundData(word(big.NewInt(150000000000)), 1664582460)
+		case call.To == negativeFeed:
+			output = roundData(negative, 1664582400)
+		}
+		_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":"%s"}`, request.ID, hexutil.Encode(output))
+	}))
+	defer server.Close()
+
+	_, err := newChainlinkSource(server.URL, map[string]string{"BNB": "bnb"})
+	assert.Error(t, err)
+
+	source, err := newChainlinkSource(server.URL, map[string]string{
+		"BNB": bnbFeed.Hex(),
+		"ETH": ethFeed.Hex(),
+		"NEG": negativeFeed.Hex(),
+	})
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		prices, err := source.GetPrices(context.Background(), []string{"BNB", "ETH", "NEG", "USDT"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]*SourcePrice{
+			"BNB": {Price: 300.12345678, UpdatedAt: time.Unix(1664582400, 0)},
+			"ETH": {Price: 1500, UpdatedAt: time.Unix(1664582460, 0)},
+		}, prices)
+	}
+	// The decimals are read once per feed.
+	assert.Equal(t, 3, decimalsCalls)
+}
+
+func TestStaticSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prices.json")
+	source := newStaticSource(path)
+
+	_, err := source.GetPrices(context.Background(), []string{"BNB"})
+	assert.Error(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"BNB": 300, "ETH": 1500}`), 0600))
+	prices, err := source.GetPrices(context.Background(), []string{"BNB", "ETH", "LEG"})
+	require.NoError(t, err)
+	assert.Len(t, prices, 2)
+	assert.Equal(t, float64(300), prices["BNB"].Price)
+	assert.Equal(t, float64(1500), prices["ETH"].Price)
+
+	// The file is read on each request.
+	require.NoError(t, os.WriteFile(path, []byte(`{"BNB": 310}`), 0600))
+	prices, err = source.GetPrices(context.Background(), []string{"BNB", "ETH"})
+	require.NoError(t, err)
+	assert.Len(t, prices, 1)
+	assert.Equal(t, float64(310), prices["BNB"].Price)
+}
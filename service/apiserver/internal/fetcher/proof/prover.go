@@ -0,0 +1,444 @@
+package proof
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/zeromicro/go-zero/core/logx"
+
+	bsmt "github.com/bnb-chain/zkbnb-smt"
+	"github.com/bnb-chain/zkbnb-smt/database/memory"
+	"github.com/bnb-chain/zkbnb/common/chain"
+	"github.com/bnb-chain/zkbnb/common/merkle"
+	"github.com/bnb-chain/zkbnb/dao/account"
+	"github.com/bnb-chain/zkbnb/dao/block"
+	"github.com/bnb-chain/zkbnb/dao/nft"
+	"github.com/bnb-chain/zkbnb/tree"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+const batchLoadSize = 1000
+
+var (
+	ErrUnavailable = errors.New("merkle proofs are not available")
+)
+
+type Config struct {
+	// Seconds between the refreshes of the trees, the prover is disabled if it's not positive.
+	RefreshInterval int
+}
+
+// Prover keeps the account tree and the nft tree at the latest verified block in memory,
+// which are loaded from the account and nft histories and rolled forward as the blocks are
+// verified, so the proofs are always against a state root which is already on L1. The asset
+// trees are small, they are rebuilt from the asset info of the account for each proof.
+type Prover interface {
+	GetAccountProof(accountIndex int64) (*merkle.AccountProof, error)
+	GetAssetProof(accountIndex, assetId int64) (*merkle.AssetProof, error)
+	GetNftProof(nftIndex int64) (*merkle.NftProof, error)
+	Stop()
+}
+
+func NewProver(config Config, accountModel account.AccountModel, accountHistoryModel account.AccountHistoryModel,
+	nftHistoryModel nft.L2NftHistoryModel, blockModel block.BlockModel) Prover {
+	p := &prover{
+		config:              config,
+		accountModel:        accountModel,
+		accountHistoryModel: accountHistoryModel,
+		nftHistoryModel:     nftHistoryModel,
+		blockModel:          blockModel,
+		quitCh:              make(chan struct{}),
+	}
+	if config.RefreshInterval > 0 {
+		go p.loop()
+	}
+	return p
+}
+
+type prover struct {
+	config              Config
+	accountModel        account.AccountModel
+	accountHistoryModel account.AccountHistoryModel
+	nftHistoryModel     nft.L2NftHistoryModel
+	blockModel          block.BlockModel
+
+	// The trees are nil until they are loaded, the mutex guards them as the proofs load
+	// the tree nodes into memory too.
+	mu          sync.Mutex
+	height      int64
+	stateRoot   []byte
+	accountTree bsmt.SparseMerkleTree
+	nftTree     bsmt.SparseMerkleTree
+
+	quitCh chan struct{}
+}
+
+func (p *prover) loop() {
+	err := p.refresh()
+	if err != nil {
+		logx.Errorf("failed to refresh merkle trees, err: %v", err)
+	}
+
+	ticker := time.NewTicker(time.Duration(p.config.RefreshInterval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			err := p.refresh()
+			if err != nil {
+				logx.Errorf("failed to refresh merkle trees, err: %v", err)
+			}
+		case <-p.quitCh:
+			return
+		}
+	}
+}
+
+func (p *prover) Stop() {
+	close(p.quitCh)
+}
+
+func (p *prover) refresh() error {
+	verifiedHeight, err := p.blockModel.GetLatestVerifiedHeight()
+	if err != nil {
+		if err == types.DbErrNotFound {
+			return nil
+		}
+		return err
+	}
+	verifiedBlock, err := p.blockModel.GetBlockByHeightWithoutTx(verifiedHeight)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	loaded, height := p.accountTree != nil, p.height
+	p.mu.Unlock()
+	if loaded && height == verifiedHeight {
+		return nil
+	}
+	if loaded && height < verifiedHeight {
+		return p.rollForward(height, verifiedBlock)
+	}
+	// The trees are reloaded if they are at a higher height, which means the blocks
+	// are reverted.
+	return p.load(verifiedBlock)
+}
+
+func (p *prover) load(verifiedBlock *block.Block) error {
+	height := verifiedBlock.BlockHeight
+	accountTree, err := bsmt.NewBASSparseMerkleTree(bsmt.NewHasher(mimc.NewMiMC()),
+		memory.NewMemoryDB(), tree.AccountTreeHeight, tree.NilAccountNodeHash)
+	if err != nil {
+		return err
+	}
+	accountNum, err := p.accountHistoryModel.GetValidAccountCount(height)
+	if err != nil {
+		return err
+	}
+	for offset := 0; offset < int(accountNum); offset += batchLoadSize {
+		_, histories, err := p.accountHistoryModel.GetValidAccounts(height, batchLoadSize, offset)
+		if err != nil {
+			return err
+		}
+		leaves, err := p.accountLeaves(histories)
+		if err != nil {
+			return err
+		}
+		err = setLeaves(accountTree, leaves)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = accountTree.Commit(nil)
+	if err != nil {
+		return err
+	}
+
+	nftTree, err := bsmt.NewBASSparseMerkleTree(bsmt.NewHasher(mimc.NewMiMC()),
+		memory.NewMemoryDB(), tree.NftTreeHeight, tree.NilNftNodeHash)
+	if err != nil {
+		return err
+	}
+	nftNum, err := p.nftHistoryModel.GetLatestNftsCountByBlockHeight(height)
+	if err != nil {
+		return err
+	}
+	for offset := 0; offset < int(nftNum); offset += batchLoadSize {
+		_, histories, err := p.nftHistoryModel.GetLatestNftsByBlockHeight(height, batchLoadSize, offset)
+		if err != nil {
+			return err
+		}
+		leaves, err := nftLeaves(histories)
+		if err != nil {
+			return err
+		}
+		err = setLeaves(nftTree, leaves)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = nftTree.Commit(nil)
+	if err != nil {
+		return err
+	}
+
+	stateRoot, err := checkStateRoot(verifiedBlock, accountTree, nftTree)
+	if err != nil {
+		p.reset()
+		return err
+	}
+	p.mu.Lock()
+	p.height = height
+	p.stateRoot = stateRoot
+	p.accountTree = accountTree
+	p.nftTree = nftTree
+	p.mu.Unlock()
+	logx.Infof("merkle trees are loaded at height %d", height)
+	return nil
+}
+
+// rollForward applies the latest histories of the blocks in (from, verifiedBlock] to the
+// trees, the trees are dropped on failure and reloaded at the next refresh.
+func (p *prover) rollForward(from int64, verifiedBlock *block.Block) error {
+	accountHistories, err := p.accountHistoryModel.GetAccountHistoriesBetween(from, verifiedBlock.BlockHeight)
+	if err != nil && err != types.DbErrNotFound {
+		return err
+	}
+	accountLeaves, err := p.accountLeaves(accountHistories)
+	if err != nil {
+		return err
+	}
+	nftHistories, err := p.nftHistoryModel.GetNftHistoriesBetween(from, verifiedBlock.BlockHeight)
+	if err != nil && err != types.DbErrNotFound {
+		return err
+	}
+	nftLeaves, err := nftLeaves(nftHistories)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stateRoot, err := func() ([]byte, error) {
+		err := setLeaves(p.accountTree, accountLeaves)
+		if err != nil {
+			return nil, err
+		}
+		err = setLeaves(p.nftTree, nftLeaves)
+		if err != nil {
+			return nil, err
+		}
+		accountVersion := p.accountTree.LatestVersion()
+		_, err = p.accountTree.Commit(&accountVersion)
+		if err != nil {
+			return nil, err
+		}
+		nftVersion := p.nftTree.LatestVersion()
+		_, err = p.nftTree.Commit(&nftVersion)
+		if err != nil {
+			return nil, err
+		}
+		return checkStateRoot(verifiedBlock, p.accountTree, p.nftTree)
+	}()
+	if err != nil {
+		p.accountTree = nil
+		p.nftTree = nil
+		return err
+	}
+	p.height = verifiedBlock.BlockHeight
+	p.stateRoot = stateRoot
+	return nil
+}
+
+func (p *prover) reset() {
+	p.mu.Lock()
+	p.accountTree = nil
+	p.nftTree = nil
+	p.mu.Unlock()
+}
+
+func checkStateRoot(verifiedBlock *block.Block, accountTree, nftTree bsmt.SparseMerkleTree) ([]byte, error) {
+	stateRoot := tree.ComputeStateRootHash(accountTree.Root(), nftTree.Root())
+	if common.Bytes2Hex(stateRoot) != verifiedBlock.StateRoot {
+		return nil, fmt.Errorf("state root mismatch at height %d, expected %s, got %s",
+			verifiedBlock.BlockHeight, verifiedBlock.StateRoot, common.Bytes2Hex(stateRoot))
+	}
+	return stateRoot, nil
+}
+
+// accountLeaves computes the leaves of the accounts from the histories, the later histories
+// of an account override the earlier ones.
+func (p *prover) accountLeaves(histories []*account.AccountHistory) (map[int64][]byte, error) {
+	leaves := make(map[int64][]byte, len(histories))
+	for _, history := range histories {
+		accountInfo, err := p.accountModel.GetAccountByIndex(history.AccountIndex)
+		if err != nil {
+			return nil, err
+		}
+		leaf, err := tree.AccountToNode(accountInfo.AccountNameHash, accountInfo.PublicKey,
+			history.Nonce, history.CollectionNonce, common.FromHex(history.AssetRoot))
+		if err != nil {
+			return nil, err
+		}
+		leaves[history.AccountIndex] = leaf
+	}
+	return leaves, nil
+}
+
+// nftLeaves computes the leaves of the nfts from the histories, the later histories of a nft
+// override the earlier ones.
+func nftLeaves(histories []*nft.L2NftHistory) (map[int64][]byte, error) {
+	leaves := make(map[int64][]byte, len(histories))
+	for _, history := range histories {
+		leaf, err := tree.NftAssetToNode(history)
+		if err != nil {
+			return nil, err
+		}
+		leaves[history.NftIndex] = leaf
+	}
+	return leaves, nil
+}
+
+func setLeaves(smt bsmt.SparseMerkleTree, leaves map[int64][]byte) error {
+	for index, leaf := range leaves {
+		err := smt.Set(uint64(index), leaf)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *prover) GetAccountProof(accountIndex int64) (*merkle.AccountProof, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.accountTree == nil {
+		return nil, ErrUnavailable
+	}
+	return p.getAccountProof(accountIndex)
+}
+
+func (p *prover) getAccountProof(accountIndex int64) (*merkle.AccountProof, error) {
+	accountInfo, err := p.accountModel.GetAccountByIndex(accountIndex)
+	if err != nil {
+		return nil, err
+	}
+	history, err := p.accountHistoryModel.GetLatestAccountHistory(accountIndex, p.height+1)
+	if err != nil {
+		return nil, err
+	}
+	siblings, err := p.accountTree.GetProof(uint64(accountIndex))
+	if err != nil {
+		return nil, err
+	}
+	return &merkle.AccountProof{
+		BlockHeight:     p.height,
+		StateRoot:       common.Bytes2Hex(p.stateRoot),
+		NftRoot:         common.Bytes2Hex(p.nftTree.Root()),
+		AccountIndex:    accountIndex,
+		AccountNameHash: accountInfo.AccountNameHash,
+		AccountPk:       accountInfo.PublicKey,
+		Nonce:           history.Nonce,
+		CollectionNonce: history.CollectionNonce,
+		AssetRoot:       history.AssetRoot,
+		Siblings:        merkle.FormatSiblings(siblings),
+	}, nil
+}
+
+func (p *prover) GetAssetProof(accountIndex, assetId int64) (*merkle.AssetProof, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.accountTree == nil {
+		return nil, ErrUnavailable
+	}
+	accountProof, err := p.getAccountProof(accountIndex)
+	if err != nil {
+		return nil, err
+	}
+	history, err := p.accountHistoryModel.GetLatestAccountHistory(accountIndex, p.height+1)
+	if err != nil {
+		return nil, err
+	}
+	accountInfo, err := chain.ToFormatAccountInfo(&account.Account{
+		AccountIndex: history.AccountIndex,
+		AssetInfo:    history.AssetInfo,
+		AssetRoot:    history.AssetRoot,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	assetTree, err := tree.NewMemAccountAssetTree()
+	if err != nil {
+		return nil, err
+	}
+	for id, asset := range accountInfo.AssetInfo {
+		leaf, err := tree.AssetToNode(asset.Balance.String(), asset.OfferCanceledOrFinalized.String())
+		if err != nil {
+			return nil, err
+		}
+		err = assetTree.Set(uint64(id), leaf)
+		if err != nil {
+			return nil, err
+		}
+	}
+	_, err = assetTree.Commit(nil)
+	if err != nil {
+		return nil, err
+	}
+	if common.Bytes2Hex(assetTree.Root()) != history.AssetRoot {
+		return nil, fmt.Errorf("asset root mismatch of account %d", accountIndex)
+	}
+	siblings, err := assetTree.GetProof(uint64(assetId))
+	if err != nil {
+		return nil, err
+	}
+
+	assetProof := &merkle.AssetProof{
+		AccountProof:             accountProof,
+		AssetId:                  assetId,
+		Balance:                  types.ZeroBigInt.String(),
+		OfferCanceledOrFinalized: types.ZeroBigInt.String(),
+		Siblings:                 merkle.FormatSiblings(siblings),
+	}
+	if asset, ok := accountInfo.AssetInfo[assetId]; ok {
+		assetProof.Balance = asset.Balance.String()
+		assetProof.OfferCanceledOrFinalized = asset.OfferCanceledOrFinalized.String()
+	}
+	return assetProof, nil
+}
+
+func (p *prover) GetNftProof(nftIndex int64) (*merkle.NftProof, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.nftTree == nil {
+		return nil, ErrUnavailable
+	}
+	history, err := p.nftHistoryModel.GetLatestNftHistory(nftIndex, p.height+1)
+	if err != nil {
+		return nil, err
+	}
+	siblings, err := p.nftTree.GetProof(uint64(nftIndex))
+	if err != nil {
+		return nil, err
+	}
+	return &merkle.NftProof{
+		BlockHeight:         p.height,
+		StateRoot:           common.Bytes2Hex(p.stateRoot),
+		AccountRoot:         common.Bytes2Hex(p.accountTree.Root()),
+		NftIndex:            nftIndex,
+		CreatorAccountIndex: history.CreatorAccountIndex,
+		OwnerAccountIndex:   history.OwnerAccountIndex,
+		NftContentHash:      history.NftContentHash,
+		NftL1Address:        history.NftL1Address,
+		NftL1TokenId:        history.NftL1TokenId,
+		CreatorTreasuryRate: history.CreatorTreasuryRate,
+		CollectionId:        history.CollectionId,
+		Siblings:            merkle.FormatSiblings(siblings),
+	}, nil
+}
@@ -9,6 +9,7 @@ import (
 	block "github.com/bnb-chain/zkbnb/service/apiserver/internal/handler/block"
 	info "github.com/bnb-chain/zkbnb/service/apiserver/internal/handler/info"
 	nft "github.com/bnb-chain/zkbnb/service/apiserver/internal/handler/nft"
+	proof "github.com/bnb-chain/zkbnb/service/apiserver/internal/handler/proof"
 	root "github.com/bnb-chain/zkbnb/service/apiserver/internal/handler/root"
 	transaction "github.com/bnb-chain/zkbnb/service/apiserver/internal/handler/transaction"
 	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
@@ -39,6 +40,11 @@ func RegisterHandlers(server *rest.Server, serverCtx *svc.ServiceContext) {
 				Path:    "/api/v1/account",
 				Handler: account.GetAccountHandler(serverCtx),
 			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/accountBalanceHistory",
+				Handler: account.GetAccountBalanceHistoryHandler(serverCtx),
+			},
 		},
 	)
 
@@ -149,6 +155,21 @@ func RegisterHandlers(server *rest.Server, serverCtx *svc.ServiceContext) {
 				Path:    "/api/v1/sendTx",
 				Handler: transaction.SendTxHandler(serverCtx),
 			},
+			{
+				Method:  http.MethodPost,
+				Path:    "/api/v1/sendTxs",
+				Handler: transaction.SendTxsHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodPost,
+				Path:    "/api/v1/simulateTx",
+				Handler: transaction.SimulateTxHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/withdrawalStatus",
+				Handler: transaction.GetWithdrawalStatusHandler(serverCtx),
+			},
 		},
 	)
 
@@ -164,6 +185,51 @@ func RegisterHandlers(server *rest.Server, serverCtx *svc.ServiceContext) {
 				Path:    "/api/v1/accountNfts",
 				Handler: nft.GetAccountNftsHandler(serverCtx),
 			},
+			{
+				Method:  http.MethodPost,
+				Path:    "/api/v1/sendOffer",
+				Handler: nft.SendOfferHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/nftOffers",
+				Handler: nft.GetNftOffersHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/collectionOffers",
+				Handler: nft.GetCollectionOffersHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/accountOffers",
+				Handler: nft.GetAccountOffersHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/atomicMatchTx",
+				Handler: nft.GetAtomicMatchTxHandler(serverCtx),
+			},
+		},
+	)
+
+	server.AddRoutes(
+		[]rest.Route{
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/accountProof",
+				Handler: proof.GetAccountProofHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/assetProof",
+				Handler: proof.GetAssetProofHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/nftProof",
+				Handler: proof.GetNftProofHandler(serverCtx),
+			},
 		},
 	)
 }
@@ -0,0 +1,94 @@
+package subscription
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/rest/httpx"
+
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/logic/subscription"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/notifier"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+)
+
+const (
+	pingInterval = 30 * time.Second
+	writeTimeout = 10 * time.Second
+)
+
+var upgrader = websocket.Upgrader{
+	// The apiserver allows cross-origin requests, so does the subscription.
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+}
+
+func SubscribeHandler(svcCtx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req types.ReqSubscribe
+		if err := httpx.Parse(r, &req); err != nil {
+			httpx.Error(w, err)
+			return
+		}
+
+		l := subscription.NewSubscribeLogic(r.Context(), svcCtx)
+		sub, err := l.Subscribe(&req)
+		if err != nil {
+			httpx.Error(w, err)
+			return
+		}
+		defer svcCtx.Notifier.Unsubscribe(sub)
+
+		// The upgrader replies the error to the client if it fails.
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		serveSubscription(conn, sub)
+	}
+}
+
+func serveSubscription(conn *websocket.Conn, sub *notifier.Subscription) {
+	// The messages from the client are discarded, the reader is only used to handle
+	// the control messages and find out the closed connection.
+	closeCh := make(chan struct{})
+	go func() {
+		defer close(closeCh)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case e, ok := <-sub.Events():
+			if !ok {
+				message := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "subscription is dropped")
+				//nolint:errcheck
+				conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(writeTimeout))
+				return
+			}
+			//nolint:errcheck
+			conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := conn.WriteJSON(e); err != nil {
+				logx.Errorf("failed to push event, err: %v", err)
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+				return
+			}
+		case <-closeCh:
+			return
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/zeromicro/go-zero/rest"
+
+	subscription "github.com/bnb-chain/zkbnb/service/apiserver/internal/handler/subscription"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+)
+
+// RegisterSubscriptionHandlers registers the websocket routes, which can't be generated
+// by goctl like the ones in routes.go.
+func RegisterSubscriptionHandlers(server *rest.Server, serverCtx *svc.ServiceContext) {
+	server.AddRoutes(
+		[]rest.Route{
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/subscribe",
+				Handler: subscription.SubscribeHandler(serverCtx),
+			},
+		},
+	)
+}
@@ -0,0 +1,85 @@
+package account
+
+import (
+	"context"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb-crypto/ffmath"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/logic/utils"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+type GetAccountBalanceHistoryLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGetAccountBalanceHistoryLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GetAccountBalanceHistoryLogic {
+	return &GetAccountBalanceHistoryLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// GetAccountBalanceHistory lists the balance changes of the account from the latest, which
+// are built from the tx details of the txs in blocks. The balance is the one after the change.
+func (l *GetAccountBalanceHistoryLogic) GetAccountBalanceHistory(req *types.ReqGetAccountBalanceHistory) (resp *types.BalanceChanges, err error) {
+	if req.AssetId < types2.NilAssetId {
+		return nil, types2.AppErrInvalidParam.RefineError("invalid asset_id")
+	}
+	index, err := utils.GetAccountIndex(l.svcCtx, req.By, req.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := l.svcCtx.TxDetailModel.GetAssetChangesCountByAccountIndex(index, req.AssetId)
+	if err != nil {
+		return nil, types2.AppErrInternal
+	}
+
+	resp = &types.BalanceChanges{
+		Total:   total,
+		Changes: make([]*types.BalanceChange, 0, req.Limit),
+	}
+	if total == 0 || total <= int64(req.Offset) {
+		return resp, nil
+	}
+
+	changes, err := l.svcCtx.TxDetailModel.GetAssetChangesByAccountIndex(index, req.AssetId, int64(req.Limit), int64(req.Offset))
+	if err != nil {
+		return nil, types2.AppErrInternal
+	}
+	for _, change := range changes {
+		balance, err := types2.ParseAccountAsset(change.Balance)
+		if err != nil {
+			return nil, types2.AppErrInternal
+		}
+		delta, err := types2.ParseAccountAsset(change.BalanceDelta)
+		if err != nil {
+			return nil, types2.AppErrInternal
+		}
+		if balance.Balance == nil {
+			balance.Balance = types2.ZeroBigInt
+		}
+		if delta.Balance == nil {
+			delta.Balance = types2.ZeroBigInt
+		}
+		assetName, _ := l.svcCtx.MemCache.GetAssetNameById(change.AssetId)
+		resp.Changes = append(resp.Changes, &types.BalanceChange{
+			TxHash:       change.TxHash,
+			TxType:       change.TxType,
+			BlockHeight:  change.BlockHeight,
+			AssetId:      change.AssetId,
+			AssetName:    assetName,
+			Balance:      ffmath.Add(balance.Balance, delta.Balance).String(),
+			BalanceDelta: delta.Balance.String(),
+			CreatedAt:    change.CreatedAt.Unix(),
+		})
+	}
+	return resp, nil
+}
@@ -8,17 +8,13 @@ import (
 
 	"github.com/zeromicro/go-zero/core/logx"
 
+	"github.com/bnb-chain/zkbnb/common/chain"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/logic/utils"
 	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
 	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
 	types2 "github.com/bnb-chain/zkbnb/types"
 )
 
-const (
-	queryByIndex = "index"
-	queryByName  = "name"
-	queryByPk    = "pk"
-)
-
 type GetAccountLogic struct {
 	logx.Logger
 	ctx    context.Context
@@ -33,30 +29,27 @@ func NewGetAccountLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GetAcc
 	}
 }
 
+// GetAccount returns the latest account, or the account after the block of the height is
+// executed if the height is specified. The asset values are always in the latest prices.
 func (l *GetAccountLogic) GetAccount(req *types.ReqGetAccount) (resp *types.Account, err error) {
-	index := int64(0)
-	switch req.By {
-	case queryByIndex:
-		index, err = strconv.ParseInt(req.Value, 10, 64)
-		if err != nil || index < 0 {
-			return nil, types2.AppErrInvalidParam.RefineError("invalid value for account index")
-		}
-	case queryByName:
-		index, err = l.svcCtx.MemCache.GetAccountIndexByName(req.Value)
-	case queryByPk:
-		index, err = l.svcCtx.MemCache.GetAccountIndexByPk(req.Value)
-	default:
-		return nil, types2.AppErrInvalidParam.RefineError("param by should be index|name|pk")
+	if req.Height < types2.NilBlockHeight {
+		return nil, types2.AppErrInvalidParam.RefineError("invalid height")
 	}
-
+	index, err := utils.GetAccountIndex(l.svcCtx, req.By, req.Value)
 	if err != nil {
-		if err == types2.DbErrNotFound {
-			return nil, types2.AppErrNotFound
-		}
-		return nil, types2.AppErrInternal
+		return nil, err
 	}
 
-	account, err := l.svcCtx.StateFetcher.GetLatestAccount(index)
+	var account *types2.AccountInfo
+	if req.Height == types2.NilBlockHeight {
+		account, err = l.svcCtx.StateFetcher.GetLatestAccount(index)
+	} else {
+		err = verifyHeight(l.svcCtx, req.Height)
+		if err != nil {
+			return nil, err
+		}
+		account, err = l.getAccountAtHeight(index, req.Height)
+	}
 	if err != nil {
 		if err == types2.DbErrNotFound {
 			return nil, types2.AppErrNotFound
@@ -134,3 +127,31 @@ func (l *GetAccountLogic) GetAccount(req *types.ReqGetAccount) (resp *types.Acco
 
 	return resp, nil
 }
+
+// getAccountAtHeight restores the account from its latest snapshot at the height.
+func (l *GetAccountLogic) getAccountAtHeight(index, height int64) (*types2.AccountInfo, error) {
+	accountHistory, err := l.svcCtx.AccountHistoryModel.GetLatestAccountHistory(index, height+1)
+	if err != nil {
+		return nil, err
+	}
+	account, err := l.svcCtx.AccountModel.GetAccountByIndex(index)
+	if err != nil {
+		return nil, err
+	}
+	account.Nonce = accountHistory.Nonce
+	account.CollectionNonce = accountHistory.CollectionNonce
+	account.AssetInfo = accountHistory.AssetInfo
+	account.AssetRoot = accountHistory.AssetRoot
+	return chain.ToFormatAccountInfo(account)
+}
+
+func verifyHeight(svcCtx *svc.ServiceContext, height int64) error {
+	currentHeight, err := svcCtx.BlockModel.GetCurrentBlockHeight()
+	if err != nil {
+		return types2.AppErrInternal
+	}
+	if height > currentHeight {
+		return types2.AppErrInvalidParam.RefineError("height is greater than the current block height")
+	}
+	return nil
+}
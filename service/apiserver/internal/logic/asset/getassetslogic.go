@@ -8,6 +8,8 @@ import (
 
 	"github.com/zeromicro/go-zero/core/logx"
 
+	assetdao "github.com/bnb-chain/zkbnb/dao/asset"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/logic/utils"
 	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
 	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
 	types2 "github.com/bnb-chain/zkbnb/types"
@@ -48,21 +50,31 @@ func (l *GetAssetsLogic) GetAssets(req *types.ReqGetRange) (resp *types.Assets,
 		return nil, types2.AppErrInternal
 	}
 
+	gasAssets, err := utils.GetGasAssets(l.svcCtx)
+	if err != nil {
+		return nil, err
+	}
+
 	resp.Assets = make([]*types.Asset, 0)
 	for _, asset := range assets {
-		assetPrice, err := l.svcCtx.PriceFetcher.GetCurrencyPrice(l.ctx, asset.AssetSymbol)
+		var isGasAsset uint32
+		if gasAssets.Contains(int64(asset.AssetId)) {
+			isGasAsset = assetdao.IsGasAsset
+		}
+		assetPrice, err := l.svcCtx.PriceFetcher.GetPrice(l.ctx, asset.AssetSymbol)
 		if err != nil {
 			return nil, types2.AppErrInternal
 		}
 		resp.Assets = append(resp.Assets, &types.Asset{
-			Id:         asset.AssetId,
-			Name:       asset.AssetName,
-			Decimals:   asset.Decimals,
-			Symbol:     asset.AssetSymbol,
-			Address:    asset.L1Address,
-			Price:      strconv.FormatFloat(assetPrice, 'E', -1, 64),
-			IsGasAsset: asset.IsGasAsset,
-			Icon:       fmt.Sprintf(iconBaseUrl, strings.ToLower(asset.AssetSymbol), strings.ToLower(asset.AssetSymbol)),
+			Id:          asset.AssetId,
+			Name:        asset.AssetName,
+			Decimals:    asset.Decimals,
+			Symbol:      asset.AssetSymbol,
+			Address:     asset.L1Address,
+			Price:       strconv.FormatFloat(assetPrice.Price, 'E', -1, 64),
+			PriceSource: assetPrice.Source,
+			IsGasAsset:  isGasAsset,
+			Icon:        fmt.Sprintf(iconBaseUrl, strings.ToLower(asset.AssetSymbol), strings.ToLower(asset.AssetSymbol)),
 		})
 	}
 	return resp, nil
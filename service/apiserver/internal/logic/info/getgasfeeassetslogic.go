@@ -5,6 +5,8 @@ import (
 
 	"github.com/zeromicro/go-zero/core/logx"
 
+	assetdao "github.com/bnb-chain/zkbnb/dao/asset"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/logic/utils"
 	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
 	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
 	types2 "github.com/bnb-chain/zkbnb/types"
@@ -25,20 +27,29 @@ func NewGetGasFeeAssetsLogic(ctx context.Context, svcCtx *svc.ServiceContext) *G
 }
 
 func (l *GetGasFeeAssetsLogic) GetGasFeeAssets() (*types.GasFeeAssets, error) {
-	assets, err := l.svcCtx.AssetModel.GetGasAssets()
+	gasAssets, err := utils.GetGasAssets(l.svcCtx)
 	if err != nil {
-		return nil, types2.AppErrInternal
+		return nil, err
 	}
 
-	resp := &types.GasFeeAssets{Assets: make([]types.Asset, 0, len(assets))}
-	for _, asset := range assets {
+	resp := &types.GasFeeAssets{Assets: make([]types.Asset, 0, len(gasAssets.AssetIds))}
+	for _, assetId := range gasAssets.AssetIds {
+		asset, err := l.svcCtx.MemCache.GetAssetByIdWithFallback(assetId, func() (interface{}, error) {
+			return l.svcCtx.AssetModel.GetAssetById(assetId)
+		})
+		if err != nil {
+			if err == types2.DbErrNotFound {
+				continue
+			}
+			return nil, types2.AppErrInternal
+		}
 		resp.Assets = append(resp.Assets, types.Asset{
 			Id:         asset.AssetId,
 			Name:       asset.AssetName,
 			Decimals:   asset.Decimals,
 			Symbol:     asset.AssetSymbol,
 			Address:    asset.L1Address,
-			IsGasAsset: asset.IsGasAsset,
+			IsGasAsset: assetdao.IsGasAsset,
 		})
 	}
 	return resp, nil
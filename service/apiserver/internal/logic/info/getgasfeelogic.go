@@ -2,11 +2,11 @@ package info
 
 import (
 	"context"
-	"encoding/json"
 	"strconv"
 
 	"github.com/zeromicro/go-zero/core/logx"
 
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/logic/utils"
 	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
 	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
 	types2 "github.com/bnb-chain/zkbnb/types"
@@ -26,22 +26,16 @@ func NewGetGasFeeLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GetGasF
 	}
 }
 
+// GetGasFee returns the recommended fee computed by the gas fee oracle for the tx type, and
+// the minimum fee accepted at the moment. Both are the static fee if the oracle hasn't
+// computed any fees.
 func (l *GetGasFeeLogic) GetGasFee(req *types.ReqGetGasFee) (*types.GasFee, error) {
-	gasFeeConfig, err := l.svcCtx.MemCache.GetSysConfigWithFallback(types2.SysGasFee, func() (interface{}, error) {
-		return l.svcCtx.SysConfigModel.GetSysConfigByName(types2.SysGasFee)
-	})
+	minFees, recommendedFees, err := utils.GetGasFees(l.svcCtx)
 	if err != nil {
-		logx.Errorf("fail to get gas fee config, err: %s", err.Error())
-		return nil, types2.AppErrInternal
-	}
-	m := make(map[uint32]map[int]int64)
-	err = json.Unmarshal([]byte(gasFeeConfig.Value), &m)
-	if err != nil {
-		logx.Errorf("fail to unmarshal gas fee config, err: %s", err.Error())
-		return nil, types2.AppErrInternal
+		return nil, err
 	}
 
-	gasAsset, ok := m[req.AssetId]
+	gasAsset, ok := recommendedFees[req.AssetId]
 	if !ok {
 		logx.Errorf("cannot find gas config for asset id: %d", req.AssetId)
 		return nil, types2.AppErrInvalidGasAsset
@@ -50,9 +44,14 @@ func (l *GetGasFeeLogic) GetGasFee(req *types.ReqGetGasFee) (*types.GasFee, erro
 	if !ok {
 		return nil, types2.AppErrInvalidTxType
 	}
+	minGasFee, ok := minFees[req.AssetId][int(req.TxType)]
+	if !ok {
+		minGasFee = gasFee
+	}
 
 	resp := &types.GasFee{
-		GasFee: strconv.FormatInt(gasFee, 10),
+		GasFee:    strconv.FormatInt(gasFee, 10),
+		MinGasFee: strconv.FormatInt(minGasFee, 10),
 	}
 	return resp, nil
 }
@@ -31,7 +31,12 @@ func NewGetAccountNftsLogic(ctx context.Context, svcCtx *svc.ServiceContext) *Ge
 	}
 }
 
+// GetAccountNfts returns the nfts owned by the account at the moment, or after the block of
+// the height is executed if the height is specified.
 func (l *GetAccountNftsLogic) GetAccountNfts(req *types.ReqGetAccountNfts) (resp *types.Nfts, err error) {
+	if req.Height < types2.NilBlockHeight {
+		return nil, types2.AppErrInvalidParam.RefineError("invalid height")
+	}
 	resp = &types.Nfts{
 		Nfts: make([]*types.Nft, 0, int64(req.Offset)),
 	}
@@ -58,6 +63,10 @@ func (l *GetAccountNftsLogic) GetAccountNfts(req *types.ReqGetAccountNfts) (resp
 		return nil, types2.AppErrInternal
 	}
 
+	if req.Height != types2.NilBlockHeight {
+		return l.getAccountNftsAtHeight(resp, accountIndex, req)
+	}
+
 	total, err := l.svcCtx.NftModel.GetNftsCountByAccountIndex(accountIndex)
 	if err != nil {
 		if err != types2.DbErrNotFound {
@@ -93,3 +102,44 @@ func (l *GetAccountNftsLogic) GetAccountNfts(req *types.ReqGetAccountNfts) (resp
 	}
 	return resp, nil
 }
+
+func (l *GetAccountNftsLogic) getAccountNftsAtHeight(resp *types.Nfts, accountIndex int64, req *types.ReqGetAccountNfts) (*types.Nfts, error) {
+	currentHeight, err := l.svcCtx.BlockModel.GetCurrentBlockHeight()
+	if err != nil {
+		return nil, types2.AppErrInternal
+	}
+	if req.Height > currentHeight {
+		return nil, types2.AppErrInvalidParam.RefineError("height is greater than the current block height")
+	}
+
+	total, err := l.svcCtx.NftHistoryModel.GetNftsCountByAccountIndexAtHeight(accountIndex, req.Height)
+	if err != nil {
+		return nil, types2.AppErrInternal
+	}
+	resp.Total = total
+	if total == 0 || total <= int64(req.Offset) {
+		return resp, nil
+	}
+
+	nfts, err := l.svcCtx.NftHistoryModel.GetNftsByAccountIndexAtHeight(accountIndex, req.Height, int64(req.Limit), int64(req.Offset))
+	if err != nil {
+		return nil, types2.AppErrInternal
+	}
+	for _, nft := range nfts {
+		creatorName, _ := l.svcCtx.MemCache.GetAccountNameByIndex(nft.CreatorAccountIndex)
+		ownerName, _ := l.svcCtx.MemCache.GetAccountNameByIndex(nft.OwnerAccountIndex)
+		resp.Nfts = append(resp.Nfts, &types.Nft{
+			Index:               nft.NftIndex,
+			CreatorAccountIndex: nft.CreatorAccountIndex,
+			CreatorAccountName:  creatorName,
+			OwnerAccountIndex:   nft.OwnerAccountIndex,
+			OwnerAccountName:    ownerName,
+			ContentHash:         nft.NftContentHash,
+			L1Address:           nft.NftL1Address,
+			L1TokenId:           nft.NftL1TokenId,
+			CreatorTreasuryRate: nft.CreatorTreasuryRate,
+			CollectionId:        nft.CollectionId,
+		})
+	}
+	return resp, nil
+}
@@ -0,0 +1,76 @@
+package nft
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+type GetAccountOffersLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGetAccountOffersLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GetAccountOffersLogic {
+	return &GetAccountOffersLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *GetAccountOffersLogic) GetAccountOffers(req *types.ReqGetAccountOffers) (resp *types.Offers, err error) {
+	resp = &types.Offers{
+		Offers: make([]*types.Offer, 0, int64(req.Limit)),
+	}
+
+	accountIndex := int64(0)
+	switch req.By {
+	case queryByAccountIndex:
+		accountIndex, err = strconv.ParseInt(req.Value, 10, 64)
+		if err != nil || accountIndex < 0 {
+			return nil, types2.AppErrInvalidParam.RefineError("invalid value for account_index")
+		}
+	case queryByAccountName:
+		accountIndex, err = l.svcCtx.MemCache.GetAccountIndexByName(req.Value)
+	case queryByAccountPk:
+		accountIndex, err = l.svcCtx.MemCache.GetAccountIndexByPk(req.Value)
+	default:
+		return nil, types2.AppErrInvalidParam.RefineError("param by should be account_index|account_name|account_pk")
+	}
+
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			return resp, nil
+		}
+		return nil, types2.AppErrInternal
+	}
+
+	now := time.Now().UnixMilli()
+	total, err := l.svcCtx.OfferModel.GetOpenOffersCountByAccountIndex(accountIndex, now)
+	if err != nil {
+		return nil, types2.AppErrInternal
+	}
+
+	resp.Total = total
+	if total == 0 || total <= int64(req.Offset) {
+		return resp, nil
+	}
+
+	offers, err := l.svcCtx.OfferModel.GetOpenOffersByAccountIndex(accountIndex, now, int64(req.Limit), int64(req.Offset))
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			return resp, nil
+		}
+		return nil, types2.AppErrInternal
+	}
+	resp.Offers = convertOffers(l.svcCtx, offers)
+	return resp, nil
+}
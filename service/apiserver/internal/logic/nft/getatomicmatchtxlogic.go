@@ -0,0 +1,160 @@
+package nft
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb-crypto/ffmath"
+	"github.com/bnb-chain/zkbnb-crypto/wasm/txtypes"
+	"github.com/bnb-chain/zkbnb/core"
+	"github.com/bnb-chain/zkbnb/core/executor"
+	"github.com/bnb-chain/zkbnb/dao/offer"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/logic/utils"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+type GetAtomicMatchTxLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGetAtomicMatchTxLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GetAtomicMatchTxLogic {
+	return &GetAtomicMatchTxLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// GetAtomicMatchTx builds the atomic match tx which settles a pair of matching open offers in
+// the offer book. The tx is sent by the given account with its next nonce, it should be signed
+// by the sender before sending. The gas fee is the recommended one if it's not specified.
+func (l *GetAtomicMatchTxLogic) GetAtomicMatchTx(req *types.ReqGetAtomicMatchTx) (resp *types.AtomicMatchTx, err error) {
+	buyOffer, err := l.getOpenOffer(req.BuyOfferAccountIndex, req.BuyOfferId)
+	if err != nil {
+		return nil, err
+	}
+	sellOffer, err := l.getOpenOffer(req.SellOfferAccountIndex, req.SellOfferId)
+	if err != nil {
+		return nil, err
+	}
+	if buyOffer.Type != types2.BuyOfferType || sellOffer.Type != types2.SellOfferType {
+		return nil, types2.AppErrInvalidOffer.RefineError("invalid offer type")
+	}
+	if buyOffer.AccountIndex == sellOffer.AccountIndex {
+		return nil, types2.AppErrInvalidOffer.RefineError("same buyer and seller")
+	}
+	if sellOffer.NftIndex != buyOffer.NftIndex ||
+		sellOffer.AssetId != buyOffer.AssetId ||
+		sellOffer.AssetAmount.String() != buyOffer.AssetAmount.String() ||
+		sellOffer.TreasuryRate != buyOffer.TreasuryRate {
+		return nil, types2.AppErrInvalidOffer.RefineError("buy offer mismatches sell offer")
+	}
+
+	bc, err := core.NewBlockChainForDryRun(l.svcCtx.AccountModel, l.svcCtx.NftModel, l.svcCtx.TxPoolModel,
+		l.svcCtx.AssetModel, l.svcCtx.SysConfigModel, l.svcCtx.GasFeeModel, l.svcCtx.RedisCache, false)
+	if err != nil {
+		logx.Error("fail to init blockchain runner:", err)
+		return nil, types2.AppErrInternal
+	}
+	nonce, err := bc.StateDB().GetPendingNonce(req.AccountIndex)
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			return nil, types2.AppErrNotFound
+		}
+		return nil, types2.AppErrInternal
+	}
+	gasAccountIndex, err := bc.StateDB().GetGasAccountIndex()
+	if err != nil {
+		return nil, types2.AppErrInternal
+	}
+	gasFeeAssetAmount, err := l.getGasFee(int64(req.GasFeeAssetId), req.GasFeeAssetAmount)
+	if err != nil {
+		return nil, err
+	}
+	// The offers are verified against the latest state again, so that the tx isn't built for
+	// the offers which can't be settled, e.g. the buyer doesn't have enough balance.
+	if _, err = verifyOffer(bc, buyOffer); err != nil {
+		return nil, err
+	}
+	nft, err := verifyOffer(bc, sellOffer)
+	if err != nil {
+		return nil, err
+	}
+
+	expiredAt := buyOffer.ExpiredAt
+	if sellOffer.ExpiredAt < expiredAt {
+		expiredAt = sellOffer.ExpiredAt
+	}
+	txInfo := &txtypes.AtomicMatchTxInfo{
+		AccountIndex:      req.AccountIndex,
+		BuyOffer:          buyOffer,
+		SellOffer:         sellOffer,
+		GasAccountIndex:   gasAccountIndex,
+		GasFeeAssetId:     int64(req.GasFeeAssetId),
+		GasFeeAssetAmount: gasFeeAssetAmount,
+		TreasuryAmount: ffmath.Div(ffmath.Multiply(sellOffer.AssetAmount, big.NewInt(sellOffer.TreasuryRate)),
+			big.NewInt(executor.TenThousand)),
+		CreatorAmount: ffmath.Div(ffmath.Multiply(sellOffer.AssetAmount, big.NewInt(nft.CreatorTreasuryRate)),
+			big.NewInt(executor.TenThousand)),
+		Nonce:     nonce,
+		ExpiredAt: expiredAt,
+	}
+	txInfoBytes, err := json.Marshal(txInfo)
+	if err != nil {
+		return nil, types2.AppErrInternal
+	}
+	return &types.AtomicMatchTx{
+		TxType: types2.TxTypeAtomicMatch,
+		TxInfo: string(txInfoBytes),
+	}, nil
+}
+
+func (l *GetAtomicMatchTxLogic) getOpenOffer(accountIndex, offerId int64) (*txtypes.OfferTxInfo, error) {
+	o, err := l.svcCtx.OfferModel.GetOffer(accountIndex, offerId)
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			return nil, types2.AppErrNotFound
+		}
+		return nil, types2.AppErrInternal
+	}
+	if o.Status != offer.StatusOpen || o.ExpiredAt < time.Now().UnixMilli() {
+		return nil, types2.AppErrInvalidOffer.RefineError("offer is not open")
+	}
+	offerInfo, err := types2.ParseOfferTxInfo(o.OfferInfo)
+	if err != nil {
+		return nil, types2.AppErrInternal
+	}
+	return offerInfo, nil
+}
+
+func (l *GetAtomicMatchTxLogic) getGasFee(gasFeeAssetId int64, gasFeeAssetAmount string) (*big.Int, error) {
+	if gasFeeAssetAmount != "" {
+		gasFee, ok := new(big.Int).SetString(gasFeeAssetAmount, 10)
+		if !ok {
+			return nil, types2.AppErrInvalidParam.RefineError("invalid gas_fee_asset_amount")
+		}
+		return gasFee, nil
+	}
+
+	_, recommendedFees, err := utils.GetGasFees(l.svcCtx)
+	if err != nil {
+		return nil, err
+	}
+	gasAsset, ok := recommendedFees[uint32(gasFeeAssetId)]
+	if !ok {
+		return nil, types2.AppErrInvalidGasAsset
+	}
+	gasFee, ok := gasAsset[types2.TxTypeAtomicMatch]
+	if !ok {
+		return nil, types2.AppErrInvalidTxType
+	}
+	return big.NewInt(gasFee), nil
+}
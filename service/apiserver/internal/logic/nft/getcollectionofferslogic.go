@@ -0,0 +1,56 @@
+package nft
+
+import (
+	"context"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+type GetCollectionOffersLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGetCollectionOffersLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GetCollectionOffersLogic {
+	return &GetCollectionOffersLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// GetCollectionOffers lists the open offers of the nfts in a collection, which is identified
+// by its creator and the collection id of the creator.
+func (l *GetCollectionOffersLogic) GetCollectionOffers(req *types.ReqGetCollectionOffers) (resp *types.Offers, err error) {
+	resp = &types.Offers{
+		Offers: make([]*types.Offer, 0, int64(req.Limit)),
+	}
+
+	now := time.Now().UnixMilli()
+	total, err := l.svcCtx.OfferModel.GetOpenOffersCountByCollection(req.CreatorAccountIndex, req.CollectionId, now)
+	if err != nil {
+		return nil, types2.AppErrInternal
+	}
+
+	resp.Total = total
+	if total == 0 || total <= int64(req.Offset) {
+		return resp, nil
+	}
+
+	offers, err := l.svcCtx.OfferModel.GetOpenOffersByCollection(req.CreatorAccountIndex, req.CollectionId, now,
+		int64(req.Limit), int64(req.Offset))
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			return resp, nil
+		}
+		return nil, types2.AppErrInternal
+	}
+	resp.Offers = convertOffers(l.svcCtx, offers)
+	return resp, nil
+}
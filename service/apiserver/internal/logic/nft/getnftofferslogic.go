@@ -0,0 +1,65 @@
+package nft
+
+import (
+	"context"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb/dao/offer"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/logic/utils"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+type GetNftOffersLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGetNftOffersLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GetNftOffersLogic {
+	return &GetNftOffersLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *GetNftOffersLogic) GetNftOffers(req *types.ReqGetNftOffers) (resp *types.Offers, err error) {
+	resp = &types.Offers{
+		Offers: make([]*types.Offer, 0, int64(req.Limit)),
+	}
+
+	now := time.Now().UnixMilli()
+	total, err := l.svcCtx.OfferModel.GetOpenOffersCountByNftIndex(req.NftIndex, now)
+	if err != nil {
+		return nil, types2.AppErrInternal
+	}
+
+	resp.Total = total
+	if total == 0 || total <= int64(req.Offset) {
+		return resp, nil
+	}
+
+	offers, err := l.svcCtx.OfferModel.GetOpenOffersByNftIndex(req.NftIndex, now, int64(req.Limit), int64(req.Offset))
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			return resp, nil
+		}
+		return nil, types2.AppErrInternal
+	}
+	resp.Offers = convertOffers(l.svcCtx, offers)
+	return resp, nil
+}
+
+func convertOffers(svcCtx *svc.ServiceContext, offers []*offer.Offer) []*types.Offer {
+	result := make([]*types.Offer, 0, len(offers))
+	for _, o := range offers {
+		converted := utils.ConvertOffer(o)
+		converted.AccountName, _ = svcCtx.MemCache.GetAccountNameByIndex(o.AccountIndex)
+		result = append(result, converted)
+	}
+	return result
+}
@@ -0,0 +1,150 @@
+package nft
+
+import (
+	"context"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb-crypto/wasm/txtypes"
+	"github.com/bnb-chain/zkbnb/core"
+	"github.com/bnb-chain/zkbnb/core/executor"
+	nftdao "github.com/bnb-chain/zkbnb/dao/nft"
+	"github.com/bnb-chain/zkbnb/dao/offer"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/logic/utils"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+type SendOfferLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewSendOfferLogic(ctx context.Context, svcCtx *svc.ServiceContext) *SendOfferLogic {
+	return &SendOfferLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// SendOffer verifies the signed offer against the latest state, and adds it to the offer
+// book if it could be settled by an atomic match tx at the moment.
+func (l *SendOfferLogic) SendOffer(req *types.ReqSendOffer) (resp *types.Offer, err error) {
+	offerInfo, err := types2.ParseOfferTxInfo(req.OfferInfo)
+	if err != nil {
+		return nil, types2.AppErrInvalidOffer.RefineError("invalid offer info")
+	}
+
+	bc, err := core.NewBlockChainForDryRun(l.svcCtx.AccountModel, l.svcCtx.NftModel, l.svcCtx.TxPoolModel,
+		l.svcCtx.AssetModel, l.svcCtx.SysConfigModel, l.svcCtx.GasFeeModel, l.svcCtx.RedisCache, false)
+	if err != nil {
+		logx.Error("fail to init blockchain runner:", err)
+		return nil, types2.AppErrInternal
+	}
+	nft, err := verifyOffer(bc, offerInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := l.svcCtx.OfferModel.GetOffer(offerInfo.AccountIndex, offerInfo.OfferId)
+	if err != nil && err != types2.DbErrNotFound {
+		return nil, types2.AppErrInternal
+	}
+	if err == nil && existing.Status != offer.StatusExpired {
+		return nil, types2.AppErrInvalidOffer.RefineError("offer id is used")
+	}
+
+	newOffer := &offer.Offer{
+		OfferType:           offerInfo.Type,
+		OfferId:             offerInfo.OfferId,
+		AccountIndex:        offerInfo.AccountIndex,
+		NftIndex:            offerInfo.NftIndex,
+		CreatorAccountIndex: nft.CreatorAccountIndex,
+		CollectionId:        nft.CollectionId,
+		AssetId:             offerInfo.AssetId,
+		AssetAmount:         offerInfo.AssetAmount.String(),
+		ListedAt:            offerInfo.ListedAt,
+		ExpiredAt:           offerInfo.ExpiredAt,
+		TreasuryRate:        offerInfo.TreasuryRate,
+		OfferInfo:           req.OfferInfo,
+		Status:              offer.StatusOpen,
+	}
+	err = l.svcCtx.OfferModel.CreateOffer(newOffer)
+	if err != nil {
+		logx.Errorf("fail to create offer, err: %s", err.Error())
+		return nil, types2.AppErrInternal
+	}
+
+	resp = utils.ConvertOffer(newOffer)
+	resp.AccountName, _ = l.svcCtx.MemCache.GetAccountNameByIndex(newOffer.AccountIndex)
+	return resp, nil
+}
+
+// verifyOffer checks the offer in the same way as the atomic match executor, except that the
+// matching offer is unknown yet. The nft of the offer is returned.
+func verifyOffer(bc *core.BlockChain, offerInfo *txtypes.OfferTxInfo) (*nftdao.L2Nft, error) {
+	err := offerInfo.Validate()
+	if err != nil {
+		return nil, types2.AppErrInvalidOffer.RefineError(err.Error())
+	}
+	if offerInfo.ExpiredAt < time.Now().UnixMilli() {
+		return nil, types2.AppErrInvalidOffer.RefineError("offer is expired")
+	}
+	// only gas assets are allowed for atomic match
+	gasAssets, err := bc.StateDB().GetGasAssets()
+	if err != nil {
+		return nil, types2.AppErrInternal
+	}
+	if !gasAssets.Contains(offerInfo.AssetId) {
+		return nil, types2.AppErrInvalidOffer.RefineError("invalid asset of offer")
+	}
+
+	account, err := bc.StateDB().GetFormatAccount(offerInfo.AccountIndex)
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			return nil, types2.AppErrInvalidOffer.RefineError("account not found")
+		}
+		return nil, types2.AppErrInternal
+	}
+	err = offerInfo.VerifySignature(account.PublicKey)
+	if err != nil {
+		return nil, types2.AppErrInvalidOffer.RefineError(err.Error())
+	}
+
+	offerAsset, ok := account.AssetInfo[offerInfo.OfferId/executor.OfferPerAsset]
+	if ok && offerAsset.OfferCanceledOrFinalized != nil &&
+		offerAsset.OfferCanceledOrFinalized.Bit(int(offerInfo.OfferId%executor.OfferPerAsset)) == 1 {
+		return nil, types2.AppErrInvalidOffer.RefineError("offer canceled or finalized")
+	}
+
+	nft, err := bc.StateDB().GetNft(offerInfo.NftIndex)
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			return nil, types2.AppErrInvalidOffer.RefineError("nft not found")
+		}
+		return nil, types2.AppErrInternal
+	}
+	if nft.NftContentHash == types2.EmptyNftContentHash {
+		return nil, types2.AppErrInvalidOffer.RefineError("nft not found")
+	}
+
+	switch offerInfo.Type {
+	case types2.SellOfferType:
+		if nft.OwnerAccountIndex != offerInfo.AccountIndex {
+			return nil, types2.AppErrInvalidOffer.RefineError("seller is not owner")
+		}
+	case types2.BuyOfferType:
+		if nft.OwnerAccountIndex == offerInfo.AccountIndex {
+			return nil, types2.AppErrInvalidOffer.RefineError("buyer is owner")
+		}
+		asset, ok := account.AssetInfo[offerInfo.AssetId]
+		if !ok || asset.Balance.Cmp(offerInfo.AssetAmount) < 0 {
+			return nil, types2.AppErrInvalidOffer.RefineError("buy balance is not enough")
+		}
+	}
+	return nft, nil
+}
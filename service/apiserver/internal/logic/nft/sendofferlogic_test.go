@@ -0,0 +1,177 @@
+package nft
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	curve "github.com/bnb-chain/zkbnb-crypto/ecc/ztwistededwards/tebn254"
+	"github.com/bnb-chain/zkbnb-crypto/wasm/txtypes"
+	"github.com/bnb-chain/zkbnb/core"
+	"github.com/bnb-chain/zkbnb/core/executor"
+	"github.com/bnb-chain/zkbnb/dao/account"
+	nftdao "github.com/bnb-chain/zkbnb/dao/nft"
+	"github.com/bnb-chain/zkbnb/dao/sysconfig"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+const (
+	testSeller   = 2
+	testBuyer    = 3
+	testNftIndex = 1
+)
+
+type testAccountModel struct {
+	account.AccountModel
+	accounts map[int64]*account.Account
+}
+
+func (m *testAccountModel) GetAccountByIndex(accountIndex int64) (*account.Account, error) {
+	a, ok := m.accounts[accountIndex]
+	if !ok {
+		return nil, types2.DbErrNotFound
+	}
+	return a, nil
+}
+
+type testNftModel struct {
+	nftdao.L2NftModel
+	nfts map[int64]*nftdao.L2Nft
+}
+
+func (m *testNftModel) GetNft(nftIndex int64) (*nftdao.L2Nft, error) {
+	n, ok := m.nfts[nftIndex]
+	if !ok {
+		return nil, types2.DbErrNotFound
+	}
+	return n, nil
+}
+
+type testSysConfigModel struct {
+	sysconfig.SysConfigModel
+	configs map[string]string
+}
+
+func (m *testSysConfigModel) GetSysConfigByName(name string) (*sysconfig.SysConfig, error) {
+	value, ok := m.configs[name]
+	if !ok {
+		return nil, types2.DbErrNotFound
+	}
+	return &sysconfig.SysConfig{Name: name, Value: value}, nil
+}
+
+type testOfferChain struct {
+	bc  *core.BlockChain
+	sks map[int64]*txtypes.PrivateKey
+}
+
+// newTestOfferChain creates the seller owning the nft and the buyer, with the balances of
+// BNB and the given canceled or finalized offer bits.
+func newTestOfferChain(t *testing.T, offerBits map[int64]int64) *testOfferChain {
+	c := &testOfferChain{sks: make(map[int64]*txtypes.PrivateKey)}
+	accountModel := &testAccountModel{accounts: make(map[int64]*account.Account)}
+	for _, accountIndex := range []int64{testSeller, testBuyer} {
+		sk, err := curve.GenerateEddsaPrivateKey(fmt.Sprintf("account %d", accountIndex))
+		require.NoError(t, err)
+		c.sks[accountIndex] = sk
+		assetInfo, err := json.Marshal(map[int64]*types2.AccountAsset{
+			types2.BNBAssetId: {
+				AssetId:                  types2.BNBAssetId,
+				Balance:                  big.NewInt(1000),
+				OfferCanceledOrFinalized: big.NewInt(offerBits[accountIndex]),
+			},
+		})
+		require.NoError(t, err)
+		accountModel.accounts[accountIndex] = &account.Account{
+			AccountIndex: accountIndex,
+			PublicKey:    common.Bytes2Hex(sk.PublicKey.Bytes()),
+			AssetInfo:    string(assetInfo),
+		}
+	}
+	nftModel := &testNftModel{nfts: map[int64]*nftdao.L2Nft{
+		testNftIndex: {NftIndex: testNftIndex, OwnerAccountIndex: testSeller, CreatorAccountIndex: testSeller, NftContentHash: "01"},
+	}}
+	gasAssets, err := json.Marshal([]*types2.GasAssets{{Version: types2.InitialGasAssetsVersion, AssetIds: []int64{types2.BNBAssetId}}})
+	require.NoError(t, err)
+	sysConfigModel := &testSysConfigModel{configs: map[string]string{types2.SysGasAssets: string(gasAssets)}}
+	c.bc, err = core.NewBlockChainForDryRun(accountModel, nftModel, nil, nil, sysConfigModel, nil, nil, false)
+	require.NoError(t, err)
+	return c
+}
+
+func (c *testOfferChain) offer(t *testing.T, offerType, accountIndex, offerId, amount int64) *txtypes.OfferTxInfo {
+	return c.signOffer(t, c.sks[accountIndex], &txtypes.OfferSegmentFormat{
+		Type:         offerType,
+		OfferId:      offerId,
+		AccountIndex: accountIndex,
+		NftIndex:     testNftIndex,
+		AssetId:      types2.BNBAssetId,
+		AssetAmount:  fmt.Sprintf("%d", amount),
+		ListedAt:     time.Now().UnixMilli(),
+		ExpiredAt:    time.Now().Add(time.Hour).UnixMilli(),
+	})
+}
+
+func (c *testOfferChain) signOffer(t *testing.T, sk *txtypes.PrivateKey, segment *txtypes.OfferSegmentFormat) *txtypes.OfferTxInfo {
+	segmentBytes, err := json.Marshal(segment)
+	require.NoError(t, err)
+	offerInfo, err := txtypes.ConstructOfferTxInfo(sk, string(segmentBytes))
+	require.NoError(t, err)
+	return offerInfo
+}
+
+func TestVerifyOffer(t *testing.T) {
+	// The offer 1 of the seller and the offer 0 of the buyer are canceled or finalized.
+	c := newTestOfferChain(t, map[int64]int64{testSeller: 0b10, testBuyer: 0b1})
+	verify := func(offerInfo *txtypes.OfferTxInfo) error {
+		_, err := verifyOffer(c.bc, offerInfo)
+		return err
+	}
+
+	nft, err := verifyOffer(c.bc, c.offer(t, types2.SellOfferType, testSeller, 0, 100))
+	require.NoError(t, err)
+	assert.Equal(t, int64(testSeller), nft.CreatorAccountIndex)
+	require.NoError(t, verify(c.offer(t, types2.BuyOfferType, testBuyer, 1, 100)))
+
+	for _, offerInfo := range []*txtypes.OfferTxInfo{
+		c.offer(t, types2.SellOfferType, testSeller, 1, 100),
+		c.offer(t, types2.BuyOfferType, testBuyer, 0, 100),
+	} {
+		err = verify(offerInfo)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "offer canceled or finalized")
+	}
+	// The bits of the other offer asset are not set.
+	require.NoError(t, verify(c.offer(t, types2.BuyOfferType, testBuyer, executor.OfferPerAsset, 100)))
+
+	for _, tc := range []struct {
+		offerInfo *txtypes.OfferTxInfo
+		err       string
+	}{
+		{c.offer(t, types2.SellOfferType, testBuyer, 2, 100), "seller is not owner"},
+		{c.offer(t, types2.BuyOfferType, testSeller, 2, 100), "buyer is owner"},
+		{c.offer(t, types2.BuyOfferType, testBuyer, 2, 1001), "buy balance is not enough"},
+		{c.signOffer(t, c.sks[testSeller], &txtypes.OfferSegmentFormat{
+			Type: types2.BuyOfferType, OfferId: 2, AccountIndex: testBuyer, NftIndex: testNftIndex,
+			AssetId: types2.BNBAssetId, AssetAmount: "100", ListedAt: 1, ExpiredAt: time.Now().Add(time.Hour).UnixMilli(),
+		}), "invalid signature"},
+		{c.signOffer(t, c.sks[testBuyer], &txtypes.OfferSegmentFormat{
+			Type: types2.BuyOfferType, OfferId: 2, AccountIndex: testBuyer, NftIndex: testNftIndex,
+			AssetId: types2.BNBAssetId, AssetAmount: "100", ListedAt: 1, ExpiredAt: time.Now().Add(-time.Hour).UnixMilli(),
+		}), "offer is expired"},
+		{c.signOffer(t, c.sks[testBuyer], &txtypes.OfferSegmentFormat{
+			Type: types2.BuyOfferType, OfferId: 2, AccountIndex: testBuyer, NftIndex: testNftIndex,
+			AssetId: 2, AssetAmount: "100", ListedAt: 1, ExpiredAt: time.Now().Add(time.Hour).UnixMilli(),
+		}), "invalid asset of offer"},
+	} {
+		err = verify(tc.offerInfo)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), tc.err)
+	}
+}
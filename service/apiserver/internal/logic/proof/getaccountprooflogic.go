@@ -0,0 +1,69 @@
+package proof
+
+import (
+	"context"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb/common/merkle"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/fetcher/proof"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/logic/utils"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+type GetAccountProofLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGetAccountProofLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GetAccountProofLogic {
+	return &GetAccountProofLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// GetAccountProof returns the proof of the account leaf against the state root of the latest
+// verified block, which could be checked by merkle.VerifyAccountProof.
+func (l *GetAccountProofLogic) GetAccountProof(req *types.ReqGetAccountProof) (resp *types.AccountProof, err error) {
+	index, err := utils.GetAccountIndex(l.svcCtx, req.By, req.Value)
+	if err != nil {
+		return nil, err
+	}
+	accountProof, err := l.svcCtx.MerkleProver.GetAccountProof(index)
+	if err != nil {
+		return nil, convertError(l.Logger, err)
+	}
+	return convertAccountProof(accountProof), nil
+}
+
+func convertAccountProof(p *merkle.AccountProof) *types.AccountProof {
+	return &types.AccountProof{
+		BlockHeight:     p.BlockHeight,
+		StateRoot:       p.StateRoot,
+		NftRoot:         p.NftRoot,
+		AccountIndex:    p.AccountIndex,
+		AccountNameHash: p.AccountNameHash,
+		AccountPk:       p.AccountPk,
+		Nonce:           p.Nonce,
+		CollectionNonce: p.CollectionNonce,
+		AssetRoot:       p.AssetRoot,
+		Siblings:        p.Siblings,
+	}
+}
+
+func convertError(logger logx.Logger, err error) error {
+	switch err {
+	case proof.ErrUnavailable:
+		return types2.AppErrNoMerkleProof
+	case types2.DbErrNotFound:
+		return types2.AppErrNotFound
+	default:
+		logger.Errorf("fail to get merkle proof, err: %v", err)
+		return types2.AppErrInternal
+	}
+}
@@ -0,0 +1,51 @@
+package proof
+
+import (
+	"context"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/logic/utils"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	"github.com/bnb-chain/zkbnb/tree"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+type GetAssetProofLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGetAssetProofLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GetAssetProofLogic {
+	return &GetAssetProofLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// GetAssetProof returns the proof of the asset leaf in the asset tree of the account, together
+// with the proof of the account, which could be checked by merkle.VerifyAssetProof. The leaf
+// of an asset the account never holds is the empty one.
+func (l *GetAssetProofLogic) GetAssetProof(req *types.ReqGetAssetProof) (resp *types.AssetProof, err error) {
+	if req.AssetId >= 1<<tree.AssetTreeHeight {
+		return nil, types2.AppErrInvalidParam.RefineError("invalid asset_id")
+	}
+	index, err := utils.GetAccountIndex(l.svcCtx, req.By, req.Value)
+	if err != nil {
+		return nil, err
+	}
+	assetProof, err := l.svcCtx.MerkleProver.GetAssetProof(index, int64(req.AssetId))
+	if err != nil {
+		return nil, convertError(l.Logger, err)
+	}
+	return &types.AssetProof{
+		AccountProof:             convertAccountProof(assetProof.AccountProof),
+		AssetId:                  assetProof.AssetId,
+		Balance:                  assetProof.Balance,
+		OfferCanceledOrFinalized: assetProof.OfferCanceledOrFinalized,
+		Siblings:                 assetProof.Siblings,
+	}, nil
+}
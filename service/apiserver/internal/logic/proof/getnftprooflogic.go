@@ -0,0 +1,52 @@
+package proof
+
+import (
+	"context"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	"github.com/bnb-chain/zkbnb/tree"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+type GetNftProofLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGetNftProofLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GetNftProofLogic {
+	return &GetNftProofLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// GetNftProof returns the proof of the nft leaf against the state root of the latest verified
+// block, which could be checked by merkle.VerifyNftProof.
+func (l *GetNftProofLogic) GetNftProof(req *types.ReqGetNftProof) (resp *types.NftProof, err error) {
+	if req.NftIndex < 0 || req.NftIndex >= 1<<tree.NftTreeHeight {
+		return nil, types2.AppErrInvalidParam.RefineError("invalid nft_index")
+	}
+	nftProof, err := l.svcCtx.MerkleProver.GetNftProof(req.NftIndex)
+	if err != nil {
+		return nil, convertError(l.Logger, err)
+	}
+	return &types.NftProof{
+		BlockHeight:         nftProof.BlockHeight,
+		StateRoot:           nftProof.StateRoot,
+		AccountRoot:         nftProof.AccountRoot,
+		NftIndex:            nftProof.NftIndex,
+		CreatorAccountIndex: nftProof.CreatorAccountIndex,
+		OwnerAccountIndex:   nftProof.OwnerAccountIndex,
+		NftContentHash:      nftProof.NftContentHash,
+		NftL1Address:        nftProof.NftL1Address,
+		NftL1TokenId:        nftProof.NftL1TokenId,
+		CreatorTreasuryRate: nftProof.CreatorTreasuryRate,
+		CollectionId:        nftProof.CollectionId,
+		Siblings:            nftProof.Siblings,
+	}, nil
+}
@@ -0,0 +1,58 @@
+package subscription
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/notifier"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+type SubscribeLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewSubscribeLogic(ctx context.Context, svcCtx *svc.ServiceContext) *SubscribeLogic {
+	return &SubscribeLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *SubscribeLogic) Subscribe(req *types.ReqSubscribe) (*notifier.Subscription, error) {
+	eventTypes := make([]string, 0)
+	if req.Types != "" {
+		for _, eventType := range strings.Split(req.Types, ",") {
+			eventType = strings.TrimSpace(eventType)
+			if !notifier.IsValidEventType(eventType) {
+				return nil, types2.AppErrInvalidParam.RefineError("invalid value for types")
+			}
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+
+	accountIndexes := make([]int64, 0)
+	if req.AccountIndexes != "" {
+		for _, value := range strings.Split(req.AccountIndexes, ",") {
+			accountIndex, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+			if err != nil || accountIndex < 0 {
+				return nil, types2.AppErrInvalidParam.RefineError("invalid value for account_indexes")
+			}
+			accountIndexes = append(accountIndexes, accountIndex)
+		}
+	}
+
+	filter, err := notifier.NewFilter(eventTypes, accountIndexes)
+	if err != nil {
+		return nil, types2.AppErrInvalidParam.RefineError(err.Error())
+	}
+	return l.svcCtx.Notifier.Subscribe(filter), nil
+}
@@ -0,0 +1,117 @@
+package transaction
+
+import (
+	"context"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	txdao "github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/dao/withdrawal"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+const (
+	// WithdrawalStatusFailed means the tx is failed on layer-2, nothing is withdrawn
+	WithdrawalStatusFailed = "failed"
+	// WithdrawalStatusOnL2 means the tx is in the tx pool or in a block not verified yet
+	WithdrawalStatusOnL2 = "on_l2"
+	// WithdrawalStatusVerified means the block is verified, but the transfer on layer-1 is
+	// not synced by the monitor yet
+	WithdrawalStatusVerified = "verified"
+	// WithdrawalStatusPaidOut means the funds or the nft are sent to the layer-1 address
+	WithdrawalStatusPaidOut = "paid_out"
+	// WithdrawalStatusPendingClaim means the transfer on layer-1 failed, the funds or the nft
+	// are kept by the rollup contract until they are claimed
+	WithdrawalStatusPendingClaim = "pending_claim"
+)
+
+type GetWithdrawalStatusLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGetWithdrawalStatusLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GetWithdrawalStatusLogic {
+	return &GetWithdrawalStatusLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *GetWithdrawalStatusLogic) GetWithdrawalStatus(req *types.ReqGetWithdrawalStatus) (resp *types.WithdrawalStatus, err error) {
+	tx, err := l.svcCtx.TxModel.GetTxByHash(req.Hash)
+	if err != nil {
+		if err != types2.DbErrNotFound {
+			return nil, types2.AppErrInternal
+		}
+		tx, err = l.svcCtx.TxPoolModel.GetTxByTxHash(req.Hash)
+		if err != nil {
+			if err == types2.DbErrNotFound {
+				return nil, types2.AppErrNotFound
+			}
+			return nil, types2.AppErrInternal
+		}
+	}
+
+	resp = &types.WithdrawalStatus{
+		TxHash:      tx.TxHash,
+		TxType:      tx.TxType,
+		BlockHeight: tx.BlockHeight,
+	}
+	switch tx.TxType {
+	case types2.TxTypeWithdraw:
+		txInfo, err := types2.ParseWithdrawTxInfo(tx.TxInfo)
+		if err != nil {
+			logx.Errorf("parse withdraw tx info failed, err: %v", err)
+			return nil, types2.AppErrInternal
+		}
+		resp.ToAddress = txInfo.ToAddress
+		resp.AssetId = txInfo.AssetId
+		resp.AssetAmount = txInfo.AssetAmount.String()
+		resp.NftIndex = types2.NilNftIndex
+	case types2.TxTypeWithdrawNft:
+		txInfo, err := types2.ParseWithdrawNftTxInfo(tx.TxInfo)
+		if err != nil {
+			logx.Errorf("parse withdraw nft tx info failed, err: %v", err)
+			return nil, types2.AppErrInternal
+		}
+		resp.ToAddress = txInfo.ToAddress
+		resp.AssetId = types2.NilAssetId
+		resp.AssetAmount = types2.NilAssetAmount
+		resp.NftIndex = txInfo.NftIndex
+	default:
+		return nil, types2.AppErrInvalidTxType
+	}
+
+	switch {
+	case tx.TxStatus == txdao.StatusFailed:
+		resp.Status = WithdrawalStatusFailed
+		return resp, nil
+	case tx.TxStatus < txdao.StatusVerified:
+		resp.Status = WithdrawalStatusOnL2
+		return resp, nil
+	}
+
+	w, err := l.svcCtx.WithdrawalModel.GetWithdrawalByL2TxHash(tx.TxHash)
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			resp.Status = WithdrawalStatusVerified
+			return resp, nil
+		}
+		return nil, types2.AppErrInternal
+	}
+	resp.L1TxHash = w.L1TxHash
+	if w.Status == withdrawal.StatusPending {
+		resp.Status = WithdrawalStatusPendingClaim
+		// the nft is claimed as a whole, the amount is only for the assets
+		if w.TxType == types2.TxTypeWithdraw {
+			resp.ClaimableAmount = w.AssetAmount
+		}
+		return resp, nil
+	}
+	resp.Status = WithdrawalStatusPaidOut
+	return resp, nil
+}
@@ -2,6 +2,7 @@ package transaction
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/zeromicro/go-zero/core/logx"
 
@@ -38,15 +39,33 @@ func (s *SendTxLogic) SendTx(req *types.ReqSendTx) (resp *types.TxHash, err erro
 
 	resp = &types.TxHash{}
 	bc, err := core.NewBlockChainForDryRun(s.svcCtx.AccountModel, s.svcCtx.NftModel, s.svcCtx.TxPoolModel,
-		s.svcCtx.AssetModel, s.svcCtx.SysConfigModel, s.svcCtx.RedisCache)
+		s.svcCtx.AssetModel, s.svcCtx.SysConfigModel, s.svcCtx.GasFeeModel, s.svcCtx.RedisCache, s.svcCtx.Config.TxPool.SpeculativeDryRun)
 	if err != nil {
 		logx.Error("fail to init blockchain runner:", err)
 		return nil, types2.AppErrInternal
 	}
-	newTx := &tx.Tx{
+	newTx := newPoolTx(req.TxType, req.TxInfo)
+
+	err = bc.ApplyTransaction(newTx)
+	if err != nil {
+		return resp, err
+	}
+	if err := checkPendingTxsPerAccount(s.svcCtx, []*tx.Tx{newTx}, bc.ReplacedTxs()); err != nil {
+		return resp, err
+	}
+	if err := createPoolTxs(s.svcCtx.TxPoolModel, []*tx.Tx{newTx}, bc.ReplacedTxs()); err != nil {
+		return resp, err
+	}
+
+	resp.TxHash = newTx.TxHash
+	return resp, nil
+}
+
+func newPoolTx(txType uint32, txInfo string) *tx.Tx {
+	return &tx.Tx{
 		TxHash: types2.EmptyTxHash, // Would be computed in prepare method of executors.
-		TxType: int64(req.TxType),
-		TxInfo: req.TxInfo,
+		TxType: int64(txType),
+		TxInfo: txInfo,
 
 		GasFeeAssetId: types2.NilAssetId,
 		GasFee:        types2.NilAssetAmount,
@@ -59,16 +78,56 @@ func (s *SendTxLogic) SendTx(req *types.ReqSendTx) (resp *types.TxHash, err erro
 		BlockHeight: types2.NilBlockHeight,
 		TxStatus:    tx.StatusPending,
 	}
+}
 
-	err = bc.ApplyTransaction(newTx)
-	if err != nil {
-		return resp, err
+// checkPendingTxsPerAccount rejects the new txs if any of their accounts would have more pending
+// txs than the limit, the txs replaced by them don't count. The evictor of the committer is the
+// backstop for the txs of an account sent concurrently.
+func checkPendingTxsPerAccount(svcCtx *svc.ServiceContext, newTxs []*tx.Tx, replacedTxs []*tx.Tx) error {
+	maxPendingTxsPerAccount := svcCtx.Config.TxPool.MaxPendingTxsPerAccount
+	if maxPendingTxsPerAccount <= 0 {
+		return nil
+	}
+	newTxsCount := make(map[int64]int)
+	for _, newTx := range newTxs {
+		newTxsCount[newTx.AccountIndex]++
 	}
-	if err := s.svcCtx.TxPoolModel.CreateTxs([]*tx.Tx{newTx}); err != nil {
-		logx.Errorf("fail to create pool tx: %v, err: %s", newTx, err.Error())
-		return resp, types2.AppErrInternal
+	for _, replacedTx := range replacedTxs {
+		newTxsCount[replacedTx.AccountIndex]--
 	}
+	for accountIndex, count := range newTxsCount {
+		if count <= 0 {
+			continue
+		}
+		pendingTxs, err := svcCtx.TxPoolModel.GetPendingTxsByAccountIndex(accountIndex)
+		if err != nil && err != types2.DbErrNotFound {
+			logx.Errorf("fail to get pending txs of account %d, err: %s", accountIndex, err.Error())
+			return types2.AppErrInternal
+		}
+		if len(pendingTxs)+count > maxPendingTxsPerAccount {
+			return types2.AppErrTooManyTxs.RefineError(fmt.Sprintf(" of account %d, the limit is %d",
+				accountIndex, maxPendingTxsPerAccount))
+		}
+	}
+	return nil
+}
 
-	resp.TxHash = newTx.TxHash
-	return resp, nil
+// createPoolTxs inserts the new txs into the tx pool, the pending txs replaced by them are
+// failed in the same transaction.
+func createPoolTxs(txPoolModel tx.TxPoolModel, newTxs []*tx.Tx, replacedTxs []*tx.Tx) error {
+	if len(replacedTxs) == 0 {
+		if err := txPoolModel.CreateTxs(newTxs); err != nil {
+			logx.Errorf("fail to create pool txs, err: %s", err.Error())
+			return types2.AppErrInternal
+		}
+		return nil
+	}
+	if err := txPoolModel.ReplaceTxs(replacedTxs, newTxs); err != nil {
+		if err == types2.DbErrFailToReplacePoolTx {
+			return types2.AppErrInvalidTxField.RefineError("the replaced tx is already executed")
+		}
+		logx.Errorf("fail to replace pool txs, err: %s", err.Error())
+		return types2.AppErrInternal
+	}
+	return nil
 }
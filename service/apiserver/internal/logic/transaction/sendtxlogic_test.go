@@ -0,0 +1,66 @@
+package transaction
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+)
+
+func TestSendTxReplacement(t *testing.T) {
+	send := func(c *testChain, txToSend *types.TxToSend) (*types.TxHash, error) {
+		return NewSendTxLogic(context.Background(), c.svcCtx).SendTx(&types.ReqSendTx{
+			TxType: txToSend.TxType,
+			TxInfo: txToSend.TxInfo,
+		})
+	}
+
+	for _, speculative := range []bool{false, true} {
+		t.Run(fmt.Sprintf("speculative %v", speculative), func(t *testing.T) {
+			c := newTestChain(t, speculative)
+			c.pool(t, c.transfer(t, "a", "b", 100, 0))
+			c.pool(t, c.transfer(t, "a", "b", 100, 1))
+			require.Len(t, c.txPool.txs, 2)
+
+			// The same nonce is only accepted with a higher gas fee.
+			_, err := send(c, c.transfer(t, "a", "c", 100, 1))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "replacement tx underpriced")
+			_, err = send(c, c.transferWithFee(t, "a", "c", 100, testGasFee-1, 1))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "replacement tx underpriced")
+			require.Len(t, c.txPool.txs, 2)
+
+			resp, err := send(c, c.transferWithFee(t, "a", "c", 100, testGasFee+1, 1))
+			require.NoError(t, err)
+			require.Len(t, c.txPool.txs, 3)
+			assert.Equal(t, tx.StatusFailed, c.txPool.txs[1].TxStatus)
+			assert.Equal(t, tx.FailReasonReplaced, c.txPool.txs[1].FailReason)
+			replacingTx := c.txPool.txs[2]
+			assert.Equal(t, resp.TxHash, replacingTx.TxHash)
+			assert.Equal(t, tx.StatusPending, replacingTx.TxStatus)
+			assert.Equal(t, int64(1), replacingTx.Nonce)
+			assert.Equal(t, fmt.Sprintf("%d", testGasFee+1), replacingTx.GasFee)
+
+			// The first pending tx could be replaced as well, while the later one is kept.
+			_, err = send(c, c.transferWithFee(t, "a", "c", 100, testGasFee+1, 0))
+			require.NoError(t, err)
+			require.Len(t, c.txPool.txs, 4)
+			assert.Equal(t, tx.StatusFailed, c.txPool.txs[0].TxStatus)
+			assert.Equal(t, tx.StatusPending, c.txPool.txs[2].TxStatus)
+
+			// The replacement doesn't open a gap in the nonces.
+			_, err = send(c, c.transfer(t, "a", "c", 100, 3))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "invalid nonce")
+			_, err = send(c, c.transfer(t, "a", "c", 100, 2))
+			require.NoError(t, err)
+			require.Len(t, c.txPool.txs, 5)
+		})
+	}
+}
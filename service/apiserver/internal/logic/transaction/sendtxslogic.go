@@ -0,0 +1,91 @@
+package transaction
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb/core"
+	"github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+const maxTxsPerBatch = 100
+
+type SendTxsLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewSendTxsLogic(ctx context.Context, svcCtx *svc.ServiceContext) *SendTxsLogic {
+	return &SendTxsLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// SendTxs verifies the txs in order against the same dry-run state, so a tx could depend
+// on the previous ones, e.g. the txs of an account with consecutive nonces. The txs are
+// inserted into the tx pool only if all of them are valid.
+func (s *SendTxsLogic) SendTxs(req *types.ReqSendTxs) (resp *types.TxHashes, err error) {
+	if len(req.Txs) == 0 || len(req.Txs) > maxTxsPerBatch {
+		return nil, types2.AppErrInvalidParam.RefineError(fmt.Sprintf("txs count should be in [1:%d]", maxTxsPerBatch))
+	}
+
+	pendingTxCount, err := s.svcCtx.TxPoolModel.GetTxsTotalCount()
+	if err != nil {
+		return nil, types2.AppErrInternal
+	}
+
+	if s.svcCtx.Config.TxPool.MaxPendingTxCount > 0 && pendingTxCount+int64(len(req.Txs)) > int64(s.svcCtx.Config.TxPool.MaxPendingTxCount) {
+		return nil, types2.AppErrTooManyTxs
+	}
+
+	bc, err := core.NewBlockChainForDryRun(s.svcCtx.AccountModel, s.svcCtx.NftModel, s.svcCtx.TxPoolModel,
+		s.svcCtx.AssetModel, s.svcCtx.SysConfigModel, s.svcCtx.GasFeeModel, s.svcCtx.RedisCache, s.svcCtx.Config.TxPool.SpeculativeDryRun)
+	if err != nil {
+		logx.Error("fail to init blockchain runner:", err)
+		return nil, types2.AppErrInternal
+	}
+
+	newTxs := make([]*tx.Tx, 0, len(req.Txs))
+	for index, txToSend := range req.Txs {
+		if txToSend == nil {
+			return nil, types2.AppErrInvalidParam.RefineError(fmt.Sprintf("nil tx, tx index: %d", index))
+		}
+		newTx := newPoolTx(txToSend.TxType, txToSend.TxInfo)
+		err = bc.ApplyTransaction(newTx)
+		if err != nil {
+			return nil, refineTxError(err, index)
+		}
+		newTxs = append(newTxs, newTx)
+	}
+
+	if err := checkPendingTxsPerAccount(s.svcCtx, newTxs, bc.ReplacedTxs()); err != nil {
+		return nil, err
+	}
+	if err := createPoolTxs(s.svcCtx.TxPoolModel, newTxs, bc.ReplacedTxs()); err != nil {
+		return nil, err
+	}
+
+	resp = &types.TxHashes{
+		TxHashes: make([]string, 0, len(newTxs)),
+	}
+	for _, newTx := range newTxs {
+		resp.TxHashes = append(resp.TxHashes, newTx.TxHash)
+	}
+	return resp, nil
+}
+
+// refineTxError attaches the index of the failed tx to the error.
+func refineTxError(err error, index int) error {
+	if e, ok := err.(types2.Error); ok {
+		return e.RefineError(fmt.Sprintf(", tx index: %d", index))
+	}
+	return types2.AppErrInvalidTxField.RefineError(err.Error(), fmt.Sprintf(", tx index: %d", index))
+}
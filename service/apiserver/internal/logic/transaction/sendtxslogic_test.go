@@ -0,0 +1,373 @@
+package transaction
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	curve "github.com/bnb-chain/zkbnb-crypto/ecc/ztwistededwards/tebn254"
+	"github.com/bnb-chain/zkbnb-crypto/wasm/txtypes"
+	"github.com/bnb-chain/zkbnb/dao/account"
+	"github.com/bnb-chain/zkbnb/dao/dbcache"
+	"github.com/bnb-chain/zkbnb/dao/gasfee"
+	"github.com/bnb-chain/zkbnb/dao/sysconfig"
+	"github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+const (
+	testGasAccountIndex = 1
+	testGasFee          = 100
+)
+
+type testAccountModel struct {
+	account.AccountModel
+	accounts map[int64]*account.Account
+}
+
+func (m *testAccountModel) GetAccountByIndex(accountIndex int64) (*account.Account, error) {
+	a, ok := m.accounts[accountIndex]
+	if !ok {
+		return nil, types2.DbErrNotFound
+	}
+	copied := *a
+	return &copied, nil
+}
+
+type testTxPoolModel struct {
+	tx.TxPoolModel
+	txs []*tx.Tx
+}
+
+func (m *testTxPoolModel) GetTxsTotalCount() (int64, error) {
+	return int64(len(m.txs)), nil
+}
+
+func (m *testTxPoolModel) GetPendingTxsByAccountIndex(accountIndex int64) ([]*tx.Tx, error) {
+	var txs []*tx.Tx
+	for _, poolTx := range m.txs {
+		if poolTx.AccountIndex == accountIndex && poolTx.TxStatus == tx.StatusPending {
+			txs = append(txs, poolTx)
+		}
+	}
+	if len(txs) == 0 {
+		return nil, types2.DbErrNotFound
+	}
+	sort.Slice(txs, func(i, j int) bool {
+		return txs[i].Nonce < txs[j].Nonce
+	})
+	return txs, nil
+}
+
+func (m *testTxPoolModel) GetPendingTxByAccountIndexAndNonce(accountIndex int64, nonce int64) (*tx.Tx, error) {
+	for _, poolTx := range m.txs {
+		if poolTx.AccountIndex == accountIndex && poolTx.Nonce == nonce && poolTx.TxStatus == tx.StatusPending {
+			return poolTx, nil
+		}
+	}
+	return nil, types2.DbErrNotFound
+}
+
+func (m *testTxPoolModel) GetMaxNonceByAccountIndex(accountIndex int64) (int64, error) {
+	txs, err := m.GetPendingTxsByAccountIndex(accountIndex)
+	if err != nil {
+		return 0, err
+	}
+	return txs[len(txs)-1].Nonce, nil
+}
+
+func (m *testTxPoolModel) CreateTxs(txs []*tx.Tx) error {
+	m.txs = append(m.txs, txs...)
+	return nil
+}
+
+func (m *testTxPoolModel) ReplaceTxs(replacedTxs []*tx.Tx, newTxs []*tx.Tx) error {
+	for _, replacedTx := range replacedTxs {
+		if replacedTx.TxStatus != tx.StatusPending {
+			return types2.DbErrFailToReplacePoolTx
+		}
+		replacedTx.TxStatus = tx.StatusFailed
+		replacedTx.FailReason = tx.FailReasonReplaced
+	}
+	return m.CreateTxs(newTxs)
+}
+
+type testSysConfigModel struct {
+	sysconfig.SysConfigModel
+	configs map[string]string
+}
+
+func (m *testSysConfigModel) GetSysConfigByName(name string) (*sysconfig.SysConfig, error) {
+	value, ok := m.configs[name]
+	if !ok {
+		return nil, types2.DbErrNotFound
+	}
+	return &sysconfig.SysConfig{Name: name, Value: value}, nil
+}
+
+type testGasFeeModel struct {
+	gasfee.GasFeeModel
+}
+
+func (m *testGasFeeModel) GetLatestGasFee() (*gasfee.GasFee, error) {
+	return nil, types2.DbErrNotFound
+}
+
+func (m *testGasFeeModel) GetGasFeeAt(time.Time) (*gasfee.GasFee, error) {
+	return nil, types2.DbErrNotFound
+}
+
+// testRedisCache misses all the keys, the states are read from the models.
+type testRedisCache struct {
+	dbcache.Cache
+}
+
+func (c *testRedisCache) Get(context.Context, string, interface{}) (interface{}, error) {
+	return nil, errors.New("not found")
+}
+
+func (c *testRedisCache) Set(context.Context, string, interface{}) error {
+	return nil
+}
+
+type testAccount struct {
+	index    int64
+	nameHash string
+	sk       *txtypes.PrivateKey
+}
+
+type testChain struct {
+	svcCtx   *svc.ServiceContext
+	txPool   *testTxPoolModel
+	accounts map[string]*testAccount
+}
+
+// newTestChain creates the accounts a, b and c with the balances of BNB, whose committed
+// nonces are all 0.
+func newTestChain(t *testing.T, speculative bool) *testChain {
+	c := &testChain{
+		txPool:   &testTxPoolModel{},
+		accounts: make(map[string]*testAccount),
+	}
+	accountModel := &testAccountModel{accounts: make(map[int64]*account.Account)}
+	for i, name := range []string{"gas", "a", "b", "c"} {
+		sk, err := curve.GenerateEddsaPrivateKey(name)
+		require.NoError(t, err)
+		a := &testAccount{
+			index:    int64(i + testGasAccountIndex),
+			nameHash: common.BigToHash(big.NewInt(int64(i + 1))).Hex(),
+			sk:       sk,
+		}
+		c.accounts[name] = a
+		assetInfo, err := json.Marshal(map[int64]*types2.AccountAsset{
+			types2.BNBAssetId: {AssetId: types2.BNBAssetId, Balance: big.NewInt(1000000), OfferCanceledOrFinalized: big.NewInt(0)},
+		})
+		require.NoError(t, err)
+		accountModel.accounts[a.index] = &account.Account{
+			AccountIndex:    a.index,
+			AccountName:     name,
+			PublicKey:       common.Bytes2Hex(sk.PublicKey.Bytes()),
+			AccountNameHash: a.nameHash,
+			AssetInfo:       string(assetInfo),
+		}
+	}
+	gasAssets, err := json.Marshal([]*types2.GasAssets{{Version: types2.InitialGasAssetsVersion, AssetIds: []int64{types2.BNBAssetId}}})
+	require.NoError(t, err)
+	c.svcCtx = &svc.ServiceContext{
+		RedisCache:   &testRedisCache{},
+		TxPoolModel:  c.txPool,
+		AccountModel: accountModel,
+		SysConfigModel: &testSysConfigModel{configs: map[string]string{
+			types2.GasAccountIndex: fmt.Sprintf("%d", testGasAccountIndex),
+			types2.SysGasFee:       fmt.Sprintf(`{"%d":{"%d":%d}}`, types2.BNBAssetId, types2.TxTypeTransfer, testGasFee),
+			types2.SysGasAssets:    string(gasAssets),
+		}},
+		GasFeeModel: &testGasFeeModel{},
+	}
+	c.svcCtx.Config.TxPool.SpeculativeDryRun = speculative
+	return c
+}
+
+func (c *testChain) transfer(t *testing.T, from, to string, amount, nonce int64) *types.TxToSend {
+	return c.transferWithFee(t, from, to, amount, testGasFee, nonce)
+}
+
+func (c *testChain) transferWithFee(t *testing.T, from, to string, amount, gasFee, nonce int64) *types.TxToSend {
+	segment, err := json.Marshal(&txtypes.TransferSegmentFormat{
+		FromAccountIndex:  c.accounts[from].index,
+		ToAccountIndex:    c.accounts[to].index,
+		ToAccountNameHash: c.accounts[to].nameHash,
+		AssetId:           types2.BNBAssetId,
+		AssetAmount:       fmt.Sprintf("%d", amount),
+		GasAccountIndex:   testGasAccountIndex,
+		GasFeeAssetId:     types2.BNBAssetId,
+		GasFeeAssetAmount: fmt.Sprintf("%d", gasFee),
+		ExpiredAt:         time.Now().Add(time.Hour).UnixMilli(),
+		Nonce:             nonce,
+	})
+	require.NoError(t, err)
+	txInfo, err := txtypes.ConstructTransferTxInfo(c.accounts[from].sk, string(segment))
+	require.NoError(t, err)
+	txInfoBytes, err := json.Marshal(txInfo)
+	require.NoError(t, err)
+	return &types.TxToSend{TxType: types2.TxTypeTransfer, TxInfo: string(txInfoBytes)}
+}
+
+// pool accepts the tx into the tx pool as if it was sent before.
+func (c *testChain) pool(t *testing.T, txToSend *types.TxToSend) {
+	_, err := NewSendTxsLogic(context.Background(), c.svcCtx).SendTxs(&types.ReqSendTxs{Txs: []*types.TxToSend{txToSend}})
+	require.NoError(t, err)
+}
+
+func TestSendTxsNonceOfReceiver(t *testing.T) {
+	for _, speculative := range []bool{false, true} {
+		t.Run(fmt.Sprintf("speculative %v", speculative), func(t *testing.T) {
+			c := newTestChain(t, speculative)
+			// b already has the pending txs of nonce 0 and 1 in the tx pool.
+			c.pool(t, c.transfer(t, "b", "c", 100, 0))
+			c.pool(t, c.transfer(t, "b", "c", 100, 1))
+			require.Len(t, c.txPool.txs, 2)
+
+			// b receives from a in the same batch, its next nonce is still 2.
+			l := NewSendTxsLogic(context.Background(), c.svcCtx)
+			_, err := l.SendTxs(&types.ReqSendTxs{Txs: []*types.TxToSend{
+				c.transfer(t, "a", "b", 100, 0),
+				c.transfer(t, "b", "c", 100, 1),
+			}})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "replacement tx underpriced")
+			assert.Contains(t, err.Error(), "tx index: 1")
+
+			_, err = l.SendTxs(&types.ReqSendTxs{Txs: []*types.TxToSend{
+				c.transfer(t, "a", "b", 100, 0),
+				c.transfer(t, "b", "c", 100, 3),
+			}})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "invalid nonce")
+			assert.Contains(t, err.Error(), "tx index: 1")
+			require.Len(t, c.txPool.txs, 2)
+
+			resp, err := l.SendTxs(&types.ReqSendTxs{Txs: []*types.TxToSend{
+				c.transfer(t, "a", "b", 100, 0),
+				c.transfer(t, "b", "c", 100, 2),
+				c.transfer(t, "b", "a", 100, 3),
+			}})
+			require.NoError(t, err)
+			assert.Len(t, resp.TxHashes, 3)
+			require.Len(t, c.txPool.txs, 5)
+			for i, nonce := range []int64{0, 2, 3} {
+				assert.Equal(t, nonce, c.txPool.txs[i+2].Nonce)
+			}
+
+			// a received from b in the batch above, but its own next nonce is 1.
+			_, err = l.SendTxs(&types.ReqSendTxs{Txs: []*types.TxToSend{
+				c.transfer(t, "a", "c", 100, 1),
+			}})
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestSendTxsReplayPendingTxs(t *testing.T) {
+	send := func(c *testChain, txs ...*types.TxToSend) error {
+		_, err := NewSendTxsLogic(context.Background(), c.svcCtx).SendTxs(&types.ReqSendTxs{Txs: txs})
+		return err
+	}
+
+	t.Run("pending txs use up the balance", func(t *testing.T) {
+		for _, speculative := range []bool{false, true} {
+			c := newTestChain(t, speculative)
+			c.pool(t, c.transfer(t, "a", "b", 600000, 0))
+
+			// a has 1000000 - 600000 - 100 left after the pending tx.
+			err := send(c, c.transfer(t, "a", "c", 600000, 1))
+			if speculative {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "invalid asset amount")
+			} else {
+				// The committed balance is checked without the speculative dry-run.
+				require.NoError(t, err)
+			}
+		}
+
+		c := newTestChain(t, true)
+		c.pool(t, c.transfer(t, "a", "b", 600000, 0))
+		c.pool(t, c.transfer(t, "a", "b", 100000, 1))
+		// The committer would fail the tx short of 1 for the fee.
+		err := send(c, c.transfer(t, "a", "c", 299701, 2))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid asset amount")
+		require.NoError(t, send(c, c.transfer(t, "a", "c", 299700, 2)))
+		require.Len(t, c.txPool.txs, 3)
+	})
+
+	t.Run("invalid pending txs are skipped", func(t *testing.T) {
+		c := newTestChain(t, true)
+		c.pool(t, c.transfer(t, "a", "b", 100, 0))
+		// The pending tx is no longer valid, e.g. the balance is spent by a priority tx.
+		c.txPool.txs[0].TxInfo = c.transfer(t, "a", "b", 2000000, 0).TxInfo
+
+		// The committer fails the pending tx, then the next nonce of a is still 0.
+		err := send(c, c.transfer(t, "a", "c", 100, 1))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid nonce")
+
+		// The balance isn't spent by the skipped tx, it's replaced with a higher fee.
+		err = send(c, c.transfer(t, "a", "c", 900000, 0))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "replacement tx underpriced")
+		require.NoError(t, send(c, c.transferWithFee(t, "a", "c", 900000, testGasFee+1, 0)))
+		require.Len(t, c.txPool.txs, 2)
+		assert.Equal(t, tx.StatusFailed, c.txPool.txs[0].TxStatus)
+	})
+}
+
+func TestSendTxsMaxPendingTxsPerAccount(t *testing.T) {
+	c := newTestChain(t, false)
+	c.svcCtx.Config.TxPool.MaxPendingTxsPerAccount = 2
+	l := NewSendTxsLogic(context.Background(), c.svcCtx)
+	c.pool(t, c.transfer(t, "a", "b", 100, 0))
+
+	// The batch is rejected as a whole if it takes a over the limit.
+	_, err := l.SendTxs(&types.ReqSendTxs{Txs: []*types.TxToSend{
+		c.transfer(t, "b", "c", 100, 0),
+		c.transfer(t, "a", "b", 100, 1),
+		c.transfer(t, "a", "b", 100, 2),
+	}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "too many pending txs of account 2")
+	require.Len(t, c.txPool.txs, 1)
+
+	_, err = l.SendTxs(&types.ReqSendTxs{Txs: []*types.TxToSend{
+		c.transfer(t, "b", "c", 100, 0),
+		c.transfer(t, "a", "b", 100, 1),
+	}})
+	require.NoError(t, err)
+	require.Len(t, c.txPool.txs, 3)
+
+	_, err = NewSendTxLogic(context.Background(), c.svcCtx).SendTx(&types.ReqSendTx{
+		TxType: types2.TxTypeTransfer,
+		TxInfo: c.transfer(t, "a", "b", 100, 2).TxInfo,
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "too many pending txs")
+
+	// A replacement doesn't add a pending tx.
+	_, err = NewSendTxLogic(context.Background(), c.svcCtx).SendTx(&types.ReqSendTx{
+		TxType: types2.TxTypeTransfer,
+		TxInfo: c.transferWithFee(t, "a", "c", 100, testGasFee+1, 1).TxInfo,
+	})
+	require.NoError(t, err)
+	require.Len(t, c.txPool.txs, 4)
+}
@@ -0,0 +1,137 @@
+package transaction
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb-crypto/ffmath"
+	"github.com/bnb-chain/zkbnb/core"
+	"github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+type SimulateTxLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewSimulateTxLogic(ctx context.Context, svcCtx *svc.ServiceContext) *SimulateTxLogic {
+	return &SimulateTxLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// SimulateTx runs the tx through the whole executor pipeline on a dry-run state, and returns
+// the tx details, the resulting balances and the gas charged. The tx pool is not touched.
+// The signatures are not verified for the unsigned txs, so wallets could preview the txs
+// before asking the users to sign them.
+func (s *SimulateTxLogic) SimulateTx(req *types.ReqSimulateTx) (resp *types.SimulatedTx, err error) {
+	bc, err := core.NewBlockChainForSimulation(s.svcCtx.AccountModel, s.svcCtx.NftModel, s.svcCtx.TxPoolModel,
+		s.svcCtx.AssetModel, s.svcCtx.SysConfigModel, s.svcCtx.GasFeeModel, s.svcCtx.RedisCache, s.svcCtx.Config.TxPool.SpeculativeDryRun,
+		isUnsignedTx(req.TxInfo))
+	if err != nil {
+		logx.Error("fail to init blockchain runner:", err)
+		return nil, types2.AppErrInternal
+	}
+	simulatedTx := newPoolTx(req.TxType, req.TxInfo)
+
+	err = bc.ApplyTransaction(simulatedTx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp = &types.SimulatedTx{
+		TxHash:        simulatedTx.TxHash,
+		GasFeeAssetId: simulatedTx.GasFeeAssetId,
+		GasFee:        simulatedTx.GasFee,
+		TxDetails:     make([]*types.TxDetail, 0, len(simulatedTx.TxDetails)),
+		Balances:      make([]*types.SimulatedBalance, 0),
+	}
+	for _, txDetail := range simulatedTx.TxDetails {
+		resp.TxDetails = append(resp.TxDetails, convertTxDetail(txDetail))
+	}
+	resp.Balances, err = getSimulatedBalances(bc, simulatedTx.TxDetails)
+	if err != nil {
+		logx.Errorf("fail to get simulated balances, err: %s", err.Error())
+		return nil, types2.AppErrInternal
+	}
+	return resp, nil
+}
+
+// isUnsignedTx checks whether the signature of the layer2 tx is missing.
+func isUnsignedTx(txInfo string) bool {
+	signedTxInfo := struct {
+		Sig []byte
+	}{}
+	if err := json.Unmarshal([]byte(txInfo), &signedTxInfo); err != nil {
+		// The invalid tx info is reported by the executors.
+		return false
+	}
+	return len(signedTxInfo.Sig) == 0
+}
+
+// getSimulatedBalances returns the balances of the fungible assets changed by the tx, in
+// the order of the tx details.
+func getSimulatedBalances(bc *core.BlockChain, txDetails []*tx.TxDetail) ([]*types.SimulatedBalance, error) {
+	gasAccountIndex, err := bc.StateDB().GetGasAccountIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make([]*types.SimulatedBalance, 0, len(txDetails))
+	visited := make(map[int64]map[int64]bool)
+	for _, txDetail := range txDetails {
+		if txDetail.AssetType != types2.FungibleAssetType {
+			continue
+		}
+		if visited[txDetail.AccountIndex][txDetail.AssetId] {
+			continue
+		}
+		if visited[txDetail.AccountIndex] == nil {
+			visited[txDetail.AccountIndex] = make(map[int64]bool)
+		}
+		visited[txDetail.AccountIndex][txDetail.AssetId] = true
+
+		account, err := bc.StateDB().GetFormatAccount(txDetail.AccountIndex)
+		if err != nil {
+			return nil, err
+		}
+		balance := types2.ZeroBigInt
+		if asset, ok := account.AssetInfo[txDetail.AssetId]; ok {
+			balance = asset.Balance
+		}
+		// The gas is kept aside and added to the gas account when the block is committed.
+		if txDetail.AccountIndex == gasAccountIndex {
+			balance = ffmath.Add(balance, bc.StateDB().GetPendingUpdateGas(txDetail.AssetId))
+		}
+		balances = append(balances, &types.SimulatedBalance{
+			AccountIndex: txDetail.AccountIndex,
+			AssetId:      txDetail.AssetId,
+			Balance:      balance.String(),
+		})
+	}
+	return balances, nil
+}
+
+func convertTxDetail(txDetail *tx.TxDetail) *types.TxDetail {
+	return &types.TxDetail{
+		AssetId:         txDetail.AssetId,
+		AssetType:       txDetail.AssetType,
+		AccountIndex:    txDetail.AccountIndex,
+		AccountName:     txDetail.AccountName,
+		Balance:         txDetail.Balance,
+		BalanceDelta:    txDetail.BalanceDelta,
+		Order:           txDetail.Order,
+		AccountOrder:    txDetail.AccountOrder,
+		Nonce:           txDetail.Nonce,
+		CollectionNonce: txDetail.CollectionNonce,
+		IsGas:           txDetail.IsGas,
+	}
+}
@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"strconv"
+
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+const (
+	queryByIndex = "index"
+	queryByName  = "name"
+	queryByPk    = "pk"
+)
+
+// GetAccountIndex resolves the account index by the index, the name or the pk of the account.
+func GetAccountIndex(svcCtx *svc.ServiceContext, by, value string) (index int64, err error) {
+	switch by {
+	case queryByIndex:
+		index, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || index < 0 {
+			return 0, types2.AppErrInvalidParam.RefineError("invalid value for account index")
+		}
+	case queryByName:
+		index, err = svcCtx.MemCache.GetAccountIndexByName(value)
+	case queryByPk:
+		index, err = svcCtx.MemCache.GetAccountIndexByPk(value)
+	default:
+		return 0, types2.AppErrInvalidParam.RefineError("param by should be index|name|pk")
+	}
+
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			return 0, types2.AppErrNotFound
+		}
+		return 0, types2.AppErrInternal
+	}
+	return index, nil
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"github.com/bnb-chain/zkbnb/dao/offer"
 	"github.com/bnb-chain/zkbnb/dao/tx"
 	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
 )
@@ -26,5 +27,21 @@ func ConvertTx(tx *tx.Tx) *types.Tx {
 		Nonce:         tx.Nonce,
 		ExpiredAt:     tx.ExpiredAt,
 		CreatedAt:     tx.CreatedAt.Unix(),
+		FailReason:    tx.FailReason,
+	}
+}
+
+func ConvertOffer(offer *offer.Offer) *types.Offer {
+	return &types.Offer{
+		Type:         offer.OfferType,
+		OfferId:      offer.OfferId,
+		AccountIndex: offer.AccountIndex,
+		NftIndex:     offer.NftIndex,
+		AssetId:      offer.AssetId,
+		AssetAmount:  offer.AssetAmount,
+		ListedAt:     offer.ListedAt,
+		ExpiredAt:    offer.ExpiredAt,
+		TreasuryRate: offer.TreasuryRate,
+		OfferInfo:    offer.OfferInfo,
 	}
 }
@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb/dao/dbcache"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+// GetGasFees returns the latest minimum and recommended fees of the gas fee oracle, i.e.
+// asset id -> (tx type -> fee), the static fees are returned for both if there are none.
+func GetGasFees(svcCtx *svc.ServiceContext) (minFees, recommendedFees map[uint32]map[int]int64, err error) {
+	gasFee, err := svcCtx.MemCache.GetGasFeeWithFallback(func() (interface{}, error) {
+		return svcCtx.GasFeeModel.GetLatestGasFee()
+	})
+	if err != nil && err != types2.DbErrNotFound {
+		logx.Errorf("fail to get gas fee, err: %s", err.Error())
+		return nil, nil, types2.AppErrInternal
+	}
+	if err == types2.DbErrNotFound {
+		gasFeeConfig, err := svcCtx.MemCache.GetSysConfigWithFallback(types2.SysGasFee, func() (interface{}, error) {
+			return svcCtx.SysConfigModel.GetSysConfigByName(types2.SysGasFee)
+		})
+		if err != nil {
+			logx.Errorf("fail to get gas fee config, err: %s", err.Error())
+			return nil, nil, types2.AppErrInternal
+		}
+		staticFees := make(map[uint32]map[int]int64)
+		err = json.Unmarshal([]byte(gasFeeConfig.Value), &staticFees)
+		if err != nil {
+			logx.Errorf("fail to unmarshal gas fee config, err: %s", err.Error())
+			return nil, nil, types2.AppErrInternal
+		}
+		return staticFees, staticFees, nil
+	}
+
+	minFees = make(map[uint32]map[int]int64)
+	err = json.Unmarshal([]byte(gasFee.MinFees), &minFees)
+	if err != nil {
+		logx.Errorf("fail to unmarshal min gas fees, err: %s", err.Error())
+		return nil, nil, types2.AppErrInternal
+	}
+	recommendedFees = make(map[uint32]map[int]int64)
+	err = json.Unmarshal([]byte(gasFee.RecommendedFees), &recommendedFees)
+	if err != nil {
+		logx.Errorf("fail to unmarshal recommended gas fees, err: %s", err.Error())
+		return nil, nil, types2.AppErrInternal
+	}
+	return minFees, recommendedFees, nil
+}
+
+// GetGasAssets returns the latest version of the gas assets, which the new txs pay the gas
+// fees in. They're cached in redis, which is dropped when a new version is appended.
+func GetGasAssets(svcCtx *svc.ServiceContext) (*types2.GasAssets, error) {
+	gasAssetsValue := ""
+	_, err := svcCtx.RedisCache.Get(context.Background(), dbcache.GasAssetsKey, &gasAssetsValue)
+	if err != nil {
+		cfgGasAssets, err := svcCtx.SysConfigModel.GetSysConfigByName(types2.SysGasAssets)
+		if err != nil {
+			logx.Errorf("fail to get gas assets config, err: %s", err.Error())
+			return nil, types2.AppErrInternal
+		}
+		gasAssetsValue = cfgGasAssets.Value
+		_ = svcCtx.RedisCache.Set(context.Background(), dbcache.GasAssetsKey, gasAssetsValue)
+	}
+	gasAssets, err := types2.ParseGasAssets(gasAssetsValue, types2.NilGasAssetsVersion)
+	if err != nil {
+		logx.Errorf("fail to parse gas assets config, err: %s", err.Error())
+		return nil, types2.AppErrInternal
+	}
+	return gasAssets, nil
+}
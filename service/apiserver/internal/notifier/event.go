@@ -0,0 +1,121 @@
+package notifier
+
+import (
+	"fmt"
+)
+
+const (
+	EventTypeBlock       = "block"
+	EventTypeBlockStatus = "block_status"
+	EventTypePendingTx   = "pending_tx"
+	EventTypeFailedTx    = "failed_tx"
+	EventTypeAccount     = "account"
+	EventTypeNft         = "nft"
+)
+
+var eventTypes = map[string]bool{
+	EventTypeBlock:       true,
+	EventTypeBlockStatus: true,
+	EventTypePendingTx:   true,
+	EventTypeFailedTx:    true,
+	EventTypeAccount:     true,
+	EventTypeNft:         true,
+}
+
+type Event struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+
+	// the accounts related to the event, it is nil for the chain events
+	accountIndexes []int64
+}
+
+type Block struct {
+	Height          int64  `json:"height"`
+	Commitment      string `json:"commitment"`
+	Status          int64  `json:"status"`
+	Size            uint16 `json:"size"`
+	StateRoot       string `json:"state_root"`
+	CommittedTxHash string `json:"committed_tx_hash"`
+	CommittedAt     int64  `json:"committed_at"`
+	VerifiedTxHash  string `json:"verified_tx_hash"`
+	VerifiedAt      int64  `json:"verified_at"`
+}
+
+type Tx struct {
+	Hash         string `json:"hash"`
+	Type         int64  `json:"type"`
+	Status       int    `json:"status"`
+	AccountIndex int64  `json:"account_index"`
+	Nonce        int64  `json:"nonce"`
+	CreatedAt    int64  `json:"created_at"`
+	FailReason   string `json:"fail_reason,omitempty"`
+}
+
+type AccountAsset struct {
+	Id      int64  `json:"id"`
+	Balance string `json:"balance"`
+}
+
+type Account struct {
+	Index       int64           `json:"index"`
+	Nonce       int64           `json:"nonce"`
+	Assets      []*AccountAsset `json:"assets"`
+	BlockHeight int64           `json:"block_height"`
+}
+
+type Nft struct {
+	Index               int64  `json:"index"`
+	CreatorAccountIndex int64  `json:"creator_account_index"`
+	OwnerAccountIndex   int64  `json:"owner_account_index"`
+	ContentHash         string `json:"content_hash"`
+	L1Address           string `json:"l1_address"`
+	L1TokenId           string `json:"l1_token_id"`
+	CollectionId        int64  `json:"collection_id"`
+	BlockHeight         int64  `json:"block_height"`
+}
+
+// Filter selects the events pushed to a subscription, an empty filter selects all events.
+type Filter struct {
+	types          map[string]bool
+	accountIndexes map[int64]bool
+}
+
+func NewFilter(eventTypes []string, accountIndexes []int64) (*Filter, error) {
+	f := &Filter{
+		types:          make(map[string]bool, len(eventTypes)),
+		accountIndexes: make(map[int64]bool, len(accountIndexes)),
+	}
+	for _, eventType := range eventTypes {
+		if !IsValidEventType(eventType) {
+			return nil, fmt.Errorf("invalid event type %s", eventType)
+		}
+		f.types[eventType] = true
+	}
+	for _, accountIndex := range accountIndexes {
+		if accountIndex < 0 {
+			return nil, fmt.Errorf("invalid account index %d", accountIndex)
+		}
+		f.accountIndexes[accountIndex] = true
+	}
+	return f, nil
+}
+
+func IsValidEventType(eventType string) bool {
+	return eventTypes[eventType]
+}
+
+func (f *Filter) Match(e *Event) bool {
+	if len(f.types) != 0 && !f.types[e.Type] {
+		return false
+	}
+	if len(f.accountIndexes) == 0 || e.accountIndexes == nil {
+		return true
+	}
+	for _, accountIndex := range e.accountIndexes {
+		if f.accountIndexes[accountIndex] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,404 @@
+package notifier
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb/dao/account"
+	"github.com/bnb-chain/zkbnb/dao/block"
+	"github.com/bnb-chain/zkbnb/dao/nft"
+	"github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+const (
+	pollInterval   = 500 * time.Millisecond
+	pollBlockLimit = 100
+	pollTxLimit    = 1000
+
+	subscriptionBufferSize = 256
+)
+
+// Notifier polls the states written by the committer and the monitor, and pushes
+// the changes to the subscriptions as events.
+type Notifier interface {
+	Subscribe(filter *Filter) *Subscription
+	Unsubscribe(sub *Subscription)
+	Stop()
+}
+
+// Subscription receives the events selected by its filter, the events channel is
+// closed when the subscription is unsubscribed or the subscriber is too slow.
+type Subscription struct {
+	filter *Filter
+	events chan *Event
+}
+
+func (s *Subscription) Events() <-chan *Event {
+	return s.events
+}
+
+func NewNotifier(blockModel block.BlockModel, txPoolModel tx.TxPoolModel,
+	accountHistoryModel account.AccountHistoryModel, nftHistoryModel nft.L2NftHistoryModel) Notifier {
+	n := &notifier{
+		blockModel:          blockModel,
+		txPoolModel:         txPoolModel,
+		accountHistoryModel: accountHistoryModel,
+		nftHistoryModel:     nftHistoryModel,
+		subscriptions:       make(map[*Subscription]bool),
+		blockStatuses:       make(map[int64]int64),
+		quitCh:              make(chan struct{}),
+	}
+	n.init()
+	go n.loop()
+	return n
+}
+
+type notifier struct {
+	blockModel          block.BlockModel
+	txPoolModel         tx.TxPoolModel
+	accountHistoryModel account.AccountHistoryModel
+	nftHistoryModel     nft.L2NftHistoryModel
+
+	mu            sync.RWMutex
+	subscriptions map[*Subscription]bool
+	latestBlock   *Block
+
+	// the cursors of the polled states, they are only accessed by the loop
+	verifiedHeight    int64
+	blockHeight       int64
+	blockStatuses     map[int64]int64
+	accountHeight     int64
+	nftHeight         int64
+	txId              int64
+	failedTxDeletedAt time.Time
+	failedTxId        int64
+
+	quitCh chan struct{}
+}
+
+func (n *notifier) Subscribe(filter *Filter) *Subscription {
+	sub := &Subscription{
+		filter: filter,
+		events: make(chan *Event, subscriptionBufferSize),
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.subscriptions[sub] = true
+	// Push the latest block first, so the subscriber knows where the events start from.
+	if n.latestBlock != nil {
+		e := &Event{Type: EventTypeBlock, Data: n.latestBlock}
+		if filter.Match(e) {
+			sub.events <- e
+		}
+	}
+	return sub
+}
+
+func (n *notifier) Unsubscribe(sub *Subscription) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.subscriptions[sub] {
+		delete(n.subscriptions, sub)
+		close(sub.events)
+	}
+}
+
+func (n *notifier) Stop() {
+	close(n.quitCh)
+}
+
+func (n *notifier) publish(e *Event) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for sub := range n.subscriptions {
+		if !sub.filter.Match(e) {
+			continue
+		}
+		select {
+		case sub.events <- e:
+		default:
+			logx.Errorf("subscription is too slow, drop it")
+			delete(n.subscriptions, sub)
+			close(sub.events)
+		}
+	}
+}
+
+func (n *notifier) init() {
+	var err error
+	n.failedTxDeletedAt = time.Now()
+	n.verifiedHeight, err = n.blockModel.GetLatestVerifiedHeight()
+	if err != nil && err != types.DbErrNotFound {
+		logx.Errorf("failed to get latest verified height, err: %v", err)
+	}
+	n.txId, err = n.txPoolModel.GetLatestTxId()
+	if err != nil && err != types.DbErrNotFound {
+		logx.Errorf("failed to get latest pool tx id, err: %v", err)
+	}
+
+	currentHeight, err := n.blockModel.GetCurrentBlockHeight()
+	if err != nil {
+		if err != types.DbErrNotFound {
+			logx.Errorf("failed to get current block height, err: %v", err)
+		}
+		return
+	}
+	currentBlock, err := n.blockModel.GetBlockByHeightWithoutTx(currentHeight)
+	if err != nil {
+		logx.Errorf("failed to get current block, err: %v", err)
+		return
+	}
+	// The proposing block is not committed yet, it is pushed when committed.
+	if currentBlock.BlockStatus <= block.StatusProposing && currentHeight > 0 {
+		currentBlock, err = n.blockModel.GetBlockByHeightWithoutTx(currentHeight - 1)
+		if err != nil {
+			logx.Errorf("failed to get current block, err: %v", err)
+			return
+		}
+	}
+	n.blockHeight = currentBlock.BlockHeight
+	n.latestBlock = convertBlock(currentBlock)
+	n.accountHeight = n.blockHeight
+	n.nftHeight = n.blockHeight
+}
+
+func (n *notifier) loop() {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			n.pollBlocks()
+			n.pollTxs()
+			n.pollAccounts()
+			n.pollNfts()
+		case <-n.quitCh:
+			return
+		}
+	}
+}
+
+func (n *notifier) pollBlocks() {
+	currentHeight, err := n.blockModel.GetCurrentBlockHeight()
+	if err != nil {
+		if err != types.DbErrNotFound {
+			logx.Errorf("failed to get current block height, err: %v", err)
+		}
+		return
+	}
+	// The blocks above the current height are rolled back.
+	if currentHeight < n.blockHeight {
+		for height := range n.blockStatuses {
+			if height > currentHeight {
+				delete(n.blockStatuses, height)
+			}
+		}
+		n.blockHeight = currentHeight
+		if n.accountHeight > currentHeight {
+			n.accountHeight = currentHeight
+		}
+		if n.nftHeight > currentHeight {
+			n.nftHeight = currentHeight
+		}
+	}
+
+	blocks, err := n.blockModel.GetBlocksWithoutTxsBetween(n.verifiedHeight+1, n.blockHeight+pollBlockLimit)
+	if err != nil {
+		if err != types.DbErrNotFound {
+			logx.Errorf("failed to get blocks, err: %v", err)
+		}
+		return
+	}
+	for _, b := range blocks {
+		if b.BlockStatus <= block.StatusProposing {
+			break
+		}
+		status, ok := n.blockStatuses[b.BlockHeight]
+		n.blockStatuses[b.BlockHeight] = b.BlockStatus
+		if b.BlockHeight > n.blockHeight {
+			n.blockHeight = b.BlockHeight
+			blockInfo := convertBlock(b)
+			n.mu.Lock()
+			n.latestBlock = blockInfo
+			n.mu.Unlock()
+			n.publish(&Event{Type: EventTypeBlock, Data: blockInfo})
+		} else if ok && status != b.BlockStatus {
+			n.publish(&Event{Type: EventTypeBlockStatus, Data: convertBlock(b)})
+		}
+	}
+
+	// The blocks are verified in order, forget the verified ones.
+	for n.blockStatuses[n.verifiedHeight+1] == block.StatusVerifiedAndExecuted {
+		delete(n.blockStatuses, n.verifiedHeight+1)
+		n.verifiedHeight++
+	}
+}
+
+func (n *notifier) pollTxs() {
+	poolTxs, err := n.txPoolModel.GetTxsForIdGreaterThan(n.txId, pollTxLimit)
+	if err != nil && err != types.DbErrNotFound {
+		logx.Errorf("failed to get pool txs, err: %v", err)
+	}
+	for _, poolTx := range poolTxs {
+		n.txId = int64(poolTx.ID)
+		n.publish(&Event{
+			Type:           EventTypePendingTx,
+			Data:           convertTx(poolTx),
+			accountIndexes: []int64{poolTx.AccountIndex},
+		})
+	}
+
+	failedTxs, err := n.txPoolModel.GetFailedTxsDeletedAfter(n.failedTxDeletedAt, n.failedTxId, pollTxLimit)
+	if err != nil && err != types.DbErrNotFound {
+		logx.Errorf("failed to get failed pool txs, err: %v", err)
+	}
+	for _, failedTx := range failedTxs {
+		n.failedTxDeletedAt = failedTx.DeletedAt.Time
+		n.failedTxId = int64(failedTx.ID)
+		n.publish(&Event{
+			Type:           EventTypeFailedTx,
+			Data:           convertTx(failedTx),
+			accountIndexes: []int64{failedTx.AccountIndex},
+		})
+	}
+}
+
+func (n *notifier) pollAccounts() {
+	accountHistories, err := n.accountHistoryModel.GetAccountHistoriesForHeightGreaterThan(n.accountHeight)
+	if err != nil {
+		if err != types.DbErrNotFound {
+			logx.Errorf("failed to get account histories, err: %v", err)
+		}
+		return
+	}
+	for _, accountHistory := range accountHistories {
+		n.accountHeight = accountHistory.L2BlockHeight
+		accountInfo, err := convertAccount(accountHistory)
+		if err != nil {
+			logx.Errorf("failed to convert account history, err: %v", err)
+			continue
+		}
+		n.publish(&Event{
+			Type:           EventTypeAccount,
+			Data:           accountInfo,
+			accountIndexes: []int64{accountHistory.AccountIndex},
+		})
+	}
+}
+
+func (n *notifier) pollNfts() {
+	nftHistories, err := n.nftHistoryModel.GetNftHistoriesForHeightGreaterThan(n.nftHeight)
+	if err != nil {
+		if err != types.DbErrNotFound {
+			logx.Errorf("failed to get nft histories, err: %v", err)
+		}
+		return
+	}
+	// The owners of the nfts before the polled histories, the nfts are pushed to both the
+	// previous and the new owners, e.g. the nfts are transferred, sold or withdrawn.
+	owners := make(map[int64]int64)
+	for _, nftHistory := range nftHistories {
+		previousOwner, ok := owners[nftHistory.NftIndex]
+		if !ok {
+			previousOwner, err = n.getNftOwner(nftHistory.NftIndex, nftHistory.L2BlockHeight)
+			if err != nil {
+				logx.Errorf("failed to get previous nft owner, err: %v", err)
+				previousOwner = types.NilAccountIndex
+			}
+		}
+		owners[nftHistory.NftIndex] = nftHistory.OwnerAccountIndex
+
+		n.nftHeight = nftHistory.L2BlockHeight
+		accountIndexes := []int64{nftHistory.OwnerAccountIndex}
+		if previousOwner != types.NilAccountIndex && previousOwner != nftHistory.OwnerAccountIndex {
+			accountIndexes = append(accountIndexes, previousOwner)
+		}
+		n.publish(&Event{
+			Type:           EventTypeNft,
+			Data:           convertNft(nftHistory),
+			accountIndexes: accountIndexes,
+		})
+	}
+}
+
+// getNftOwner returns the owner of the nft before the block of the height, NilAccountIndex
+// is returned if the nft is minted or deposited in the block.
+func (n *notifier) getNftOwner(nftIndex, height int64) (int64, error) {
+	nftHistory, err := n.nftHistoryModel.GetLatestNftHistory(nftIndex, height)
+	if err != nil {
+		if err == types.DbErrNotFound {
+			return types.NilAccountIndex, nil
+		}
+		return 0, err
+	}
+	return nftHistory.OwnerAccountIndex, nil
+}
+
+func convertBlock(b *block.Block) *Block {
+	return &Block{
+		Height:          b.BlockHeight,
+		Commitment:      b.BlockCommitment,
+		Status:          b.BlockStatus,
+		Size:            b.BlockSize,
+		StateRoot:       b.StateRoot,
+		CommittedTxHash: b.CommittedTxHash,
+		CommittedAt:     b.CommittedAt,
+		VerifiedTxHash:  b.VerifiedTxHash,
+		VerifiedAt:      b.VerifiedAt,
+	}
+}
+
+func convertTx(poolTx *tx.Tx) *Tx {
+	return &Tx{
+		Hash:         poolTx.TxHash,
+		Type:         poolTx.TxType,
+		Status:       poolTx.TxStatus,
+		AccountIndex: poolTx.AccountIndex,
+		Nonce:        poolTx.Nonce,
+		CreatedAt:    poolTx.CreatedAt.Unix(),
+		FailReason:   poolTx.FailReason,
+	}
+}
+
+func convertAccount(accountHistory *account.AccountHistory) (*Account, error) {
+	assets := make(map[int64]*types.AccountAsset)
+	err := json.Unmarshal([]byte(accountHistory.AssetInfo), &assets)
+	if err != nil {
+		return nil, types.JsonErrUnmarshal
+	}
+	accountInfo := &Account{
+		Index:       accountHistory.AccountIndex,
+		Nonce:       accountHistory.Nonce,
+		Assets:      make([]*AccountAsset, 0, len(assets)),
+		BlockHeight: accountHistory.L2BlockHeight,
+	}
+	for _, asset := range assets {
+		accountInfo.Assets = append(accountInfo.Assets, &AccountAsset{
+			Id:      asset.AssetId,
+			Balance: asset.Balance.String(),
+		})
+	}
+	sort.Slice(accountInfo.Assets, func(i, j int) bool {
+		return accountInfo.Assets[i].Id < accountInfo.Assets[j].Id
+	})
+	return accountInfo, nil
+}
+
+func convertNft(nftHistory *nft.L2NftHistory) *Nft {
+	return &Nft{
+		Index:               nftHistory.NftIndex,
+		CreatorAccountIndex: nftHistory.CreatorAccountIndex,
+		OwnerAccountIndex:   nftHistory.OwnerAccountIndex,
+		ContentHash:         nftHistory.NftContentHash,
+		L1Address:           nftHistory.NftL1Address,
+		L1TokenId:           nftHistory.NftL1TokenId,
+		CollectionId:        nftHistory.CollectionId,
+		BlockHeight:         nftHistory.L2BlockHeight,
+	}
+}
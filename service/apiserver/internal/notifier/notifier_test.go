@@ -0,0 +1,183 @@
+package notifier
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/zkbnb/dao/nft"
+	"github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+type testNftHistoryModel struct {
+	nft.L2NftHistoryModel
+	histories []*nft.L2NftHistory
+}
+
+func (m *testNftHistoryModel) GetNftHistoriesForHeightGreaterThan(height int64) ([]*nft.L2NftHistory, error) {
+	var histories []*nft.L2NftHistory
+	for _, history := range m.histories {
+		if history.L2BlockHeight > height {
+			histories = append(histories, history)
+		}
+	}
+	if len(histories) == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return histories, nil
+}
+
+func (m *testNftHistoryModel) GetLatestNftHistory(nftIndex, height int64) (*nft.L2NftHistory, error) {
+	var latest *nft.L2NftHistory
+	for _, history := range m.histories {
+		if history.NftIndex == nftIndex && history.L2BlockHeight < height {
+			latest = history
+		}
+	}
+	if latest == nil {
+		return nil, types.DbErrNotFound
+	}
+	return latest, nil
+}
+
+type testTxPoolModel struct {
+	tx.TxPoolModel
+	failedTxs []*tx.Tx
+}
+
+func (m *testTxPoolModel) GetTxsForIdGreaterThan(_ int64, _ int) ([]*tx.Tx, error) {
+	return nil, types.DbErrNotFound
+}
+
+func (m *testTxPoolModel) GetFailedTxsDeletedAfter(deletedAt time.Time, id int64, limit int) ([]*tx.Tx, error) {
+	var txs []*tx.Tx
+	for _, failedTx := range m.failedTxs {
+		if len(txs) == limit {
+			break
+		}
+		if failedTx.DeletedAt.Time.After(deletedAt) ||
+			(failedTx.DeletedAt.Time.Equal(deletedAt) && int64(failedTx.ID) > id) {
+			txs = append(txs, failedTx)
+		}
+	}
+	if len(txs) == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return txs, nil
+}
+
+// receive returns the events received by the subscription so far.
+func receive(sub *Subscription) []*Event {
+	var events []*Event
+	for {
+		select {
+		case e := <-sub.events:
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
+
+func TestPollNfts(t *testing.T) {
+	const (
+		nftIndex  = 1
+		creator   = int64(2)
+		buyer     = int64(3)
+		other     = int64(4)
+		emptyUser = int64(0)
+	)
+	model := &testNftHistoryModel{histories: []*nft.L2NftHistory{
+		{NftIndex: nftIndex, OwnerAccountIndex: creator, L2BlockHeight: 1},
+	}}
+	n := &notifier{
+		nftHistoryModel: model,
+		subscriptions:   make(map[*Subscription]bool),
+		nftHeight:       1,
+	}
+	subscribe := func(accountIndex int64) *Subscription {
+		filter, err := NewFilter([]string{EventTypeNft}, []int64{accountIndex})
+		require.NoError(t, err)
+		sub := &Subscription{filter: filter, events: make(chan *Event, subscriptionBufferSize)}
+		n.subscriptions[sub] = true
+		return sub
+	}
+	creatorSub, buyerSub, otherSub := subscribe(creator), subscribe(buyer), subscribe(other)
+
+	// The nft is sold to the buyer, then withdrawn by the buyer in the same poll.
+	model.histories = append(model.histories,
+		&nft.L2NftHistory{NftIndex: nftIndex, OwnerAccountIndex: buyer, L2BlockHeight: 2},
+		&nft.L2NftHistory{NftIndex: nftIndex, OwnerAccountIndex: emptyUser, L2BlockHeight: 3},
+	)
+	n.pollNfts()
+	assert.Equal(t, int64(3), n.nftHeight)
+
+	owners := func(events []*Event) []int64 {
+		owners := make([]int64, 0, len(events))
+		for _, e := range events {
+			owners = append(owners, e.Data.(*Nft).OwnerAccountIndex)
+		}
+		sort.Slice(owners, func(i, j int) bool { return owners[i] < owners[j] })
+		return owners
+	}
+	// The creator is notified that the nft is sold, and the buyer of both the changes.
+	assert.Equal(t, []int64{buyer}, owners(receive(creatorSub)))
+	assert.Equal(t, []int64{emptyUser, buyer}, owners(receive(buyerSub)))
+	assert.Empty(t, receive(otherSub))
+
+	// A minted nft has no previous owner.
+	model.histories = append(model.histories,
+		&nft.L2NftHistory{NftIndex: nftIndex + 1, OwnerAccountIndex: other, L2BlockHeight: 4},
+	)
+	n.pollNfts()
+	assert.Equal(t, []int64{other}, owners(receive(otherSub)))
+	assert.Empty(t, receive(creatorSub))
+	assert.Empty(t, receive(buyerSub))
+}
+
+func TestPollFailedTxs(t *testing.T) {
+	model := &testTxPoolModel{}
+	n := &notifier{
+		txPoolModel:       model,
+		subscriptions:     make(map[*Subscription]bool),
+		failedTxDeletedAt: time.Unix(100, 0),
+	}
+	filter, err := NewFilter([]string{EventTypeFailedTx}, nil)
+	require.NoError(t, err)
+	sub := &Subscription{filter: filter, events: make(chan *Event, subscriptionBufferSize)}
+	n.subscriptions[sub] = true
+
+	newFailedTx := func(id uint, deletedAt int64) *tx.Tx {
+		failedTx := &tx.Tx{TxHash: fmt.Sprintf("tx-%d", id), TxStatus: tx.StatusFailed}
+		failedTx.ID = id
+		failedTx.DeletedAt.Time = time.Unix(deletedAt, 0)
+		failedTx.DeletedAt.Valid = true
+		return failedTx
+	}
+	hashes := func(events []*Event) []string {
+		hashes := make([]string, 0, len(events))
+		for _, e := range events {
+			hashes = append(hashes, e.Data.(*Tx).Hash)
+		}
+		return hashes
+	}
+
+	// The txs failed before the notifier started are skipped.
+	model.failedTxs = []*tx.Tx{newFailedTx(1, 99), newFailedTx(2, 101)}
+	n.pollTxs()
+	assert.Equal(t, []string{"tx-2"}, hashes(receive(sub)))
+
+	// A tx failed at the same time as the last polled one is still published.
+	model.failedTxs = append(model.failedTxs, newFailedTx(3, 101), newFailedTx(4, 102))
+	n.pollTxs()
+	assert.Equal(t, []string{"tx-3", "tx-4"}, hashes(receive(sub)))
+	assert.Equal(t, int64(4), n.failedTxId)
+
+	n.pollTxs()
+	assert.Empty(t, receive(sub))
+}
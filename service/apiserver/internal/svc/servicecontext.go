@@ -11,13 +11,19 @@ import (
 	"github.com/bnb-chain/zkbnb/dao/asset"
 	"github.com/bnb-chain/zkbnb/dao/block"
 	"github.com/bnb-chain/zkbnb/dao/dbcache"
+	gasfeedao "github.com/bnb-chain/zkbnb/dao/gasfee"
 	"github.com/bnb-chain/zkbnb/dao/nft"
+	"github.com/bnb-chain/zkbnb/dao/offer"
 	"github.com/bnb-chain/zkbnb/dao/sysconfig"
 	"github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/dao/withdrawal"
 	"github.com/bnb-chain/zkbnb/service/apiserver/internal/cache"
 	"github.com/bnb-chain/zkbnb/service/apiserver/internal/config"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/fetcher/gasfee"
 	"github.com/bnb-chain/zkbnb/service/apiserver/internal/fetcher/price"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/fetcher/proof"
 	"github.com/bnb-chain/zkbnb/service/apiserver/internal/fetcher/state"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/notifier"
 )
 
 type ServiceContext struct {
@@ -30,13 +36,21 @@ type ServiceContext struct {
 	AccountModel        account.AccountModel
 	AccountHistoryModel account.AccountHistoryModel
 	TxModel             tx.TxModel
+	TxDetailModel       tx.TxDetailModel
 	BlockModel          block.BlockModel
 	NftModel            nft.L2NftModel
+	NftHistoryModel     nft.L2NftHistoryModel
 	AssetModel          asset.AssetModel
 	SysConfigModel      sysconfig.SysConfigModel
+	OfferModel          offer.OfferModel
+	GasFeeModel         gasfeedao.GasFeeModel
+	WithdrawalModel     withdrawal.WithdrawalModel
 
 	PriceFetcher price.Fetcher
 	StateFetcher state.Fetcher
+	GasFeeOracle gasfee.Oracle
+	MerkleProver proof.Prover
+	Notifier     notifier.Notifier
 }
 
 func NewServiceContext(c config.Config) *ServiceContext {
@@ -50,8 +64,25 @@ func NewServiceContext(c config.Config) *ServiceContext {
 	accountModel := account.NewAccountModel(db)
 	nftModel := nft.NewL2NftModel(db)
 	assetModel := asset.NewAssetModel(db)
+	accountHistoryModel := account.NewAccountHistoryModel(db)
+	blockModel := block.NewBlockModel(db)
+	nftHistoryModel := nft.NewL2NftHistoryModel(db)
+	sysConfigModel := sysconfig.NewSysConfigModel(db)
+	gasFeeModel := gasfeedao.NewGasFeeModel(db)
 	memCache := cache.MustNewMemCache(accountModel, assetModel, c.MemCache.AccountExpiration, c.MemCache.BlockExpiration,
 		c.MemCache.TxExpiration, c.MemCache.AssetExpiration, c.MemCache.PriceExpiration, c.MemCache.MaxCounterNum, c.MemCache.MaxKeyNum)
+	priceConfig := c.PriceOracle
+	if len(priceConfig.Sources) == 0 {
+		priceConfig.Sources = []price.SourceConfig{{
+			Type:  price.SourceTypeCmc,
+			Url:   c.CoinMarketCap.Url,
+			Token: c.CoinMarketCap.Token,
+		}}
+	}
+	priceFetcher, err := price.NewFetcher(memCache, assetModel, priceConfig)
+	if err != nil {
+		logx.Must(err)
+	}
 	return &ServiceContext{
 		Config:              c,
 		RedisCache:          redisCache,
@@ -59,15 +90,23 @@ func NewServiceContext(c config.Config) *ServiceContext {
 		DB:                  db,
 		TxPoolModel:         txPoolModel,
 		AccountModel:        accountModel,
-		AccountHistoryModel: account.NewAccountHistoryModel(db),
+		AccountHistoryModel: accountHistoryModel,
 		TxModel:             tx.NewTxModel(db),
-		BlockModel:          block.NewBlockModel(db),
+		TxDetailModel:       tx.NewTxDetailModel(db),
+		BlockModel:          blockModel,
 		NftModel:            nftModel,
+		NftHistoryModel:     nftHistoryModel,
 		AssetModel:          assetModel,
-		SysConfigModel:      sysconfig.NewSysConfigModel(db),
+		SysConfigModel:      sysConfigModel,
+		OfferModel:          offer.NewOfferModel(db),
+		GasFeeModel:         gasFeeModel,
+		WithdrawalModel:     withdrawal.NewWithdrawalModel(db),
 
-		PriceFetcher: price.NewFetcher(memCache, assetModel, c.CoinMarketCap.Url, c.CoinMarketCap.Token),
+		PriceFetcher: priceFetcher,
 		StateFetcher: state.NewFetcher(redisCache, accountModel, nftModel),
+		GasFeeOracle: gasfee.NewOracle(c.GasFeeOracle, c.CacheRedis[0].NewRedis(), sysConfigModel, assetModel, txPoolModel, gasFeeModel, priceFetcher),
+		MerkleProver: proof.NewProver(c.MerkleProof, accountModel, accountHistoryModel, nftHistoryModel, blockModel),
+		Notifier:     notifier.NewNotifier(blockModel, txPoolModel, accountHistoryModel, nftHistoryModel),
 	}
 }
 
@@ -78,4 +117,7 @@ func (s *ServiceContext) Shutdown() {
 	}
 	_ = s.RedisCache.Close()
 	s.PriceFetcher.Stop()
+	s.GasFeeOracle.Stop()
+	s.MerkleProver.Stop()
+	s.Notifier.Stop()
 }
@@ -31,6 +31,7 @@ func Run(configFile string) error {
 
 	server := rest.MustNewServer(c.RestConf, rest.WithCors())
 	handler.RegisterHandlers(server, ctx)
+	handler.RegisterSubscriptionHandlers(server, ctx)
 
 	logx.Infof("apiserver is starting at %s:%d...\n", c.Host, c.Port)
 	server.Start()
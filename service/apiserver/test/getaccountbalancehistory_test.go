@@ -0,0 +1,118 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+)
+
+func (s *ApiServerSuite) TestGetAccountBalanceHistory() {
+	type args struct {
+		by      string
+		value   string
+		assetId int64
+		offset  int
+		limit   int
+	}
+
+	type testcase struct {
+		name     string
+		args     args
+		httpCode int
+	}
+
+	tests := []testcase{
+		{"not found by name", args{"name", "not exist name", -1, 0, 10}, 400},
+		{"invalid by", args{"invalidby", "", -1, 0, 10}, 400},
+		{"invalid asset id", args{"index", "0", -2, 0, 10}, 400},
+		{"invalid limit", args{"index", "0", -1, 0, 0}, 400},
+	}
+
+	statusCode, accounts := GetAccounts(s, 0, 100)
+	if statusCode == http.StatusOK && len(accounts.Accounts) > 0 {
+		tests = append(tests, []testcase{
+			{"found by index", args{"index", strconv.Itoa(int(accounts.Accounts[0].Index)), -1, 0, 10}, 200},
+			{"found by name with asset", args{"name", accounts.Accounts[0].Name, 0, 0, 10}, 200},
+		}...)
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			httpCode, result := GetAccountBalanceHistory(s, tt.args.by, tt.args.value, tt.args.assetId, tt.args.offset, tt.args.limit)
+			assert.Equal(t, tt.httpCode, httpCode)
+			if httpCode == http.StatusOK {
+				assert.True(t, result.Total >= int64(len(result.Changes)))
+				for _, change := range result.Changes {
+					assert.NotEmpty(t, change.TxHash)
+					assert.True(t, change.BlockHeight > 0)
+					if tt.args.assetId >= 0 {
+						assert.Equal(t, tt.args.assetId, change.AssetId)
+					}
+				}
+				fmt.Printf("result: %+v \n", result)
+			}
+		})
+	}
+}
+
+func (s *ApiServerSuite) TestGetAccountAtHeight() {
+	statusCode, accounts := GetAccounts(s, 0, 100)
+	if statusCode != http.StatusOK || len(accounts.Accounts) == 0 {
+		return
+	}
+	_, currentHeight := GetCurrentHeight(s)
+	index := strconv.Itoa(int(accounts.Accounts[0].Index))
+
+	type testcase struct {
+		name     string
+		height   int64
+		httpCode int
+	}
+	tests := []testcase{
+		{"invalid height", -2, 400},
+		{"future height", currentHeight.Height + 1000000, 400},
+		{"latest height", currentHeight.Height, 200},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			resp, err := http.Get(fmt.Sprintf("%s/api/v1/account?by=index&value=%s&height=%d", s.url, index, tt.height))
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tt.httpCode, resp.StatusCode)
+			if resp.StatusCode == http.StatusOK {
+				body, err := io.ReadAll(resp.Body)
+				assert.NoError(t, err)
+				result := types.Account{}
+				assert.NoError(t, json.Unmarshal(body, &result))
+				_, latest := GetAccount(s, "index", index)
+				assert.Equal(t, latest.Nonce, result.Nonce)
+			}
+		})
+	}
+}
+
+func GetAccountBalanceHistory(s *ApiServerSuite, by, value string, assetId int64, offset, limit int) (int, *types.BalanceChanges) {
+	resp, err := http.Get(fmt.Sprintf("%s/api/v1/accountBalanceHistory?by=%s&value=%s&asset_id=%d&offset=%d&limit=%d",
+		s.url, by, value, assetId, offset, limit))
+	assert.NoError(s.T(), err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(s.T(), err)
+
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, nil
+	}
+	result := types.BalanceChanges{}
+	//nolint: errcheck
+	json.Unmarshal(body, &result)
+	return resp.StatusCode, &result
+}
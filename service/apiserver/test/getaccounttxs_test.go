@@ -10,6 +10,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	txdao "github.com/bnb-chain/zkbnb/dao/tx"
 	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
 )
 
@@ -65,6 +66,25 @@ func (s *ApiServerSuite) TestGetAccountTxs() {
 
 }
 
+func (s *ApiServerSuite) TestGetAccountFailedTxs() {
+	statusCode, txs := GetTxs(s, 0, 1)
+	s.Require().Equal(http.StatusOK, statusCode)
+	s.Require().NotEmpty(txs.Txs)
+	accountIndex := strconv.FormatInt(txs.Txs[0].AccountIndex, 10)
+	statusCode, before := GetAccountTxs(s, "account_index", accountIndex, 0, 10)
+	s.Require().Equal(http.StatusOK, statusCode)
+
+	failedTx := s.createFailedTx(txs.Txs[0].AccountIndex, txs.Txs[0].BlockHeight)
+	statusCode, result := GetAccountTxs(s, "account_index", accountIndex, 0, 10)
+	s.Require().Equal(http.StatusOK, statusCode)
+	assert.Equal(s.T(), before.Total+1, result.Total)
+	// The latest tx of the account is the failed one.
+	s.Require().NotEmpty(result.Txs)
+	assert.Equal(s.T(), failedTx.TxHash, result.Txs[0].Hash)
+	assert.Equal(s.T(), int64(txdao.StatusFailed), result.Txs[0].Status)
+	assert.Equal(s.T(), failedTx.FailReason, result.Txs[0].FailReason)
+}
+
 func GetAccountTxs(s *ApiServerSuite, by, value string, offset, limit int) (int, *types.Txs) {
 	resp, err := http.Get(fmt.Sprintf("%s/api/v1/accountTxs?by=%s&value=%s&offset=%d&limit=%d", s.url, by, value, offset, limit))
 	assert.NoError(s.T(), err)
@@ -0,0 +1,133 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bnb-chain/zkbnb/common/merkle"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+func (s *ApiServerSuite) TestGetAccountProof() {
+	type testcase struct {
+		name     string
+		by       string
+		value    string
+		httpCode int
+	}
+
+	tests := []testcase{
+		{"not found by name", "name", "not exist name", 400},
+		{"invalid by", "invalidby", "", 400},
+	}
+
+	statusCode, accounts := GetAccounts(s, 0, 100)
+	if statusCode == http.StatusOK && len(accounts.Accounts) > 0 {
+		tests = append(tests, []testcase{
+			{"found by index", "index", strconv.Itoa(int(accounts.Accounts[0].Index)), 200},
+			{"found by name", "name", accounts.Accounts[0].Name, 200},
+		}...)
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			result := &merkle.AccountProof{}
+			httpCode := getMerkleProof(s, fmt.Sprintf("accountProof?by=%s&value=%s", tt.by, tt.value), result)
+			assert.Equal(t, tt.httpCode, httpCode)
+			if httpCode == http.StatusOK {
+				assert.NoError(t, merkle.VerifyAccountProof(result))
+			}
+		})
+	}
+}
+
+func (s *ApiServerSuite) TestGetAssetProof() {
+	statusCode, accounts := GetAccounts(s, 0, 100)
+	if statusCode != http.StatusOK || len(accounts.Accounts) == 0 {
+		return
+	}
+	index := strconv.Itoa(int(accounts.Accounts[0].Index))
+
+	type testcase struct {
+		name     string
+		assetId  int64
+		httpCode int
+	}
+	tests := []testcase{
+		{"invalid asset id", 1 << 16, 400},
+		{"held asset", 0, 200},
+		{"not held asset", 1<<16 - 1, 200},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			result := &merkle.AssetProof{}
+			httpCode := getMerkleProof(s, fmt.Sprintf("assetProof?by=index&value=%s&asset_id=%d", index, tt.assetId), result)
+			assert.Equal(t, tt.httpCode, httpCode)
+			if httpCode == http.StatusOK {
+				assert.NoError(t, merkle.VerifyAssetProof(result))
+			}
+		})
+	}
+}
+
+func (s *ApiServerSuite) TestGetNftProof() {
+	type testcase struct {
+		name     string
+		nftIndex int64
+		httpCode int
+	}
+	tests := []testcase{
+		{"invalid nft index", -1, 400},
+		{"not found", 1<<40 - 1, 400},
+	}
+
+	statusCode, nfts := GetAccountNfts(s, "account_index", "2", 0, 10)
+	if statusCode == http.StatusOK && len(nfts.Nfts) > 0 {
+		tests = append(tests, testcase{"found", nfts.Nfts[0].Index, 200})
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			result := &merkle.NftProof{}
+			httpCode := getMerkleProof(s, fmt.Sprintf("nftProof?nft_index=%d", tt.nftIndex), result)
+			assert.Equal(t, tt.httpCode, httpCode)
+			if httpCode == http.StatusOK {
+				assert.NoError(t, merkle.VerifyNftProof(result))
+			}
+		})
+	}
+}
+
+// getMerkleProof requests the proof and decodes it into the result, it retries for a while
+// since the trees are loaded in the background.
+func getMerkleProof(s *ApiServerSuite, path string, result interface{}) int {
+	var statusCode int
+	for i := 0; i < 10; i++ {
+		resp, err := http.Get(fmt.Sprintf("%s/api/v1/%s", s.url, path))
+		assert.NoError(s.T(), err)
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.NoError(s.T(), err)
+
+		statusCode = resp.StatusCode
+		if statusCode == http.StatusOK {
+			//nolint: errcheck
+			json.Unmarshal(body, result)
+			return statusCode
+		}
+		if !strings.HasPrefix(string(body), strconv.Itoa(int(types2.AppErrNoMerkleProof.Code()))) {
+			return statusCode
+		}
+		time.Sleep(time.Second)
+	}
+	return statusCode
+}
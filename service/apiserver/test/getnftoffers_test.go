@@ -0,0 +1,100 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+)
+
+func (s *ApiServerSuite) TestGetNftOffers() {
+	type args struct {
+		nftIndex int64
+		offset   int
+		limit    int
+	}
+	tests := []struct {
+		name     string
+		args     args
+		httpCode int
+	}{
+		{"invalid limit", args{0, 0, 0}, 400},
+		{"no offers", args{math.MaxInt64, 0, 10}, 200},
+		{"found", args{0, 0, 10}, 200},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			httpCode, result := GetNftOffers(s, tt.args.nftIndex, tt.args.offset, tt.args.limit)
+			assert.Equal(t, tt.httpCode, httpCode)
+			if httpCode == http.StatusOK {
+				assert.True(t, result.Total >= int64(len(result.Offers)))
+				for _, offer := range result.Offers {
+					assert.Equal(t, tt.args.nftIndex, offer.NftIndex)
+					assert.NotEmpty(t, offer.OfferInfo)
+				}
+				fmt.Printf("result: %+v \n", result)
+			}
+		})
+	}
+}
+
+func (s *ApiServerSuite) TestSendOffer() {
+	tests := []struct {
+		name      string
+		offerInfo string
+		httpCode  int
+	}{
+		{"invalid offer info", "invalid", 400},
+		{"invalid offer", `{"Type":1,"OfferId":0,"AccountIndex":0,"NftIndex":0,"AssetId":0,"AssetAmount":1,"ListedAt":0,"ExpiredAt":0,"TreasuryRate":0,"Sig":null}`, 400},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			httpCode, _ := SendOffer(s, tt.offerInfo)
+			assert.Equal(t, tt.httpCode, httpCode)
+		})
+	}
+}
+
+func GetNftOffers(s *ApiServerSuite, nftIndex int64, offset, limit int) (int, *types.Offers) {
+	resp, err := http.Get(fmt.Sprintf("%s/api/v1/nftOffers?nft_index=%d&offset=%d&limit=%d", s.url, nftIndex, offset, limit))
+	assert.NoError(s.T(), err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(s.T(), err)
+
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, nil
+	}
+	result := types.Offers{}
+	//nolint: errcheck
+	json.Unmarshal(body, &result)
+	return resp.StatusCode, &result
+}
+
+func SendOffer(s *ApiServerSuite, offerInfo string) (int, *types.Offer) {
+	resp, err := http.PostForm(fmt.Sprintf("%s/api/v1/sendOffer", s.url), url.Values{
+		"offer_info": {offerInfo}})
+	assert.NoError(s.T(), err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(s.T(), err)
+
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, nil
+	}
+	result := types.Offer{}
+	//nolint: errcheck
+	json.Unmarshal(body, &result)
+	return resp.StatusCode, &result
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	txdao "github.com/bnb-chain/zkbnb/dao/tx"
 	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
 )
 
@@ -49,6 +50,30 @@ func (s *ApiServerSuite) TestGetTx() {
 
 }
 
+func (s *ApiServerSuite) TestGetFailedTx() {
+	statusCode, txs := GetTxs(s, 0, 1)
+	s.Require().Equal(http.StatusOK, statusCode)
+	s.Require().NotEmpty(txs.Txs)
+	failedTx := s.createFailedTx(txs.Txs[0].AccountIndex, txs.Txs[0].BlockHeight)
+
+	httpCode, result := GetTx(s, failedTx.TxHash)
+	s.Require().Equal(http.StatusOK, httpCode)
+	assert.Equal(s.T(), failedTx.TxHash, result.Tx.Hash)
+	assert.Equal(s.T(), int64(txdao.StatusFailed), result.Tx.Status)
+	assert.Equal(s.T(), failedTx.FailReason, result.Tx.FailReason)
+	assert.Equal(s.T(), failedTx.BlockHeight, result.Tx.BlockHeight)
+	// The failed tx is not part of the block at its height.
+	assert.Zero(s.T(), result.CommittedAt)
+	assert.Zero(s.T(), result.VerifiedAt)
+
+	// The failed txs are not listed with the executed txs.
+	statusCode, txs = GetTxs(s, 0, 10)
+	s.Require().Equal(http.StatusOK, statusCode)
+	for _, tx := range txs.Txs {
+		assert.NotEqual(s.T(), failedTx.TxHash, tx.Hash)
+	}
+}
+
 func GetTx(s *ApiServerSuite, hash string) (int, *types.EnrichedTx) {
 	resp, err := http.Get(fmt.Sprintf("%s/api/v1/tx?hash=%s", s.url, hash))
 	assert.NoError(s.T(), err)
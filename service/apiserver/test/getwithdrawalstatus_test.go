@@ -0,0 +1,65 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+func (s *ApiServerSuite) TestGetWithdrawalStatus() {
+	type testcase struct {
+		name     string
+		args     string //tx hash
+		httpCode int
+	}
+
+	tests := []testcase{
+		{"not found", "notexistshash", 400},
+	}
+
+	statusCode, txs := GetTxs(s, 0, 100)
+	if statusCode == http.StatusOK {
+		for _, tx := range txs.Txs {
+			if tx.Type != types2.TxTypeWithdraw && tx.Type != types2.TxTypeWithdrawNft {
+				tests = append(tests, testcase{"not a withdrawal", tx.Hash, 400})
+				break
+			}
+		}
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			httpCode, result := GetWithdrawalStatus(s, tt.args)
+			assert.Equal(t, tt.httpCode, httpCode)
+			if httpCode == http.StatusOK {
+				assert.NotEmpty(t, result.Status)
+				assert.NotEmpty(t, result.ToAddress)
+				fmt.Printf("result: %+v \n", result)
+			}
+		})
+	}
+}
+
+func GetWithdrawalStatus(s *ApiServerSuite, hash string) (int, *types.WithdrawalStatus) {
+	resp, err := http.Get(fmt.Sprintf("%s/api/v1/withdrawalStatus?hash=%s", s.url, hash))
+	assert.NoError(s.T(), err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(s.T(), err)
+
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, nil
+	}
+	result := types.WithdrawalStatus{}
+	//nolint: errcheck
+	json.Unmarshal(body, &result)
+	return resp.StatusCode, &result
+}
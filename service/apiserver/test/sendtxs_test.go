@@ -0,0 +1,67 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+func (s *ApiServerSuite) TestSendTxs() {
+	type args struct {
+		txs []*types.TxToSend
+	}
+	tests := []struct {
+		name     string
+		args     args
+		httpCode int
+		errorMsg string
+	}{
+		{"empty txs", args{[]*types.TxToSend{}}, 400, "txs count should be in"},
+		{"invalid tx info", args{[]*types.TxToSend{
+			{TxType: types2.TxTypeTransfer, TxInfo: "invalid"},
+		}}, 400, "tx index: 0"},
+		{"invalid tx type", args{[]*types.TxToSend{
+			{TxType: 100, TxInfo: "{}"},
+		}}, 400, "tx index: 0"},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			httpCode, result, body := SendTxs(s, tt.args.txs)
+			assert.Equal(t, tt.httpCode, httpCode)
+			if httpCode == http.StatusOK {
+				assert.Equal(t, len(tt.args.txs), len(result.TxHashes))
+				fmt.Printf("result: %+v \n", result)
+			} else {
+				assert.Contains(t, body, tt.errorMsg)
+			}
+		})
+	}
+}
+
+func SendTxs(s *ApiServerSuite, txs []*types.TxToSend) (int, *types.TxHashes, string) {
+	reqBody, err := json.Marshal(&types.ReqSendTxs{Txs: txs})
+	assert.NoError(s.T(), err)
+	resp, err := http.Post(fmt.Sprintf("%s/api/v1/sendTxs", s.url), "application/json", bytes.NewReader(reqBody))
+	assert.NoError(s.T(), err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(s.T(), err)
+
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, nil, string(body)
+	}
+	result := types.TxHashes{}
+	//nolint: errcheck
+	json.Unmarshal(body, &result)
+	return resp.StatusCode, &result, string(body)
+}
@@ -0,0 +1,67 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+func (s *ApiServerSuite) TestSimulateTx() {
+	type args struct {
+		txType uint32
+		txInfo string
+	}
+	tests := []struct {
+		name     string
+		args     args
+		httpCode int
+		errorMsg string
+	}{
+		{"invalid tx info", args{types2.TxTypeTransfer, "invalid"}, 400, ""},
+		{"invalid tx type", args{100, "{}"}, 400, ""},
+		{"priority tx", args{types2.TxTypeDeposit, "{}"}, 400, "invalid tx type"},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			httpCode, result, body := SimulateTx(s, tt.args.txType, tt.args.txInfo)
+			assert.Equal(t, tt.httpCode, httpCode)
+			if httpCode == http.StatusOK {
+				assert.NotEmpty(t, result.TxHash)
+				assert.NotEmpty(t, result.TxDetails)
+				fmt.Printf("result: %+v \n", result)
+			} else {
+				assert.Contains(t, body, tt.errorMsg)
+			}
+		})
+	}
+}
+
+func SimulateTx(s *ApiServerSuite, txType uint32, txInfo string) (int, *types.SimulatedTx, string) {
+	resp, err := http.PostForm(fmt.Sprintf("%s/api/v1/simulateTx", s.url), url.Values{
+		"tx_type": {strconv.Itoa(int(txType))},
+		"tx_info": {txInfo},
+	})
+	assert.NoError(s.T(), err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(s.T(), err)
+
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, nil, string(body)
+	}
+	result := types.SimulatedTx{}
+	//nolint: errcheck
+	json.Unmarshal(body, &result)
+	return resp.StatusCode, &result, string(body)
+}
@@ -0,0 +1,215 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/zkbnb/dao/account"
+	"github.com/bnb-chain/zkbnb/dao/block"
+	"github.com/bnb-chain/zkbnb/dao/nft"
+	txdao "github.com/bnb-chain/zkbnb/dao/tx"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+type subscriptionEvent struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+type subscriptionBlock struct {
+	Height int64 `json:"height"`
+	Status int64 `json:"status"`
+}
+
+func (s *ApiServerSuite) TestSubscribe() {
+	type args struct {
+		types          string
+		accountIndexes string
+	}
+	tests := []struct {
+		name     string
+		args     args
+		httpCode int
+	}{
+		{"invalid types", args{"invalid", ""}, 400},
+		{"invalid account_indexes", args{"", "-1"}, 400},
+		{"all events", args{"", ""}, 101},
+		{"block events", args{"block,block_status", ""}, 101},
+		{"account events", args{"block,pending_tx,failed_tx,account,nft", "0,1"}, 101},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			conn, httpCode := Subscribe(s, tt.args.types, tt.args.accountIndexes)
+			assert.Equal(t, tt.httpCode, httpCode)
+			if httpCode == http.StatusSwitchingProtocols {
+				defer conn.Close()
+
+				// The latest block is pushed when subscribed.
+				//nolint:errcheck
+				conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+				event := subscriptionEvent{}
+				err := conn.ReadJSON(&event)
+				assert.NoError(t, err)
+				assert.Equal(t, "block", event.Type)
+				block := subscriptionBlock{}
+				err = json.Unmarshal(event.Data, &block)
+				assert.NoError(t, err)
+				assert.True(t, block.Height > 0)
+				fmt.Printf("result: %+v \n", block)
+			}
+		})
+	}
+}
+
+func (s *ApiServerSuite) TestSubscribeEvents() {
+	const (
+		accountIndex      = int64(2)
+		otherAccountIndex = int64(3)
+	)
+	currentHeight, err := s.svcCtx.BlockModel.GetCurrentBlockHeight()
+	s.Require().NoError(err)
+	height := currentHeight + 1
+
+	// Every subscriber of an account topic only receives the events of the topic and the account,
+	// the events are the ones after the new block is pushed.
+	subscribers := map[string]struct {
+		types          string
+		accountIndexes string
+		events         []string
+	}{
+		"block_status": {"block_status", "", []string{"block_status"}},
+		// Both the txs are accepted into the pool before one of them fails.
+		"pending_tx":       {"pending_tx", fmt.Sprint(accountIndex), []string{"pending_tx", "pending_tx"}},
+		"failed_tx":        {"failed_tx", fmt.Sprint(accountIndex), []string{"failed_tx"}},
+		"account":          {"account", fmt.Sprint(accountIndex), []string{"account"}},
+		"nft":              {"nft", fmt.Sprint(accountIndex), []string{"nft"}},
+		"other account":    {"", fmt.Sprint(otherAccountIndex), []string{"block_status"}},
+		"other pending_tx": {"pending_tx", fmt.Sprint(otherAccountIndex), nil},
+	}
+	conns := make(map[string]*websocket.Conn, len(subscribers))
+	for name, subscriber := range subscribers {
+		conn, httpCode := Subscribe(s, subscriber.types, subscriber.accountIndexes)
+		s.Require().Equal(http.StatusSwitchingProtocols, httpCode)
+		defer conn.Close()
+		conns[name] = conn
+	}
+	// The latest block pushed when subscribed is not one of the new events.
+	//nolint:errcheck
+	conns["other account"].SetReadDeadline(time.Now().Add(5 * time.Second))
+	event := subscriptionEvent{}
+	s.Require().NoError(conns["other account"].ReadJSON(&event))
+	s.Require().Equal("block", event.Type)
+
+	s.T().Cleanup(func() {
+		//nolint:errcheck
+		s.svcCtx.DB.Transaction(func(dbTx *gorm.DB) error {
+			if err := s.svcCtx.BlockModel.DeleteBlocksForHeightGreaterThanInTransact(dbTx, currentHeight); err != nil {
+				return err
+			}
+			if err := s.svcCtx.AccountHistoryModel.DeleteAccountHistoriesForHeightGreaterThanInTransact(dbTx, currentHeight); err != nil {
+				return err
+			}
+			if err := s.svcCtx.NftHistoryModel.DeleteNftHistoriesForHeightGreaterThanInTransact(dbTx, currentHeight); err != nil {
+				return err
+			}
+			return dbTx.Unscoped().Where("tx_hash LIKE ?", "subscribe-tx-%").Delete(&txdao.PoolTx{}).Error
+		})
+	})
+
+	// A new block with the account and nft changes of the accounts.
+	err = s.svcCtx.DB.Transaction(func(dbTx *gorm.DB) error {
+		err := s.svcCtx.BlockModel.CreateBlockInTransact(dbTx, &block.Block{
+			BlockHeight: height,
+			BlockStatus: block.StatusPending,
+		})
+		if err != nil {
+			return err
+		}
+		err = s.svcCtx.AccountHistoryModel.CreateAccountHistoriesInTransact(dbTx, []*account.AccountHistory{
+			{AccountIndex: accountIndex, AssetInfo: "{}", L2BlockHeight: height},
+		})
+		if err != nil {
+			return err
+		}
+		return s.svcCtx.NftHistoryModel.CreateNftHistoriesInTransact(dbTx, []*nft.L2NftHistory{
+			{NftIndex: 99999999, OwnerAccountIndex: accountIndex, L2BlockHeight: height},
+		})
+	})
+	s.Require().NoError(err)
+	//nolint:errcheck
+	conns["other account"].SetReadDeadline(time.Now().Add(5 * time.Second))
+	s.Require().NoError(conns["other account"].ReadJSON(&event))
+	s.Require().Equal("block", event.Type)
+	newBlock := subscriptionBlock{}
+	s.Require().NoError(json.Unmarshal(event.Data, &newBlock))
+	s.Require().Equal(height, newBlock.Height)
+
+	// The block is committed after it's pushed, and the txs of the account are accepted and failed.
+	err = s.svcCtx.DB.Table(block.BlockTableName).Where("block_height = ?", height).
+		Update("block_status", block.StatusCommitted).Error
+	s.Require().NoError(err)
+	poolTxs := []*txdao.Tx{
+		{TxHash: fmt.Sprintf("subscribe-tx-%d-pending", height), AccountIndex: accountIndex, TxStatus: txdao.StatusPending},
+		{TxHash: fmt.Sprintf("subscribe-tx-%d-failed", height), AccountIndex: accountIndex, TxStatus: txdao.StatusFailed},
+	}
+	for _, poolTx := range poolTxs {
+		poolTx.TxType = types2.TxTypeTransfer
+		poolTx.NftIndex = types2.NilNftIndex
+		poolTx.CollectionId = types2.NilCollectionNonce
+		poolTx.AssetId = types2.NilAssetId
+		poolTx.BlockHeight = types2.NilBlockHeight
+	}
+	s.Require().NoError(s.svcCtx.TxPoolModel.CreateTxs(poolTxs))
+	err = s.svcCtx.DB.Where("tx_hash = ?", poolTxs[1].TxHash).Delete(&txdao.PoolTx{}).Error
+	s.Require().NoError(err)
+
+	for name, subscriber := range subscribers {
+		s.T().Run(name, func(t *testing.T) {
+			conn := conns[name]
+			received := make([]string, 0)
+			for {
+				//nolint:errcheck
+				conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+				event := subscriptionEvent{}
+				if err := conn.ReadJSON(&event); err != nil {
+					break
+				}
+				received = append(received, event.Type)
+				if event.Type == "block_status" {
+					b := subscriptionBlock{}
+					assert.NoError(t, json.Unmarshal(event.Data, &b))
+					assert.Equal(t, height, b.Height)
+					assert.Equal(t, int64(block.StatusCommitted), b.Status)
+				}
+			}
+			assert.ElementsMatch(t, subscriber.events, received)
+		})
+	}
+}
+
+func Subscribe(s *ApiServerSuite, types, accountIndexes string) (*websocket.Conn, int) {
+	params := url.Values{}
+	if types != "" {
+		params.Set("types", types)
+	}
+	if accountIndexes != "" {
+		params.Set("account_indexes", accountIndexes)
+	}
+	wsUrl := fmt.Sprintf("%s/api/v1/subscribe?%s", strings.Replace(s.url, "http", "ws", 1), params.Encode())
+	conn, resp, err := websocket.DefaultDialer.Dial(wsUrl, nil)
+	if resp == nil {
+		assert.NoError(s.T(), err)
+		return nil, 0
+	}
+	return conn, resp.StatusCode
+}
@@ -1,6 +1,7 @@
 package test
 
 import (
+	"encoding/json"
 	"fmt"
 	"os/exec"
 	"testing"
@@ -12,15 +13,22 @@ import (
 	"github.com/zeromicro/go-zero/core/stores/cache"
 	"github.com/zeromicro/go-zero/core/stores/redis"
 	"github.com/zeromicro/go-zero/rest"
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/zkbnb/dao/sysconfig"
+	txdao "github.com/bnb-chain/zkbnb/dao/tx"
 
 	"github.com/bnb-chain/zkbnb/service/apiserver/internal/config"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/fetcher/proof"
 	"github.com/bnb-chain/zkbnb/service/apiserver/internal/handler"
 	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	types2 "github.com/bnb-chain/zkbnb/types"
 )
 
 type ApiServerSuite struct {
 	suite.Suite
 	server *rest.Server
+	svcCtx *svc.ServiceContext
 	url    string
 }
 
@@ -50,6 +58,46 @@ func testDBShutdown() {
 	cmd.Run()
 }
 
+// testDBMigrate migrates the tables and the sys configs which are newer than the fixture image.
+func testDBMigrate(ctx *svc.ServiceContext) {
+	migrations := []func() error{
+		ctx.TxPoolModel.CreatePoolTxTable,
+		ctx.TxModel.CreateTxTable,
+		ctx.BlockModel.CreateBlockTable,
+		ctx.NftHistoryModel.CreateL2NftHistoryTable,
+		ctx.OfferModel.CreateOfferTable,
+		ctx.GasFeeModel.CreateGasFeeTable,
+		ctx.WithdrawalModel.CreateWithdrawalTable,
+	}
+	for _, migrate := range migrations {
+		if err := migrate(); err != nil {
+			panic(err)
+		}
+	}
+
+	_, err := ctx.SysConfigModel.GetSysConfigByName(types2.SysGasAssets)
+	if err == types2.DbErrNotFound {
+		gasAssets, err := json.Marshal([]*types2.GasAssets{{
+			Version:  types2.InitialGasAssetsVersion,
+			AssetIds: types2.InitialGasAssetIds,
+		}})
+		if err != nil {
+			panic(err)
+		}
+		_, err = ctx.SysConfigModel.CreateSysConfigs([]*sysconfig.SysConfig{{
+			Name:      types2.SysGasAssets,
+			Value:     string(gasAssets),
+			ValueType: "[]*GasAssets",
+			Comment:   "versions of gas assets",
+		}})
+		if err != nil {
+			panic(err)
+		}
+	} else if err != nil {
+		panic(err)
+	}
+}
+
 func (s *ApiServerSuite) SetupSuite() {
 	testDBSetup()
 	c := config.Config{
@@ -61,9 +109,12 @@ func (s *ApiServerSuite) SetupSuite() {
 			},
 		},
 		TxPool: struct {
-			MaxPendingTxCount int
+			MaxPendingTxCount       int
+			MaxPendingTxsPerAccount int  `json:",optional"`
+			SpeculativeDryRun       bool `json:",optional"`
 		}{
 			MaxPendingTxCount: 10000,
+			SpeculativeDryRun: true,
 		},
 		LogConf: logx.LogConf{},
 		CoinMarketCap: struct {
@@ -83,6 +134,7 @@ func (s *ApiServerSuite) SetupSuite() {
 		}{AccountExpiration: 10000, AssetExpiration: 10000, BlockExpiration: 10000, TxExpiration: 10000, PriceExpiration: 3600000, MaxCounterNum: 10000, MaxKeyNum: 10000},
 	}
 	c.Postgres = struct{ DataSource string }{DataSource: "host=127.0.0.1 user=postgres password=ZkBNB@123 dbname=zkbnb port=5433 sslmode=disable"}
+	c.MerkleProof = proof.Config{RefreshInterval: 1}
 	c.CacheRedis = cache.CacheConf{}
 	c.CacheRedis = append(c.CacheRedis, cache.NodeConf{
 		RedisConf: redis.RedisConf{Host: "127.0.0.1"},
@@ -90,11 +142,14 @@ func (s *ApiServerSuite) SetupSuite() {
 	logx.DisableStat()
 
 	ctx := svc.NewServiceContext(c)
+	s.svcCtx = ctx
+	testDBMigrate(ctx)
 
 	s.url = fmt.Sprintf("http://127.0.0.1:%d", c.Port)
 	s.server = rest.MustNewServer(c.RestConf, rest.WithCors())
 
 	handler.RegisterHandlers(s.server, ctx)
+	handler.RegisterSubscriptionHandlers(s.server, ctx)
 	logx.Infof("Starting server at %s", s.url)
 	go s.server.Start()
 	time.Sleep(1 * time.Second)
@@ -105,3 +160,30 @@ func (s *ApiServerSuite) TearDownSuite() {
 	s.server.Stop()
 	testDBShutdown()
 }
+
+// createFailedTx stores a tx failed in the committer at the height for the account, the tx
+// is deleted at the end of the test.
+func (s *ApiServerSuite) createFailedTx(accountIndex, height int64) *txdao.Tx {
+	failedTx := &txdao.Tx{
+		TxHash:       fmt.Sprintf("failed-tx-%d", time.Now().UnixNano()),
+		TxType:       types2.TxTypeTransfer,
+		TxInfo:       "{}",
+		AccountIndex: accountIndex,
+		GasFee:       "0",
+		TxAmount:     "0",
+		NftIndex:     types2.NilNftIndex,
+		CollectionId: types2.NilCollectionNonce,
+		AssetId:      types2.NilAssetId,
+		BlockHeight:  height,
+		TxStatus:     txdao.StatusFailed,
+		FailReason:   "invalid nonce",
+	}
+	err := s.svcCtx.DB.Transaction(func(dbTx *gorm.DB) error {
+		return s.svcCtx.TxModel.CreateFailedTxsInTransact(dbTx, []*txdao.Tx{failedTx})
+	})
+	s.Require().NoError(err)
+	s.T().Cleanup(func() {
+		s.svcCtx.DB.Unscoped().Where("tx_hash = ?", failedTx.TxHash).Delete(&txdao.Tx{})
+	})
+	return failedTx
+}
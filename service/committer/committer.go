@@ -1,12 +1,16 @@
 package committer
 
 import (
+	"errors"
+	"sync/atomic"
 	"time"
 
 	"github.com/zeromicro/go-zero/core/conf"
 	"github.com/zeromicro/go-zero/core/logx"
 	"github.com/zeromicro/go-zero/core/proc"
+	"github.com/zeromicro/go-zero/core/prometheus"
 
+	"github.com/bnb-chain/zkbnb/common/health"
 	"github.com/bnb-chain/zkbnb/service/committer/committer"
 )
 
@@ -17,12 +21,26 @@ func Run(configFile string) error {
 	conf.MustLoad(configFile, &c)
 	logx.MustSetup(c.LogConf)
 	logx.DisableStat()
+	prometheus.StartAgent(c.Prometheus)
+
+	// Serve the health endpoints while the trees are loaded, which could take a long time.
+	var treesLoaded int32
+	healthServer := health.NewServer(c.Health.Config)
+	healthServer.AddReadinessCheck("trees", func() error {
+		if atomic.LoadInt32(&treesLoaded) == 0 {
+			return errors.New("the trees are not loaded yet")
+		}
+		return nil
+	})
+	healthServer.Start()
 
 	committer, err := committer.NewCommitter(&c)
 	if err != nil {
 		logx.Error("new committer failed:", err)
 		return err
 	}
+	atomic.StoreInt32(&treesLoaded, 1)
+	committer.RegisterHealthChecks(healthServer)
 
 	proc.SetTimeToForceQuit(GracefulShutdownTimeout)
 	proc.AddShutdownListener(func() {
@@ -3,14 +3,21 @@ package committer
 import (
 	"errors"
 	"fmt"
+	"math/big"
+	"strconv"
 	"time"
 
-	"github.com/prometheus/client_golang/prometheus"
 	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/prometheus"
 	"gorm.io/gorm"
 
+	"github.com/bnb-chain/zkbnb/common/chain"
+	"github.com/bnb-chain/zkbnb/common/health"
 	"github.com/bnb-chain/zkbnb/core"
+	"github.com/bnb-chain/zkbnb/dao/account"
 	"github.com/bnb-chain/zkbnb/dao/block"
+	"github.com/bnb-chain/zkbnb/dao/nft"
+	"github.com/bnb-chain/zkbnb/dao/offer"
 	"github.com/bnb-chain/zkbnb/dao/tx"
 	"github.com/bnb-chain/zkbnb/types"
 )
@@ -19,25 +26,29 @@ const (
 	MaxCommitterInterval = 60 * 1
 )
 
-var (
-	priorityOperationMetric = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: "zkbnb",
-		Name:      "priority_operation_process",
-		Help:      "Priority operation requestID metrics.",
-	})
-	priorityOperationHeightMetric = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: "zkbnb",
-		Name:      "priority_operation_process_height",
-		Help:      "Priority operation height metrics.",
-	})
-)
-
 type Config struct {
 	core.ChainConfig
 
 	BlockConfig struct {
 		OptionalBlockSizes []int
 	}
+	//nolint:staticcheck
+	TxPool struct {
+		// Interval in seconds to evict the pending txs from the tx pool, 10 by default
+		EvictInterval int `json:",optional"`
+		// Max pending txs of an account, the ones with higher nonces are evicted, no limit if 0
+		MaxPendingTxsPerAccount int `json:",optional"`
+	} `json:",optional"`
+	// The metrics listener, the metrics are not served if the host is empty
+	//nolint:staticcheck
+	Prometheus prometheus.Config `json:",optional"`
+	//nolint:staticcheck
+	Health struct {
+		health.Config
+		// Max seconds to stay on a block with executed txs, or to block the main loop, 10 times
+		// of the commit interval by default
+		MaxBlockDuration int `json:",optional"`
+	} `json:",optional"`
 	LogConf logx.LogConf
 }
 
@@ -47,7 +58,13 @@ type Committer struct {
 	maxTxsPerBlock     int
 	optionalBlockSizes []int
 
-	bc *core.BlockChain
+	bc      *core.BlockChain
+	evictor *evictor
+
+	// The progress of the main loop in unix nanoseconds, see markProgress.
+	heartbeat   int64
+	blockHeight int64
+	blockSince  int64
 }
 
 func NewCommitter(config *Config) (*Committer, error) {
@@ -60,11 +77,8 @@ func NewCommitter(config *Config) (*Committer, error) {
 		return nil, fmt.Errorf("new blockchain error: %v", err)
 	}
 
-	if err := prometheus.Register(priorityOperationMetric); err != nil {
-		return nil, fmt.Errorf("prometheus.Register priorityOperationMetric error: %v", err)
-	}
-	if err := prometheus.Register(priorityOperationHeightMetric); err != nil {
-		return nil, fmt.Errorf("prometheus.Register priorityOperationHeightMetric error: %v", err)
+	if err := registerMetrics(); err != nil {
+		return nil, err
 	}
 
 	committer := &Committer{
@@ -73,7 +87,8 @@ func NewCommitter(config *Config) (*Committer, error) {
 		maxTxsPerBlock:     config.BlockConfig.OptionalBlockSizes[len(config.BlockConfig.OptionalBlockSizes)-1],
 		optionalBlockSizes: config.BlockConfig.OptionalBlockSizes,
 
-		bc: bc,
+		bc:      bc,
+		evictor: newEvictor(config, bc.TxPoolModel, bc.AccountModel, bc.OfferModel),
 	}
 	return committer, nil
 }
@@ -83,6 +98,7 @@ func (c *Committer) Run() {
 	if err != nil {
 		panic("restore executed tx failed: " + err.Error())
 	}
+	go c.evictor.Run()
 
 	latestRequestId, err := c.getLatestExecutedRequestId()
 	if err != nil {
@@ -94,6 +110,18 @@ func (c *Committer) Run() {
 		if !c.running {
 			break
 		}
+		c.markProgress(curBlock)
+
+		// Roll back the chain if the committed blocks are reverted on L1.
+		rolledBack, err := c.rollback()
+		if err != nil {
+			panic("rollback blocks failed: " + err.Error())
+		}
+		if rolledBack {
+			curBlock = c.bc.CurrentBlock()
+			latestRequestId = -1
+		}
+
 		if curBlock.BlockStatus > block.StatusProposing {
 			curBlock, err = c.bc.ProposeNewBlock()
 			if err != nil {
@@ -102,7 +130,7 @@ func (c *Committer) Run() {
 		}
 
 		// Read pending transactions from tx pool.
-		pendingTxs, err := c.bc.TxPoolModel.GetTxsByStatus(tx.StatusPending)
+		pendingTxs, err := c.getPendingTxs()
 		if err != nil {
 			logx.Error("get pending transactions from tx pool failed:", err)
 			return
@@ -113,7 +141,17 @@ func (c *Committer) Run() {
 			}
 
 			time.Sleep(100 * time.Millisecond)
-			pendingTxs, err = c.bc.TxPoolModel.GetTxsByStatus(tx.StatusPending)
+			c.markProgress(curBlock)
+			rolledBack, err = c.rollback()
+			if err != nil {
+				panic("rollback blocks failed: " + err.Error())
+			}
+			if rolledBack {
+				curBlock = c.bc.CurrentBlock()
+				latestRequestId = -1
+				break
+			}
+			pendingTxs, err = c.getPendingTxs()
 			if err != nil {
 				logx.Error("get pending transactions from tx pool failed:", err)
 				return
@@ -122,64 +160,109 @@ func (c *Committer) Run() {
 
 		pendingUpdatePoolTxs := make([]*tx.Tx, 0, len(pendingTxs))
 		pendingDeletePoolTxs := make([]*tx.Tx, 0, len(pendingTxs))
-		for _, poolTx := range pendingTxs {
-			if c.shouldCommit(curBlock) {
-				break
-			}
-
-			logx.Infof("apply transaction, txHash=%s", poolTx.TxHash)
-			err = c.bc.ApplyTransaction(poolTx)
-			if err != nil {
-				logx.Errorf("apply pool tx ID: %d failed, err %v ", poolTx.ID, err)
-				poolTx.TxStatus = tx.StatusFailed
-				pendingDeletePoolTxs = append(pendingDeletePoolTxs, poolTx)
-				continue
+		// The executed txs which are no longer pending in the tx pool, they are dropped from
+		// the block.
+		droppedTxs := make([]*tx.Tx, 0)
+		for len(pendingTxs) > 0 && !c.shouldCommit(curBlock) {
+			// Apply the txs which fit in the block at a time, the non-conflicting txs in them
+			// are verified concurrently.
+			batchSize := c.maxTxsPerBlock - len(c.bc.Statedb.Txs)
+			if batchSize > len(pendingTxs) {
+				batchSize = len(pendingTxs)
 			}
+			batchTxs := pendingTxs[:batchSize]
+			pendingTxs = pendingTxs[batchSize:]
+
+			errs := c.bc.ApplyTransactions(batchTxs)
+			for i, poolTx := range batchTxs {
+				logx.Infof("apply transaction, txHash=%s", poolTx.TxHash)
+				if errs[i] != nil {
+					logx.Errorf("apply pool tx ID: %d failed, err %v ", poolTx.ID, errs[i])
+					applyFailuresMetric.WithLabelValues(strconv.FormatInt(poolTx.TxType, 10), applyFailureReason(errs[i])).Inc()
+					poolTx.TxStatus = tx.StatusFailed
+					poolTx.FailReason = errs[i].Error()
+					poolTx.BlockHeight = curBlock.BlockHeight
+					pendingDeletePoolTxs = append(pendingDeletePoolTxs, poolTx)
+					continue
+				}
 
-			if types.IsPriorityOperationTx(poolTx.TxType) {
-				request, err := c.bc.PriorityRequestModel.GetPriorityRequestsByL2TxHash(poolTx.TxHash)
-				if err == nil {
+				if types.IsPriorityOperationTx(poolTx.TxType) {
+					request, err := c.bc.PriorityRequestModel.GetPriorityRequestsByL2TxHash(poolTx.TxHash)
+					if err == nil {
 
-					priorityOperationMetric.Set(float64(request.RequestId))
-					priorityOperationHeightMetric.Set(float64(request.L1BlockHeight))
+						priorityOperationMetric.Set(float64(request.RequestId))
+						priorityOperationHeightMetric.Set(float64(request.L1BlockHeight))
 
-					if latestRequestId != -1 && request.RequestId != latestRequestId+1 {
-						logx.Errorf("invalid request ID: %d, txHash: %s", request.RequestId, poolTx.TxHash)
-						return
+						if latestRequestId != -1 && request.RequestId != latestRequestId+1 {
+							logx.Errorf("invalid request ID: %d, txHash: %s", request.RequestId, poolTx.TxHash)
+							return
+						}
+						latestRequestId = request.RequestId
+					} else {
+						logx.Errorf("query txHash: %s in PriorityRequestTable failed, err %v ", poolTx.TxHash, err)
 					}
-					latestRequestId = request.RequestId
-				} else {
-					logx.Errorf("query txHash: %s in PriorityRequestTable failed, err %v ", poolTx.TxHash, err)
 				}
-			}
 
-			// Write the proposed block into database when the first transaction executed.
-			if len(c.bc.Statedb.Txs) == 1 {
-				err = c.createNewBlock(curBlock, poolTx)
-				if err != nil {
-					panic("create new block failed" + err.Error())
+				// Write the proposed block into database when the first transaction executed.
+				appliedTxsMetric.Inc()
+				if curBlock.ID == 0 {
+					skippedTxs, err := c.createNewBlock(curBlock, poolTx)
+					if err != nil {
+						panic("create new block failed" + err.Error())
+					}
+					droppedTxs = append(droppedTxs, skippedTxs...)
+				} else {
+					pendingUpdatePoolTxs = append(pendingUpdatePoolTxs, poolTx)
 				}
-			} else {
-				pendingUpdatePoolTxs = append(pendingUpdatePoolTxs, poolTx)
 			}
 		}
 
-		err = c.bc.StateDB().SyncStateCacheToRedis()
-		if err != nil {
-			panic("sync redis cache failed: " + err.Error())
-		}
-
 		err = c.bc.DB().DB.Transaction(func(dbTx *gorm.DB) error {
-			err := c.bc.TxPoolModel.UpdateTxsInTransact(dbTx, pendingUpdatePoolTxs)
+			skippedTxs, err := c.bc.TxPoolModel.UpdateTxsInTransact(dbTx, pendingUpdatePoolTxs)
 			if err != nil {
 				return err
 			}
-			return c.bc.TxPoolModel.DeleteTxsInTransact(dbTx, pendingDeletePoolTxs)
+			droppedTxs = append(droppedTxs, skippedTxs...)
+			if len(droppedTxs) > 0 {
+				// The executed txs are replaced or evicted after they are read, their failures
+				// are kept. The states of the block are discarded, the other txs of the block
+				// are put back to the tx pool and executed again without them.
+				return c.bc.TxPoolModel.RevertTxsForHeightGreaterThanInTransact(dbTx, curBlock.BlockHeight-1)
+			}
+			// Keep the failed status of the deleted txs, so that they could be queried. The
+			// ones which are no longer pending already have their failures.
+			skippedTxs, err = c.bc.TxPoolModel.UpdateTxsInTransact(dbTx, pendingDeletePoolTxs)
+			if err != nil {
+				return err
+			}
+			failedTxs := excludeTxs(pendingDeletePoolTxs, skippedTxs)
+			err = c.bc.TxModel.CreateFailedTxsInTransact(dbTx, failedTxs)
+			if err != nil {
+				return err
+			}
+			return c.bc.TxPoolModel.DeleteTxsInTransact(dbTx, failedTxs)
 		})
 		if err != nil {
 			panic("update tx pool failed: " + err.Error())
 		}
 
+		if len(droppedTxs) > 0 {
+			for _, droppedTx := range droppedTxs {
+				logx.Infof("drop tx from block, it is no longer pending, txHash=%s", droppedTx.TxHash)
+			}
+			err = c.bc.DiscardPendingStates()
+			if err != nil {
+				panic("discard pending states failed: " + err.Error())
+			}
+			latestRequestId = -1
+			continue
+		}
+
+		err = c.bc.StateDB().SyncStateCacheToRedis()
+		if err != nil {
+			panic("sync redis cache failed: " + err.Error())
+		}
+
 		if c.shouldCommit(curBlock) {
 			logx.Infof("commit new block, height=%d, blockSize=%d", curBlock.BlockHeight, curBlock.BlockSize)
 			curBlock, err = c.commitNewBlock(curBlock)
@@ -195,6 +278,7 @@ func (c *Committer) Run() {
 
 func (c *Committer) Shutdown() {
 	c.running = false
+	c.evictor.Stop()
 	c.bc.Statedb.Close()
 	c.bc.ChainDB.Close()
 }
@@ -232,15 +316,51 @@ func (c *Committer) restoreExecutedTxs() (*block.Block, error) {
 	return curBlock, nil
 }
 
-func (c *Committer) createNewBlock(curBlock *block.Block, poolTx *tx.Tx) error {
-	return c.bc.DB().DB.Transaction(func(dbTx *gorm.DB) error {
-		err := c.bc.TxPoolModel.UpdateTxsInTransact(dbTx, []*tx.Tx{poolTx})
+// getPendingTxs reads the pending txs from the tx pool in the execution order, see
+// chain.SortPoolTxs for the details.
+func (c *Committer) getPendingTxs() ([]*tx.Tx, error) {
+	pendingTxs, err := c.bc.TxPoolModel.GetTxsByStatus(tx.StatusPending)
+	if err != nil {
+		return nil, err
+	}
+	gasConfig, err := c.bc.StateDB().GetMinGasFees(time.Time{})
+	if err != nil {
+		logx.Errorf("get gas fees failed, execute the txs in the pool order, err: %v", err)
+		return pendingTxs, nil
+	}
+	return chain.SortPoolTxs(pendingTxs, gasConfig), nil
+}
+
+// createNewBlock writes the proposing block with its first executed tx, the block is created
+// even if the tx is skipped since it's no longer pending, see UpdateTxsInTransact.
+func (c *Committer) createNewBlock(curBlock *block.Block, poolTx *tx.Tx) (skippedTxs []*tx.Tx, err error) {
+	err = c.bc.DB().DB.Transaction(func(dbTx *gorm.DB) error {
+		skippedTxs, err = c.bc.TxPoolModel.UpdateTxsInTransact(dbTx, []*tx.Tx{poolTx})
 		if err != nil {
 			return err
 		}
 
 		return c.bc.BlockModel.CreateBlockInTransact(dbTx, curBlock)
 	})
+	return skippedTxs, err
+}
+
+// excludeTxs returns the txs which are not in the excluded ones.
+func excludeTxs(txs []*tx.Tx, excludedTxs []*tx.Tx) []*tx.Tx {
+	if len(excludedTxs) == 0 {
+		return txs
+	}
+	excluded := make(map[uint]bool, len(excludedTxs))
+	for _, excludedTx := range excludedTxs {
+		excluded[excludedTx.ID] = true
+	}
+	keptTxs := make([]*tx.Tx, 0, len(txs))
+	for _, poolTx := range txs {
+		if !excluded[poolTx.ID] {
+			keptTxs = append(keptTxs, poolTx)
+		}
+	}
+	return keptTxs
 }
 
 func (c *Committer) shouldCommit(curBlock *block.Block) bool {
@@ -255,10 +375,12 @@ func (c *Committer) shouldCommit(curBlock *block.Block) bool {
 
 func (c *Committer) commitNewBlock(curBlock *block.Block) (*block.Block, error) {
 	blockSize := c.computeCurrentBlockSize()
+	txsCount := len(c.bc.Statedb.Txs)
 	blockStates, err := c.bc.CommitNewBlock(blockSize, curBlock.CreatedAt.UnixMilli())
 	if err != nil {
 		return nil, err
 	}
+	blockFillRatioMetric.WithLabelValues(strconv.Itoa(blockSize)).Observe(float64(txsCount) / float64(blockSize))
 
 	err = c.bc.Statedb.SyncPendingGasAccount()
 	if err != nil {
@@ -302,6 +424,11 @@ func (c *Committer) commitNewBlock(curBlock *block.Block) (*block.Block, error)
 				return err
 			}
 		}
+		// close the offers settled, canceled or invalidated by the block
+		err = c.closeOffersInTransact(tx, blockStates.Block.Txs, blockStates.PendingAccount, blockStates.PendingNft)
+		if err != nil {
+			return err
+		}
 		// delete txs from tx pool
 		err := c.bc.DB().TxPoolModel.DeleteTxsInTransact(tx, blockStates.Block.Txs)
 		if err != nil {
@@ -318,6 +445,91 @@ func (c *Committer) commitNewBlock(curBlock *block.Block) (*block.Block, error)
 	return blockStates.Block, nil
 }
 
+// closeOffersInTransact closes the offers in the offer book which could never be matched after
+// the txs, i.e. the offers settled by the atomic match txs, the offers canceled, the offers
+// of the nfts which change owners and the buy offers whose buyers spend the balances.
+func (c *Committer) closeOffersInTransact(dbTx *gorm.DB, txs []*tx.Tx, pendingAccounts []*account.Account,
+	pendingNfts []*nft.L2Nft) error {
+	for _, executedTx := range txs {
+		switch executedTx.TxType {
+		case types.TxTypeAtomicMatch:
+			txInfo, err := types.ParseAtomicMatchTxInfo(executedTx.TxInfo)
+			if err != nil {
+				return err
+			}
+			err = c.bc.DB().OfferModel.UpdateOfferStatusInTransact(dbTx, txInfo.BuyOffer.AccountIndex,
+				txInfo.BuyOffer.OfferId, offer.StatusFinalized)
+			if err != nil {
+				return err
+			}
+			err = c.bc.DB().OfferModel.UpdateOfferStatusInTransact(dbTx, txInfo.SellOffer.AccountIndex,
+				txInfo.SellOffer.OfferId, offer.StatusFinalized)
+			if err != nil {
+				return err
+			}
+		case types.TxTypeCancelOffer:
+			txInfo, err := types.ParseCancelOfferTxInfo(executedTx.TxInfo)
+			if err != nil {
+				return err
+			}
+			err = c.bc.DB().OfferModel.UpdateOfferStatusInTransact(dbTx, txInfo.AccountIndex,
+				txInfo.OfferId, offer.StatusCanceled)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, pendingNft := range pendingNfts {
+		ownerAccountIndex := pendingNft.OwnerAccountIndex
+		if pendingNft.NftContentHash == types.EmptyNftContentHash {
+			ownerAccountIndex = types.NilAccountIndex
+		}
+		err := c.bc.DB().OfferModel.InvalidateNftOffersInTransact(dbTx, pendingNft.NftIndex, ownerAccountIndex)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(pendingAccounts) == 0 {
+		return nil
+	}
+	accounts := make(map[int64]*account.Account, len(pendingAccounts))
+	accountIndexes := make([]int64, 0, len(pendingAccounts))
+	for _, pendingAccount := range pendingAccounts {
+		accounts[pendingAccount.AccountIndex] = pendingAccount
+		accountIndexes = append(accountIndexes, pendingAccount.AccountIndex)
+	}
+	buyOffers, err := c.bc.DB().OfferModel.GetOpenBuyOffersInTransact(dbTx, accountIndexes)
+	if err != nil && err != types.DbErrNotFound {
+		return err
+	}
+	formatAccounts := make(map[int64]*types.AccountInfo)
+	for _, buyOffer := range buyOffers {
+		formatAccount, ok := formatAccounts[buyOffer.AccountIndex]
+		if !ok {
+			formatAccount, err = chain.ToFormatAccountInfo(accounts[buyOffer.AccountIndex])
+			if err != nil {
+				return err
+			}
+			formatAccounts[buyOffer.AccountIndex] = formatAccount
+		}
+		amount, ok := new(big.Int).SetString(buyOffer.AssetAmount, 10)
+		if !ok {
+			return fmt.Errorf("invalid asset amount %s of offer %d", buyOffer.AssetAmount, buyOffer.OfferId)
+		}
+		asset, ok := formatAccount.AssetInfo[buyOffer.AssetId]
+		if ok && asset.Balance.Cmp(amount) >= 0 {
+			continue
+		}
+		err = c.bc.DB().OfferModel.UpdateOfferStatusInTransact(dbTx, buyOffer.AccountIndex, buyOffer.OfferId, offer.StatusInvalid)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (c *Committer) computeCurrentBlockSize() int {
 	var blockSize int
 	for i := 0; i < len(c.optionalBlockSizes); i++ {
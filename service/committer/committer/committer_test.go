@@ -0,0 +1,202 @@
+package committer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/zkbnb-crypto/wasm/txtypes"
+	"github.com/bnb-chain/zkbnb/core"
+	"github.com/bnb-chain/zkbnb/core/statedb"
+	"github.com/bnb-chain/zkbnb/dao/account"
+	"github.com/bnb-chain/zkbnb/dao/nft"
+	"github.com/bnb-chain/zkbnb/dao/offer"
+	"github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+// testOfferModel is an in-memory offer book with the same transitions as the offer model.
+type testOfferModel struct {
+	offer.OfferModel
+	offers []*offer.Offer
+}
+
+func (m *testOfferModel) getStatus(accountIndex, offerId int64) int {
+	for _, o := range m.offers {
+		if o.AccountIndex == accountIndex && o.OfferId == offerId {
+			return int(o.Status)
+		}
+	}
+	return -1
+}
+
+func (m *testOfferModel) ExpireOffers(now int64) (count int64, err error) {
+	for _, o := range m.offers {
+		if o.Status == offer.StatusOpen && o.ExpiredAt < now {
+			o.Status = offer.StatusExpired
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *testOfferModel) UpdateOfferStatusInTransact(_ *gorm.DB, accountIndex, offerId, status int64) error {
+	for _, o := range m.offers {
+		if o.AccountIndex == accountIndex && o.OfferId == offerId && o.Status == offer.StatusOpen {
+			o.Status = status
+		}
+	}
+	return nil
+}
+
+func (m *testOfferModel) InvalidateNftOffersInTransact(_ *gorm.DB, nftIndex, ownerAccountIndex int64) error {
+	for _, o := range m.offers {
+		if o.NftIndex != nftIndex || o.Status != offer.StatusOpen {
+			continue
+		}
+		if ownerAccountIndex == types.NilAccountIndex ||
+			(o.OfferType == types.SellOfferType && o.AccountIndex != ownerAccountIndex) ||
+			(o.OfferType == types.BuyOfferType && o.AccountIndex == ownerAccountIndex) {
+			o.Status = offer.StatusInvalid
+		}
+	}
+	return nil
+}
+
+func (m *testOfferModel) GetOpenBuyOffersInTransact(_ *gorm.DB, accountIndexes []int64) (offers []*offer.Offer, err error) {
+	for _, o := range m.offers {
+		if o.OfferType != types.BuyOfferType || o.Status != offer.StatusOpen {
+			continue
+		}
+		for _, accountIndex := range accountIndexes {
+			if o.AccountIndex == accountIndex {
+				offers = append(offers, o)
+			}
+		}
+	}
+	if len(offers) == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return offers, nil
+}
+
+func newTestOffer(offerType, accountIndex, offerId, nftIndex, amount int64) *offer.Offer {
+	return &offer.Offer{
+		OfferType:    offerType,
+		OfferId:      offerId,
+		AccountIndex: accountIndex,
+		NftIndex:     nftIndex,
+		AssetId:      types.BNBAssetId,
+		AssetAmount:  big.NewInt(amount).String(),
+		ExpiredAt:    time.Now().Add(time.Hour).UnixMilli(),
+		Status:       offer.StatusOpen,
+	}
+}
+
+func newTestOfferTx(t *testing.T, txType int64, txInfo interface{}) *tx.Tx {
+	txInfoBytes, err := json.Marshal(txInfo)
+	require.NoError(t, err)
+	return &tx.Tx{TxType: txType, TxInfo: string(txInfoBytes)}
+}
+
+func newTestAccount(t *testing.T, accountIndex, balance int64) *account.Account {
+	assetInfo, err := json.Marshal(map[int64]*types.AccountAsset{
+		types.BNBAssetId: {AssetId: types.BNBAssetId, Balance: big.NewInt(balance), OfferCanceledOrFinalized: big.NewInt(0)},
+	})
+	require.NoError(t, err)
+	return &account.Account{AccountIndex: accountIndex, AssetInfo: string(assetInfo)}
+}
+
+func TestCloseOffersInTransact(t *testing.T) {
+	const (
+		seller = int64(2)
+		buyer  = int64(3)
+		other  = int64(4)
+	)
+	offers := &testOfferModel{offers: []*offer.Offer{
+		newTestOffer(types.SellOfferType, seller, 0, 1, 100),
+		newTestOffer(types.BuyOfferType, buyer, 0, 1, 100),
+		newTestOffer(types.BuyOfferType, other, 0, 1, 90),
+		newTestOffer(types.SellOfferType, seller, 1, 2, 100),
+		newTestOffer(types.BuyOfferType, buyer, 1, 2, 100),
+		newTestOffer(types.SellOfferType, seller, 2, 3, 100),
+		newTestOffer(types.BuyOfferType, other, 1, 3, 100),
+		newTestOffer(types.BuyOfferType, buyer, 2, 4, 200),
+	}}
+	c := &Committer{bc: &core.BlockChain{ChainDB: &statedb.ChainDB{OfferModel: offers}}}
+
+	// The buyer buys nft 1 with an atomic match, and the seller cancels the offer of nft 2.
+	matchTx := newTestOfferTx(t, types.TxTypeAtomicMatch, &txtypes.AtomicMatchTxInfo{
+		BuyOffer:  &txtypes.OfferTxInfo{AccountIndex: buyer, OfferId: 0},
+		SellOffer: &txtypes.OfferTxInfo{AccountIndex: seller, OfferId: 0},
+	})
+	cancelTx := newTestOfferTx(t, types.TxTypeCancelOffer, &txtypes.CancelOfferTxInfo{AccountIndex: seller, OfferId: 1})
+	pendingNfts := []*nft.L2Nft{{NftIndex: 1, OwnerAccountIndex: buyer, NftContentHash: "01"}}
+	// The buyer spends 100 on nft 1, the rest is enough for the offer of nft 2 but not nft 4.
+	pendingAccounts := []*account.Account{newTestAccount(t, seller, 100), newTestAccount(t, buyer, 150)}
+	err := c.closeOffersInTransact(nil, []*tx.Tx{matchTx, cancelTx, {TxType: types.TxTypeTransfer}},
+		pendingAccounts, pendingNfts)
+	require.NoError(t, err)
+	assert.Equal(t, offer.StatusFinalized, offers.getStatus(seller, 0))
+	assert.Equal(t, offer.StatusFinalized, offers.getStatus(buyer, 0))
+	// The other buyer could buy nft 1 from the new owner.
+	assert.Equal(t, offer.StatusOpen, offers.getStatus(other, 0))
+	assert.Equal(t, offer.StatusCanceled, offers.getStatus(seller, 1))
+	assert.Equal(t, offer.StatusOpen, offers.getStatus(buyer, 1))
+	assert.Equal(t, offer.StatusInvalid, offers.getStatus(buyer, 2))
+
+	// The new owner of nft 1 can't buy it, and the sell offer of the previous owner is invalid.
+	offers.offers = append(offers.offers,
+		newTestOffer(types.BuyOfferType, seller, 3, 1, 100),
+		newTestOffer(types.SellOfferType, other, 2, 1, 100),
+	)
+	pendingNfts = []*nft.L2Nft{
+		{NftIndex: 1, OwnerAccountIndex: seller, NftContentHash: "01"},
+		// nft 3 is withdrawn
+		{NftIndex: 3, OwnerAccountIndex: types.NilAccountIndex, NftContentHash: types.EmptyNftContentHash},
+	}
+	err = c.closeOffersInTransact(nil, nil, nil, pendingNfts)
+	require.NoError(t, err)
+	assert.Equal(t, offer.StatusInvalid, offers.getStatus(seller, 3))
+	assert.Equal(t, offer.StatusInvalid, offers.getStatus(other, 2))
+	assert.Equal(t, offer.StatusOpen, offers.getStatus(other, 0))
+	assert.Equal(t, offer.StatusInvalid, offers.getStatus(seller, 2))
+	assert.Equal(t, offer.StatusInvalid, offers.getStatus(other, 1))
+
+	// The canceled offer stays closed, and the buy offer is closed when the buyer spends the balance.
+	err = c.closeOffersInTransact(nil, []*tx.Tx{cancelTx}, []*account.Account{newTestAccount(t, buyer, 0)}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, offer.StatusCanceled, offers.getStatus(seller, 1))
+	assert.Equal(t, offer.StatusInvalid, offers.getStatus(buyer, 1))
+}
+
+func TestEvictorExpireOffers(t *testing.T) {
+	offers := &testOfferModel{offers: []*offer.Offer{
+		newTestOffer(types.SellOfferType, 2, 0, 1, 100),
+		newTestOffer(types.BuyOfferType, 3, 0, 1, 100),
+		newTestOffer(types.BuyOfferType, 3, 1, 2, 100),
+	}}
+	offers.offers[0].ExpiredAt = time.Now().Add(-time.Second).UnixMilli()
+	offers.offers[2].ExpiredAt = time.Now().Add(-time.Second).UnixMilli()
+	offers.offers[2].Status = offer.StatusCanceled
+	e := newEvictor(&Config{}, nil, nil, offers)
+
+	require.NoError(t, e.expireOffers())
+	assert.Equal(t, offer.StatusExpired, offers.getStatus(2, 0))
+	assert.Equal(t, offer.StatusOpen, offers.getStatus(3, 0))
+	assert.Equal(t, offer.StatusCanceled, offers.getStatus(3, 1))
+}
+
+func TestApplyFailureReason(t *testing.T) {
+	assert.Equal(t, "25003", applyFailureReason(types.AppErrInvalidGasAsset))
+	assert.Equal(t, "20002", applyFailureReason(types.AppErrInvalidTxField.RefineError("invalid nonce 12, expected 11")))
+	assert.Equal(t, "20002", applyFailureReason(fmt.Errorf("verify inputs: %w", types.AppErrInvalidTxField)))
+	assert.Equal(t, "internal", applyFailureReason(errors.New("balance is not enough")))
+}
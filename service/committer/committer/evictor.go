@@ -0,0 +1,118 @@
+package committer
+
+import (
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb/common/chain"
+	"github.com/bnb-chain/zkbnb/dao/account"
+	"github.com/bnb-chain/zkbnb/dao/offer"
+	"github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+const defaultEvictInterval = 10
+
+// evictor removes the pending txs which could never be executed or exceed the limits from
+// the tx pool in background, so that they don't occupy the tx pool until the committer
+// reaches them. The evicted txs are kept as failed txs with the fail reasons. The expired
+// offers are closed in the offer book too.
+type evictor struct {
+	txPoolModel             tx.TxPoolModel
+	accountModel            account.AccountModel
+	offerModel              offer.OfferModel
+	interval                time.Duration
+	maxPendingTxsPerAccount int
+
+	quitCh chan struct{}
+}
+
+func newEvictor(config *Config, txPoolModel tx.TxPoolModel, accountModel account.AccountModel, offerModel offer.OfferModel) *evictor {
+	interval := config.TxPool.EvictInterval
+	if interval <= 0 {
+		interval = defaultEvictInterval
+	}
+	return &evictor{
+		txPoolModel:             txPoolModel,
+		accountModel:            accountModel,
+		offerModel:              offerModel,
+		interval:                time.Duration(interval) * time.Second,
+		maxPendingTxsPerAccount: config.TxPool.MaxPendingTxsPerAccount,
+		quitCh:                  make(chan struct{}),
+	}
+}
+
+func (e *evictor) Run() {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			err := e.evict()
+			if err != nil {
+				logx.Errorf("evict pool txs failed: %v", err)
+			}
+			err = e.expireOffers()
+			if err != nil {
+				logx.Errorf("expire offers failed: %v", err)
+			}
+		case <-e.quitCh:
+			return
+		}
+	}
+}
+
+func (e *evictor) Stop() {
+	close(e.quitCh)
+}
+
+func (e *evictor) evict() error {
+	pendingTxs, err := e.txPoolModel.GetTxsByStatus(tx.StatusPending)
+	if err != nil {
+		return err
+	}
+
+	// The nonces of the committed accounts lag behind the executed ones, so the txs being
+	// executed are never evicted for the low nonces.
+	committedNonces := make(map[int64]int64)
+	for _, poolTx := range pendingTxs {
+		if types.IsPriorityOperationTx(poolTx.TxType) {
+			continue
+		}
+		if _, ok := committedNonces[poolTx.AccountIndex]; ok {
+			continue
+		}
+		committedAccount, err := e.accountModel.GetAccountByIndex(poolTx.AccountIndex)
+		if err != nil {
+			if err == types.DbErrNotFound {
+				continue
+			}
+			return err
+		}
+		committedNonces[poolTx.AccountIndex] = committedAccount.Nonce
+	}
+
+	evictedTxs := chain.SelectEvictedTxs(pendingTxs, committedNonces, time.Now().UnixMilli(), e.maxPendingTxsPerAccount)
+	if len(evictedTxs) == 0 {
+		return nil
+	}
+	count, err := e.txPoolModel.EvictTxs(evictedTxs)
+	if err != nil {
+		return err
+	}
+	logx.Infof("evict %d pool txs", count)
+	return nil
+}
+
+// expireOffers closes the open offers in the offer book which are expired.
+func (e *evictor) expireOffers() error {
+	count, err := e.offerModel.ExpireOffers(time.Now().UnixMilli())
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		logx.Infof("expire %d offers", count)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package committer
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/bnb-chain/zkbnb/common/health"
+	"github.com/bnb-chain/zkbnb/dao/block"
+)
+
+// RegisterHealthChecks registers the checks of the main loop, the committer is not ready if it
+// stays on a block with executed txs for too long, which is committed in MaxCommitterInterval
+// normally.
+func (c *Committer) RegisterHealthChecks(s *health.Server) {
+	maxBlockDuration := time.Duration(c.config.Health.MaxBlockDuration) * time.Second
+	if maxBlockDuration <= 0 {
+		maxBlockDuration = 10 * MaxCommitterInterval * time.Second
+	}
+
+	s.AddLivenessCheck("loop", func() error {
+		heartbeat := atomic.LoadInt64(&c.heartbeat)
+		if heartbeat == 0 {
+			return nil
+		}
+		if d := time.Since(time.Unix(0, heartbeat)); d > maxBlockDuration {
+			return fmt.Errorf("the main loop is blocked for %s", d.Truncate(time.Second))
+		}
+		return nil
+	})
+	s.AddReadinessCheck("block", func() error {
+		since := atomic.LoadInt64(&c.blockSince)
+		if since == 0 {
+			return errors.New("the executed txs are not restored yet")
+		}
+		if d := time.Since(time.Unix(0, since)); d > maxBlockDuration {
+			return fmt.Errorf("stuck on block %d for %s", atomic.LoadInt64(&c.blockHeight), d.Truncate(time.Second))
+		}
+		return nil
+	})
+}
+
+// markProgress records the liveness of the main loop, and the time since the current block
+// executes its first tx.
+func (c *Committer) markProgress(curBlock *block.Block) {
+	now := time.Now().UnixNano()
+	atomic.StoreInt64(&c.heartbeat, now)
+	if len(c.bc.Statedb.Txs) == 0 || atomic.LoadInt64(&c.blockHeight) != curBlock.BlockHeight {
+		atomic.StoreInt64(&c.blockHeight, curBlock.BlockHeight)
+		atomic.StoreInt64(&c.blockSince, now)
+	}
+}
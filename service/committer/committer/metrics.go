@@ -0,0 +1,72 @@
+package committer
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/bnb-chain/zkbnb/core"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+var (
+	priorityOperationMetric = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "zkbnb",
+		Name:      "priority_operation_process",
+		Help:      "Priority operation requestID metrics.",
+	})
+	priorityOperationHeightMetric = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "zkbnb",
+		Name:      "priority_operation_process_height",
+		Help:      "Priority operation height metrics.",
+	})
+	appliedTxsMetric = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "zkbnb",
+		Subsystem: "committer",
+		Name:      "applied_txs_total",
+		Help:      "Number of the txs applied to the blocks.",
+	})
+	applyFailuresMetric = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "zkbnb",
+		Subsystem: "committer",
+		Name:      "apply_failures_total",
+		Help:      "Number of the txs failed to be applied, by tx type and error code.",
+	}, []string{"tx_type", "reason"})
+	blockFillRatioMetric = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "zkbnb",
+		Subsystem: "committer",
+		Name:      "block_fill_ratio",
+		Help:      "Ratio of the txs to the size of the committed blocks, by block size.",
+		Buckets:   prometheus.LinearBuckets(0.1, 0.1, 10),
+	}, []string{"block_size"})
+)
+
+func registerMetrics() error {
+	metrics := map[string]prometheus.Collector{
+		"priorityOperationMetric":       priorityOperationMetric,
+		"priorityOperationHeightMetric": priorityOperationHeightMetric,
+		"appliedTxsMetric":              appliedTxsMetric,
+		"applyFailuresMetric":           applyFailuresMetric,
+		"blockFillRatioMetric":          blockFillRatioMetric,
+		"treeCommitLatencyMetric":       core.TreeCommitLatencyMetric,
+	}
+	for name, metric := range metrics {
+		if err := prometheus.Register(metric); err != nil {
+			return fmt.Errorf("prometheus.Register %s error: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// applyFailureReason returns the reason label of applyFailuresMetric for the error, which is
+// the code of the error if it has one, otherwise internal. The error messages are refined with
+// the tx fields, so they're logged instead of being used as the labels.
+func applyFailureReason(err error) string {
+	var codeErr types.Error
+	if errors.As(err, &codeErr) {
+		return strconv.FormatInt(int64(codeErr.Code()), 10)
+	}
+	return "internal"
+}
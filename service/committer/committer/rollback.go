@@ -0,0 +1,248 @@
+package committer
+
+import (
+	"encoding/json"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/zkbnb/dao/account"
+	"github.com/bnb-chain/zkbnb/dao/nft"
+	"github.com/bnb-chain/zkbnb/dao/rollback"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+// rollback handles the blocks reverted on L1, it rolls back the database to the latest
+// kept block, puts the reverted txs back to the tx pool and then rewinds the trees.
+// It returns true if the chain is rolled back.
+func (c *Committer) rollback() (bool, error) {
+	rollbacks, err := c.bc.RollbackModel.GetCommitterPendingRollbacks()
+	if err != nil {
+		if err == types.DbErrNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+
+	height := rollbacks[0].BlockHeight
+	for _, r := range rollbacks {
+		if r.BlockHeight < height {
+			height = r.BlockHeight
+		}
+	}
+	logx.Infof("rollback blocks, height=%d", height)
+
+	accountIndexes, assetIds, nftIndexes, err := c.getRevertedStates(height, rollbacks)
+	if err != nil {
+		return false, err
+	}
+
+	accountIndexesBytes, err := json.Marshal(accountIndexes)
+	if err != nil {
+		return false, err
+	}
+	assetIdsBytes, err := json.Marshal(assetIds)
+	if err != nil {
+		return false, err
+	}
+	nftIndexesBytes, err := json.Marshal(nftIndexes)
+	if err != nil {
+		return false, err
+	}
+	for _, r := range rollbacks {
+		r.BlockHeight = height
+		r.AccountIndexes = string(accountIndexesBytes)
+		r.AssetIds = string(assetIdsBytes)
+		r.NftIndexes = string(nftIndexesBytes)
+	}
+
+	pendingUpdateAccounts, pendingDeleteAccounts, err := c.getRollbackAccounts(height, accountIndexes)
+	if err != nil {
+		return false, err
+	}
+	pendingUpdateNfts, pendingDeleteNfts, err := c.getRollbackNfts(height, nftIndexes)
+	if err != nil {
+		return false, err
+	}
+
+	// The reverted states are recorded in the same transaction, so the trees could
+	// still be rewound if the committer is restarted before they are rewound.
+	err = c.bc.DB().DB.Transaction(func(tx *gorm.DB) error {
+		err := c.bc.TxPoolModel.RevertTxsForHeightGreaterThanInTransact(tx, height)
+		if err != nil {
+			return err
+		}
+		err = c.bc.TxModel.DeleteTxsForHeightGreaterThanInTransact(tx, height)
+		if err != nil {
+			return err
+		}
+		err = c.bc.BlockModel.DeleteBlocksForHeightGreaterThanInTransact(tx, height)
+		if err != nil {
+			return err
+		}
+		err = c.bc.CompressedBlockModel.DeleteCompressedBlocksForHeightGreaterThanInTransact(tx, height)
+		if err != nil {
+			return err
+		}
+		if len(pendingUpdateAccounts) != 0 {
+			err = c.bc.AccountModel.UpdateAccountsInTransact(tx, pendingUpdateAccounts)
+			if err != nil {
+				return err
+			}
+		}
+		if len(pendingDeleteAccounts) != 0 {
+			err = c.bc.AccountModel.DeleteAccountsInTransact(tx, pendingDeleteAccounts)
+			if err != nil {
+				return err
+			}
+		}
+		err = c.bc.AccountHistoryModel.DeleteAccountHistoriesForHeightGreaterThanInTransact(tx, height)
+		if err != nil {
+			return err
+		}
+		if len(pendingUpdateNfts) != 0 {
+			err = c.bc.L2NftModel.UpdateNftsInTransact(tx, pendingUpdateNfts)
+			if err != nil {
+				return err
+			}
+		}
+		if len(pendingDeleteNfts) != 0 {
+			err = c.bc.L2NftModel.DeleteNftsInTransact(tx, pendingDeleteNfts)
+			if err != nil {
+				return err
+			}
+		}
+		err = c.bc.L2NftHistoryModel.DeleteNftHistoriesForHeightGreaterThanInTransact(tx, height)
+		if err != nil {
+			return err
+		}
+		return c.bc.RollbackModel.UpdateRollbacksInTransact(tx, rollbacks)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	err = c.bc.RollBack(height, accountIndexes, assetIds, nftIndexes)
+	if err != nil {
+		return false, err
+	}
+
+	for _, r := range rollbacks {
+		r.CommitterStatus = rollback.StatusHandled
+	}
+	err = c.bc.DB().DB.Transaction(func(tx *gorm.DB) error {
+		return c.bc.RollbackModel.UpdateRollbacksInTransact(tx, rollbacks)
+	})
+	if err != nil {
+		return false, err
+	}
+	logx.Infof("rollback blocks success, height=%d", height)
+	return true, nil
+}
+
+// getRevertedStates returns the accounts, assets and nfts updated by the blocks above
+// the height, including the ones recorded by a previous unfinished rollback.
+func (c *Committer) getRevertedStates(height int64, rollbacks []*rollback.Rollback) (
+	accountIndexes, assetIds, nftIndexes []int64, err error) {
+	accountIndexMap := make(map[int64]bool)
+	assetIdMap := make(map[int64]bool)
+	nftIndexMap := make(map[int64]bool)
+
+	for _, r := range rollbacks {
+		err = rollback.UnmarshalIndexes(r.AccountIndexes, accountIndexMap)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		err = rollback.UnmarshalIndexes(r.AssetIds, assetIdMap)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		err = rollback.UnmarshalIndexes(r.NftIndexes, nftIndexMap)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	accountHistories, err := c.bc.AccountHistoryModel.GetAccountHistoriesForHeightGreaterThan(height)
+	if err != nil && err != types.DbErrNotFound {
+		return nil, nil, nil, err
+	}
+	for _, accountHistory := range accountHistories {
+		accountIndexMap[accountHistory.AccountIndex] = true
+		assets := make(map[int64]*types.AccountAsset)
+		err = json.Unmarshal([]byte(accountHistory.AssetInfo), &assets)
+		if err != nil {
+			return nil, nil, nil, types.JsonErrUnmarshal
+		}
+		for assetId := range assets {
+			assetIdMap[assetId] = true
+		}
+	}
+
+	nftHistories, err := c.bc.L2NftHistoryModel.GetNftHistoriesForHeightGreaterThan(height)
+	if err != nil && err != types.DbErrNotFound {
+		return nil, nil, nil, err
+	}
+	for _, nftHistory := range nftHistories {
+		nftIndexMap[nftHistory.NftIndex] = true
+	}
+
+	return rollback.SortedIndexes(accountIndexMap), rollback.SortedIndexes(assetIdMap), rollback.SortedIndexes(nftIndexMap), nil
+}
+
+// getRollbackAccounts returns the accounts restored from the histories at the height,
+// and the accounts created by the reverted blocks which should be deleted.
+func (c *Committer) getRollbackAccounts(height int64, accountIndexes []int64) (
+	pendingUpdateAccounts []*account.Account, pendingDeleteAccounts []int64, err error) {
+	for _, accountIndex := range accountIndexes {
+		accountHistory, err := c.bc.AccountHistoryModel.GetLatestAccountHistory(accountIndex, height+1)
+		if err == types.DbErrNotFound {
+			pendingDeleteAccounts = append(pendingDeleteAccounts, accountIndex)
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		accountInfo, err := c.bc.AccountModel.GetAccountByIndex(accountIndex)
+		if err != nil {
+			return nil, nil, err
+		}
+		accountInfo.Nonce = accountHistory.Nonce
+		accountInfo.CollectionNonce = accountHistory.CollectionNonce
+		accountInfo.AssetInfo = accountHistory.AssetInfo
+		accountInfo.AssetRoot = accountHistory.AssetRoot
+		pendingUpdateAccounts = append(pendingUpdateAccounts, accountInfo)
+	}
+	return pendingUpdateAccounts, pendingDeleteAccounts, nil
+}
+
+// getRollbackNfts returns the nfts restored from the histories at the height, and the
+// nfts created by the reverted blocks which should be deleted.
+func (c *Committer) getRollbackNfts(height int64, nftIndexes []int64) (
+	pendingUpdateNfts []*nft.L2Nft, pendingDeleteNfts []int64, err error) {
+	for _, nftIndex := range nftIndexes {
+		nftHistory, err := c.bc.L2NftHistoryModel.GetLatestNftHistory(nftIndex, height+1)
+		if err == types.DbErrNotFound {
+			pendingDeleteNfts = append(pendingDeleteNfts, nftIndex)
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		nftInfo, err := c.bc.L2NftModel.GetNft(nftIndex)
+		if err != nil {
+			return nil, nil, err
+		}
+		nftInfo.CreatorAccountIndex = nftHistory.CreatorAccountIndex
+		nftInfo.OwnerAccountIndex = nftHistory.OwnerAccountIndex
+		nftInfo.NftContentHash = nftHistory.NftContentHash
+		nftInfo.NftL1Address = nftHistory.NftL1Address
+		nftInfo.NftL1TokenId = nftHistory.NftL1TokenId
+		nftInfo.CreatorTreasuryRate = nftHistory.CreatorTreasuryRate
+		nftInfo.CollectionId = nftHistory.CollectionId
+		pendingUpdateNfts = append(pendingUpdateNfts, nftInfo)
+	}
+	return pendingUpdateNfts, pendingDeleteNfts, nil
+}
@@ -2,6 +2,9 @@ package config
 
 import (
 	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/prometheus"
+
+	"github.com/bnb-chain/zkbnb/common/health"
 )
 
 type Config struct {
@@ -15,6 +18,14 @@ type Config struct {
 		MaxHandledBlocksCount   int64
 		KeptHistoryBlocksCount  int64 // KeptHistoryBlocksCount define the count of blocks to keep in table, old blocks will be cleaned
 	}
+	//nolint:staticcheck
+	Prometheus prometheus.Config `json:",optional"`
+	//nolint:staticcheck
+	Health struct {
+		health.Config
+		// Max L1 blocks the synced height trails the head, 10 times of MaxHandledBlocksCount by default
+		MaxL1Lag int64 `json:",optional"`
+	} `json:",optional"`
 	LogConf logx.LogConf
 }
 
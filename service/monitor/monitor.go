@@ -7,7 +7,9 @@ import (
 	"github.com/zeromicro/go-zero/core/conf"
 	"github.com/zeromicro/go-zero/core/logx"
 	"github.com/zeromicro/go-zero/core/proc"
+	"github.com/zeromicro/go-zero/core/prometheus"
 
+	"github.com/bnb-chain/zkbnb/common/health"
 	"github.com/bnb-chain/zkbnb/service/monitor/config"
 	"github.com/bnb-chain/zkbnb/service/monitor/monitor"
 )
@@ -20,8 +22,12 @@ func Run(configFile string) error {
 	c.Validate()
 	logx.MustSetup(c.LogConf)
 	logx.DisableStat()
+	prometheus.StartAgent(c.Prometheus)
 
 	m := monitor.NewMonitor(c)
+	healthServer := health.NewServer(c.Health.Config)
+	m.RegisterHealthChecks(healthServer)
+	healthServer.Start()
 	cronJob := cron.New(cron.WithChain(
 		cron.SkipIfStillRunning(cron.DiscardLogger),
 	))
@@ -0,0 +1,47 @@
+package monitor
+
+import (
+	"fmt"
+
+	"github.com/bnb-chain/zkbnb/common/health"
+	"github.com/bnb-chain/zkbnb/dao/l1syncedblock"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+// RegisterHealthChecks registers the checks of the database, the L1 rpc, how far the synced
+// L1 height trails the L1 chain head, and whether the monitor is halted by a L1 reorg.
+func (m *Monitor) RegisterHealthChecks(s *health.Server) {
+	maxLag := m.Config.Health.MaxL1Lag
+	if maxLag <= 0 {
+		maxLag = 10 * m.Config.ChainConfig.MaxHandledBlocksCount
+	}
+
+	s.AddReadinessCheck("database", health.DatabaseCheck(m.db))
+	s.AddReadinessCheck("l1_reorg", m.haltError)
+	s.AddReadinessCheck("l1_rpc", func() error {
+		_, err := m.cli.GetHeight()
+		return err
+	})
+	s.AddReadinessCheck("l1_lag", func() error {
+		latestHeight, err := m.cli.GetHeight()
+		if err != nil {
+			return fmt.Errorf("unable to get the l1 height: %v", err)
+		}
+		for _, monitorType := range []int{l1syncedblock.TypeGeneric, l1syncedblock.TypeGovernance} {
+			syncedHeight := m.Config.ChainConfig.StartL1BlockHeight
+			syncedBlock, err := m.L1SyncedBlockModel.GetLatestL1SyncedBlockByType(monitorType)
+			if err != nil && err != types.DbErrNotFound {
+				return fmt.Errorf("unable to get the synced l1 height: %v", err)
+			}
+			if syncedBlock != nil {
+				syncedHeight = syncedBlock.L1BlockHeight
+			}
+			lag := int64(latestHeight) - int64(m.Config.ChainConfig.ConfirmBlocksCount) - syncedHeight
+			if lag > maxLag {
+				return fmt.Errorf("the synced l1 height %d trails the head %d by %d blocks, more than %d",
+					syncedHeight, latestHeight, lag, maxLag)
+			}
+		}
+		return nil
+	})
+}
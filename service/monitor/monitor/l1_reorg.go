@@ -0,0 +1,186 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/zkbnb/dao/l1syncedblock"
+	"github.com/bnb-chain/zkbnb/dao/priorityrequest"
+	"github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+// checkL1Reorg checks that the next block to sync is the child of the latest synced one,
+// otherwise the synced blocks are reorged and they are rewound to the fork point.
+func (m *Monitor) checkL1Reorg(monitorType int, latestSyncedBlock *l1syncedblock.L1SyncedBlock, nextHeight int64) error {
+	if latestSyncedBlock == nil || latestSyncedBlock.L1BlockHash == "" {
+		return nil
+	}
+	header, err := m.cli.GetBlockHeaderByNumber(big.NewInt(nextHeight))
+	if err != nil {
+		return fmt.Errorf("failed to get block header, err: %v", err)
+	}
+	if header.ParentHash.Hex() == latestSyncedBlock.L1BlockHash {
+		return nil
+	}
+	logx.Errorf("l1 reorg detected, the parent of block %d is %s, but block %d is synced as %s",
+		nextHeight, header.ParentHash.Hex(), latestSyncedBlock.L1BlockHeight, latestSyncedBlock.L1BlockHash)
+	forkHeight, err := m.rewindL1SyncedBlocks(monitorType)
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("l1 reorg detected, the synced blocks are rewound to %d", forkHeight)
+}
+
+// rewindL1SyncedBlocks deletes the synced blocks which are no longer on the L1 chain, and the
+// priority requests in them, so that they are synced again. It halts the monitor instead if
+// the rewound blocks contain the changes which cannot be reverted, i.e. the priority requests
+// already executed by the committer, or the governance events.
+func (m *Monitor) rewindL1SyncedBlocks(monitorType int) (int64, error) {
+	syncedBlocks, err := m.L1SyncedBlockModel.GetL1SyncedBlocksByType(monitorType)
+	if err != nil && err != types.DbErrNotFound {
+		return 0, fmt.Errorf("failed to get synced blocks, err: %v", err)
+	}
+	forkHeight := m.Config.ChainConfig.StartL1BlockHeight
+	var rewoundBlocks []*l1syncedblock.L1SyncedBlock
+	for _, syncedBlock := range syncedBlocks {
+		// the blocks synced before the hash is recorded are trusted
+		if syncedBlock.L1BlockHash == "" {
+			forkHeight = syncedBlock.L1BlockHeight
+			break
+		}
+		header, err := m.cli.GetBlockHeaderByNumber(big.NewInt(syncedBlock.L1BlockHeight))
+		if err != nil {
+			return 0, fmt.Errorf("failed to get block header, err: %v", err)
+		}
+		if header.Hash().Hex() == syncedBlock.L1BlockHash {
+			forkHeight = syncedBlock.L1BlockHeight
+			break
+		}
+		rewoundBlocks = append(rewoundBlocks, syncedBlock)
+	}
+
+	var rewoundEvents []*L1Event
+	for _, rewoundBlock := range rewoundBlocks {
+		var events []*L1Event
+		if err := json.Unmarshal([]byte(rewoundBlock.BlockInfo), &events); err != nil {
+			return 0, fmt.Errorf("failed to unmarshal the events of synced block %d, err: %v", rewoundBlock.L1BlockHeight, err)
+		}
+		rewoundEvents = append(rewoundEvents, events...)
+	}
+	if monitorType == l1syncedblock.TypeGovernance {
+		if len(rewoundEvents) > 0 {
+			return 0, m.halt("l1 reorg below block %d reverts %d governance events, the assets and the sys configs "+
+				"should be fixed manually, the first reverted event is in tx %s", forkHeight, len(rewoundEvents), rewoundEvents[0].TxHash)
+		}
+		err = m.db.Transaction(func(dbTx *gorm.DB) error {
+			return m.L1SyncedBlockModel.DeleteL1SyncedBlocksForHeightGreaterThanInTransact(dbTx, monitorType, forkHeight)
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to rewind synced blocks, err: %v", err)
+		}
+		logx.Infof("governance synced blocks are rewound to %d", forkHeight)
+		return forkHeight, nil
+	}
+
+	// stop creating the pool txs of the requests while they are rewound
+	m.priorityRequestLock.Lock()
+	defer m.priorityRequestLock.Unlock()
+
+	requests, err := m.PriorityRequestModel.GetPriorityRequestsForL1HeightGreaterThan(forkHeight)
+	if err != nil && err != types.DbErrNotFound {
+		return 0, fmt.Errorf("failed to get priority requests, err: %v", err)
+	}
+	var pendingDeletePoolTxs []*tx.Tx
+	for _, request := range requests {
+		if request.Status != priorityrequest.HandledStatus {
+			continue
+		}
+		poolTx, err := m.TxPoolModel.GetTxByTxHash(request.L2TxHash)
+		if err != nil && err != types.DbErrNotFound {
+			return 0, fmt.Errorf("failed to get pool tx, err: %v", err)
+		}
+		if err == types.DbErrNotFound || poolTx.TxStatus != tx.StatusPending || poolTx.DeletedAt.Valid {
+			return 0, m.halt("l1 reorg below block %d reverts the priority request %d in l1 tx %s at block %d, "+
+				"which is already executed as l2 tx %s, the l2 state should be fixed manually",
+				forkHeight, request.RequestId, request.L1TxHash, request.L1BlockHeight, request.L2TxHash)
+		}
+		pendingDeletePoolTxs = append(pendingDeletePoolTxs, poolTx)
+	}
+	// the withdrawals are recorded again when the verify txs are synced again
+	var verifyTxHashes []string
+	for _, event := range rewoundEvents {
+		if event.EventType == EventTypeVerifiedBlock {
+			verifyTxHashes = append(verifyTxHashes, event.TxHash)
+		}
+	}
+
+	err = m.db.Transaction(func(dbTx *gorm.DB) error {
+		err := m.L1SyncedBlockModel.DeleteL1SyncedBlocksForHeightGreaterThanInTransact(dbTx, monitorType, forkHeight)
+		if err != nil {
+			return err
+		}
+		err = m.PriorityRequestModel.DeletePriorityRequestsForL1HeightGreaterThanInTransact(dbTx, forkHeight)
+		if err != nil {
+			return err
+		}
+		// The txs are created again with the same hashes if the requests are mined again,
+		// and the committer fails to execute the deleted ones.
+		err = m.TxPoolModel.PurgePendingTxsInTransact(dbTx, pendingDeletePoolTxs)
+		if err != nil {
+			return err
+		}
+		return m.WithdrawalModel.DeleteWithdrawalsByL1TxHashesInTransact(dbTx, verifyTxHashes)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to rewind synced blocks, err: %v", err)
+	}
+	logx.Infof("generic synced blocks are rewound to %d, %d priority requests and %d pool txs are deleted",
+		forkHeight, len(requests), len(pendingDeletePoolTxs))
+	return forkHeight, nil
+}
+
+// getL1BlockHash returns the hash of the block to be recorded with the synced block.
+func (m *Monitor) getL1BlockHash(height int64) (string, error) {
+	header, err := m.cli.GetBlockHeaderByNumber(big.NewInt(height))
+	if err != nil {
+		return "", fmt.Errorf("failed to get block header, err: %v", err)
+	}
+	return header.Hash().Hex(), nil
+}
+
+// halt stops the monitor until it is fixed manually, the reason is reported by the health
+// checks as well.
+func (m *Monitor) halt(format string, args ...interface{}) error {
+	reason := fmt.Sprintf(format, args...)
+	logx.Severef("monitor is halted: %s", reason)
+	m.haltReason.Store(reason)
+	return fmt.Errorf("monitor is halted: %s", reason)
+}
+
+func (m *Monitor) haltError() error {
+	if reason, ok := m.haltReason.Load().(string); ok {
+		return fmt.Errorf("monitor is halted: %s", reason)
+	}
+	return nil
+}
@@ -0,0 +1,422 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package monitor
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/zkbnb/common/l1"
+	"github.com/bnb-chain/zkbnb/dao/l1syncedblock"
+	"github.com/bnb-chain/zkbnb/dao/priorityrequest"
+	"github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/dao/withdrawal"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+// testConnPool only begins and ends the transactions, the models of the tests don't run sql.
+type testConnPool struct {
+	commits   int
+	rollbacks int
+}
+
+var errTestConnPool = errors.New("no sql in the tests")
+
+func (p *testConnPool) PrepareContext(context.Context, string) (*sql.Stmt, error) {
+	return nil, errTestConnPool
+}
+
+func (p *testConnPool) ExecContext(context.Context, string, ...interface{}) (sql.Result, error) {
+	return nil, errTestConnPool
+}
+
+func (p *testConnPool) QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error) {
+	return nil, errTestConnPool
+}
+
+func (p *testConnPool) QueryRowContext(context.Context, string, ...interface{}) *sql.Row {
+	return nil
+}
+
+func (p *testConnPool) BeginTx(context.Context, *sql.TxOptions) (gorm.ConnPool, error) {
+	return &testTx{testConnPool: p}, nil
+}
+
+type testTx struct {
+	*testConnPool
+}
+
+func (tx *testTx) Commit() error {
+	tx.commits++
+	return nil
+}
+
+func (tx *testTx) Rollback() error {
+	tx.rollbacks++
+	return nil
+}
+
+// testChain returns the headers of the canonical L1 chain.
+type testChain struct {
+	l1.Provider
+	headers []*ethTypes.Header
+}
+
+func newTestChain(height int64) *testChain {
+	c := &testChain{}
+	c.fork(0, height, 0)
+	return c
+}
+
+// fork replaces the blocks above the height with new ones up to the new height.
+func (c *testChain) fork(height, newHeight int64, tag byte) {
+	c.headers = c.headers[:height]
+	for i := height; i < newHeight; i++ {
+		header := &ethTypes.Header{Number: big.NewInt(i + 1), Extra: []byte{tag}, Difficulty: big.NewInt(1)}
+		if i > 0 {
+			header.ParentHash = c.headers[i-1].Hash()
+		}
+		c.headers = append(c.headers, header)
+	}
+}
+
+func (c *testChain) GetHeight() (uint64, error) {
+	return uint64(len(c.headers)), nil
+}
+
+func (c *testChain) GetBlockHeaderByNumber(number *big.Int) (*ethTypes.Header, error) {
+	if number.Int64() < 1 || number.Int64() > int64(len(c.headers)) {
+		return nil, errors.New("not found")
+	}
+	return c.headers[number.Int64()-1], nil
+}
+
+type testL1SyncedBlockModel struct {
+	l1syncedblock.L1SyncedBlockModel
+	blocks []*l1syncedblock.L1SyncedBlock
+}
+
+func (m *testL1SyncedBlockModel) GetLatestL1SyncedBlockByType(blockType int) (*l1syncedblock.L1SyncedBlock, error) {
+	blocks, err := m.GetL1SyncedBlocksByType(blockType)
+	if err != nil {
+		return nil, err
+	}
+	return blocks[0], nil
+}
+
+func (m *testL1SyncedBlockModel) GetL1SyncedBlocksByType(blockType int) ([]*l1syncedblock.L1SyncedBlock, error) {
+	var blocks []*l1syncedblock.L1SyncedBlock
+	for i := len(m.blocks) - 1; i >= 0; i-- {
+		if m.blocks[i].Type == blockType {
+			blocks = append(blocks, m.blocks[i])
+		}
+	}
+	if len(blocks) == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return blocks, nil
+}
+
+func (m *testL1SyncedBlockModel) DeleteL1SyncedBlocksForHeightGreaterThanInTransact(_ *gorm.DB, blockType int, height int64) error {
+	blocks := m.blocks[:0]
+	for _, block := range m.blocks {
+		if block.Type != blockType || block.L1BlockHeight <= height {
+			blocks = append(blocks, block)
+		}
+	}
+	m.blocks = blocks
+	return nil
+}
+
+type testPriorityRequestModel struct {
+	priorityrequest.PriorityRequestModel
+	requests []*priorityrequest.PriorityRequest
+}
+
+func (m *testPriorityRequestModel) GetPriorityRequestsForL1HeightGreaterThan(height int64) ([]*priorityrequest.PriorityRequest, error) {
+	var requests []*priorityrequest.PriorityRequest
+	for _, request := range m.requests {
+		if request.L1BlockHeight > height {
+			requests = append(requests, request)
+		}
+	}
+	if len(requests) == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return requests, nil
+}
+
+func (m *testPriorityRequestModel) DeletePriorityRequestsForL1HeightGreaterThanInTransact(_ *gorm.DB, height int64) error {
+	requests := m.requests[:0]
+	for _, request := range m.requests {
+		if request.L1BlockHeight <= height {
+			requests = append(requests, request)
+		}
+	}
+	m.requests = requests
+	return nil
+}
+
+type testTxPoolModel struct {
+	tx.TxPoolModel
+	txs    []*tx.Tx
+	purged []uint
+}
+
+func (m *testTxPoolModel) GetTxByTxHash(hash string) (*tx.Tx, error) {
+	for _, poolTx := range m.txs {
+		if poolTx.TxHash == hash {
+			return poolTx, nil
+		}
+	}
+	return nil, types.DbErrNotFound
+}
+
+func (m *testTxPoolModel) DeleteTxsInTransact(*gorm.DB, []*tx.Tx) error {
+	return errors.New("the rewound pool txs should be purged")
+}
+
+func (m *testTxPoolModel) PurgePendingTxsInTransact(_ *gorm.DB, txs []*tx.Tx) error {
+	for _, purgedTx := range txs {
+		for i, poolTx := range m.txs {
+			if poolTx.ID == purgedTx.ID {
+				m.txs = append(m.txs[:i], m.txs[i+1:]...)
+				m.purged = append(m.purged, poolTx.ID)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+type testWithdrawalModel struct {
+	withdrawal.WithdrawalModel
+	deletedL1TxHashes []string
+}
+
+func (m *testWithdrawalModel) DeleteWithdrawalsByL1TxHashesInTransact(_ *gorm.DB, l1TxHashes []string) error {
+	m.deletedL1TxHashes = append(m.deletedL1TxHashes, l1TxHashes...)
+	return nil
+}
+
+type testMonitor struct {
+	*Monitor
+	chain            *testChain
+	connPool         *testConnPool
+	syncedBlocks     *testL1SyncedBlockModel
+	priorityRequests *testPriorityRequestModel
+	txPool           *testTxPoolModel
+	withdrawals      *testWithdrawalModel
+}
+
+func newTestMonitor(t *testing.T) *testMonitor {
+	connPool := &testConnPool{}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: connPool}), &gorm.Config{})
+	require.NoError(t, err)
+	m := &testMonitor{
+		chain:            newTestChain(20),
+		connPool:         connPool,
+		syncedBlocks:     &testL1SyncedBlockModel{},
+		priorityRequests: &testPriorityRequestModel{},
+		txPool:           &testTxPoolModel{},
+		withdrawals:      &testWithdrawalModel{},
+	}
+	m.Monitor = &Monitor{
+		cli:                  m.chain,
+		db:                   db,
+		L1SyncedBlockModel:   m.syncedBlocks,
+		PriorityRequestModel: m.priorityRequests,
+		TxPoolModel:          m.txPool,
+		WithdrawalModel:      m.withdrawals,
+	}
+	m.Config.ChainConfig.StartL1BlockHeight = 5
+	m.Config.ChainConfig.MaxHandledBlocksCount = 100
+	return m
+}
+
+// sync records the block of the current chain as synced with the events.
+func (m *testMonitor) sync(t *testing.T, blockType int, height int64, events ...*L1Event) {
+	blockInfo, err := json.Marshal(events)
+	require.NoError(t, err)
+	m.syncedBlocks.blocks = append(m.syncedBlocks.blocks, &l1syncedblock.L1SyncedBlock{
+		L1BlockHeight: height,
+		L1BlockHash:   m.chain.headers[height-1].Hash().Hex(),
+		BlockInfo:     string(blockInfo),
+		Type:          blockType,
+	})
+}
+
+// request records the deposit in the block, with its pool tx of the status if it's handled.
+func (m *testMonitor) request(height int64, requestId int64, txStatus int) {
+	request := &priorityrequest.PriorityRequest{
+		L1TxHash:      common.BigToHash(big.NewInt(requestId)).Hex(),
+		L1BlockHeight: height,
+		RequestId:     requestId,
+		Status:        priorityrequest.PendingStatus,
+	}
+	if txStatus >= 0 {
+		request.Status = priorityrequest.HandledStatus
+		request.L2TxHash = common.BigToHash(big.NewInt(requestId + 1000)).Hex()
+		poolTx := &tx.Tx{TxHash: request.L2TxHash, TxType: types.TxTypeDeposit, TxStatus: txStatus}
+		poolTx.ID = uint(requestId)
+		m.txPool.txs = append(m.txPool.txs, poolTx)
+	}
+	m.priorityRequests.requests = append(m.priorityRequests.requests, request)
+}
+
+func (m *testMonitor) syncedHeights(blockType int) []int64 {
+	var heights []int64
+	for _, block := range m.syncedBlocks.blocks {
+		if block.Type == blockType {
+			heights = append(heights, block.L1BlockHeight)
+		}
+	}
+	return heights
+}
+
+func TestCheckL1Reorg(t *testing.T) {
+	m := newTestMonitor(t)
+	for height := int64(6); height <= 10; height++ {
+		m.sync(t, l1syncedblock.TypeGeneric, height)
+	}
+	m.request(10, 1, tx.StatusPending)
+
+	// The next block is the child of the latest synced one.
+	from, to, err := m.getBlockRangeToSync(l1syncedblock.TypeGeneric)
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), from)
+	assert.Equal(t, int64(20), to)
+	assert.Equal(t, []int64{6, 7, 8, 9, 10}, m.syncedHeights(l1syncedblock.TypeGeneric))
+
+	// The blocks synced before the hash is recorded are trusted.
+	m.chain.fork(8, 20, 1)
+	for _, block := range m.syncedBlocks.blocks {
+		block.L1BlockHash = ""
+	}
+	_, _, err = m.getBlockRangeToSync(l1syncedblock.TypeGeneric)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{6, 7, 8, 9, 10}, m.syncedHeights(l1syncedblock.TypeGeneric))
+	assert.Len(t, m.txPool.txs, 1)
+	assert.Nil(t, m.haltError())
+}
+
+func TestRewindL1SyncedBlocks(t *testing.T) {
+	m := newTestMonitor(t)
+	verifyEvent := &L1Event{EventType: EventTypeVerifiedBlock, TxHash: "0x1111"}
+	commitEvent := &L1Event{EventType: EventTypeCommittedBlock, TxHash: "0x2222"}
+	m.sync(t, l1syncedblock.TypeGeneric, 6)
+	m.sync(t, l1syncedblock.TypeGeneric, 7, commitEvent)
+	m.request(7, 1, tx.StatusExecuted)
+	m.sync(t, l1syncedblock.TypeGeneric, 8, verifyEvent)
+	m.request(8, 2, tx.StatusPending)
+	m.sync(t, l1syncedblock.TypeGeneric, 9, &L1Event{EventType: EventTypeNewPriorityRequest, TxHash: "0x3333"})
+	m.request(9, 3, tx.StatusPending)
+	m.request(9, 4, -1)
+	m.sync(t, l1syncedblock.TypeGovernance, 9)
+
+	m.chain.fork(7, 20, 1)
+	_, _, err := m.getBlockRangeToSync(l1syncedblock.TypeGeneric)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rewound to 7")
+	assert.Nil(t, m.haltError())
+
+	// The blocks above the fork point are synced again, the pending pool txs of the requests
+	// in them are purged, so that they could be created again with the same hashes.
+	assert.Equal(t, []int64{6, 7}, m.syncedHeights(l1syncedblock.TypeGeneric))
+	assert.Equal(t, []int64{9}, m.syncedHeights(l1syncedblock.TypeGovernance))
+	require.Len(t, m.priorityRequests.requests, 1)
+	assert.Equal(t, int64(1), m.priorityRequests.requests[0].RequestId)
+	assert.Equal(t, []uint{2, 3}, m.txPool.purged)
+	require.Len(t, m.txPool.txs, 1)
+	assert.Equal(t, uint(1), m.txPool.txs[0].ID)
+	assert.Equal(t, []string{verifyEvent.TxHash}, m.withdrawals.deletedL1TxHashes)
+	assert.Equal(t, 1, m.connPool.commits)
+	assert.Zero(t, m.connPool.rollbacks)
+
+	// The rewound blocks are synced from the fork point then.
+	from, _, err := m.getBlockRangeToSync(l1syncedblock.TypeGeneric)
+	require.NoError(t, err)
+	assert.Equal(t, int64(8), from)
+
+	// The governance blocks without events are rewound as well.
+	_, _, err = m.getBlockRangeToSync(l1syncedblock.TypeGovernance)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rewound to 5")
+	assert.Empty(t, m.syncedHeights(l1syncedblock.TypeGovernance))
+	assert.Nil(t, m.haltError())
+}
+
+func TestRewindL1SyncedBlocksHalt(t *testing.T) {
+	tests := []struct {
+		name      string
+		blockType int
+		txStatus  int
+		events    []*L1Event
+	}{
+		{
+			name:      "executed priority request",
+			blockType: l1syncedblock.TypeGeneric,
+			txStatus:  tx.StatusExecuted,
+		},
+		{
+			name:      "failed priority request",
+			blockType: l1syncedblock.TypeGeneric,
+			txStatus:  tx.StatusFailed,
+		},
+		{
+			name:      "governance event",
+			blockType: l1syncedblock.TypeGovernance,
+			txStatus:  tx.StatusPending,
+			events:    []*L1Event{{EventType: EventTypeAddAsset, TxHash: "0x4444"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestMonitor(t)
+			m.sync(t, tt.blockType, 8)
+			m.sync(t, tt.blockType, 9, tt.events...)
+			m.request(9, 1, tt.txStatus)
+
+			m.chain.fork(8, 20, 1)
+			_, _, err := m.getBlockRangeToSync(tt.blockType)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "monitor is halted")
+			require.Error(t, m.haltError())
+
+			// Nothing is rewound, and the monitor stays halted until it's fixed manually.
+			assert.Equal(t, []int64{8, 9}, m.syncedHeights(tt.blockType))
+			assert.Len(t, m.priorityRequests.requests, 1)
+			assert.Len(t, m.txPool.txs, 1)
+			assert.Empty(t, m.txPool.purged)
+			assert.Zero(t, m.connPool.commits)
+			_, _, err = m.getBlockRangeToSync(tt.blockType)
+			assert.Equal(t, m.haltError(), err)
+			assert.Equal(t, m.haltError(), m.MonitorPriorityRequests())
+		})
+	}
+}
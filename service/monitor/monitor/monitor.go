@@ -18,22 +18,26 @@ package monitor
 
 import (
 	"fmt"
+	"sync"
+	"sync/atomic"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/zeromicro/go-zero/core/logx"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 
-	"github.com/bnb-chain/zkbnb-eth-rpc/rpc"
 	common2 "github.com/bnb-chain/zkbnb/common"
+	"github.com/bnb-chain/zkbnb/common/l1"
 	"github.com/bnb-chain/zkbnb/dao/asset"
 	"github.com/bnb-chain/zkbnb/dao/block"
 	"github.com/bnb-chain/zkbnb/dao/l1rolluptx"
 	"github.com/bnb-chain/zkbnb/dao/l1syncedblock"
 	"github.com/bnb-chain/zkbnb/dao/priorityrequest"
 	"github.com/bnb-chain/zkbnb/dao/proof"
+	"github.com/bnb-chain/zkbnb/dao/rollback"
 	"github.com/bnb-chain/zkbnb/dao/sysconfig"
 	"github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/dao/withdrawal"
 	"github.com/bnb-chain/zkbnb/service/monitor/config"
 	"github.com/bnb-chain/zkbnb/types"
 )
@@ -55,7 +59,7 @@ var (
 type Monitor struct {
 	Config config.Config
 
-	cli *rpc.ProviderClient
+	cli l1.Provider
 
 	zkbnbContractAddress      string
 	governanceContractAddress string
@@ -70,9 +74,26 @@ type Monitor struct {
 	L2AssetModel         asset.AssetModel
 	PriorityRequestModel priorityrequest.PriorityRequestModel
 	L1SyncedBlockModel   l1syncedblock.L1SyncedBlockModel
+	RollbackModel        rollback.RollbackModel
+	WithdrawalModel      withdrawal.WithdrawalModel
+
+	// held while the priority requests are turned into pool txs, or rewound on l1 reorg
+	priorityRequestLock sync.Mutex
+	// the reason why the monitor is halted, it is set when the l1 reorg cannot be rewound
+	haltReason atomic.Value
 }
 
 func NewMonitor(c config.Config) *Monitor {
+	return newMonitor(c, nil)
+}
+
+// NewMonitorWithProvider creates the monitor syncing from the provider, e.g. the simulated
+// one, instead of the L1 node in the sysconfig.
+func NewMonitorWithProvider(c config.Config, provider l1.Provider) *Monitor {
+	return newMonitor(c, provider)
+}
+
+func newMonitor(c config.Config, provider l1.Provider) *Monitor {
 	db, err := gorm.Open(postgres.Open(c.Postgres.DataSource))
 	if err != nil {
 		logx.Errorf("gorm connect db error, err: %s", err.Error())
@@ -89,6 +110,8 @@ func NewMonitor(c config.Config) *Monitor {
 		L1SyncedBlockModel:   l1syncedblock.NewL1SyncedBlockModel(db),
 		L2AssetModel:         asset.NewAssetModel(db),
 		SysConfigModel:       sysconfig.NewSysConfigModel(db),
+		RollbackModel:        rollback.NewRollbackModel(db),
+		WithdrawalModel:      withdrawal.NewWithdrawalModel(db),
 	}
 
 	zkbnbAddressConfig, err := monitor.SysConfigModel.GetSysConfigByName(types.ZkBNBContract)
@@ -104,23 +127,25 @@ func NewMonitor(c config.Config) *Monitor {
 		panic(err)
 	}
 
-	networkRpc, err := monitor.SysConfigModel.GetSysConfigByName(c.ChainConfig.NetworkRPCSysConfigName)
-	if err != nil {
-		logx.Severef("fatal error, cannot fetch NetworkRPC from sysconfig, err: %s, SysConfigName: %s",
-			err.Error(), c.ChainConfig.NetworkRPCSysConfigName)
-		panic(err)
-	}
-	logx.Infof("ChainName: %s, zkbnbContractAddress: %s, networkRpc: %s",
-		c.ChainConfig.NetworkRPCSysConfigName, zkbnbAddressConfig.Value, networkRpc.Value)
+	if provider == nil {
+		networkRpc, err := monitor.SysConfigModel.GetSysConfigByName(c.ChainConfig.NetworkRPCSysConfigName)
+		if err != nil {
+			logx.Severef("fatal error, cannot fetch NetworkRPC from sysconfig, err: %s, SysConfigName: %s",
+				err.Error(), c.ChainConfig.NetworkRPCSysConfigName)
+			panic(err)
+		}
+		logx.Infof("ChainName: %s, zkbnbContractAddress: %s, networkRpc: %s",
+			c.ChainConfig.NetworkRPCSysConfigName, zkbnbAddressConfig.Value, networkRpc.Value)
 
-	bscRpcCli, err := rpc.NewClient(networkRpc.Value)
-	if err != nil {
-		panic(err)
+		provider, err = l1.NewRpcProvider(networkRpc.Value, zkbnbAddressConfig.Value, "")
+		if err != nil {
+			panic(err)
+		}
 	}
 
 	monitor.zkbnbContractAddress = zkbnbAddressConfig.Value
 	monitor.governanceContractAddress = governanceAddressConfig.Value
-	monitor.cli = bscRpcCli
+	monitor.cli = provider
 
 	if err := prometheus.Register(priorityOperationMetric); err != nil {
 		logx.Severef("fatal error, cannot register prometheus, err: %s", err.Error())
@@ -145,6 +170,9 @@ func (m *Monitor) Shutdown() {
 }
 
 func (m *Monitor) getBlockRangeToSync(monitorType int) (int64, int64, error) {
+	if err := m.haltError(); err != nil {
+		return 0, 0, err
+	}
 	latestHandledBlock, err := m.L1SyncedBlockModel.GetLatestL1SyncedBlockByType(monitorType)
 	var handledHeight int64
 	if err != nil {
@@ -165,6 +193,13 @@ func (m *Monitor) getBlockRangeToSync(monitorType int) (int64, int64, error) {
 
 	safeHeight := latestHeight - m.Config.ChainConfig.ConfirmBlocksCount
 	safeHeight = uint64(common2.MinInt64(int64(safeHeight), handledHeight+m.Config.ChainConfig.MaxHandledBlocksCount))
+	if int64(safeHeight) <= handledHeight {
+		return handledHeight + 1, int64(safeHeight), nil
+	}
 
+	err = m.checkL1Reorg(monitorType, latestHandledBlock, handledHeight+1)
+	if err != nil {
+		return 0, 0, err
+	}
 	return handledHeight + 1, int64(safeHeight), nil
 }
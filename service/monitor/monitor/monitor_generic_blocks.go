@@ -23,20 +23,21 @@ import (
 	"math/big"
 
 	"github.com/ethereum/go-ethereum"
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/zeromicro/go-zero/core/logx"
 	"gorm.io/gorm"
 
 	zkbnb "github.com/bnb-chain/zkbnb-eth-rpc/core"
-	"github.com/bnb-chain/zkbnb-eth-rpc/rpc"
+	"github.com/bnb-chain/zkbnb/common/l1"
 	"github.com/bnb-chain/zkbnb/dao/block"
 	"github.com/bnb-chain/zkbnb/dao/l1rolluptx"
 	"github.com/bnb-chain/zkbnb/dao/l1syncedblock"
 	"github.com/bnb-chain/zkbnb/dao/priorityrequest"
 	"github.com/bnb-chain/zkbnb/dao/proof"
+	"github.com/bnb-chain/zkbnb/dao/rollback"
 	"github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/dao/withdrawal"
 	types2 "github.com/bnb-chain/zkbnb/types"
 )
 
@@ -70,6 +71,14 @@ func (m *Monitor) MonitorGenericBlocks() (err error) {
 
 		relatedBlocks        = make(map[int64]*block.Block)
 		relatedBlockTxStatus = make(map[int64]int)
+
+		revertedBlock *rollback.Rollback
+
+		// the withdrawal events and the verified blocks of each verify tx, and the verify txs
+		// with the withdrawal events failing to decode
+		withdrawalEvents     = make(map[string][]*withdrawalEvent)
+		verifiedBlockHeights = make(map[string][]int64)
+		invalidWithdrawalTxs = make(map[string]bool)
 	)
 	for _, vlog := range logs {
 		l1EventInfo := &L1Event{
@@ -91,8 +100,17 @@ func (m *Monitor) MonitorGenericBlocks() (err error) {
 				return fmt.Errorf("failed to convert NewPriorityRequest log, err: %v", err)
 			}
 			priorityRequests = append(priorityRequests, l2TxEventMonitorInfo)
-		case zkbnbLogWithdrawalSigHash.Hex():
-		case zkbnbLogWithdrawalPendingSigHash.Hex():
+		case zkbnbLogWithdrawalSigHash.Hex(), zkbnbLogWithdrawNftSigHash.Hex(), zkbnbLogWithdrawalNftPendingSigHash.Hex():
+			l1EventInfo.EventType = withdrawalEventTypes[vlog.Topics[0]]
+
+			// the withdrawals are only tracked for the users, don't block the blocks on them
+			event, err := convertLogToWithdrawalEvent(vlog)
+			if err != nil {
+				logx.Severef("failed to convert withdrawal log of tx %s, err: %v", l1EventInfo.TxHash, err)
+				invalidWithdrawalTxs[l1EventInfo.TxHash] = true
+				continue
+			}
+			withdrawalEvents[l1EventInfo.TxHash] = append(withdrawalEvents[l1EventInfo.TxHash], event)
 		case zkbnbLogBlockCommitSigHash.Hex():
 			l1EventInfo.EventType = EventTypeCommittedBlock
 
@@ -133,8 +151,31 @@ func (m *Monitor) MonitorGenericBlocks() (err error) {
 			relatedBlocks[blockHeight].VerifiedAt = int64(logBlock.Time)
 			relatedBlocks[blockHeight].BlockStatus = block.StatusVerifiedAndExecuted
 			relatedBlockTxStatus[blockHeight] = tx.StatusVerified
+			verifiedBlockHeights[l1EventInfo.TxHash] = append(verifiedBlockHeights[l1EventInfo.TxHash], blockHeight)
 		case zkbnbLogBlocksRevertSigHash.Hex():
 			l1EventInfo.EventType = EventTypeRevertedBlock
+
+			var event zkbnb.ZkBNBBlocksRevert
+			if err := ZkBNBContractAbi.UnpackIntoInterface(&event, EventNameBlocksRevert, vlog.Data); err != nil {
+				return fmt.Errorf("failed to unpack ZkBNBBlocksRevert err: %v", err)
+			}
+
+			// the blocks above the committed height are reverted
+			revertHeight := int64(event.TotalBlocksCommitted)
+			logx.Infof("blocks reverted on l1, committed: %d, verified: %d", event.TotalBlocksCommitted, event.TotalBlocksVerified)
+			if revertedBlock == nil || revertHeight < revertedBlock.BlockHeight {
+				revertedBlock = &rollback.Rollback{
+					BlockHeight:     revertHeight,
+					CommitterStatus: rollback.StatusPending,
+					WitnessStatus:   rollback.StatusPending,
+				}
+			}
+			for blockHeight := range relatedBlocks {
+				if blockHeight > revertHeight {
+					delete(relatedBlocks, blockHeight)
+					delete(relatedBlockTxStatus, blockHeight)
+				}
+			}
 		default:
 		}
 
@@ -148,12 +189,35 @@ func (m *Monitor) MonitorGenericBlocks() (err error) {
 	if err != nil {
 		return err
 	}
+	l1BlockHash, err := m.getL1BlockHash(endHeight)
+	if err != nil {
+		return err
+	}
 	l1BlockMonitorInfo := &l1syncedblock.L1SyncedBlock{
 		L1BlockHeight: endHeight,
+		L1BlockHash:   l1BlockHash,
 		BlockInfo:     string(eventInfosBytes),
 		Type:          l1syncedblock.TypeGeneric,
 	}
 
+	// match the withdrawal events to the txs of the executed blocks, the mismatched ones are
+	// reported but don't block the monitor. The verify txs without the events are matched as
+	// well, since the failed transfers of the assets emit nothing.
+	var pendingWithdrawals []*withdrawal.Withdrawal
+	for l1TxHash, blockHeights := range verifiedBlockHeights {
+		if invalidWithdrawalTxs[l1TxHash] {
+			continue
+		}
+		withdrawals, err := m.getWithdrawals(l1TxHash, blockHeights, withdrawalEvents[l1TxHash])
+		if err == types2.DbErrSqlOperation || err == types2.DbErrNotFound {
+			return fmt.Errorf("failed to get the withdrawals of tx %s, err: %v", l1TxHash, err)
+		} else if err != nil {
+			logx.Severef("failed to match the withdrawal events of tx %s, err: %v", l1TxHash, err)
+			continue
+		}
+		pendingWithdrawals = append(pendingWithdrawals, withdrawals...)
+	}
+
 	// get pending update blocks
 	pendingUpdateBlocks := make([]*block.Block, 0, len(relatedBlocks))
 	pendingUpdateCommittedBlocks := make(map[string]*block.Block, 0)
@@ -251,7 +315,28 @@ func (m *Monitor) MonitorGenericBlocks() (err error) {
 
 		//update tx status
 		err = m.TxModel.UpdateTxsStatusInTransact(tx, relatedBlockTxStatus)
-		return err
+		if err != nil {
+			return err
+		}
+		//create withdrawals
+		err = m.WithdrawalModel.CreateWithdrawalsInTransact(tx, pendingWithdrawals)
+		if err != nil {
+			return err
+		}
+
+		// record the reverted blocks, the committer and witness will roll back their states,
+		// and the rollup txs of the reverted blocks should be sent again
+		if revertedBlock != nil {
+			err = m.RollbackModel.CreateRollbackInTransact(tx, revertedBlock)
+			if err != nil {
+				return err
+			}
+			err = m.L1RollupTxModel.DeleteL1RollupTxsForHeightGreaterThanInTransact(tx, revertedBlock.BlockHeight)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
 	})
 	if err != nil {
 		return fmt.Errorf("failed to store monitor info, err: %v", err)
@@ -260,7 +345,7 @@ func (m *Monitor) MonitorGenericBlocks() (err error) {
 	return nil
 }
 
-func getZkBNBContractLogs(cli *rpc.ProviderClient, zkbnbContract string, startHeight, endHeight uint64) ([]types.Log, error) {
+func getZkBNBContractLogs(cli l1.Provider, zkbnbContract string, startHeight, endHeight uint64) ([]types.Log, error) {
 	query := ethereum.FilterQuery{
 		FromBlock: big.NewInt(int64(startHeight)),
 		ToBlock:   big.NewInt(int64(endHeight)),
@@ -273,21 +358,18 @@ func getZkBNBContractLogs(cli *rpc.ProviderClient, zkbnbContract string, startHe
 	return logs, nil
 }
 
-func getPriorityRequestCount(cli *rpc.ProviderClient, zkbnbContract string, startHeight, endHeight uint64) (int, error) {
-	zkbnbInstance, err := zkbnb.LoadZkBNBInstance(cli, zkbnbContract)
-	if err != nil {
-		return 0, err
+func getPriorityRequestCount(cli l1.Provider, zkbnbContract string, startHeight, endHeight uint64) (int, error) {
+	query := ethereum.FilterQuery{
+		FromBlock: big.NewInt(int64(startHeight)),
+		ToBlock:   big.NewInt(int64(endHeight)),
+		Addresses: []common.Address{common.HexToAddress(zkbnbContract)},
+		Topics:    [][]common.Hash{{zkbnbLogNewPriorityRequestSigHash}},
 	}
-	priorityRequests, err := zkbnbInstance.ZkBNBFilterer.
-		FilterNewPriorityRequest(&bind.FilterOpts{Start: startHeight, End: &endHeight})
+	priorityRequests, err := cli.FilterLogs(context.Background(), query)
 	if err != nil {
 		return 0, err
 	}
-	priorityRequestCount := 0
-	for priorityRequests.Next() {
-		priorityRequestCount++
-	}
-	return priorityRequestCount, nil
+	return len(priorityRequests), nil
 }
 
 func convertLogToNewPriorityRequestEvent(log types.Log) (*priorityrequest.PriorityRequest, error) {
@@ -37,28 +37,16 @@ import (
 
 func (m *Monitor) getNewL2Asset(event zkbnb.GovernanceNewAsset) (*asset.Asset, error) {
 	// get asset info by contract address
-	erc20Instance, err := zkbnb.LoadERC20(m.cli, event.AssetAddress.Hex())
-	if err != nil {
-		return nil, err
-	}
-	name, err := erc20Instance.Name(EmptyCallOpts())
-	if err != nil {
-		return nil, err
-	}
-	symbol, err := erc20Instance.Symbol(EmptyCallOpts())
-	if err != nil {
-		return nil, err
-	}
-	decimals, err := erc20Instance.Decimals(EmptyCallOpts())
+	info, err := m.cli.GetERC20Info(event.AssetAddress.Hex())
 	if err != nil {
 		return nil, err
 	}
 	l2Asset := &asset.Asset{
 		AssetId:     uint32(event.AssetId),
 		L1Address:   event.AssetAddress.Hex(),
-		AssetName:   name,
-		AssetSymbol: strings.ToUpper(symbol),
-		Decimals:    uint32(decimals),
+		AssetName:   info.Name,
+		AssetSymbol: strings.ToUpper(info.Symbol),
+		Decimals:    uint32(info.Decimals),
 		Status:      asset.StatusActive,
 	}
 
@@ -209,8 +197,13 @@ func (m *Monitor) MonitorGovernanceBlocks() (err error) {
 	if err != nil {
 		return err
 	}
+	l1BlockHash, err := m.getL1BlockHash(endHeight)
+	if err != nil {
+		return err
+	}
 	syncedBlock := &l1syncedblock.L1SyncedBlock{
 		L1BlockHeight: endHeight,
+		L1BlockHash:   l1BlockHash,
 		BlockInfo:     string(eventInfosBytes),
 		Type:          l1syncedblock.TypeGovernance,
 	}
@@ -30,6 +30,12 @@ import (
 )
 
 func (m *Monitor) MonitorPriorityRequests() error {
+	if err := m.haltError(); err != nil {
+		return err
+	}
+	m.priorityRequestLock.Lock()
+	defer m.priorityRequestLock.Unlock()
+
 	pendingRequests, err := m.PriorityRequestModel.GetPriorityRequestsByStatus(PendingStatus)
 	if err != nil {
 		if err != types.DbErrNotFound {
@@ -20,6 +20,7 @@ import (
 	"strings"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 
 	zkbnb "github.com/bnb-chain/zkbnb-eth-rpc/core"
@@ -31,6 +32,9 @@ const (
 	EventNameNewPriorityRequest = "NewPriorityRequest"
 	EventNameBlockCommit        = "BlockCommit"
 	EventNameBlockVerification  = "BlockVerification"
+	EventNameBlocksRevert       = "BlocksRevert"
+	EventNameWithdrawal         = "Withdrawal"
+	EventNameWithdrawNft        = "WithdrawNft"
 
 	EventTypeNewPriorityRequest = 0
 	EventTypeCommittedBlock     = 1
@@ -49,6 +53,10 @@ const (
 	EventTypeValidatorStatusUpdate = 7
 	EventTypeAssetPausedUpdate     = 8
 
+	EventTypeWithdrawal           = 9
+	EventTypeWithdrawNft          = 10
+	EventTypeWithdrawalNftPending = 11
+
 	PendingStatus = priorityrequest.PendingStatus
 
 	TxTypeRegisterZns = types.TxTypeRegisterZns
@@ -61,19 +69,27 @@ const (
 var (
 	ZkBNBContractAbi, _ = abi.JSON(strings.NewReader(zkbnb.ZkBNBMetaData.ABI))
 	// ZkBNB contract logs sig
-	zkbnbLogNewPriorityRequestSig = []byte("NewPriorityRequest(address,uint64,uint8,bytes,uint256)")
-	zkbnbLogWithdrawalSig         = []byte("Withdrawal(uint16,uint128)")
-	zkbnbLogWithdrawalPendingSig  = []byte("WithdrawalPending(uint16,uint128)")
-	zkbnbLogBlockCommitSig        = []byte("BlockCommit(uint32)")
-	zkbnbLogBlockVerificationSig  = []byte("BlockVerification(uint32)")
-	zkbnbLogBlocksRevertSig       = []byte("BlocksRevert(uint32,uint32)")
-
-	zkbnbLogNewPriorityRequestSigHash = crypto.Keccak256Hash(zkbnbLogNewPriorityRequestSig)
-	zkbnbLogWithdrawalSigHash         = crypto.Keccak256Hash(zkbnbLogWithdrawalSig)
-	zkbnbLogWithdrawalPendingSigHash  = crypto.Keccak256Hash(zkbnbLogWithdrawalPendingSig)
-	zkbnbLogBlockCommitSigHash        = crypto.Keccak256Hash(zkbnbLogBlockCommitSig)
-	zkbnbLogBlockVerificationSigHash  = crypto.Keccak256Hash(zkbnbLogBlockVerificationSig)
-	zkbnbLogBlocksRevertSigHash       = crypto.Keccak256Hash(zkbnbLogBlocksRevertSig)
+	zkbnbLogNewPriorityRequestSig   = []byte("NewPriorityRequest(address,uint64,uint8,bytes,uint256)")
+	zkbnbLogWithdrawalSig           = []byte("Withdrawal(uint16,uint128)")
+	zkbnbLogBlockCommitSig          = []byte("BlockCommit(uint32)")
+	zkbnbLogBlockVerificationSig    = []byte("BlockVerification(uint32)")
+	zkbnbLogBlocksRevertSig         = []byte("BlocksRevert(uint32,uint32)")
+	zkbnbLogWithdrawNftSig          = []byte("WithdrawNft(uint32,address,address,uint256)")
+	zkbnbLogWithdrawalNftPendingSig = []byte("WithdrawalNFTPending(uint40)")
+
+	zkbnbLogNewPriorityRequestSigHash   = crypto.Keccak256Hash(zkbnbLogNewPriorityRequestSig)
+	zkbnbLogWithdrawalSigHash           = crypto.Keccak256Hash(zkbnbLogWithdrawalSig)
+	zkbnbLogBlockCommitSigHash          = crypto.Keccak256Hash(zkbnbLogBlockCommitSig)
+	zkbnbLogBlockVerificationSigHash    = crypto.Keccak256Hash(zkbnbLogBlockVerificationSig)
+	zkbnbLogBlocksRevertSigHash         = crypto.Keccak256Hash(zkbnbLogBlocksRevertSig)
+	zkbnbLogWithdrawNftSigHash          = crypto.Keccak256Hash(zkbnbLogWithdrawNftSig)
+	zkbnbLogWithdrawalNftPendingSigHash = crypto.Keccak256Hash(zkbnbLogWithdrawalNftPendingSig)
+
+	withdrawalEventTypes = map[common.Hash]uint8{
+		zkbnbLogWithdrawalSigHash:           EventTypeWithdrawal,
+		zkbnbLogWithdrawNftSigHash:          EventTypeWithdrawNft,
+		zkbnbLogWithdrawalNftPendingSigHash: EventTypeWithdrawalNftPending,
+	}
 
 	GovernanceContractAbi, _ = abi.JSON(strings.NewReader(zkbnb.GovernanceMetaData.ABI))
 
@@ -21,7 +21,6 @@ import (
 	"strconv"
 
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 )
 
@@ -31,7 +30,3 @@ func ComputeL1TxTxHash(requestId int64, txHash string) string {
 	hFunc.Write(common.FromHex(txHash))
 	return hex.EncodeToString(hFunc.Sum(nil))
 }
-
-func EmptyCallOpts() *bind.CallOpts {
-	return &bind.CallOpts{}
-}
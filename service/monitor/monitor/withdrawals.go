@@ -0,0 +1,249 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package monitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/bnb-chain/zkbnb-crypto/wasm/txtypes"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+
+	zkbnb "github.com/bnb-chain/zkbnb-eth-rpc/core"
+	"github.com/bnb-chain/zkbnb/common/chain"
+	"github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/dao/withdrawal"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+// withdrawalEvent is emitted by the rollup contract for each withdraw operation of the
+// executed blocks whose transfer succeeds. If the transfer fails, the funds are added to
+// the pending balance without an event, while the nft is stored with WithdrawalNFTPending,
+// both are claimed by the owner later.
+type withdrawalEvent struct {
+	nft     bool
+	pending bool
+
+	assetId int64
+	amount  *big.Int
+
+	// set by WithdrawNft
+	accountIndex int64
+	// set by WithdrawalNFTPending
+	nftIndex int64
+}
+
+func (e *withdrawalEvent) matchAsset(assetId int64, amount *big.Int) bool {
+	return e != nil && !e.nft && e.assetId == assetId && e.amount.Cmp(amount) == 0
+}
+
+// matchNft checks the account index of WithdrawNft, or the nft index of WithdrawalNFTPending.
+func (e *withdrawalEvent) matchNft(accountIndex, nftIndex int64) bool {
+	if e == nil || !e.nft {
+		return false
+	}
+	if e.pending {
+		return e.nftIndex == nftIndex
+	}
+	return e.accountIndex == accountIndex
+}
+
+// convertLogToWithdrawalEvent decodes the events as the abi of the contract binding.
+func convertLogToWithdrawalEvent(vlog ethTypes.Log) (*withdrawalEvent, error) {
+	switch vlog.Topics[0] {
+	case zkbnbLogWithdrawalSigHash:
+		var event zkbnb.ZkBNBWithdrawal
+		if err := ZkBNBContractAbi.UnpackIntoInterface(&event, EventNameWithdrawal, vlog.Data); err != nil {
+			return nil, err
+		}
+		return &withdrawalEvent{assetId: int64(event.AssetId), amount: event.Amount}, nil
+	case zkbnbLogWithdrawNftSigHash:
+		var event zkbnb.ZkBNBWithdrawNft
+		if err := ZkBNBContractAbi.UnpackIntoInterface(&event, EventNameWithdrawNft, vlog.Data); err != nil {
+			return nil, err
+		}
+		return &withdrawalEvent{nft: true, accountIndex: int64(event.AccountIndex)}, nil
+	case zkbnbLogWithdrawalNftPendingSigHash:
+		// the nft index is the only field, which is indexed
+		if len(vlog.Topics) != 2 {
+			return nil, fmt.Errorf("invalid WithdrawalNFTPending topics size %d", len(vlog.Topics))
+		}
+		return &withdrawalEvent{
+			nft:      true,
+			pending:  true,
+			nftIndex: vlog.Topics[1].Big().Int64(),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown withdrawal event %s", vlog.Topics[0].Hex())
+	}
+}
+
+// withdrawOperation is a pending on-chain operation of the block, which is executed by the
+// rollup contract, together with the tx generating it.
+type withdrawOperation struct {
+	tx     *tx.Tx
+	txInfo txtypes.TxInfo
+}
+
+// getWithdrawals matches the withdrawal events of the verify tx to the Withdraw and
+// WithdrawNft txs of the verified blocks. The contract executes the pending on-chain
+// operations of the blocks in order, and emits at most one event for each of them:
+//   - Withdraw and FullExit emit Withdrawal if the transfer succeeds, and nothing otherwise,
+//     the FullExit of a zero amount may emit nothing as well.
+//   - WithdrawNft and FullExitNft emit WithdrawNft or WithdrawalNFTPending, except the
+//     FullExitNft of an empty nft.
+//
+// So a Withdraw without the next event matching it is pending. The events of the same asset
+// and amount can't be told apart, they are matched to the txs in order.
+//
+// The db errors are returned as is, the other errors mean the events don't match the blocks.
+func (m *Monitor) getWithdrawals(l1TxHash string, blockHeights []int64, events []*withdrawalEvent) ([]*withdrawal.Withdrawal, error) {
+	sort.Slice(blockHeights, func(i, j int) bool {
+		return blockHeights[i] < blockHeights[j]
+	})
+	var operations []*withdrawOperation
+	for _, height := range blockHeights {
+		blockOperations, err := m.getWithdrawOperations(height)
+		if err != nil {
+			return nil, err
+		}
+		operations = append(operations, blockOperations...)
+	}
+
+	var withdrawals []*withdrawal.Withdrawal
+	for _, operation := range operations {
+		var (
+			l2Tx   = operation.tx
+			status = withdrawal.StatusPaid
+			event  *withdrawalEvent
+		)
+		if len(events) > 0 {
+			event = events[0]
+		}
+		switch txInfo := operation.txInfo.(type) {
+		case *txtypes.WithdrawTxInfo:
+			if event.matchAsset(txInfo.AssetId, txInfo.AssetAmount) {
+				events = events[1:]
+			} else {
+				status = withdrawal.StatusPending
+			}
+		case *txtypes.FullExitTxInfo:
+			if event.matchAsset(txInfo.AssetId, txInfo.AssetAmount) {
+				events = events[1:]
+			}
+			continue
+		case *txtypes.WithdrawNftTxInfo:
+			if !event.matchNft(txInfo.AccountIndex, txInfo.NftIndex) {
+				return nil, fmt.Errorf("no withdrawal event matches the tx %s of block %d", l2Tx.TxHash, l2Tx.BlockHeight)
+			}
+			events = events[1:]
+			if event.pending {
+				status = withdrawal.StatusPending
+			}
+		case *txtypes.FullExitNftTxInfo:
+			if bytes.Equal(txInfo.NftContentHash, make([]byte, len(txInfo.NftContentHash))) {
+				continue
+			}
+			if !event.matchNft(txInfo.AccountIndex, txInfo.NftIndex) {
+				return nil, fmt.Errorf("no withdrawal event matches the tx %s of block %d", l2Tx.TxHash, l2Tx.BlockHeight)
+			}
+			events = events[1:]
+			continue
+		}
+
+		switch txInfo := operation.txInfo.(type) {
+		case *txtypes.WithdrawTxInfo:
+			withdrawals = append(withdrawals, &withdrawal.Withdrawal{
+				L2TxHash:     l2Tx.TxHash,
+				TxType:       l2Tx.TxType,
+				BlockHeight:  l2Tx.BlockHeight,
+				AccountIndex: txInfo.FromAccountIndex,
+				ToAddress:    txInfo.ToAddress,
+				AssetId:      txInfo.AssetId,
+				AssetAmount:  txInfo.AssetAmount.String(),
+				NftIndex:     types.NilNftIndex,
+				L1TxHash:     l1TxHash,
+				Status:       status,
+			})
+		case *txtypes.WithdrawNftTxInfo:
+			withdrawals = append(withdrawals, &withdrawal.Withdrawal{
+				L2TxHash:     l2Tx.TxHash,
+				TxType:       l2Tx.TxType,
+				BlockHeight:  l2Tx.BlockHeight,
+				AccountIndex: txInfo.AccountIndex,
+				ToAddress:    txInfo.ToAddress,
+				AssetId:      types.NilAssetId,
+				AssetAmount:  types.NilAssetAmount,
+				NftIndex:     txInfo.NftIndex,
+				L1TxHash:     l1TxHash,
+				Status:       status,
+			})
+		}
+	}
+	if len(events) > 0 {
+		return nil, fmt.Errorf("%d withdrawal events are not matched", len(events))
+	}
+	return withdrawals, nil
+}
+
+// getWithdrawOperations pairs the pending on-chain operations of the block with the txs
+// generating them, which are in the same order.
+func (m *Monitor) getWithdrawOperations(height int64) ([]*withdrawOperation, error) {
+	b, err := m.BlockModel.GetBlockByHeight(height)
+	if err != nil {
+		return nil, err
+	}
+	if b.PendingOnChainOperationsPubData == "" {
+		return nil, nil
+	}
+	var pubDataList [][]byte
+	err = json.Unmarshal([]byte(b.PendingOnChainOperationsPubData), &pubDataList)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the on-chain operations of block %d, err: %v", height, err)
+	}
+
+	operations := make([]*withdrawOperation, 0, len(pubDataList))
+	for _, blockTx := range b.Txs {
+		if blockTx.TxStatus == tx.StatusFailed {
+			continue
+		}
+		switch blockTx.TxType {
+		case types.TxTypeWithdraw, types.TxTypeWithdrawNft, types.TxTypeFullExit, types.TxTypeFullExitNft:
+		default:
+			continue
+		}
+		if len(operations) == len(pubDataList) {
+			return nil, fmt.Errorf("the on-chain operations of block %d are less than the txs", height)
+		}
+		pubData := pubDataList[len(operations)]
+		txInfo, err := chain.ParseTxPubData(pubData)
+		if err != nil {
+			return nil, err
+		}
+		if int64(pubData[0]) != blockTx.TxType {
+			return nil, fmt.Errorf("the on-chain operation of tx %s is of type %d", blockTx.TxHash, pubData[0])
+		}
+		operations = append(operations, &withdrawOperation{tx: blockTx, txInfo: txInfo})
+	}
+	if len(operations) != len(pubDataList) {
+		return nil, fmt.Errorf("the on-chain operations of block %d are more than the txs", height)
+	}
+	return operations, nil
+}
@@ -0,0 +1,258 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package monitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	common2 "github.com/bnb-chain/zkbnb/common"
+	"github.com/bnb-chain/zkbnb/common/chain"
+	"github.com/bnb-chain/zkbnb/dao/block"
+	"github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/dao/withdrawal"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+var withdrawalRecipient = common.HexToAddress("0x2000000000000000000000000000000000000002")
+
+type testBlockModel struct {
+	block.BlockModel
+	blocks map[int64]*block.Block
+}
+
+func (m *testBlockModel) GetBlockByHeight(height int64) (*block.Block, error) {
+	b, ok := m.blocks[height]
+	if !ok {
+		return nil, types.DbErrNotFound
+	}
+	return b, nil
+}
+
+// testWithdrawOp is a withdraw tx of the block, with the fields checked by the matching.
+type testWithdrawOp struct {
+	txType       int64
+	failed       bool
+	accountIndex int64
+	assetId      int64
+	amount       int64
+	nftIndex     int64
+	// the FullExitNft of an empty nft has the zero content hash
+	emptyNft bool
+}
+
+func (op *testWithdrawOp) pubData() []byte {
+	chunks := make([][]byte, chain.TxPubDataBytesSize/32)
+	var buf bytes.Buffer
+	buf.WriteByte(uint8(op.txType))
+	buf.Write(common2.Uint32ToBytes(uint32(op.accountIndex)))
+	switch op.txType {
+	case types.TxTypeWithdraw:
+		buf.Write(withdrawalRecipient.Bytes())
+		buf.Write(common2.Uint16ToBytes(uint16(op.assetId)))
+		chunks[0] = common2.SuffixPaddingBufToChunkSize(buf.Bytes())
+		chunks[1] = common2.PrefixPaddingBufToChunkSize(
+			append(common2.Uint128ToBytes(big.NewInt(op.amount)), make([]byte, 8)...))
+	case types.TxTypeFullExit:
+		buf.Write(common2.Uint16ToBytes(uint16(op.assetId)))
+		buf.Write(common2.Uint128ToBytes(big.NewInt(op.amount)))
+		chunks[0] = common2.SuffixPaddingBufToChunkSize(buf.Bytes())
+	case types.TxTypeWithdrawNft, types.TxTypeFullExitNft:
+		buf.Write(common2.Uint32ToBytes(0))
+		buf.Write(common2.Uint16ToBytes(0))
+		buf.Write(common2.Uint40ToBytes(op.nftIndex))
+		chunks[0] = common2.SuffixPaddingBufToChunkSize(buf.Bytes())
+		contentHash := common2.PrefixPaddingBufToChunkSize([]byte{1})
+		if op.emptyNft {
+			contentHash = make([]byte, 32)
+		}
+		if op.txType == types.TxTypeWithdrawNft {
+			chunks[2] = common2.PrefixPaddingBufToChunkSize(append(withdrawalRecipient.Bytes(), make([]byte, 8)...))
+			chunks[3] = contentHash
+		} else {
+			chunks[4] = contentHash
+		}
+	}
+	var pubData []byte
+	for _, chunk := range chunks {
+		if chunk == nil {
+			chunk = make([]byte, 32)
+		}
+		pubData = append(pubData, chunk...)
+	}
+	return pubData
+}
+
+func newTestWithdrawBlock(t *testing.T, height int64, ops ...*testWithdrawOp) *block.Block {
+	b := &block.Block{BlockHeight: height}
+	var pubDataList [][]byte
+	for i, op := range ops {
+		status := tx.StatusVerified
+		if op.failed {
+			status = tx.StatusFailed
+		} else if op.txType != types.TxTypeTransfer {
+			// the transfers aren't on-chain operations
+			pubDataList = append(pubDataList, op.pubData())
+		}
+		b.Txs = append(b.Txs, &tx.Tx{
+			TxHash:      fmt.Sprintf("tx-%d-%d", height, i),
+			TxType:      op.txType,
+			TxStatus:    status,
+			BlockHeight: height,
+		})
+	}
+	if len(pubDataList) > 0 {
+		pubData, err := json.Marshal(pubDataList)
+		require.NoError(t, err)
+		b.PendingOnChainOperationsPubData = string(pubData)
+	}
+	return b
+}
+
+func assetEvent(assetId, amount int64) *withdrawalEvent {
+	return &withdrawalEvent{assetId: assetId, amount: big.NewInt(amount)}
+}
+
+func TestGetWithdrawals(t *testing.T) {
+	blocks := &testBlockModel{blocks: make(map[int64]*block.Block)}
+	m := &Monitor{BlockModel: blocks}
+	blocks.blocks[1] = newTestWithdrawBlock(t, 1,
+		&testWithdrawOp{txType: types.TxTypeWithdraw, accountIndex: 2, assetId: 1, amount: 100},
+		&testWithdrawOp{txType: types.TxTypeFullExit, accountIndex: 2, assetId: 1, amount: 0},
+		&testWithdrawOp{txType: types.TxTypeWithdraw, accountIndex: 2, assetId: 1, amount: 10, failed: true},
+		&testWithdrawOp{txType: types.TxTypeTransfer},
+		&testWithdrawOp{txType: types.TxTypeWithdraw, accountIndex: 3, assetId: 1, amount: 50},
+		&testWithdrawOp{txType: types.TxTypeFullExitNft, accountIndex: 3, nftIndex: 7, emptyNft: true},
+		&testWithdrawOp{txType: types.TxTypeWithdrawNft, accountIndex: 2, nftIndex: 5},
+		&testWithdrawOp{txType: types.TxTypeFullExit, accountIndex: 3, assetId: 0, amount: 30},
+	)
+	blocks.blocks[2] = newTestWithdrawBlock(t, 2,
+		&testWithdrawOp{txType: types.TxTypeWithdrawNft, accountIndex: 3, nftIndex: 8},
+		&testWithdrawOp{txType: types.TxTypeFullExitNft, accountIndex: 3, nftIndex: 9},
+	)
+	blocks.blocks[3] = newTestWithdrawBlock(t, 3)
+
+	operations, err := m.getWithdrawOperations(1)
+	require.NoError(t, err)
+	require.Len(t, operations, 6)
+	for i, txIndex := range []int{0, 1, 4, 5, 6, 7} {
+		assert.Equal(t, blocks.blocks[1].Txs[txIndex].TxHash, operations[i].tx.TxHash)
+	}
+	operations, err = m.getWithdrawOperations(3)
+	require.NoError(t, err)
+	assert.Empty(t, operations)
+
+	statuses := func(withdrawals []*withdrawal.Withdrawal) map[string]int {
+		statuses := make(map[string]int)
+		for _, w := range withdrawals {
+			assert.Equal(t, "0xverify", w.L1TxHash)
+			statuses[w.L2TxHash] = w.Status
+		}
+		return statuses
+	}
+	// The second Withdraw fails to transfer and emits nothing, as the zero-amount FullExit and
+	// the FullExitNft of the empty nft.
+	events := []*withdrawalEvent{
+		assetEvent(1, 100),
+		{nft: true, accountIndex: 2},
+		assetEvent(0, 30),
+		{nft: true, pending: true, nftIndex: 8},
+		{nft: true, accountIndex: 3},
+	}
+	withdrawals, err := m.getWithdrawals("0xverify", []int64{2, 1, 3}, events)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{
+		"tx-1-0": withdrawal.StatusPaid,
+		"tx-1-4": withdrawal.StatusPending,
+		"tx-1-6": withdrawal.StatusPaid,
+		"tx-2-0": withdrawal.StatusPending,
+	}, statuses(withdrawals))
+	for _, w := range withdrawals {
+		switch w.L2TxHash {
+		case "tx-1-4":
+			assert.Equal(t, int64(3), w.AccountIndex)
+			assert.Equal(t, int64(1), w.AssetId)
+			assert.Equal(t, "50", w.AssetAmount)
+			assert.Equal(t, types.NilNftIndex, w.NftIndex)
+		case "tx-2-0":
+			assert.Equal(t, int64(8), w.NftIndex)
+			assert.Equal(t, int64(types.NilAssetId), w.AssetId)
+		}
+	}
+
+	// The zero-amount FullExit may emit Withdrawal as well.
+	events = append([]*withdrawalEvent{events[0], assetEvent(1, 0)}, events[1:]...)
+	withdrawals, err = m.getWithdrawals("0xverify", []int64{1, 2}, events)
+	require.NoError(t, err)
+	assert.Len(t, withdrawals, 4)
+
+	// The nft withdrawals always emit one event.
+	_, err = m.getWithdrawals("0xverify", []int64{1, 2}, events[:4])
+	assert.Error(t, err)
+	// All the events must be matched.
+	_, err = m.getWithdrawals("0xverify", []int64{1, 2}, append(events, assetEvent(1, 1)))
+	assert.Error(t, err)
+	// The db errors are returned as is.
+	_, err = m.getWithdrawals("0xverify", []int64{4}, nil)
+	assert.Equal(t, types.DbErrNotFound, err)
+
+	// The on-chain operations don't match the txs of the block.
+	blocks.blocks[4] = newTestWithdrawBlock(t, 4,
+		&testWithdrawOp{txType: types.TxTypeWithdraw, accountIndex: 2, assetId: 1, amount: 100})
+	blocks.blocks[4].Txs[0].TxType = types.TxTypeFullExit
+	_, err = m.getWithdrawOperations(4)
+	assert.Error(t, err)
+	blocks.blocks[4].Txs = nil
+	_, err = m.getWithdrawOperations(4)
+	assert.Error(t, err)
+}
+
+func TestConvertLogToWithdrawalEvent(t *testing.T) {
+	data, err := ZkBNBContractAbi.Events[EventNameWithdrawal].Inputs.NonIndexed().Pack(uint16(1), big.NewInt(100))
+	require.NoError(t, err)
+	event, err := convertLogToWithdrawalEvent(ethTypes.Log{Topics: []common.Hash{zkbnbLogWithdrawalSigHash}, Data: data})
+	require.NoError(t, err)
+	assert.True(t, event.matchAsset(1, big.NewInt(100)))
+
+	data, err = ZkBNBContractAbi.Events[EventNameWithdrawNft].Inputs.NonIndexed().
+		Pack(uint32(2), common.Address{}, withdrawalRecipient, big.NewInt(1))
+	require.NoError(t, err)
+	event, err = convertLogToWithdrawalEvent(ethTypes.Log{Topics: []common.Hash{zkbnbLogWithdrawNftSigHash}, Data: data})
+	require.NoError(t, err)
+	assert.True(t, event.matchNft(2, 5))
+	assert.False(t, event.pending)
+
+	event, err = convertLogToWithdrawalEvent(ethTypes.Log{
+		Topics: []common.Hash{zkbnbLogWithdrawalNftPendingSigHash, common.BigToHash(big.NewInt(5))},
+	})
+	require.NoError(t, err)
+	assert.True(t, event.matchNft(2, 5))
+	assert.True(t, event.pending)
+
+	_, err = convertLogToWithdrawalEvent(ethTypes.Log{Topics: []common.Hash{zkbnbLogWithdrawalSigHash}, Data: data[:10]})
+	assert.Error(t, err)
+	_, err = convertLogToWithdrawalEvent(ethTypes.Log{Topics: []common.Hash{zkbnbLogWithdrawalNftPendingSigHash}})
+	assert.Error(t, err)
+}
@@ -2,7 +2,10 @@ package config
 
 import (
 	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/prometheus"
 	"github.com/zeromicro/go-zero/core/stores/cache"
+
+	"github.com/bnb-chain/zkbnb/common/health"
 )
 
 type Config struct {
@@ -10,12 +13,36 @@ type Config struct {
 		DataSource string
 	}
 	CacheRedis cache.CacheConf
-	LogConf    logx.LogConf
-	KeyPath    struct {
-		ProvingKeyPath   []string
-		VerifyingKeyPath []string
-	}
-	BlockConfig struct {
+	//nolint:staticcheck
+	Prometheus prometheus.Config `json:",optional"`
+	//nolint:staticcheck
+	Health struct {
+		health.Config
+		// Max block witnesses waiting for proofs, 100 by default
+		MaxLag int64 `json:",optional"`
+	} `json:",optional"`
+	LogConf logx.LogConf
+	KeyPath KeyPath
+	// The keys of the circuits compiled with the other gas assets versions, the blocks of
+	// all the configured versions are proved.
+	ExtraKeyPaths []KeyPath `json:",optional"`
+	BlockConfig   struct {
 		OptionalBlockSizes []int
 	}
+	//nolint:staticcheck
+	Prover struct {
+		// The identity of the prover, the hostname and the process id by default
+		Id string `json:",optional"`
+		// Lease in seconds of the claimed block witness, 30 by default
+		LeaseDuration int `json:",optional"`
+		// Interval in seconds to renew the lease, a third of the lease by default
+		HeartbeatInterval int `json:",optional"`
+	} `json:",optional"`
+}
+
+type KeyPath struct {
+	ProvingKeyPath   []string
+	VerifyingKeyPath []string
+	// The version of the gas assets which the keys are set up with, 1 by default
+	GasAssetsVersion int64 `json:",optional"`
 }
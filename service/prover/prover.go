@@ -1,13 +1,17 @@
 package prover
 
 import (
+	"errors"
+	"sync/atomic"
 	"time"
 
 	"github.com/robfig/cron/v3"
 	"github.com/zeromicro/go-zero/core/conf"
 	"github.com/zeromicro/go-zero/core/logx"
 	"github.com/zeromicro/go-zero/core/proc"
+	"github.com/zeromicro/go-zero/core/prometheus"
 
+	"github.com/bnb-chain/zkbnb/common/health"
 	"github.com/bnb-chain/zkbnb/service/prover/config"
 	"github.com/bnb-chain/zkbnb/service/prover/prover"
 )
@@ -19,8 +23,22 @@ func Run(configFile string) error {
 	conf.MustLoad(configFile, &c)
 	logx.MustSetup(c.LogConf)
 	logx.DisableStat()
+	prometheus.StartAgent(c.Prometheus)
+
+	// Serve the health endpoints while the circuits are compiled, which could take a long time.
+	var circuitsLoaded int32
+	healthServer := health.NewServer(c.Health.Config)
+	healthServer.AddReadinessCheck("circuits", func() error {
+		if atomic.LoadInt32(&circuitsLoaded) == 0 {
+			return errors.New("the circuits are not compiled yet")
+		}
+		return nil
+	})
+	healthServer.Start()
 
 	p := prover.NewProver(c)
+	atomic.StoreInt32(&circuitsLoaded, 1)
+	p.RegisterHealthChecks(healthServer)
 	cronJob := cron.New(cron.WithChain(
 		cron.SkipIfStillRunning(cron.DiscardLogger),
 	))
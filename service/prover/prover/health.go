@@ -0,0 +1,31 @@
+package prover
+
+import (
+	"fmt"
+
+	"github.com/bnb-chain/zkbnb/common/health"
+	"github.com/bnb-chain/zkbnb/dao/blockwitness"
+)
+
+const defaultMaxLag = 100
+
+// RegisterHealthChecks registers the checks of the database and the block witnesses waiting
+// for proofs.
+func (p *Prover) RegisterHealthChecks(s *health.Server) {
+	maxLag := p.Config.Health.MaxLag
+	if maxLag <= 0 {
+		maxLag = defaultMaxLag
+	}
+
+	s.AddReadinessCheck("database", health.DatabaseCheck(p.DB))
+	s.AddReadinessCheck("lag", func() error {
+		lag, err := p.BlockWitnessModel.GetBlockWitnessesCountByStatus(blockwitness.StatusPublished)
+		if err != nil {
+			return fmt.Errorf("unable to get the lag: %v", err)
+		}
+		if lag > maxLag {
+			return fmt.Errorf("%d block witnesses waiting for proofs, more than %d", lag, maxLag)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,33 @@
+package prover
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	proofTimeMetric = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "zkbnb",
+		Subsystem: "prover",
+		Name:      "proof_seconds",
+		Help:      "Time to generate the proof of a block, by block size.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+	}, []string{"block_size"})
+	proverQueueDepthMetric = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "zkbnb",
+		Subsystem: "prover",
+		Name:      "queue_depth",
+		Help:      "Number of the block witnesses waiting to be claimed by the provers.",
+	})
+)
+
+func registerMetrics() error {
+	if err := prometheus.Register(proofTimeMetric); err != nil {
+		return fmt.Errorf("prometheus.Register proofTimeMetric error: %v", err)
+	}
+	if err := prometheus.Register(proverQueueDepthMetric); err != nil {
+		return fmt.Errorf("prometheus.Register proverQueueDepthMetric error: %v", err)
+	}
+	return nil
+}
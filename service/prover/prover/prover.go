@@ -3,21 +3,23 @@ package prover
 import (
 	"encoding/json"
 	"fmt"
+	"os"
+	"strconv"
+	"time"
 
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend/groth16"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/frontend/cs/r1cs"
 	"github.com/zeromicro/go-zero/core/logx"
-	"github.com/zeromicro/go-zero/core/stores/redis"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 
 	"github.com/bnb-chain/zkbnb-crypto/circuit"
 	"github.com/bnb-chain/zkbnb/common/prove"
-	"github.com/bnb-chain/zkbnb/common/redislock"
 	"github.com/bnb-chain/zkbnb/dao/blockwitness"
 	"github.com/bnb-chain/zkbnb/dao/proof"
+	"github.com/bnb-chain/zkbnb/dao/sysconfig"
 	"github.com/bnb-chain/zkbnb/service/prover/config"
 	"github.com/bnb-chain/zkbnb/types"
 )
@@ -25,23 +27,27 @@ import (
 type Prover struct {
 	Config config.Config
 
-	RedisConn *redis.Redis
+	// The identity of the prover in the block witness queue.
+	Id                string
+	LeaseDuration     time.Duration
+	HeartbeatInterval time.Duration
 
 	DB                *gorm.DB
 	ProofModel        proof.ProofModel
 	BlockWitnessModel blockwitness.BlockWitnessModel
+	SysConfigModel    sysconfig.SysConfigModel
 
-	VerifyingKeys      []groth16.VerifyingKey
-	ProvingKeys        []groth16.ProvingKey
 	OptionalBlockSizes []int
-	R1cs               []frontend.CompiledConstraintSystem
+	// gas assets version -> circuits
+	Circuits map[int64]*Circuits
 }
 
-func WithRedis(redisType string, redisPass string) redis.Option {
-	return func(p *redis.Redis) {
-		p.Type = redisType
-		p.Pass = redisPass
-	}
+// Circuits are the circuits of the optional block sizes compiled with a version of the gas assets.
+type Circuits struct {
+	GasAssets     *types.GasAssets
+	VerifyingKeys []groth16.VerifyingKey
+	ProvingKeys   []groth16.ProvingKey
+	R1cs          []frontend.CompiledConstraintSystem
 }
 
 func IsBlockSizesSorted(blockSizes []int) bool {
@@ -58,92 +64,140 @@ func NewProver(c config.Config) *Prover {
 	if err != nil {
 		logx.Errorf("gorm connect db error, err = %s", err.Error())
 	}
-	redisConn := redis.New(c.CacheRedis[0].Host, WithRedis(c.CacheRedis[0].Type, c.CacheRedis[0].Pass))
 	prover := &Prover{
 		Config:            c,
-		RedisConn:         redisConn,
+		Id:                c.Prover.Id,
+		LeaseDuration:     time.Duration(c.Prover.LeaseDuration) * time.Second,
+		HeartbeatInterval: time.Duration(c.Prover.HeartbeatInterval) * time.Second,
 		DB:                db,
 		BlockWitnessModel: blockwitness.NewBlockWitnessModel(db),
 		ProofModel:        proof.NewProofModel(db),
+		SysConfigModel:    sysconfig.NewSysConfigModel(db),
+		Circuits:          make(map[int64]*Circuits),
+	}
+
+	if prover.Id == "" {
+		hostname, _ := os.Hostname()
+		prover.Id = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+	if prover.LeaseDuration <= 0 {
+		prover.LeaseDuration = DefaultLeaseDuration
+	}
+	if prover.HeartbeatInterval <= 0 || prover.HeartbeatInterval >= prover.LeaseDuration {
+		prover.HeartbeatInterval = prover.LeaseDuration / 3
 	}
 
 	if !IsBlockSizesSorted(c.BlockConfig.OptionalBlockSizes) {
 		panic("invalid OptionalBlockSizes")
 	}
-
 	prover.OptionalBlockSizes = c.BlockConfig.OptionalBlockSizes
-	prover.ProvingKeys = make([]groth16.ProvingKey, len(prover.OptionalBlockSizes))
-	prover.VerifyingKeys = make([]groth16.VerifyingKey, len(prover.OptionalBlockSizes))
-	prover.R1cs = make([]frontend.CompiledConstraintSystem, len(prover.OptionalBlockSizes))
-	for i := 0; i < len(prover.OptionalBlockSizes); i++ {
+
+	cfgGasAssets, err := prover.SysConfigModel.GetSysConfigByName(types.SysGasAssets)
+	if err != nil {
+		panic("gas assets loading error: " + err.Error())
+	}
+	for _, keyPath := range append([]config.KeyPath{c.KeyPath}, c.ExtraKeyPaths...) {
+		version := keyPath.GasAssetsVersion
+		if version == 0 {
+			version = types.InitialGasAssetsVersion
+		}
+		gasAssets, err := types.ParseGasAssets(cfgGasAssets.Value, version)
+		if err != nil {
+			panic("gas assets loading error: " + err.Error())
+		}
+		if _, ok := prover.Circuits[version]; ok {
+			panic(fmt.Sprintf("duplicated keys of gas assets version %d", version))
+		}
+		prover.Circuits[version] = newCircuits(prover.OptionalBlockSizes, gasAssets, keyPath)
+	}
+
+	if err := registerMetrics(); err != nil {
+		panic(err)
+	}
+
+	return prover
+}
+
+func newCircuits(blockSizes []int, gasAssets *types.GasAssets, keyPath config.KeyPath) *Circuits {
+	var err error
+	circuits := &Circuits{
+		GasAssets:     gasAssets,
+		ProvingKeys:   make([]groth16.ProvingKey, len(blockSizes)),
+		VerifyingKeys: make([]groth16.VerifyingKey, len(blockSizes)),
+		R1cs:          make([]frontend.CompiledConstraintSystem, len(blockSizes)),
+	}
+	for i := 0; i < len(blockSizes); i++ {
 		var blockConstraints circuit.BlockConstraints
-		blockConstraints.TxsCount = prover.OptionalBlockSizes[i]
+		blockConstraints.TxsCount = blockSizes[i]
 		blockConstraints.Txs = make([]circuit.TxConstraints, blockConstraints.TxsCount)
 		for i := 0; i < blockConstraints.TxsCount; i++ {
 			blockConstraints.Txs[i] = circuit.GetZeroTxConstraint()
 		}
-		blockConstraints.GasAssetIds = types.GasAssets[:]
+		blockConstraints.GasAssetIds = gasAssets.AssetIds
 		blockConstraints.GasAccountIndex = types.GasAccount
-		blockConstraints.Gas = circuit.GetZeroGasConstraints(types.GasAssets[:])
+		blockConstraints.Gas = circuit.GetZeroGasConstraints(gasAssets.AssetIds)
 
-		logx.Infof("start compile block size %d blockConstraints", blockConstraints.TxsCount)
-		prover.R1cs[i], err = frontend.Compile(ecc.BN254, r1cs.NewBuilder, &blockConstraints, frontend.IgnoreUnconstrainedInputs())
+		logx.Infof("start compile block size %d blockConstraints, gas assets version %d", blockConstraints.TxsCount, gasAssets.Version)
+		circuits.R1cs[i], err = frontend.Compile(ecc.BN254, r1cs.NewBuilder, &blockConstraints, frontend.IgnoreUnconstrainedInputs())
 		if err != nil {
 			panic("r1cs init error")
 		}
-		logx.Infof("blockConstraints constraints: %d", prover.R1cs[i].GetNbConstraints())
+		logx.Infof("blockConstraints constraints: %d", circuits.R1cs[i].GetNbConstraints())
 		logx.Info("finish compile blockConstraints")
 		// read proving and verifying keys
-		prover.ProvingKeys[i], err = prove.LoadProvingKey(c.KeyPath.ProvingKeyPath[i])
+		circuits.ProvingKeys[i], err = prove.LoadProvingKey(keyPath.ProvingKeyPath[i])
 		if err != nil {
 			panic("provingKey loading error")
 		}
-		prover.VerifyingKeys[i], err = prove.LoadVerifyingKey(c.KeyPath.VerifyingKeyPath[i])
+		circuits.VerifyingKeys[i], err = prove.LoadVerifyingKey(keyPath.VerifyingKeyPath[i])
 		if err != nil {
 			panic("verifyingKey loading error")
 		}
 	}
+	return circuits
+}
 
-	return prover
+// GasAssetsVersions returns the gas assets versions of the circuits, the prover only claims
+// the block witnesses of these versions.
+func (p *Prover) GasAssetsVersions() []int64 {
+	versions := make([]int64, 0, len(p.Circuits))
+	for version := range p.Circuits {
+		versions = append(versions, version)
+	}
+	return versions
 }
 
-func (p *Prover) ProveBlock() error {
-	blockWitness, err := func() (*blockwitness.BlockWitness, error) {
-		lock := redislock.GetRedisLockByKey(p.RedisConn, RedisLockKey)
-		err := redislock.TryAcquireLock(lock)
-		if err != nil {
-			return nil, err
-		}
-		//nolint:errcheck
-		defer lock.Release()
+func (p *Prover) ProveBlock() (err error) {
+	if queueDepth, err := p.BlockWitnessModel.GetBlockWitnessesCountByStatus(blockwitness.StatusPublished); err != nil {
+		logx.Errorf("get block witnesses count failed, err: %v", err)
+	} else {
+		proverQueueDepthMetric.Set(float64(queueDepth))
+	}
 
-		// Fetch unproved block witness.
-		blockWitness, err := p.BlockWitnessModel.GetLatestBlockWitness()
-		if err != nil {
-			return nil, err
-		}
-		// Update status of block witness.
-		err = p.BlockWitnessModel.UpdateBlockWitnessStatus(blockWitness, blockwitness.StatusReceived)
-		if err != nil {
-			return nil, err
-		}
-		return blockWitness, nil
-	}()
+	// Claim the next unproved block witness, its lease is renewed while proving, so that
+	// the block witness is claimed by the other provers soon if this prover dies.
+	var blockWitness *blockwitness.BlockWitness
+	blockWitness, err = p.BlockWitnessModel.ClaimBlockWitness(p.Id, p.GasAssetsVersions(), p.LeaseDuration)
 	if err != nil {
 		if err == types.DbErrNotFound {
 			return nil
 		}
 		return err
 	}
+	logx.Infof("prover %s claims block witness of height %d", p.Id, blockWitness.Height)
+
+	stopHeartbeat := p.startHeartbeat(blockWitness)
+	defer stopHeartbeat()
+
 	defer func() {
 		if err == nil {
 			return
 		}
 
-		// Recover block witness status.
-		res := p.BlockWitnessModel.UpdateBlockWitnessStatus(blockWitness, blockwitness.StatusPublished)
+		// Release the block witness for the other provers.
+		res := p.BlockWitnessModel.ReleaseBlockWitness(blockWitness)
 		if res != nil {
-			logx.Errorf("revert block witness status failed, err %v", res)
+			logx.Errorf("release block witness failed, err %v", res)
 		}
 	}()
 
@@ -154,6 +208,10 @@ func (p *Prover) ProveBlock() error {
 		return err
 	}
 
+	circuits, ok := p.Circuits[blockWitness.GasAssetsVersion]
+	if !ok {
+		return fmt.Errorf("can't find circuits of gas assets version %d", blockWitness.GasAssetsVersion)
+	}
 	var keyIndex int
 	for ; keyIndex < len(p.OptionalBlockSizes); keyIndex++ {
 		if len(cryptoBlock.Txs) == p.OptionalBlockSizes[keyIndex] {
@@ -165,10 +223,12 @@ func (p *Prover) ProveBlock() error {
 	}
 
 	// Generate proof.
-	blockProof, err := prove.GenerateProof(p.R1cs[keyIndex], p.ProvingKeys[keyIndex], p.VerifyingKeys[keyIndex], cryptoBlock)
+	start := time.Now()
+	blockProof, err := prove.GenerateProof(circuits.R1cs[keyIndex], circuits.ProvingKeys[keyIndex], circuits.VerifyingKeys[keyIndex], cryptoBlock)
 	if err != nil {
 		return fmt.Errorf("failed to generateProof, err: %v", err)
 	}
+	proofTimeMetric.WithLabelValues(strconv.Itoa(len(cryptoBlock.Txs))).Observe(time.Since(start).Seconds())
 
 	formattedProof, err := prove.FormatProof(blockProof, cryptoBlock.OldStateRoot, cryptoBlock.NewStateRoot, cryptoBlock.BlockCommitment)
 	if err != nil {
@@ -185,6 +245,17 @@ func (p *Prover) ProveBlock() error {
 	_, err = p.ProofModel.GetProofByBlockHeight(blockWitness.Height)
 	if err == nil {
 		logx.Errorf("blockProof of height %d exists", blockWitness.Height)
+		err = p.BlockWitnessModel.CompleteBlockWitness(blockWitness)
+		if err == types.DbErrNotFound {
+			err = nil
+		}
+		return err
+	}
+
+	// Skip the proof if the block witness is deleted by a rollback while proving.
+	latestBlockWitness, err := p.BlockWitnessModel.GetBlockWitnessByHeight(blockWitness.Height)
+	if err != nil || latestBlockWitness.ID != blockWitness.ID {
+		logx.Errorf("block witness of height %d is reverted", blockWitness.Height)
 		return nil
 	}
 
@@ -194,9 +265,53 @@ func (p *Prover) ProveBlock() error {
 		Status:      proof.NotSent,
 	}
 	err = p.ProofModel.CreateProof(row)
+	if err != nil {
+		return err
+	}
+	// The block witness is claimed again if it fails to be completed, and the existing proof
+	// is found then.
+	err = p.BlockWitnessModel.CompleteBlockWitness(blockWitness)
+	if err == types.DbErrNotFound {
+		// Deleted by a rollback after the proof is created, or claimed by another prover
+		// after the lease expired, which finds the existing proof and completes it.
+		err = nil
+	}
 	return err
 }
 
+// startHeartbeat renews the lease of the claimed block witness in background until the returned
+// function is called. The renewals update the lease of a copy of the block witness, since the
+// block witness is used by the prover meanwhile.
+func (p *Prover) startHeartbeat(blockWitness *blockwitness.BlockWitness) (stop func()) {
+	leasedWitness := &blockwitness.BlockWitness{
+		Height: blockWitness.Height,
+		Prover: blockWitness.Prover,
+	}
+	leasedWitness.ID = blockWitness.ID
+	quitCh := make(chan struct{})
+	go p.heartbeat(leasedWitness, quitCh)
+	return func() {
+		close(quitCh)
+	}
+}
+
+// heartbeat renews the lease of the claimed block witness until the quit channel is closed.
+func (p *Prover) heartbeat(blockWitness *blockwitness.BlockWitness, quitCh chan struct{}) {
+	ticker := time.NewTicker(p.HeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			err := p.BlockWitnessModel.RenewBlockWitnessLease(blockWitness, p.LeaseDuration)
+			if err != nil {
+				logx.Errorf("renew lease of block witness %d failed, err: %v", blockWitness.Height, err)
+			}
+		case <-quitCh:
+			return
+		}
+	}
+}
+
 func (p *Prover) Shutdown() {
 	sqlDB, err := p.DB.DB()
 	if err == nil && sqlDB != nil {
@@ -0,0 +1,71 @@
+package prover
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/zkbnb/dao/blockwitness"
+)
+
+// testBlockWitnessModel renews the leases like the block witness model, i.e. the lease fields
+// of the given block witness are updated.
+type testBlockWitnessModel struct {
+	blockwitness.BlockWitnessModel
+	mu      sync.Mutex
+	renewed []blockwitness.BlockWitness
+}
+
+func (m *testBlockWitnessModel) RenewBlockWitnessLease(witness *blockwitness.BlockWitness, leaseDuration time.Duration) error {
+	witness.LeaseExpiredAt = time.Now().Add(leaseDuration).UnixMilli()
+	witness.UpdatedAt = time.Now()
+	m.mu.Lock()
+	m.renewed = append(m.renewed, *witness)
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *testBlockWitnessModel) renewedCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.renewed)
+}
+
+func TestHeartbeat(t *testing.T) {
+	model := &testBlockWitnessModel{}
+	p := &Prover{
+		LeaseDuration:     time.Minute,
+		HeartbeatInterval: time.Millisecond,
+		BlockWitnessModel: model,
+	}
+	blockWitness := &blockwitness.BlockWitness{
+		Height:         10,
+		Status:         blockwitness.StatusReceived,
+		Prover:         "prover-1",
+		LeaseExpiredAt: 1000,
+	}
+	blockWitness.ID = 3
+
+	// The block witness is used while the lease is renewed, which is caught by the race
+	// detector if the renewals write to it.
+	stopHeartbeat := p.startHeartbeat(blockWitness)
+	for model.renewedCount() < 3 {
+		blockWitness.LeaseExpiredAt = 1000
+		blockWitness.UpdatedAt = time.Time{}
+		time.Sleep(time.Millisecond)
+	}
+	stopHeartbeat()
+
+	assert.Equal(t, int64(1000), blockWitness.LeaseExpiredAt)
+	model.mu.Lock()
+	defer model.mu.Unlock()
+	require.GreaterOrEqual(t, len(model.renewed), 3)
+	for _, renewed := range model.renewed {
+		assert.Equal(t, uint(3), renewed.ID)
+		assert.Equal(t, "prover-1", renewed.Prover)
+		assert.Greater(t, renewed.LeaseExpiredAt, time.Now().UnixMilli())
+	}
+}
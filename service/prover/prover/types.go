@@ -16,4 +16,6 @@
 
 package prover
 
-const RedisLockKey = "prover_mutex_key"
+import "time"
+
+const DefaultLeaseDuration = 30 * time.Second
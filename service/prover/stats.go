@@ -0,0 +1,42 @@
+package prover
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/zeromicro/go-zero/core/conf"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/zkbnb/dao/blockwitness"
+	"github.com/bnb-chain/zkbnb/service/prover/config"
+)
+
+var statusNames = map[int64]string{
+	blockwitness.StatusReceived: "proving",
+	blockwitness.StatusProved:   "proved",
+}
+
+// Stats prints the block witnesses claimed by each prover, grouped by status.
+func Stats(configFile string) error {
+	var c config.Config
+	conf.MustLoad(configFile, &c)
+
+	db, err := gorm.Open(postgres.Open(c.Postgres.DataSource))
+	if err != nil {
+		return fmt.Errorf("gorm connect db error, err: %v", err)
+	}
+	stats, err := blockwitness.NewBlockWitnessModel(db).GetProverStats()
+	if err != nil {
+		return fmt.Errorf("get prover stats error, err: %v", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROVER\tSTATUS\tCOUNT\tMAX HEIGHT\tLAST ACTIVE")
+	for _, stat := range stats {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\n", stat.Prover, statusNames[stat.Status], stat.Count,
+			stat.MaxHeight, stat.LastActiveAt.Format("2006-01-02 15:04:05"))
+	}
+	return w.Flush()
+}
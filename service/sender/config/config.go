@@ -2,6 +2,9 @@ package config
 
 import (
 	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/prometheus"
+
+	"github.com/bnb-chain/zkbnb/common/health"
 )
 
 type Config struct {
@@ -10,12 +13,40 @@ type Config struct {
 	}
 	ChainConfig struct {
 		NetworkRPCSysConfigName string
-		MaxWaitingTime          int64
-		MaxBlockCount           int
-		ConfirmBlocksCount      uint64
-		Sk                      string
-		GasLimit                uint64
-		GasPrice                uint64
+		// Seconds a rollup tx waits to be mined before it is replaced with bumped fees
+		MaxWaitingTime     int64
+		MaxBlockCount      int
+		ConfirmBlocksCount uint64
+		Sk                 string
+		GasLimit           uint64
+		// Legacy gas price in wei, the suggested fees are used if it is 0
+		GasPrice uint64
+		// Max gas price or fee cap in wei of the rollup txs and their replacements, no limit if 0
+		MaxGasPrice uint64 `json:",optional"`
+		// Percent of the fees bumped by the replacement, at least 10
+		GasPriceBumpPercent uint64 `json:",default=20"`
 	}
+	// Gas estimation of the rollup txs for the batching, the built-in values are used if 0
+	//nolint:staticcheck
+	Batching struct {
+		CommitBaseGas      uint64 `json:",optional"`
+		CommitBlockGas     uint64 `json:",optional"`
+		CalldataGasPerByte uint64 `json:",optional"`
+		VerifyBaseGas      uint64 `json:",optional"`
+		VerifyProofGas     uint64 `json:",optional"`
+		// The batch waits for more blocks if its gas per block is above the target, no wait if 0
+		TargetCommitGasPerBlock uint64 `json:",optional"`
+		TargetVerifyGasPerBlock uint64 `json:",optional"`
+		// Max seconds a block waits to be batched, 600 by default
+		MaxBatchLatency int64 `json:",optional"`
+	} `json:",optional"`
+	//nolint:staticcheck
+	Prometheus prometheus.Config `json:",optional"`
+	//nolint:staticcheck
+	Health struct {
+		health.Config
+		// Max seconds a rollup tx stays pending, 600 by default
+		MaxPendingTxAge int64 `json:",optional"`
+	} `json:",optional"`
 	LogConf logx.LogConf
 }
@@ -7,7 +7,9 @@ import (
 	"github.com/zeromicro/go-zero/core/conf"
 	"github.com/zeromicro/go-zero/core/logx"
 	"github.com/zeromicro/go-zero/core/proc"
+	"github.com/zeromicro/go-zero/core/prometheus"
 
+	"github.com/bnb-chain/zkbnb/common/health"
 	"github.com/bnb-chain/zkbnb/service/sender/config"
 	"github.com/bnb-chain/zkbnb/service/sender/sender"
 )
@@ -19,8 +21,12 @@ func Run(configFile string) error {
 	conf.MustLoad(configFile, &c)
 	logx.MustSetup(c.LogConf)
 	logx.DisableStat()
+	prometheus.StartAgent(c.Prometheus)
 
 	s := sender.NewSender(c)
+	healthServer := health.NewServer(c.Health.Config)
+	s.RegisterHealthChecks(healthServer)
+	healthServer.Start()
 	// new cron
 	cronJob := cron.New(cron.WithChain(
 		cron.SkipIfStillRunning(cron.DiscardLogger),
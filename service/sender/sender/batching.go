@@ -0,0 +1,201 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sender
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb/dao/block"
+	"github.com/bnb-chain/zkbnb/dao/compressedblock"
+	sconfig "github.com/bnb-chain/zkbnb/service/sender/config"
+)
+
+const (
+	defaultCommitBaseGas      = 60000
+	defaultCommitBlockGas     = 40000
+	defaultCalldataGasPerByte = 16
+	defaultVerifyBaseGas      = 60000
+	defaultVerifyProofGas     = 300000
+	defaultMaxBatchLatency    = 600
+)
+
+// batchCandidate is a block which is ready to be committed or verified.
+type batchCandidate struct {
+	height int64
+	// gas spent by the block in the rollup tx, the base gas of the tx excluded
+	gas uint64
+	// time since which the block is waiting to be sent
+	readyAt time.Time
+}
+
+// batchDecision is the blocks sent in the next rollup tx, or the reason to wait for more.
+type batchDecision struct {
+	count  int
+	gas    uint64
+	wait   bool
+	reason string
+}
+
+// batchPolicy picks the blocks of the commit and verify txs. The gas of a batch is estimated
+// with the base gas of the tx and the gas of each block, the pub data size for the commit and
+// the proof for the verify. The batch is the largest one under the gas limit, it waits for
+// more blocks if the gas per block is above the target, but not longer than the max latency.
+type batchPolicy struct {
+	maxBlockCount int
+	gasLimit      uint64
+
+	commitBaseGas      uint64
+	commitBlockGas     uint64
+	calldataGasPerByte uint64
+	verifyBaseGas      uint64
+	verifyProofGas     uint64
+
+	targetCommitGasPerBlock uint64
+	targetVerifyGasPerBlock uint64
+	maxLatency              time.Duration
+}
+
+func newBatchPolicy(c sconfig.Config) *batchPolicy {
+	b := c.Batching
+	return &batchPolicy{
+		maxBlockCount:           c.ChainConfig.MaxBlockCount,
+		gasLimit:                c.ChainConfig.GasLimit,
+		commitBaseGas:           withDefault(b.CommitBaseGas, defaultCommitBaseGas),
+		commitBlockGas:          withDefault(b.CommitBlockGas, defaultCommitBlockGas),
+		calldataGasPerByte:      withDefault(b.CalldataGasPerByte, defaultCalldataGasPerByte),
+		verifyBaseGas:           withDefault(b.VerifyBaseGas, defaultVerifyBaseGas),
+		verifyProofGas:          withDefault(b.VerifyProofGas, defaultVerifyProofGas),
+		targetCommitGasPerBlock: b.TargetCommitGasPerBlock,
+		targetVerifyGasPerBlock: b.TargetVerifyGasPerBlock,
+		maxLatency:              time.Duration(withDefault(uint64(b.MaxBatchLatency), defaultMaxBatchLatency)) * time.Second,
+	}
+}
+
+func withDefault(value, defaultValue uint64) uint64 {
+	if value == 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// commitCandidates returns the compressed blocks with the gas of their pub data in the
+// commit tx.
+func (p *batchPolicy) commitCandidates(blocks []*compressedblock.CompressedBlock) []*batchCandidate {
+	candidates := make([]*batchCandidate, 0, len(blocks))
+	for _, b := range blocks {
+		// the pub data is hex encoded
+		pubDataSize := uint64(len(b.PublicData) / 2)
+		candidates = append(candidates, &batchCandidate{
+			height:  b.BlockHeight,
+			gas:     p.commitBlockGas + pubDataSize*p.calldataGasPerByte,
+			readyAt: time.UnixMilli(b.Timestamp),
+		})
+	}
+	return candidates
+}
+
+// verifyCandidates returns the committed blocks with the gas of their proofs in the verify
+// tx, a block is waiting since it is committed on L1.
+func (p *batchPolicy) verifyCandidates(blocks []*block.Block) []*batchCandidate {
+	candidates := make([]*batchCandidate, 0, len(blocks))
+	for _, b := range blocks {
+		readyAt := b.CreatedAt
+		if b.CommittedAt > 0 {
+			readyAt = time.Unix(b.CommittedAt, 0)
+		}
+		candidates = append(candidates, &batchCandidate{
+			height:  b.BlockHeight,
+			gas:     p.verifyProofGas,
+			readyAt: readyAt,
+		})
+	}
+	return candidates
+}
+
+func (p *batchPolicy) decideCommit(candidates []*batchCandidate, now time.Time) *batchDecision {
+	return p.decide(candidates, p.commitBaseGas, p.targetCommitGasPerBlock, now)
+}
+
+func (p *batchPolicy) decideVerify(candidates []*batchCandidate, now time.Time) *batchDecision {
+	return p.decide(candidates, p.verifyBaseGas, p.targetVerifyGasPerBlock, now)
+}
+
+func (p *batchPolicy) decide(candidates []*batchCandidate, baseGas, targetGasPerBlock uint64, now time.Time) *batchDecision {
+	if len(candidates) == 0 {
+		return &batchDecision{wait: true, reason: "no block is ready"}
+	}
+
+	d := &batchDecision{gas: baseGas}
+	for _, c := range candidates {
+		if p.maxBlockCount > 0 && d.count >= p.maxBlockCount {
+			d.reason = fmt.Sprintf("max block count %d is reached", p.maxBlockCount)
+			break
+		}
+		if p.gasLimit > 0 && d.gas+c.gas > p.gasLimit {
+			d.reason = fmt.Sprintf("the next block exceeds the gas limit %d", p.gasLimit)
+			break
+		}
+		d.gas += c.gas
+		d.count++
+	}
+	if d.count == 0 {
+		// the estimation may be too high, the single block is sent anyway to not stop the rollup
+		d.count, d.gas = 1, baseGas+candidates[0].gas
+		d.reason = fmt.Sprintf("a single block exceeds the gas limit %d", p.gasLimit)
+		return d
+	}
+	if d.reason != "" {
+		// the batch is full, more blocks don't lower the gas per block
+		return d
+	}
+
+	gasPerBlock := d.gas / uint64(d.count)
+	if targetGasPerBlock == 0 {
+		d.reason = "all the ready blocks are batched"
+		return d
+	}
+	if gasPerBlock <= targetGasPerBlock {
+		d.reason = fmt.Sprintf("gas per block %d is under the target %d", gasPerBlock, targetGasPerBlock)
+		return d
+	}
+	latency := now.Sub(candidates[0].readyAt)
+	if latency >= p.maxLatency {
+		d.reason = fmt.Sprintf("gas per block %d is above the target %d, but the latency %s reaches the max %s",
+			gasPerBlock, targetGasPerBlock, latency.Truncate(time.Second), p.maxLatency)
+		return d
+	}
+	d.wait = true
+	d.reason = fmt.Sprintf("gas per block %d is above the target %d, wait for more blocks for %s",
+		gasPerBlock, targetGasPerBlock, (p.maxLatency - latency).Truncate(time.Second))
+	return d
+}
+
+func logBatchDecision(txType string, candidates []*batchCandidate, d *batchDecision) {
+	if len(candidates) == 0 {
+		return
+	}
+	if d.wait {
+		logx.Infof("%s batch waits, ready blocks: %d-%d, estimated gas: %d, reason: %s",
+			txType, candidates[0].height, candidates[d.count-1].height, d.gas, d.reason)
+		return
+	}
+	logx.Infof("%s batch is sent, blocks: %d-%d, ready blocks: %d, estimated gas: %d, reason: %s",
+		txType, candidates[0].height, candidates[d.count-1].height, len(candidates), d.gas, d.reason)
+}
@@ -0,0 +1,245 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sender
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	zkbnb "github.com/bnb-chain/zkbnb-eth-rpc/core"
+	"github.com/bnb-chain/zkbnb/common/l1"
+	"github.com/bnb-chain/zkbnb/common/prove"
+	"github.com/bnb-chain/zkbnb/dao/block"
+	"github.com/bnb-chain/zkbnb/dao/compressedblock"
+	"github.com/bnb-chain/zkbnb/dao/l1rolluptx"
+	"github.com/bnb-chain/zkbnb/dao/proof"
+	"github.com/bnb-chain/zkbnb/dao/rollback"
+	"github.com/bnb-chain/zkbnb/dao/sysconfig"
+	sconfig "github.com/bnb-chain/zkbnb/service/sender/config"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+func TestBatchPolicyDecide(t *testing.T) {
+	now := time.Now()
+	candidates := func(count int, gas uint64, age time.Duration) []*batchCandidate {
+		result := make([]*batchCandidate, 0, count)
+		for i := 0; i < count; i++ {
+			result = append(result, &batchCandidate{height: int64(i + 1), gas: gas, readyAt: now.Add(-age)})
+		}
+		return result
+	}
+	policy := func(targetGasPerBlock uint64) *batchPolicy {
+		return &batchPolicy{
+			maxBlockCount:           5,
+			gasLimit:                500000,
+			commitBaseGas:           100000,
+			targetCommitGasPerBlock: targetGasPerBlock,
+			maxLatency:              time.Minute,
+		}
+	}
+
+	tests := []struct {
+		name       string
+		policy     *batchPolicy
+		candidates []*batchCandidate
+		count      int
+		gas        uint64
+		wait       bool
+		reason     string
+	}{
+		{"no block", policy(0), nil, 0, 0, true, "no block is ready"},
+		{"max block count", policy(120000), candidates(10, 50000, 0), 5, 350000, false, "max block count 5 is reached"},
+		{"gas limit", policy(0), candidates(4, 150000, 0), 2, 400000, false, "the next block exceeds the gas limit 500000"},
+		{"single oversize block", policy(0), candidates(2, 600000, 0), 1, 700000, false, "a single block exceeds the gas limit 500000"},
+		{"no target", policy(0), candidates(3, 50000, 0), 3, 250000, false, "all the ready blocks are batched"},
+		{"under target", policy(100000), candidates(3, 50000, 0), 3, 250000, false, "gas per block 83333 is under the target 100000"},
+		{"above target", policy(100000), candidates(1, 50000, 10*time.Second), 1, 150000, true,
+			"gas per block 150000 is above the target 100000, wait for more blocks for 50s"},
+		{"max latency", policy(100000), candidates(1, 50000, 2*time.Minute), 1, 150000, false,
+			"gas per block 150000 is above the target 100000, but the latency 2m0s reaches the max 1m0s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := tt.policy.decideCommit(tt.candidates, now)
+			assert.Equal(t, tt.count, d.count)
+			assert.Equal(t, tt.gas, d.gas)
+			assert.Equal(t, tt.wait, d.wait)
+			assert.Equal(t, tt.reason, d.reason)
+		})
+	}
+}
+
+func TestBatchPolicyCandidates(t *testing.T) {
+	var c sconfig.Config
+	c.ChainConfig.MaxBlockCount = 3
+	policy := newBatchPolicy(c)
+
+	commitCandidates := policy.commitCandidates([]*compressedblock.CompressedBlock{
+		{BlockHeight: 1, PublicData: "00ff00ff", Timestamp: 1000},
+	})
+	require.Len(t, commitCandidates, 1)
+	assert.Equal(t, uint64(defaultCommitBlockGas+4*defaultCalldataGasPerByte), commitCandidates[0].gas)
+	assert.Equal(t, time.UnixMilli(1000), commitCandidates[0].readyAt)
+
+	verifyCandidates := policy.verifyCandidates([]*block.Block{
+		{BlockHeight: 1, CommittedAt: 2000},
+		{BlockHeight: 2},
+	})
+	require.Len(t, verifyCandidates, 2)
+	assert.Equal(t, uint64(defaultVerifyProofGas), verifyCandidates[0].gas)
+	assert.Equal(t, time.Unix(2000, 0), verifyCandidates[0].readyAt)
+	assert.Equal(t, verifyCandidates[1].readyAt, time.Time{})
+}
+
+func TestVerifyAndExecuteBlocksContiguousProofs(t *testing.T) {
+	blocks := make([]*block.Block, 0, 4)
+	for height := int64(1); height <= 4; height++ {
+		blocks = append(blocks, &block.Block{BlockHeight: height, BlockStatus: block.StatusCommitted})
+	}
+	// the proof of block 3 is not ready, so only the blocks 1 and 2 could be verified
+	proofs := []*proof.Proof{newTestProof(t, 1), newTestProof(t, 2), newTestProof(t, 4)}
+
+	var c sconfig.Config
+	c.ChainConfig.MaxBlockCount = 10
+	c.ChainConfig.GasLimit = 20000000
+	c.ChainConfig.GasPrice = 1000000000
+	rollupTxModel := &testL1RollupTxModel{}
+	provider := &testProvider{}
+	s := &Sender{
+		config:          c,
+		batchPolicy:     newBatchPolicy(c),
+		cli:             provider,
+		blockModel:      &testBlockModel{blocks: blocks},
+		l1RollupTxModel: rollupTxModel,
+		sysConfigModel:  &testSysConfigModel{},
+		proofModel:      &testProofModel{proofs: proofs},
+		rollbackModel:   &testRollbackModel{},
+	}
+
+	require.NoError(t, s.VerifyAndExecuteBlocks())
+	require.Len(t, provider.verifiedBlocks, 2)
+	assert.Equal(t, uint32(1), provider.verifiedBlocks[0].BlockHeader.BlockNumber)
+	assert.Equal(t, uint32(2), provider.verifiedBlocks[1].BlockHeader.BlockNumber)
+	assert.Len(t, provider.proofs, 2*proofSize)
+	require.Len(t, rollupTxModel.created, 1)
+	assert.Equal(t, int64(2), rollupTxModel.created[0].L2BlockHeight)
+	assert.Equal(t, uint8(l1rolluptx.TxTypeVerifyAndExecute), rollupTxModel.created[0].TxType)
+}
+
+// proofSize is the number of the proof elements of a block in the verify tx.
+const proofSize = 8
+
+func newTestProof(t *testing.T, height int64) *proof.Proof {
+	one := big.NewInt(1)
+	proofInfo, err := json.Marshal(&prove.FormattedProof{
+		A:      [2]*big.Int{one, one},
+		B:      [2][2]*big.Int{{one, one}, {one, one}},
+		C:      [2]*big.Int{one, one},
+		Inputs: [3]*big.Int{one, one, one},
+	})
+	require.NoError(t, err)
+	return &proof.Proof{BlockNumber: height, ProofInfo: string(proofInfo), Status: proof.NotSent}
+}
+
+type testProvider struct {
+	l1.Provider
+	verifiedBlocks []zkbnb.OldZkBNBVerifyAndExecuteBlockInfo
+	proofs         []*big.Int
+}
+
+func (p *testProvider) VerifyAndExecuteBlocks(blocks []zkbnb.OldZkBNBVerifyAndExecuteBlockInfo, proofs []*big.Int,
+	opts *l1.TxOptions) (*ethTypes.Transaction, error) {
+	p.verifiedBlocks = blocks
+	p.proofs = proofs
+	return ethTypes.NewTx(&ethTypes.LegacyTx{GasPrice: opts.GasPrice, Gas: opts.GasLimit}), nil
+}
+
+type testRollbackModel struct {
+	rollback.RollbackModel
+}
+
+func (m *testRollbackModel) GetPendingRollbacksCount() (int64, error) {
+	return 0, nil
+}
+
+type testL1RollupTxModel struct {
+	l1rolluptx.L1RollupTxModel
+	created []*l1rolluptx.L1RollupTx
+}
+
+func (m *testL1RollupTxModel) GetLatestPendingTx(txType int64) (*l1rolluptx.L1RollupTx, error) {
+	return nil, types.DbErrNotFound
+}
+
+func (m *testL1RollupTxModel) GetLatestHandledTx(txType int64) (*l1rolluptx.L1RollupTx, error) {
+	return nil, types.DbErrNotFound
+}
+
+func (m *testL1RollupTxModel) CreateL1RollupTx(tx *l1rolluptx.L1RollupTx) error {
+	m.created = append(m.created, tx)
+	return nil
+}
+
+type testBlockModel struct {
+	block.BlockModel
+	blocks []*block.Block
+}
+
+func (m *testBlockModel) GetCommittedBlocksBetween(start, end int64) ([]*block.Block, error) {
+	var blocks []*block.Block
+	for _, b := range m.blocks {
+		if b.BlockHeight >= start && b.BlockHeight <= end && b.BlockStatus == block.StatusCommitted {
+			blocks = append(blocks, b)
+		}
+	}
+	if len(blocks) == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return blocks, nil
+}
+
+type testProofModel struct {
+	proof.ProofModel
+	proofs []*proof.Proof
+}
+
+func (m *testProofModel) GetProofsBetween(start int64, end int64) ([]*proof.Proof, error) {
+	var proofs []*proof.Proof
+	for _, p := range m.proofs {
+		if p.BlockNumber >= start && p.BlockNumber <= end {
+			proofs = append(proofs, p)
+		}
+	}
+	if len(proofs) == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return proofs, nil
+}
+
+type testSysConfigModel struct {
+	sysconfig.SysConfigModel
+}
+
+func (m *testSysConfigModel) UpsertSysConfig(config *sysconfig.SysConfig) error {
+	return nil
+}
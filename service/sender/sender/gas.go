@@ -0,0 +1,157 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sender
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb/common/l1"
+	"github.com/bnb-chain/zkbnb/dao/l1rolluptx"
+	"github.com/bnb-chain/zkbnb/dao/sysconfig"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+// minGasPriceBumpPercent is the min bump of the replacement tx accepted by the L1 nodes.
+const minGasPriceBumpPercent = 10
+
+// newTxOptions returns the fees of a new rollup tx. The EIP-1559 fee caps are used if the
+// L1 chain has the base fee, unless the gas price is configured.
+func (s *Sender) newTxOptions() (*l1.TxOptions, error) {
+	opts := &l1.TxOptions{GasLimit: s.config.ChainConfig.GasLimit}
+	if s.config.ChainConfig.GasPrice == 0 {
+		header, err := s.cli.GetBlockHeaderByNumber(nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the latest l1 header: %v", err)
+		}
+		if header.BaseFee != nil {
+			tipCap, err := s.cli.SuggestGasTipCap(context.Background())
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch gas tip cap: %v", err)
+			}
+			// the fee cap keeps the tx valid while the base fee doubles
+			feeCap := new(big.Int).Add(new(big.Int).Mul(header.BaseFee, big.NewInt(2)), tipCap)
+			opts.GasPrice = s.capGasPrice(feeCap)
+			opts.GasTipCap = minBigInt(tipCap, opts.GasPrice)
+			s.publishGasPrice(new(big.Int).Add(header.BaseFee, tipCap))
+			return opts, nil
+		}
+	}
+
+	gasPrice, err := s.getGasPrice()
+	if err != nil {
+		return nil, err
+	}
+	opts.GasPrice = s.capGasPrice(gasPrice)
+	return opts, nil
+}
+
+// replacementTxOptions returns the options replacing the stuck tx with the same nonce. The
+// fees are bumped by the configured percent, or set to the current fees if they are
+// higher. It returns nil if the fees can't be bumped enough under the max gas price.
+func (s *Sender) replacementTxOptions(stuckTx *l1rolluptx.L1RollupTx) (*l1.TxOptions, error) {
+	oldGasPrice, ok := new(big.Int).SetString(stuckTx.GasPrice, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid gas price %q", stuckTx.GasPrice)
+	}
+	oldTipCap := oldGasPrice
+	if stuckTx.GasTipCap != "" {
+		if oldTipCap, ok = new(big.Int).SetString(stuckTx.GasTipCap, 10); !ok {
+			return nil, fmt.Errorf("invalid gas tip cap %q", stuckTx.GasTipCap)
+		}
+	}
+	opts, err := s.newTxOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	bumpPercent := s.config.ChainConfig.GasPriceBumpPercent
+	if bumpPercent < minGasPriceBumpPercent {
+		bumpPercent = minGasPriceBumpPercent
+	}
+	opts.Nonce = &stuckTx.Nonce
+	opts.GasPrice = s.capGasPrice(bumpGasPrice(oldGasPrice, opts.GasPrice, bumpPercent))
+	if opts.GasPrice.Cmp(bumpGasPrice(oldGasPrice, nil, minGasPriceBumpPercent)) < 0 {
+		return nil, nil
+	}
+	if opts.GasTipCap != nil {
+		opts.GasTipCap = minBigInt(bumpGasPrice(oldTipCap, opts.GasTipCap, bumpPercent), opts.GasPrice)
+		if opts.GasTipCap.Cmp(bumpGasPrice(oldTipCap, nil, minGasPriceBumpPercent)) < 0 {
+			return nil, nil
+		}
+	}
+	return opts, nil
+}
+
+// getGasPrice returns the configured gas price or the suggested one, the gas price is
+// published in the sysconfig for the gas fee oracle.
+func (s *Sender) getGasPrice() (*big.Int, error) {
+	var gasPrice *big.Int
+	if s.config.ChainConfig.GasPrice > 0 {
+		gasPrice = big.NewInt(int64(s.config.ChainConfig.GasPrice))
+	} else {
+		var err error
+		gasPrice, err = s.cli.SuggestGasPrice(context.Background())
+		if err != nil {
+			logx.Errorf("failed to fetch gas price: %v", err)
+			return nil, err
+		}
+	}
+	s.publishGasPrice(gasPrice)
+	return gasPrice, nil
+}
+
+func (s *Sender) publishGasPrice(gasPrice *big.Int) {
+	err := s.sysConfigModel.UpsertSysConfig(&sysconfig.SysConfig{
+		Name:      types.L1GasPrice,
+		Value:     gasPrice.String(),
+		ValueType: "string",
+		Comment:   "L1 gas price in wei",
+	})
+	if err != nil {
+		logx.Errorf("failed to publish gas price: %v", err)
+	}
+}
+
+func (s *Sender) capGasPrice(gasPrice *big.Int) *big.Int {
+	maxGasPrice := s.config.ChainConfig.MaxGasPrice
+	if maxGasPrice > 0 && gasPrice.Cmp(new(big.Int).SetUint64(maxGasPrice)) > 0 {
+		return new(big.Int).SetUint64(maxGasPrice)
+	}
+	return gasPrice
+}
+
+// bumpGasPrice returns the price bumped by the percent, or the current price if it is
+// higher.
+func bumpGasPrice(price, currentPrice *big.Int, percent uint64) *big.Int {
+	bumped := new(big.Int).Mul(price, new(big.Int).SetUint64(100+percent))
+	bumped.Div(bumped, big.NewInt(100))
+	if currentPrice != nil && currentPrice.Cmp(bumped) > 0 {
+		return currentPrice
+	}
+	return bumped
+}
+
+func minBigInt(a, b *big.Int) *big.Int {
+	if a.Cmp(b) < 0 {
+		return a
+	}
+	return b
+}
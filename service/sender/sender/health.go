@@ -0,0 +1,43 @@
+package sender
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bnb-chain/zkbnb/common/health"
+	"github.com/bnb-chain/zkbnb/dao/l1rolluptx"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+const defaultMaxPendingTxAge = 10 * time.Minute
+
+// RegisterHealthChecks registers the checks of the database, the L1 rpc and the pending
+// rollup txs, a rollup tx is stuck if it stays pending for too long.
+func (s *Sender) RegisterHealthChecks(server *health.Server) {
+	maxPendingTxAge := time.Duration(s.config.Health.MaxPendingTxAge) * time.Second
+	if maxPendingTxAge <= 0 {
+		maxPendingTxAge = defaultMaxPendingTxAge
+	}
+
+	server.AddReadinessCheck("database", health.DatabaseCheck(s.db))
+	server.AddReadinessCheck("l1_rpc", func() error {
+		_, err := s.cli.GetHeight()
+		return err
+	})
+	server.AddReadinessCheck("rollup_txs", func() error {
+		pendingTxs, err := s.l1RollupTxModel.GetL1RollupTxsByStatus(l1rolluptx.StatusPending)
+		if err != nil {
+			if err == types.DbErrNotFound {
+				return nil
+			}
+			return fmt.Errorf("unable to get the pending rollup txs: %v", err)
+		}
+		for _, pendingTx := range pendingTxs {
+			if age := time.Since(pendingTx.CreatedAt); age > maxPendingTxAge {
+				return fmt.Errorf("rollup tx %s of block %d is pending for %s", pendingTx.L1TxHash,
+					pendingTx.L2BlockHeight, age.Truncate(time.Second))
+			}
+		}
+		return nil
+	})
+}
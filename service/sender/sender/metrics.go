@@ -0,0 +1,122 @@
+package sender
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/bnb-chain/zkbnb/dao/block"
+	"github.com/bnb-chain/zkbnb/dao/l1rolluptx"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+var rollupTxTypeNames = map[uint8]string{
+	l1rolluptx.TxTypeCommit:           "commit",
+	l1rolluptx.TxTypeVerifyAndExecute: "verify_and_execute",
+}
+
+var (
+	commitLagMetric = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "zkbnb",
+		Subsystem: "sender",
+		Name:      "commit_lag_blocks",
+		Help:      "Number of the sealed blocks not committed on L1 yet.",
+	})
+	verifyLagMetric = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "zkbnb",
+		Subsystem: "sender",
+		Name:      "verify_lag_blocks",
+		Help:      "Number of the blocks committed on L1 but not verified yet.",
+	})
+	l1GasUsedMetric = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "zkbnb",
+		Subsystem: "sender",
+		Name:      "l1_gas_used_total",
+		Help:      "L1 gas spent by the handled rollup txs, by tx type.",
+	}, []string{"tx_type"})
+	pendingRollupTxAgeMetric = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "zkbnb",
+		Subsystem: "sender",
+		Name:      "pending_rollup_tx_age_seconds",
+		Help:      "Age of the oldest pending rollup tx, by tx type.",
+	}, []string{"tx_type"})
+	replacedTxsMetric = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "zkbnb",
+		Subsystem: "sender",
+		Name:      "replaced_rollup_txs_total",
+		Help:      "Number of the stuck rollup txs replaced with bumped fees, by tx type.",
+	}, []string{"tx_type"})
+)
+
+func registerMetrics() error {
+	metrics := map[string]prometheus.Collector{
+		"commitLagMetric":          commitLagMetric,
+		"verifyLagMetric":          verifyLagMetric,
+		"l1GasUsedMetric":          l1GasUsedMetric,
+		"pendingRollupTxAgeMetric": pendingRollupTxAgeMetric,
+		"replacedTxsMetric":        replacedTxsMetric,
+	}
+	for name, metric := range metrics {
+		if err := prometheus.Register(metric); err != nil {
+			return fmt.Errorf("prometheus.Register %s error: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// updatePendingTxAge reports the age of the oldest pending rollup tx of each type, or zero
+// if there is no pending one.
+func updatePendingTxAge(pendingTxs []*l1rolluptx.L1RollupTx) {
+	ages := make(map[uint8]float64, len(rollupTxTypeNames))
+	for txType := range rollupTxTypeNames {
+		ages[txType] = 0
+	}
+	for _, pendingTx := range pendingTxs {
+		age := time.Since(pendingTx.CreatedAt).Seconds()
+		if age > ages[pendingTx.TxType] {
+			ages[pendingTx.TxType] = age
+		}
+	}
+	for txType, age := range ages {
+		if name, ok := rollupTxTypeNames[txType]; ok {
+			pendingRollupTxAgeMetric.WithLabelValues(name).Set(age)
+		}
+	}
+}
+
+// updateLagMetrics reports the blocks waiting to be committed and verified on L1, the heights
+// are taken from the handled rollup txs.
+func (s *Sender) updateLagMetrics() error {
+	sealedHeight, err := s.blockModel.GetCurrentBlockHeight()
+	if err != nil {
+		return err
+	}
+	currentBlock, err := s.blockModel.GetBlockByHeightWithoutTx(sealedHeight)
+	if err != nil {
+		return err
+	}
+	if currentBlock.BlockStatus == block.StatusProposing {
+		sealedHeight--
+	}
+
+	var committedHeight, verifiedHeight int64
+	lastCommitTx, err := s.l1RollupTxModel.GetLatestHandledTx(l1rolluptx.TxTypeCommit)
+	if err != nil && err != types.DbErrNotFound {
+		return err
+	}
+	if lastCommitTx != nil {
+		committedHeight = lastCommitTx.L2BlockHeight
+	}
+	lastVerifyTx, err := s.l1RollupTxModel.GetLatestHandledTx(l1rolluptx.TxTypeVerifyAndExecute)
+	if err != nil && err != types.DbErrNotFound {
+		return err
+	}
+	if lastVerifyTx != nil {
+		verifiedHeight = lastVerifyTx.L2BlockHeight
+	}
+
+	commitLagMetric.Set(float64(sealedHeight - committedHeight))
+	verifyLagMetric.Set(float64(committedHeight - verifiedHeight))
+	return nil
+}
@@ -0,0 +1,261 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sender
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/zkbnb-crypto/wasm/txtypes"
+	common2 "github.com/bnb-chain/zkbnb/common"
+	"github.com/bnb-chain/zkbnb/common/chain"
+	"github.com/bnb-chain/zkbnb/common/l1"
+	"github.com/bnb-chain/zkbnb/dao/block"
+	"github.com/bnb-chain/zkbnb/dao/compressedblock"
+	"github.com/bnb-chain/zkbnb/dao/l1rolluptx"
+	"github.com/bnb-chain/zkbnb/dao/l1syncedblock"
+	"github.com/bnb-chain/zkbnb/dao/priorityrequest"
+	"github.com/bnb-chain/zkbnb/dao/proof"
+	"github.com/bnb-chain/zkbnb/dao/rollback"
+	"github.com/bnb-chain/zkbnb/dao/sysconfig"
+	"github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/dao/withdrawal"
+	mconfig "github.com/bnb-chain/zkbnb/service/monitor/config"
+	"github.com/bnb-chain/zkbnb/service/monitor/monitor"
+	sconfig "github.com/bnb-chain/zkbnb/service/sender/config"
+	"github.com/bnb-chain/zkbnb/tree"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+var (
+	pipelineDsn = "host=localhost user=postgres password=ZkBNB@123 dbname=zkbnb port=5438 sslmode=disable"
+
+	pipelineZkBNBAddress      = common.HexToAddress("0x1000000000000000000000000000000000000001")
+	pipelineGovernanceAddress = common.HexToAddress("0x1000000000000000000000000000000000000002")
+	pipelineDepositor         = common.HexToAddress("0x2000000000000000000000000000000000000001")
+)
+
+func testDBSetup() {
+	testDBShutdown()
+	time.Sleep(5 * time.Second)
+	cmd := exec.Command("docker", "run", "--name", "postgres-ut-sender", "-p", "5438:5432",
+		"-e", "POSTGRES_PASSWORD=ZkBNB@123", "-e", "POSTGRES_USER=postgres", "-e", "POSTGRES_DB=zkbnb",
+		"-e", "PGDATA=/var/lib/postgresql/pgdata", "-d", "ghcr.io/bnb-chain/zkbnb/zkbnb-ut-postgres:0.0.2")
+	if err := cmd.Run(); err != nil {
+		panic(err)
+	}
+	time.Sleep(15 * time.Second)
+}
+
+func testDBShutdown() {
+	cmd := exec.Command("docker", "kill", "postgres-ut-sender")
+	//nolint:errcheck
+	cmd.Run()
+	time.Sleep(time.Second)
+	cmd = exec.Command("docker", "rm", "postgres-ut-sender")
+	//nolint:errcheck
+	cmd.Run()
+}
+
+// testDBMigrate recreates the tables of the monitor and the sender, with the contracts of
+// the simulated chain in the sys configs.
+func testDBMigrate(t *testing.T, db *gorm.DB) {
+	migrations := []struct{ drop, create func() error }{
+		{sysconfig.NewSysConfigModel(db).DropSysConfigTable, sysconfig.NewSysConfigModel(db).CreateSysConfigTable},
+		{block.NewBlockModel(db).DropBlockTable, block.NewBlockModel(db).CreateBlockTable},
+		{compressedblock.NewCompressedBlockModel(db).DropCompressedBlockTable, compressedblock.NewCompressedBlockModel(db).CreateCompressedBlockTable},
+		{tx.NewTxModel(db).DropTxTable, tx.NewTxModel(db).CreateTxTable},
+		{tx.NewTxDetailModel(db).DropTxDetailTable, tx.NewTxDetailModel(db).CreateTxDetailTable},
+		{tx.NewTxPoolModel(db).DropPoolTxTable, tx.NewTxPoolModel(db).CreatePoolTxTable},
+		{l1rolluptx.NewL1RollupTxModel(db).DropL1RollupTxTable, l1rolluptx.NewL1RollupTxModel(db).CreateL1RollupTxTable},
+		{proof.NewProofModel(db).DropProofTable, proof.NewProofModel(db).CreateProofTable},
+		{rollback.NewRollbackModel(db).DropRollbackTable, rollback.NewRollbackModel(db).CreateRollbackTable},
+		{priorityrequest.NewPriorityRequestModel(db).DropPriorityRequestTable, priorityrequest.NewPriorityRequestModel(db).CreatePriorityRequestTable},
+		{l1syncedblock.NewL1SyncedBlockModel(db).DropL1SyncedBlockTable, l1syncedblock.NewL1SyncedBlockModel(db).CreateL1SyncedBlockTable},
+		{withdrawal.NewWithdrawalModel(db).DropWithdrawalTable, withdrawal.NewWithdrawalModel(db).CreateWithdrawalTable},
+	}
+	for _, migration := range migrations {
+		require.NoError(t, migration.drop())
+		require.NoError(t, migration.create())
+	}
+	_, err := sysconfig.NewSysConfigModel(db).CreateSysConfigs([]*sysconfig.SysConfig{
+		{Name: types.ZkBNBContract, Value: pipelineZkBNBAddress.Hex(), ValueType: "string", Comment: "ZkBNB contract on BSC"},
+		{Name: types.GovernanceContract, Value: pipelineGovernanceAddress.Hex(), ValueType: "string", Comment: "Governance contract on BSC"},
+	})
+	require.NoError(t, err)
+}
+
+// depositRequestPubData is the pub data of the NewPriorityRequest event of the deposit.
+func depositRequestPubData(accountNameHash []byte, assetId uint16, amount int64) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte(uint8(types.TxTypeDeposit))
+	buf.Write(common2.Uint32ToBytes(0))
+	buf.Write(accountNameHash)
+	buf.Write(common2.Uint16ToBytes(assetId))
+	buf.Write(common2.Uint128ToBytes(big.NewInt(amount)))
+	return buf.Bytes()
+}
+
+// newTestDepositBlock builds the block executing the deposit to the account as the committer
+// does, on top of the empty state.
+func newTestDepositBlock(t *testing.T, height, accountIndex int64, depositTx *tx.Tx, createdAt time.Time) (
+	*block.Block, *compressedblock.CompressedBlock) {
+	var txInfo *txtypes.DepositTxInfo
+	require.NoError(t, json.Unmarshal([]byte(depositTx.TxInfo), &txInfo))
+
+	var buf bytes.Buffer
+	buf.WriteByte(uint8(types.TxTypeDeposit))
+	buf.Write(common2.Uint32ToBytes(uint32(accountIndex)))
+	buf.Write(common2.Uint16ToBytes(uint16(txInfo.AssetId)))
+	buf.Write(common2.Uint128ToBytes(txInfo.AssetAmount))
+	pubData := common2.SuffixPaddingBufToChunkSize(buf.Bytes())
+	pubData = append(pubData, common2.PrefixPaddingBufToChunkSize(txInfo.AccountNameHash)...)
+	pubData = append(pubData, make([]byte, chain.TxPubDataBytesSize-len(pubData))...)
+
+	stateRoot := common.BigToHash(big.NewInt(height)).Bytes()
+	offsets, err := json.Marshal([]uint32{0})
+	require.NoError(t, err)
+	b := &block.Block{
+		Model:                        gorm.Model{CreatedAt: createdAt},
+		BlockSize:                    1,
+		BlockHeight:                  height,
+		StateRoot:                    common.Bytes2Hex(stateRoot),
+		PriorityOperations:           1,
+		PendingOnChainOperationsHash: types.EmptyStringKeccak,
+		BlockStatus:                  block.StatusPending,
+		Txs: []*tx.Tx{{
+			TxHash:       depositTx.TxHash,
+			TxType:       depositTx.TxType,
+			TxInfo:       depositTx.TxInfo,
+			AccountIndex: accountIndex,
+			GasFee:       types.NilAssetAmount,
+			NftIndex:     types.NilNftIndex,
+			CollectionId: types.NilCollectionNonce,
+			AssetId:      txInfo.AssetId,
+			TxAmount:     txInfo.AssetAmount.String(),
+			BlockHeight:  height,
+			TxStatus:     tx.StatusPacked,
+		}},
+	}
+	b.BlockCommitment = chain.CreateBlockCommitment(height, createdAt.UnixMilli(),
+		tree.NilStateRoot, stateRoot, pubData, 1)
+	return b, &compressedblock.CompressedBlock{
+		BlockSize:         b.BlockSize,
+		BlockHeight:       height,
+		StateRoot:         b.StateRoot,
+		PublicData:        common.Bytes2Hex(pubData),
+		Timestamp:         createdAt.UnixMilli(),
+		PublicDataOffsets: string(offsets),
+	}
+}
+
+// TestRollupPipeline runs the monitor and the sender against the simulated chain: the deposit
+// on L1 is synced by the monitor, the block executing it is committed and verified by the
+// sender, and the monitor marks the block verified from the events of the rollup txs.
+func TestRollupPipeline(t *testing.T) {
+	testDBSetup()
+	defer testDBShutdown()
+	db, err := gorm.Open(postgres.Open(pipelineDsn), &gorm.Config{})
+	require.NoError(t, err)
+	testDBMigrate(t, db)
+
+	provider := l1.NewSimulatedProvider(pipelineZkBNBAddress, pipelineGovernanceAddress, defaultBlockHeader())
+	var mc mconfig.Config
+	mc.Postgres.DataSource = pipelineDsn
+	mc.ChainConfig.MaxHandledBlocksCount = 100
+	m := monitor.NewMonitorWithProvider(mc, provider)
+	defer m.Shutdown()
+	var sc sconfig.Config
+	sc.Postgres.DataSource = pipelineDsn
+	sc.ChainConfig.MaxWaitingTime = 120
+	sc.ChainConfig.MaxBlockCount = 4
+	sc.ChainConfig.GasLimit = 20000000
+	sc.ChainConfig.GasPrice = 1000000000
+	s := NewSenderWithProvider(sc, provider)
+	defer s.Shutdown()
+
+	// The deposit is synced and turned into a pool tx.
+	accountNameHash := common.FromHex("0x04b2a4e3e1b9de8b19b5fcb5f4ad4a5b6a3e9c3d0c55c1c1b1c0a19f4c1d2e3f")
+	_, err = provider.RequestPriorityOperation(pipelineDepositor, types.TxTypeDeposit,
+		depositRequestPubData(accountNameHash, 1, 100))
+	require.NoError(t, err)
+	require.NoError(t, m.MonitorGenericBlocks())
+	require.NoError(t, m.MonitorPriorityRequests())
+	requests, err := m.PriorityRequestModel.GetPriorityRequestsByStatus(priorityrequest.HandledStatus)
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+	depositTx, err := m.TxPoolModel.GetTxByTxHash(requests[0].L2TxHash)
+	require.NoError(t, err)
+	assert.Equal(t, int64(types.TxTypeDeposit), depositTx.TxType)
+
+	// The committer executes the deposit in block 1.
+	newBlock, newCompressedBlock := newTestDepositBlock(t, 1, 2, depositTx, time.UnixMilli(time.Now().UnixMilli()))
+	require.NoError(t, db.Transaction(func(dbTx *gorm.DB) error {
+		if err := m.BlockModel.CreateBlockInTransact(dbTx, newBlock); err != nil {
+			return err
+		}
+		return s.compressedBlockModel.CreateCompressedBlockInTransact(dbTx, newCompressedBlock)
+	}))
+
+	// The block is committed on L1, the monitor marks it committed from the BlockCommit event.
+	require.NoError(t, s.CommitBlocks())
+	require.NoError(t, s.UpdateSentTxs())
+	commitTx, err := s.l1RollupTxModel.GetLatestHandledTx(l1rolluptx.TxTypeCommit)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), commitTx.L2BlockHeight)
+	require.NoError(t, m.MonitorGenericBlocks())
+	committedBlock, err := m.BlockModel.GetBlockByHeightWithoutTx(1)
+	require.NoError(t, err)
+	assert.Equal(t, block.StatusCommitted, committedBlock.BlockStatus)
+	assert.Equal(t, commitTx.L1TxHash, committedBlock.CommittedTxHash)
+
+	// The block is verified with its proof, the monitor marks it verified and the tx with it.
+	require.NoError(t, s.proofModel.CreateProof(newTestProof(t, 1)))
+	require.NoError(t, s.VerifyAndExecuteBlocks())
+	require.NoError(t, s.UpdateSentTxs())
+	verifyTx, err := s.l1RollupTxModel.GetLatestHandledTx(l1rolluptx.TxTypeVerifyAndExecute)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), verifyTx.L2BlockHeight)
+	require.NoError(t, m.MonitorGenericBlocks())
+	verifiedBlock, err := m.BlockModel.GetBlockByHeightWithoutTx(1)
+	require.NoError(t, err)
+	assert.Equal(t, block.StatusVerifiedAndExecuted, verifiedBlock.BlockStatus)
+	assert.Equal(t, verifyTx.L1TxHash, verifiedBlock.VerifiedTxHash)
+	verifiedTx, err := m.TxModel.GetTxByHash(depositTx.TxHash)
+	require.NoError(t, err)
+	assert.Equal(t, tx.StatusVerified, verifiedTx.TxStatus)
+	confirmedProof, err := m.ProofModel.GetLatestConfirmedProof()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), confirmedProof.BlockNumber)
+
+	// Nothing is left to send.
+	require.NoError(t, s.CommitBlocks())
+	require.NoError(t, s.VerifyAndExecuteBlocks())
+	pendingTxs, err := s.l1RollupTxModel.GetL1RollupTxsByStatus(l1rolluptx.StatusPending)
+	assert.Equal(t, types.DbErrNotFound, err)
+	assert.Empty(t, pendingTxs)
+}
@@ -17,37 +17,38 @@
 package sender
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
 	"time"
 
+	"github.com/ethereum/go-ethereum"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/zeromicro/go-zero/core/logx"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 
 	zkbnb "github.com/bnb-chain/zkbnb-eth-rpc/core"
-	"github.com/bnb-chain/zkbnb-eth-rpc/rpc"
 	"github.com/bnb-chain/zkbnb/common/chain"
+	"github.com/bnb-chain/zkbnb/common/l1"
 	"github.com/bnb-chain/zkbnb/common/prove"
 	"github.com/bnb-chain/zkbnb/dao/block"
 	"github.com/bnb-chain/zkbnb/dao/compressedblock"
 	"github.com/bnb-chain/zkbnb/dao/l1rolluptx"
 	"github.com/bnb-chain/zkbnb/dao/proof"
+	"github.com/bnb-chain/zkbnb/dao/rollback"
 	"github.com/bnb-chain/zkbnb/dao/sysconfig"
 	sconfig "github.com/bnb-chain/zkbnb/service/sender/config"
 	"github.com/bnb-chain/zkbnb/types"
 )
 
 type Sender struct {
-	config sconfig.Config
+	config      sconfig.Config
+	batchPolicy *batchPolicy
 
 	// Client
-	cli           *rpc.ProviderClient
-	authCli       *rpc.AuthClient
-	zkbnbInstance *zkbnb.ZkBNB
+	cli l1.Provider
 
 	// Data access objects
 	db                   *gorm.DB
@@ -56,61 +57,72 @@ type Sender struct {
 	l1RollupTxModel      l1rolluptx.L1RollupTxModel
 	sysConfigModel       sysconfig.SysConfigModel
 	proofModel           proof.ProofModel
+	rollbackModel        rollback.RollbackModel
 }
 
 func NewSender(c sconfig.Config) *Sender {
+	return newSender(c, nil)
+}
+
+// NewSenderWithProvider creates the sender sending the txs through the provider, e.g. the
+// simulated one, instead of the L1 node in the sysconfig.
+func NewSenderWithProvider(c sconfig.Config, provider l1.Provider) *Sender {
+	return newSender(c, provider)
+}
+
+func newSender(c sconfig.Config, provider l1.Provider) *Sender {
 	db, err := gorm.Open(postgres.Open(c.Postgres.DataSource))
 	if err != nil {
 		logx.Errorf("gorm connect db error, err = %v", err)
 	}
 	s := &Sender{
 		config:               c,
+		batchPolicy:          newBatchPolicy(c),
 		db:                   db,
 		blockModel:           block.NewBlockModel(db),
 		compressedBlockModel: compressedblock.NewCompressedBlockModel(db),
 		l1RollupTxModel:      l1rolluptx.NewL1RollupTxModel(db),
 		sysConfigModel:       sysconfig.NewSysConfigModel(db),
 		proofModel:           proof.NewProofModel(db),
+		rollbackModel:        rollback.NewRollbackModel(db),
 	}
 
-	l1RPCEndpoint, err := s.sysConfigModel.GetSysConfigByName(c.ChainConfig.NetworkRPCSysConfigName)
-	if err != nil {
-		logx.Severef("fatal error, cannot fetch l1RPCEndpoint from sysconfig, err: %v, SysConfigName: %s",
-			err, c.ChainConfig.NetworkRPCSysConfigName)
-		panic(err)
+	if provider == nil {
+		l1RPCEndpoint, err := s.sysConfigModel.GetSysConfigByName(c.ChainConfig.NetworkRPCSysConfigName)
+		if err != nil {
+			logx.Severef("fatal error, cannot fetch l1RPCEndpoint from sysconfig, err: %v, SysConfigName: %s",
+				err, c.ChainConfig.NetworkRPCSysConfigName)
+			panic(err)
+		}
+		rollupAddress, err := s.sysConfigModel.GetSysConfigByName(types.ZkBNBContract)
+		if err != nil {
+			logx.Severef("fatal error, cannot fetch rollupAddress from sysconfig, err: %v, SysConfigName: %s",
+				err, types.ZkBNBContract)
+			panic(err)
+		}
+		provider, err = l1.NewRpcProvider(l1RPCEndpoint.Value, rollupAddress.Value, c.ChainConfig.Sk)
+		if err != nil {
+			panic(err)
+		}
 	}
-	rollupAddress, err := s.sysConfigModel.GetSysConfigByName(types.ZkBNBContract)
-	if err != nil {
-		logx.Severef("fatal error, cannot fetch rollupAddress from sysconfig, err: %v, SysConfigName: %s",
-			err, types.ZkBNBContract)
+	s.cli = provider
+	if err = registerMetrics(); err != nil {
 		panic(err)
 	}
+	return s
+}
 
-	s.cli, err = rpc.NewClient(l1RPCEndpoint.Value)
-	if err != nil {
-		panic(err)
-	}
-	chainId, err := s.cli.ChainID(context.Background())
-	if err != nil {
-		panic(err)
-	}
-	s.authCli, err = rpc.NewAuthClient(c.ChainConfig.Sk, chainId)
+func (s *Sender) CommitBlocks() (err error) {
+	// No need to submit new transaction if the reverted blocks are not rolled back yet.
+	pendingRollbacks, err := s.rollbackModel.GetPendingRollbacksCount()
 	if err != nil {
-		panic(err)
+		return err
 	}
-	s.zkbnbInstance, err = zkbnb.LoadZkBNBInstance(s.cli, rollupAddress.Value)
-	if err != nil {
-		panic(err)
+	if pendingRollbacks > 0 {
+		logx.Infof("wait for the reverted blocks to be rolled back")
+		return nil
 	}
-	return s
-}
 
-func (s *Sender) CommitBlocks() (err error) {
-	var (
-		cli           = s.cli
-		authCli       = s.authCli
-		zkbnbInstance = s.zkbnbInstance
-	)
 	pendingTx, err := s.l1RollupTxModel.GetLatestPendingTx(l1rolluptx.TxTypeCommit)
 	if err != nil && err != types.DbErrNotFound {
 		return err
@@ -134,68 +146,73 @@ func (s *Sender) CommitBlocks() (err error) {
 	if err != nil && err != types.DbErrNotFound {
 		return fmt.Errorf("failed to get compress block err: %v", err)
 	}
-	if len(blocks) == 0 {
+	candidates := s.batchPolicy.commitCandidates(blocks)
+	decision := s.batchPolicy.decideCommit(candidates, time.Now())
+	logBatchDecision("commit", candidates, decision)
+	if decision.wait {
 		return nil
 	}
+	blocks = blocks[:decision.count]
+
+	opts, err := s.newTxOptions()
+	if err != nil {
+		return err
+	}
+	newRollupTx, err := s.sendCommitTx(lastHandledTx, blocks, opts)
+	if err != nil {
+		return err
+	}
+	err = s.l1RollupTxModel.CreateL1RollupTx(newRollupTx)
+	if err != nil {
+		return fmt.Errorf("failed to create tx in database, err: %v", err)
+	}
+	logx.Infof("new blocks have been committed(height): %v:%s", newRollupTx.L2BlockHeight, newRollupTx.L1TxHash)
+	return nil
+}
+
+// sendCommitTx commits the blocks on top of the block of the last handled commit tx.
+func (s *Sender) sendCommitTx(lastHandledTx *l1rolluptx.L1RollupTx, blocks []*compressedblock.CompressedBlock,
+	opts *l1.TxOptions) (*l1rolluptx.L1RollupTx, error) {
 	pendingCommitBlocks, err := ConvertBlocksForCommitToCommitBlockInfos(blocks)
 	if err != nil {
-		return fmt.Errorf("failed to get commit block info, err: %v", err)
+		return nil, fmt.Errorf("failed to get commit block info, err: %v", err)
 	}
 	// get last block info
 	lastStoredBlockInfo := defaultBlockHeader()
 	if lastHandledTx != nil {
 		lastHandledBlockInfo, err := s.blockModel.GetBlockByHeight(lastHandledTx.L2BlockHeight)
 		if err != nil {
-			return fmt.Errorf("failed to get block info, err: %v", err)
+			return nil, fmt.Errorf("failed to get block info, err: %v", err)
 		}
 		// construct last stored block header
 		lastStoredBlockInfo = chain.ConstructStoredBlockInfo(lastHandledBlockInfo)
 	}
 
-	var gasPrice *big.Int
-	if s.config.ChainConfig.GasPrice > 0 {
-		gasPrice = big.NewInt(int64(s.config.ChainConfig.GasPrice))
-	} else {
-		gasPrice, err = s.cli.SuggestGasPrice(context.Background())
-		if err != nil {
-			logx.Errorf("failed to fetch gas price: %v", err)
-			return err
-		}
-	}
-
 	// commit blocks on-chain
-	txHash, err := zkbnb.CommitBlocks(
-		cli, authCli,
-		zkbnbInstance,
-		lastStoredBlockInfo,
-		pendingCommitBlocks,
-		gasPrice,
-		s.config.ChainConfig.GasLimit)
-	if err != nil {
-		return fmt.Errorf("failed to send commit tx, errL %v:%s", err, txHash)
-	}
-	newRollupTx := &l1rolluptx.L1RollupTx{
-		L1TxHash:      txHash,
-		TxStatus:      l1rolluptx.StatusPending,
-		TxType:        l1rolluptx.TxTypeCommit,
-		L2BlockHeight: int64(pendingCommitBlocks[len(pendingCommitBlocks)-1].BlockNumber),
-	}
-	err = s.l1RollupTxModel.CreateL1RollupTx(newRollupTx)
+	tx, err := s.cli.CommitBlocks(lastStoredBlockInfo, pendingCommitBlocks, opts)
 	if err != nil {
-		return fmt.Errorf("failed to create tx in database, err: %v", err)
+		return nil, fmt.Errorf("failed to send commit tx, err: %v", err)
 	}
-	logx.Infof("new blocks have been committed(height): %v:%s", newRollupTx.L2BlockHeight, newRollupTx.L1TxHash)
-	return nil
+	return newL1RollupTx(tx, l1rolluptx.TxTypeCommit,
+		int64(pendingCommitBlocks[len(pendingCommitBlocks)-1].BlockNumber)), nil
 }
 
 func (s *Sender) UpdateSentTxs() (err error) {
+	defer func() {
+		if err := s.updateLagMetrics(); err != nil {
+			logx.Errorf("failed to update lag metrics, err: %v", err)
+		}
+	}()
+
 	pendingTxs, err := s.l1RollupTxModel.GetL1RollupTxsByStatus(l1rolluptx.StatusPending)
 	if err != nil {
 		if err == types.DbErrNotFound {
+			updatePendingTxAge(nil)
 			return nil
 		}
 		return fmt.Errorf("failed to get pending txs, err: %v", err)
 	}
+	updatePendingTxAge(pendingTxs)
 
 	latestL1Height, err := s.cli.GetHeight()
 	if err != nil {
@@ -204,26 +221,36 @@ func (s *Sender) UpdateSentTxs() (err error) {
 
 	var (
 		pendingUpdateRxs         []*l1rolluptx.L1RollupTx
+		pendingUpdateGasUsed     []uint64
+		replacedRxs              []*l1rolluptx.L1RollupTx
 		pendingUpdateProofStatus = make(map[int64]int)
 	)
 	for _, pendingTx := range pendingTxs {
-		txHash := pendingTx.L1TxHash
-		receipt, err := s.cli.GetTransactionReceipt(txHash)
+		attempts, minedTx, receipt, err := s.getMinedTx(pendingTx)
 		if err != nil {
-			logx.Errorf("query transaction receipt %s failed, err: %v", txHash, err)
+			logx.Errorf("failed to get the attempts of rollup tx %s, err: %v", pendingTx.L1TxHash, err)
+			continue
+		}
+		if receipt == nil {
 			if time.Now().After(pendingTx.UpdatedAt.Add(time.Duration(s.config.ChainConfig.MaxWaitingTime) * time.Second)) {
-				// No need to check the response, do best effort.
-				logx.Infof("delete timeout l1 rollup tx, tx_hash=%s", pendingTx.L1TxHash)
-				//nolint:errcheck
-				s.l1RollupTxModel.DeleteL1RollupTx(pendingTx)
+				if pendingTx.GasPrice == "" {
+					// The fees of the txs sent by the older versions are unknown, they can't be replaced.
+					// No need to check the response, do best effort.
+					logx.Infof("delete timeout l1 rollup tx, tx_hash=%s", pendingTx.L1TxHash)
+					//nolint:errcheck
+					s.l1RollupTxModel.DeleteL1RollupTx(pendingTx)
+				} else if err := s.replaceStuckTx(pendingTx); err != nil {
+					logx.Errorf("failed to replace stuck rollup tx %s, err: %v", pendingTx.L1TxHash, err)
+				}
 			}
 			continue
 		}
+		txHash := minedTx.L1TxHash
 		if receipt.Status == 0 {
 			// Should direct mark tx deleted
-			logx.Infof("delete timeout l1 rollup tx, tx_hash=%s", pendingTx.L1TxHash)
+			logx.Infof("delete timeout l1 rollup tx, tx_hash=%s", txHash)
 			//nolint:errcheck
-			s.l1RollupTxModel.DeleteL1RollupTx(pendingTx)
+			s.l1RollupTxModel.DeleteL1RollupTx(minedTx)
 			// It is critical to have any failed transactions
 			panic(fmt.Sprintf("unexpected failed tx: %v", txHash))
 		}
@@ -240,23 +267,31 @@ func (s *Sender) UpdateSentTxs() (err error) {
 				if err = ZkBNBContractAbi.UnpackIntoInterface(&event, EventNameBlockCommit, vlog.Data); err != nil {
 					return err
 				}
-				validTx = int64(event.BlockNumber) == pendingTx.L2BlockHeight
+				validTx = int64(event.BlockNumber) == minedTx.L2BlockHeight
 			case zkbnbLogBlockVerificationSigHash.Hex():
 				var event zkbnb.ZkBNBBlockVerification
 				if err = ZkBNBContractAbi.UnpackIntoInterface(&event, EventNameBlockVerification, vlog.Data); err != nil {
 					return err
 				}
-				validTx = int64(event.BlockNumber) == pendingTx.L2BlockHeight
+				validTx = int64(event.BlockNumber) == minedTx.L2BlockHeight
 				pendingUpdateProofStatus[int64(event.BlockNumber)] = proof.Confirmed
 			case zkbnbLogBlocksRevertSigHash.Hex():
-				// TODO revert
+				// the reverted blocks are rolled back by the monitor and the committer
 			default:
 			}
 		}
 
 		if validTx {
-			pendingTx.TxStatus = l1rolluptx.StatusHandled
-			pendingUpdateRxs = append(pendingUpdateRxs, pendingTx)
+			// the other attempts can't be mined anymore, since the nonce is used
+			for _, attempt := range attempts {
+				if attempt.ID != minedTx.ID && attempt.TxStatus == l1rolluptx.StatusPending {
+					attempt.TxStatus = l1rolluptx.StatusReplaced
+					replacedRxs = append(replacedRxs, attempt)
+				}
+			}
+			minedTx.TxStatus = l1rolluptx.StatusHandled
+			pendingUpdateRxs = append(pendingUpdateRxs, minedTx)
+			pendingUpdateGasUsed = append(pendingUpdateGasUsed, receipt.GasUsed)
 		}
 	}
 
@@ -267,6 +302,10 @@ func (s *Sender) UpdateSentTxs() (err error) {
 		if err != nil {
 			return err
 		}
+		err = s.l1RollupTxModel.UpdateL1RollupTxsInTransact(tx, replacedRxs)
+		if err != nil {
+			return err
+		}
 		//update proof status
 		err = s.proofModel.UpdateProofsInTransact(tx, pendingUpdateProofStatus)
 		return err
@@ -274,15 +313,123 @@ func (s *Sender) UpdateSentTxs() (err error) {
 	if err != nil {
 		return fmt.Errorf("failed to updte rollup txs, err:%v", err)
 	}
+	for i, handledTx := range pendingUpdateRxs {
+		l1GasUsedMetric.WithLabelValues(rollupTxTypeNames[handledTx.TxType]).Add(float64(pendingUpdateGasUsed[i]))
+	}
+	return nil
+}
+
+// getMinedTx returns the attempts of the pending tx, i.e. the pending tx and the txs replaced
+// by it, and the attempt mined on L1 with its receipt. The receipt is nil if none is mined.
+func (s *Sender) getMinedTx(pendingTx *l1rolluptx.L1RollupTx) ([]*l1rolluptx.L1RollupTx, *l1rolluptx.L1RollupTx, *ethTypes.Receipt, error) {
+	attempts := []*l1rolluptx.L1RollupTx{pendingTx}
+	if pendingTx.GasPrice != "" {
+		var err error
+		attempts, err = s.l1RollupTxModel.GetL1RollupTxsByNonce(pendingTx.TxType, pendingTx.L2BlockHeight, pendingTx.Nonce)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	for _, attempt := range attempts {
+		receipt, err := s.cli.GetTransactionReceipt(attempt.L1TxHash)
+		if err != nil {
+			if err != ethereum.NotFound {
+				logx.Errorf("query transaction receipt %s failed, err: %v", attempt.L1TxHash, err)
+			}
+			continue
+		}
+		return attempts, attempt, receipt, nil
+	}
+	return attempts, nil, nil, nil
+}
+
+// replaceStuckTx sends the same call as the stuck tx with the same nonce and bumped fees.
+// The stuck tx is kept as replaced, it is still handled if it is mined in the end.
+func (s *Sender) replaceStuckTx(stuckTx *l1rolluptx.L1RollupTx) error {
+	lastHandledTx, err := s.l1RollupTxModel.GetLatestHandledTx(int64(stuckTx.TxType))
+	if err != nil && err != types.DbErrNotFound {
+		return err
+	}
+	start := int64(1)
+	if lastHandledTx != nil {
+		start = lastHandledTx.L2BlockHeight + 1
+	}
+	if start > stuckTx.L2BlockHeight {
+		// the blocks are handled by a tx unknown to the sender, e.g. a replacement failed to be
+		// stored, so the nonce is used already
+		logx.Infof("rollup tx %s is superseded by the handled tx %s", stuckTx.L1TxHash, lastHandledTx.L1TxHash)
+		stuckTx.TxStatus = l1rolluptx.StatusReplaced
+		return s.l1RollupTxModel.UpdateL1RollupTxsInTransact(s.db, []*l1rolluptx.L1RollupTx{stuckTx})
+	}
+
+	opts, err := s.replacementTxOptions(stuckTx)
+	if err != nil {
+		return err
+	}
+	if opts == nil {
+		logx.Errorf("unable to replace rollup tx %s under the max gas price %d",
+			stuckTx.L1TxHash, s.config.ChainConfig.MaxGasPrice)
+		return nil
+	}
+
+	var newRollupTx *l1rolluptx.L1RollupTx
+	switch stuckTx.TxType {
+	case l1rolluptx.TxTypeCommit:
+		blocks, err := s.compressedBlockModel.GetCompressedBlocksBetween(start, stuckTx.L2BlockHeight)
+		if err != nil {
+			return fmt.Errorf("failed to get compress block err: %v", err)
+		}
+		if int64(len(blocks)) != stuckTx.L2BlockHeight-start+1 {
+			return fmt.Errorf("blocks %d to %d are not found", start, stuckTx.L2BlockHeight)
+		}
+		newRollupTx, err = s.sendCommitTx(lastHandledTx, blocks, opts)
+		if err != nil {
+			return err
+		}
+	case l1rolluptx.TxTypeVerifyAndExecute:
+		blocks, err := s.blockModel.GetCommittedBlocksBetween(start, stuckTx.L2BlockHeight)
+		if err != nil {
+			return fmt.Errorf("unable to get blocks to prove, err: %v", err)
+		}
+		if int64(len(blocks)) != stuckTx.L2BlockHeight-start+1 {
+			return fmt.Errorf("committed blocks %d to %d are not found", start, stuckTx.L2BlockHeight)
+		}
+		newRollupTx, err = s.sendVerifyTx(blocks, opts)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid rollup tx type %d", stuckTx.TxType)
+	}
+
+	stuckTx.TxStatus = l1rolluptx.StatusReplaced
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		err := s.l1RollupTxModel.UpdateL1RollupTxsInTransact(tx, []*l1rolluptx.L1RollupTx{stuckTx})
+		if err != nil {
+			return err
+		}
+		return s.l1RollupTxModel.CreateL1RollupTxInTransact(tx, newRollupTx)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store the replacement tx %s, err: %v", newRollupTx.L1TxHash, err)
+	}
+	replacedTxsMetric.WithLabelValues(rollupTxTypeNames[stuckTx.TxType]).Inc()
+	logx.Infof("rollup tx %s is replaced by %s, gas price: %s, gas tip cap: %s",
+		stuckTx.L1TxHash, newRollupTx.L1TxHash, newRollupTx.GasPrice, newRollupTx.GasTipCap)
 	return nil
 }
 
 func (s *Sender) VerifyAndExecuteBlocks() (err error) {
-	var (
-		cli           = s.cli
-		authCli       = s.authCli
-		zkbnbInstance = s.zkbnbInstance
-	)
+	// No need to submit new transaction if the reverted blocks are not rolled back yet.
+	pendingRollbacks, err := s.rollbackModel.GetPendingRollbacksCount()
+	if err != nil {
+		return err
+	}
+	if pendingRollbacks > 0 {
+		logx.Infof("wait for the reverted blocks to be rolled back")
+		return nil
+	}
+
 	pendingTx, err := s.l1RollupTxModel.GetLatestPendingTx(l1rolluptx.TxTypeVerifyAndExecute)
 	if err != nil && err != types.DbErrNotFound {
 		return err
@@ -309,22 +456,59 @@ func (s *Sender) VerifyAndExecuteBlocks() (err error) {
 	if len(blocks) == 0 {
 		return nil
 	}
+	// only the blocks with the contiguous proofs can be verified
+	blockProofs, err := s.proofModel.GetProofsBetween(start, blocks[len(blocks)-1].BlockHeight)
+	if err != nil && err != types.DbErrNotFound {
+		return fmt.Errorf("unable to get proofs, err: %v", err)
+	}
+	provedCount := 0
+	for provedCount < len(blocks) && provedCount < len(blockProofs) &&
+		blockProofs[provedCount].BlockNumber == blocks[provedCount].BlockHeight {
+		provedCount++
+	}
+	candidates := s.batchPolicy.verifyCandidates(blocks[:provedCount])
+	decision := s.batchPolicy.decideVerify(candidates, time.Now())
+	logBatchDecision("verify", candidates, decision)
+	if decision.wait {
+		return nil
+	}
+	blocks = blocks[:decision.count]
+
+	opts, err := s.newTxOptions()
+	if err != nil {
+		return err
+	}
+	newRollupTx, err := s.sendVerifyTx(blocks, opts)
+	if err != nil {
+		return err
+	}
+	err = s.l1RollupTxModel.CreateL1RollupTx(newRollupTx)
+	if err != nil {
+		return fmt.Errorf(fmt.Sprintf("failed to create rollup tx in db %v", err))
+	}
+	logx.Infof("new blocks have been verified and executed(height): %d:%s", newRollupTx.L2BlockHeight, newRollupTx.L1TxHash)
+	return nil
+}
+
+// sendVerifyTx verifies and executes the blocks with their proofs.
+func (s *Sender) sendVerifyTx(blocks []*block.Block, opts *l1.TxOptions) (*l1rolluptx.L1RollupTx, error) {
 	pendingVerifyAndExecuteBlocks, err := ConvertBlocksToVerifyAndExecuteBlockInfos(blocks)
 	if err != nil {
-		return fmt.Errorf("unable to convert blocks to commit block infos: %v", err)
+		return nil, fmt.Errorf("unable to convert blocks to commit block infos: %v", err)
 	}
 
+	start := blocks[0].BlockHeight
 	blockProofs, err := s.proofModel.GetProofsBetween(start, start+int64(len(blocks))-1)
 	if err != nil {
-		return fmt.Errorf("unable to get proofs, err: %v", err)
+		return nil, fmt.Errorf("unable to get proofs, err: %v", err)
 	}
 	if len(blockProofs) != len(blocks) {
-		return errors.New("related proofs not ready")
+		return nil, errors.New("related proofs not ready")
 	}
 	// add sanity check
 	for i := range blockProofs {
 		if blockProofs[i].BlockNumber != blocks[i].BlockHeight {
-			return errors.New("proof number not match")
+			return nil, errors.New("proof number not match")
 		}
 	}
 	var proofs []*big.Int
@@ -332,7 +516,7 @@ func (s *Sender) VerifyAndExecuteBlocks() (err error) {
 		var proofInfo *prove.FormattedProof
 		err = json.Unmarshal([]byte(bProof.ProofInfo), &proofInfo)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		proofs = append(proofs, proofInfo.A[:]...)
 		proofs = append(proofs, proofInfo.B[0][0], proofInfo.B[0][1])
@@ -340,36 +524,30 @@ func (s *Sender) VerifyAndExecuteBlocks() (err error) {
 		proofs = append(proofs, proofInfo.C[:]...)
 	}
 
-	var gasPrice *big.Int
-	if s.config.ChainConfig.GasPrice > 0 {
-		gasPrice = big.NewInt(int64(s.config.ChainConfig.GasPrice))
-	} else {
-		gasPrice, err = s.cli.SuggestGasPrice(context.Background())
-		if err != nil {
-			logx.Errorf("failed to fetch gas price: %v", err)
-			return err
-		}
-	}
-
 	// Verify blocks on-chain
-	txHash, err := zkbnb.VerifyAndExecuteBlocks(cli, authCli, zkbnbInstance,
-		pendingVerifyAndExecuteBlocks, proofs, gasPrice, s.config.ChainConfig.GasLimit)
+	tx, err := s.cli.VerifyAndExecuteBlocks(pendingVerifyAndExecuteBlocks, proofs, opts)
 	if err != nil {
-		return fmt.Errorf("failed to send verify tx: %v:%s", err, txHash)
+		return nil, fmt.Errorf("failed to send verify tx: %v", err)
 	}
+	return newL1RollupTx(tx, l1rolluptx.TxTypeVerifyAndExecute,
+		int64(pendingVerifyAndExecuteBlocks[len(pendingVerifyAndExecuteBlocks)-1].BlockHeader.BlockNumber)), nil
+}
 
-	newRollupTx := &l1rolluptx.L1RollupTx{
-		L1TxHash:      txHash,
+// newL1RollupTx returns the pending rollup tx of the sent tx, with its nonce and fees kept
+// for the replacement.
+func newL1RollupTx(tx *ethTypes.Transaction, txType uint8, l2BlockHeight int64) *l1rolluptx.L1RollupTx {
+	rollupTx := &l1rolluptx.L1RollupTx{
+		L1TxHash:      tx.Hash().Hex(),
 		TxStatus:      l1rolluptx.StatusPending,
-		TxType:        l1rolluptx.TxTypeVerifyAndExecute,
-		L2BlockHeight: int64(pendingVerifyAndExecuteBlocks[len(pendingVerifyAndExecuteBlocks)-1].BlockHeader.BlockNumber),
+		TxType:        txType,
+		L2BlockHeight: l2BlockHeight,
+		Nonce:         tx.Nonce(),
+		GasPrice:      tx.GasFeeCap().String(),
 	}
-	err = s.l1RollupTxModel.CreateL1RollupTx(newRollupTx)
-	if err != nil {
-		return fmt.Errorf(fmt.Sprintf("failed to create rollup tx in db %v", err))
+	if tx.Type() == ethTypes.DynamicFeeTxType {
+		rollupTx.GasTipCap = tx.GasTipCap().String()
 	}
-	logx.Infof("new blocks have been verified and executed(height): %d:%s", newRollupTx.L2BlockHeight, newRollupTx.L1TxHash)
-	return nil
+	return rollupTx
 }
 
 func (s *Sender) Shutdown() {
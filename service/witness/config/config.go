@@ -2,7 +2,9 @@ package config
 
 import (
 	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/prometheus"
 
+	"github.com/bnb-chain/zkbnb/common/health"
 	"github.com/bnb-chain/zkbnb/tree"
 )
 
@@ -18,5 +20,13 @@ type Config struct {
 		RedisDBOption      tree.RedisDBOption `json:",optional"`
 		AssetTreeCacheSize int
 	}
+	//nolint:staticcheck
+	Prometheus prometheus.Config `json:",optional"`
+	//nolint:staticcheck
+	Health struct {
+		health.Config
+		// Max blocks without witnesses, 100 by default
+		MaxLag int64 `json:",optional"`
+	} `json:",optional"`
 	LogConf logx.LogConf
 }
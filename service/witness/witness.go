@@ -7,7 +7,9 @@ import (
 	"github.com/zeromicro/go-zero/core/conf"
 	"github.com/zeromicro/go-zero/core/logx"
 	"github.com/zeromicro/go-zero/core/proc"
+	"github.com/zeromicro/go-zero/core/prometheus"
 
+	"github.com/bnb-chain/zkbnb/common/health"
 	"github.com/bnb-chain/zkbnb/service/witness/config"
 	"github.com/bnb-chain/zkbnb/service/witness/witness"
 )
@@ -19,11 +21,15 @@ func Run(configFile string) error {
 	conf.MustLoad(configFile, &c)
 	logx.MustSetup(c.LogConf)
 	logx.DisableStat()
+	prometheus.StartAgent(c.Prometheus)
 
 	w, err := witness.NewWitness(c)
 	if err != nil {
 		panic(err)
 	}
+	healthServer := health.NewServer(c.Health.Config)
+	w.RegisterHealthChecks(healthServer)
+	healthServer.Start()
 	cronJob := cron.New(cron.WithChain(
 		cron.SkipIfStillRunning(cron.DiscardLogger),
 	))
@@ -33,7 +39,6 @@ func Run(configFile string) error {
 		if err != nil {
 			logx.Errorf("failed to generate block witness, %v", err)
 		}
-		w.RescheduleBlockWitness()
 	})
 	if err != nil {
 		panic(err)
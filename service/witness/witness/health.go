@@ -0,0 +1,29 @@
+package witness
+
+import (
+	"fmt"
+
+	"github.com/bnb-chain/zkbnb/common/health"
+)
+
+const defaultMaxLag = 100
+
+// RegisterHealthChecks registers the checks of the database and the lag behind the committer.
+func (w *Witness) RegisterHealthChecks(s *health.Server) {
+	maxLag := w.config.Health.MaxLag
+	if maxLag <= 0 {
+		maxLag = defaultMaxLag
+	}
+
+	s.AddReadinessCheck("database", health.DatabaseCheck(w.db))
+	s.AddReadinessCheck("lag", func() error {
+		lag, err := w.queueDepth()
+		if err != nil {
+			return fmt.Errorf("unable to get the lag: %v", err)
+		}
+		if lag > maxLag {
+			return fmt.Errorf("%d blocks behind the committer, more than %d", lag, maxLag)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,33 @@
+package witness
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	witnessBuildTimeMetric = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "zkbnb",
+		Subsystem: "witness",
+		Name:      "build_seconds",
+		Help:      "Time to build the witness of a block, by block size.",
+		Buckets:   prometheus.ExponentialBuckets(0.05, 2, 12),
+	}, []string{"block_size"})
+	witnessQueueDepthMetric = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "zkbnb",
+		Subsystem: "witness",
+		Name:      "queue_depth",
+		Help:      "Number of the blocks waiting for witnesses.",
+	})
+)
+
+func registerMetrics() error {
+	if err := prometheus.Register(witnessBuildTimeMetric); err != nil {
+		return fmt.Errorf("prometheus.Register witnessBuildTimeMetric error: %v", err)
+	}
+	if err := prometheus.Register(witnessQueueDepthMetric); err != nil {
+		return fmt.Errorf("prometheus.Register witnessQueueDepthMetric error: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package witness
+
+import (
+	"github.com/zeromicro/go-zero/core/logx"
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/zkbnb/dao/rollback"
+	"github.com/bnb-chain/zkbnb/tree"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+// rollback handles the blocks which are reverted on L1 and already rolled back by the
+// committer, it rewinds the trees and deletes the witnesses and proofs of the reverted blocks.
+func (w *Witness) rollback(latestWitnessHeight int64) error {
+	rollbacks, err := w.rollbackModel.GetWitnessPendingRollbacks()
+	if err != nil {
+		if err == types.DbErrNotFound {
+			return nil
+		}
+		return err
+	}
+
+	height := rollbacks[0].BlockHeight
+	accountIndexMap := make(map[int64]bool)
+	assetIdMap := make(map[int64]bool)
+	for _, r := range rollbacks {
+		if r.BlockHeight < height {
+			height = r.BlockHeight
+		}
+		err = rollback.UnmarshalIndexes(r.AccountIndexes, accountIndexMap)
+		if err != nil {
+			return err
+		}
+		err = rollback.UnmarshalIndexes(r.AssetIds, assetIdMap)
+		if err != nil {
+			return err
+		}
+	}
+	logx.Infof("rollback witness, height=%d", height)
+
+	// The trees are rewound before the witnesses are deleted, so they could still be
+	// rewound if the witness is restarted before the witnesses are deleted.
+	if latestWitnessHeight > height {
+		err = tree.RollBackTreesToHeight(w.taskPool, height, rollback.SortedIndexes(accountIndexMap), rollback.SortedIndexes(assetIdMap),
+			w.accountHistoryModel, w.accountTree, w.assetTrees, w.nftTree)
+		if err != nil {
+			return err
+		}
+		accountNums, err := w.accountHistoryModel.GetValidAccountCount(height)
+		if err != nil {
+			return err
+		}
+		w.assetTrees.RollBack(accountNums-1, height)
+	}
+
+	for _, r := range rollbacks {
+		r.WitnessStatus = rollback.StatusHandled
+	}
+	err = w.db.Transaction(func(tx *gorm.DB) error {
+		err := w.blockWitnessModel.DeleteBlockWitnessesForHeightGreaterThanInTransact(tx, height)
+		if err != nil {
+			return err
+		}
+		err = w.proofModel.DeleteProofsForHeightGreaterThanInTransact(tx, height)
+		if err != nil {
+			return err
+		}
+		return w.rollbackModel.UpdateRollbacksInTransact(tx, rollbacks)
+	})
+	if err != nil {
+		return err
+	}
+	logx.Infof("rollback witness success, height=%d", height)
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -20,14 +21,14 @@ import (
 	"github.com/bnb-chain/zkbnb/dao/blockwitness"
 	"github.com/bnb-chain/zkbnb/dao/nft"
 	"github.com/bnb-chain/zkbnb/dao/proof"
+	"github.com/bnb-chain/zkbnb/dao/rollback"
+	"github.com/bnb-chain/zkbnb/dao/sysconfig"
 	"github.com/bnb-chain/zkbnb/service/witness/config"
 	"github.com/bnb-chain/zkbnb/tree"
 	"github.com/bnb-chain/zkbnb/types"
 )
 
 const (
-	UnprovedBlockWitnessTimeout = 10 * time.Minute
-
 	BlockProcessDelta = 10
 
 	defaultTaskPoolSize = 1000
@@ -53,6 +54,8 @@ type Witness struct {
 	nftHistoryModel     nft.L2NftHistoryModel
 	proofModel          proof.ProofModel
 	blockWitnessModel   blockwitness.BlockWitnessModel
+	rollbackModel       rollback.RollbackModel
+	sysConfigModel      sysconfig.SysConfigModel
 }
 
 func NewWitness(c config.Config) (*Witness, error) {
@@ -71,8 +74,14 @@ func NewWitness(c config.Config) (*Witness, error) {
 		accountHistoryModel: account.NewAccountHistoryModel(db),
 		nftHistoryModel:     nft.NewL2NftHistoryModel(db),
 		proofModel:          proof.NewProofModel(db),
+		rollbackModel:       rollback.NewRollbackModel(db),
+		sysConfigModel:      sysconfig.NewSysConfigModel(db),
 	}
 	err = w.initState()
+	if err != nil {
+		return nil, err
+	}
+	err = registerMetrics()
 	return w, err
 }
 
@@ -133,6 +142,28 @@ func (w *Witness) GenerateBlockWitness() (err error) {
 	if err != nil && err != types.DbErrNotFound {
 		return err
 	}
+	// rewind the trees if the blocks are reverted on L1
+	err = w.rollback(latestWitnessHeight)
+	if err != nil {
+		return err
+	}
+	// wait for the committer to roll back the reverted blocks
+	pendingRollbacks, err := w.rollbackModel.GetPendingRollbacksCount()
+	if err != nil {
+		return err
+	}
+	if pendingRollbacks > 0 {
+		return nil
+	}
+	latestWitnessHeight, err = w.blockWitnessModel.GetLatestBlockWitnessHeight()
+	if err != nil && err != types.DbErrNotFound {
+		return err
+	}
+	queueDepth, err := w.queueDepth()
+	if err != nil {
+		return err
+	}
+	witnessQueueDepthMetric.Set(float64(queueDepth))
 	// get next batch of blocks
 	blocks, err := w.blockModel.GetBlocksBetween(latestWitnessHeight+1, latestWitnessHeight+BlockProcessDelta)
 	if err != nil {
@@ -150,6 +181,7 @@ func (w *Witness) GenerateBlockWitness() (err error) {
 	// scan each block
 	for _, block := range blocks {
 		logx.Infof("construct witness for block %d", block.BlockHeight)
+		start := time.Now()
 		// Step1: construct witness
 		blockWitness, err := w.constructBlockWitness(block, latestVerifiedBlockNr)
 		if err != nil {
@@ -170,73 +202,27 @@ func (w *Witness) GenerateBlockWitness() (err error) {
 			}
 			return fmt.Errorf("create unproved crypto block error, block:%d, err: %v", block.BlockHeight, err)
 		}
+		witnessBuildTimeMetric.WithLabelValues(strconv.Itoa(int(block.BlockSize))).Observe(time.Since(start).Seconds())
+		witnessQueueDepthMetric.Dec()
 	}
 	return nil
 }
 
-func (w *Witness) RescheduleBlockWitness() {
-	nextBlockNumber, err := w.getNextWitnessToCheck()
-	if err != nil {
-		logx.Errorf("failed to get next witness to check, err: %s", err.Error())
-	}
-	nextBlockWitness, err := w.blockWitnessModel.GetBlockWitnessByHeight(nextBlockNumber)
-	if err != nil {
-		logx.Errorf("failed to get latest block witness, err: %s", err.Error())
-		return
-	}
-
-	// skip if next block is not processed
-	if nextBlockWitness.Status == blockwitness.StatusPublished {
-		return
-	}
-
-	// skip if the next block proof exists
-	// if the proof is not submitted and verified in L1, there should be another alerts
-	_, err = w.proofModel.GetProofByBlockHeight(nextBlockNumber)
-	if err == nil {
-		return
-	}
-
-	// update block status to Published if it's timeout
-	if time.Now().After(nextBlockWitness.UpdatedAt.Add(UnprovedBlockWitnessTimeout)) {
-		logx.Infof("reschedule block %d", nextBlockWitness.Height)
-		err := w.blockWitnessModel.UpdateBlockWitnessStatus(nextBlockWitness, blockwitness.StatusPublished)
-		if err != nil {
-			logx.Errorf("update unproved block status error, err: %s", err.Error())
-			return
-		}
-	}
-}
-
-func (w *Witness) getNextWitnessToCheck() (int64, error) {
-	latestProof, err := w.proofModel.GetLatestProof()
+// queueDepth returns the number of the blocks above the latest witness height, the proposing
+// block is counted as well.
+func (w *Witness) queueDepth() (int64, error) {
+	latestWitnessHeight, err := w.blockWitnessModel.GetLatestBlockWitnessHeight()
 	if err != nil && err != types.DbErrNotFound {
-		logx.Errorf("failed to get latest proof, err: %s", err.Error())
 		return 0, err
 	}
-
-	if err == types.DbErrNotFound {
-		return 1, nil
-	}
-
-	latestConfirmedProof, err := w.proofModel.GetLatestConfirmedProof()
-	if err != nil && err != types.DbErrNotFound {
-		logx.Errorf("failed to get latest confirmed proof, err: %s", err.Error())
+	currentHeight, err := w.blockModel.GetCurrentBlockHeight()
+	if err != nil {
 		return 0, err
 	}
-
-	var startToCheck, endToCheck int64 = 1, latestProof.BlockNumber
-	if err != types.DbErrNotFound {
-		startToCheck = latestConfirmedProof.BlockNumber + 1
-	}
-
-	for blockHeight := startToCheck; blockHeight < endToCheck; blockHeight++ {
-		_, err = w.proofModel.GetProofByBlockHeight(blockHeight)
-		if err != nil {
-			return blockHeight, nil
-		}
+	if currentHeight < latestWitnessHeight {
+		return 0, nil
 	}
-	return endToCheck + 1, nil
+	return currentHeight - latestWitnessHeight, nil
 }
 
 func (w *Witness) constructBlockWitness(block *block.Block, latestVerifiedBlockNr int64) (*blockwitness.BlockWitness, error) {
@@ -268,7 +254,15 @@ func (w *Witness) constructBlockWitness(block *block.Block, latestVerifiedBlockN
 		txsWitness = append(txsWitness, circuit.EmptyTx(newStateRoot))
 	}
 
-	gasWitness, err := w.helper.ConstructGasWitness(block)
+	cfgGasAssets, err := w.sysConfigModel.GetSysConfigByName(types.SysGasAssets)
+	if err != nil {
+		return nil, err
+	}
+	gasAssets, err := types.ParseGasAssets(cfgGasAssets.Value, block.GasAssetsVersion)
+	if err != nil {
+		return nil, err
+	}
+	gasWitness, err := w.helper.ConstructGasWitness(block, gasAssets.AssetIds)
 	if err != nil {
 		return nil, err
 	}
@@ -292,9 +286,10 @@ func (w *Witness) constructBlockWitness(block *block.Block, latestVerifiedBlockN
 		return nil, err
 	}
 	blockWitness := blockwitness.BlockWitness{
-		Height:      block.BlockHeight,
-		WitnessData: string(bz),
-		Status:      blockwitness.StatusPublished,
+		Height:           block.BlockHeight,
+		WitnessData:      string(bz),
+		Status:           blockwitness.StatusPublished,
+		GasAssetsVersion: gasAssets.Version,
 	}
 	return &blockWitness, nil
 }
@@ -32,13 +32,17 @@ import (
 	"github.com/bnb-chain/zkbnb/dao/block"
 	"github.com/bnb-chain/zkbnb/dao/blockwitness"
 	"github.com/bnb-chain/zkbnb/dao/compressedblock"
+	"github.com/bnb-chain/zkbnb/dao/gasfee"
 	"github.com/bnb-chain/zkbnb/dao/l1rolluptx"
 	"github.com/bnb-chain/zkbnb/dao/l1syncedblock"
 	"github.com/bnb-chain/zkbnb/dao/nft"
+	"github.com/bnb-chain/zkbnb/dao/offer"
 	"github.com/bnb-chain/zkbnb/dao/priorityrequest"
 	"github.com/bnb-chain/zkbnb/dao/proof"
+	"github.com/bnb-chain/zkbnb/dao/rollback"
 	"github.com/bnb-chain/zkbnb/dao/sysconfig"
 	"github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/dao/withdrawal"
 	"github.com/bnb-chain/zkbnb/tree"
 	"github.com/bnb-chain/zkbnb/types"
 )
@@ -74,6 +78,10 @@ type dao struct {
 	l1RollupTModel       l1rolluptx.L1RollupTxModel
 	nftModel             nft.L2NftModel
 	nftHistoryModel      nft.L2NftHistoryModel
+	rollbackModel        rollback.RollbackModel
+	offerModel           offer.OfferModel
+	gasFeeModel          gasfee.GasFeeModel
+	withdrawalModel      withdrawal.WithdrawalModel
 }
 
 func Initialize(
@@ -108,6 +116,10 @@ func Initialize(
 		l1RollupTModel:       l1rolluptx.NewL1RollupTxModel(db),
 		nftModel:             nft.NewL2NftModel(db),
 		nftHistoryModel:      nft.NewL2NftHistoryModel(db),
+		rollbackModel:        rollback.NewRollbackModel(db),
+		offerModel:           offer.NewOfferModel(db),
+		gasFeeModel:          gasfee.NewGasFeeModel(db),
+		withdrawalModel:      withdrawal.NewWithdrawalModel(db),
 	}
 
 	dropTables(dao)
@@ -137,6 +149,15 @@ func initSysConfig(svrConf *contractAddr, bscTestNetworkRPC, localTestNetworkRPC
 		panic("fail to marshal gas fee config")
 	}
 
+	// the versions of the gas assets, a new version takes effect from the next block
+	gasAssets, err := json.Marshal([]*types.GasAssets{{
+		Version:  types.InitialGasAssetsVersion,
+		AssetIds: types.InitialGasAssetIds,
+	}})
+	if err != nil {
+		panic("fail to marshal gas assets")
+	}
+
 	return []*sysconfig.SysConfig{
 		{
 			Name:      types.SysGasFee,
@@ -144,6 +165,12 @@ func initSysConfig(svrConf *contractAddr, bscTestNetworkRPC, localTestNetworkRPC
 			ValueType: "string",
 			Comment:   "based on BNB",
 		},
+		{
+			Name:      types.SysGasAssets,
+			Value:     string(gasAssets),
+			ValueType: "[]*GasAssets",
+			Comment:   "versions of gas assets",
+		},
 		{
 			Name:      types.TreasuryAccountIndex,
 			Value:     "0",
@@ -222,6 +249,10 @@ func dropTables(dao *dao) {
 	assert.Nil(nil, dao.l1RollupTModel.DropL1RollupTxTable())
 	assert.Nil(nil, dao.nftModel.DropL2NftTable())
 	assert.Nil(nil, dao.nftHistoryModel.DropL2NftHistoryTable())
+	assert.Nil(nil, dao.rollbackModel.DropRollbackTable())
+	assert.Nil(nil, dao.offerModel.DropOfferTable())
+	assert.Nil(nil, dao.gasFeeModel.DropGasFeeTable())
+	assert.Nil(nil, dao.withdrawalModel.DropWithdrawalTable())
 }
 
 func initTable(dao *dao, svrConf *contractAddr, bscTestNetworkRPC, localTestNetworkRPC string) {
@@ -241,6 +272,10 @@ func initTable(dao *dao, svrConf *contractAddr, bscTestNetworkRPC, localTestNetw
 	assert.Nil(nil, dao.l1RollupTModel.CreateL1RollupTxTable())
 	assert.Nil(nil, dao.nftModel.CreateL2NftTable())
 	assert.Nil(nil, dao.nftHistoryModel.CreateL2NftHistoryTable())
+	assert.Nil(nil, dao.rollbackModel.CreateRollbackTable())
+	assert.Nil(nil, dao.offerModel.CreateOfferTable())
+	assert.Nil(nil, dao.gasFeeModel.CreateGasFeeTable())
+	assert.Nil(nil, dao.withdrawalModel.CreateWithdrawalTable())
 	rowsAffected, err := dao.assetModel.CreateAssets(initAssetsInfo())
 	if err != nil {
 		panic(err)
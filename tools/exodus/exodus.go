@@ -0,0 +1,468 @@
+package exodus
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/zeromicro/go-zero/core/conf"
+	"github.com/zeromicro/go-zero/core/logx"
+
+	zkbnb "github.com/bnb-chain/zkbnb-eth-rpc/core"
+	bsmt "github.com/bnb-chain/zkbnb-smt"
+	"github.com/bnb-chain/zkbnb-smt/database/memory"
+	"github.com/bnb-chain/zkbnb/common/chain"
+	"github.com/bnb-chain/zkbnb/common/merkle"
+	"github.com/bnb-chain/zkbnb/dao/account"
+	"github.com/bnb-chain/zkbnb/tools/exodus/internal/config"
+	"github.com/bnb-chain/zkbnb/tools/exodus/internal/svc"
+	"github.com/bnb-chain/zkbnb/tree"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+const batchSize = 1000
+
+const exitCalldataNote = "the exit function is not in the rollup contract yet, only the calldata of " +
+	"activateDesertMode is encoded, the calldata of the exits has to be assembled from the packages"
+
+// Options selects the exit packages to generate, the indexes are negative if not set.
+type Options struct {
+	AccountIndex int64
+	AssetId      int64
+	NftIndex     int64
+	// Generate the packages of all the accounts into the output directory
+	All bool
+	// The output file, or the output directory in the batch mode, the package is
+	// printed if it's empty.
+	Output string
+}
+
+// StoredBlockInfo is the block info stored in the rollup contract, which the exits are
+// verified against.
+type StoredBlockInfo struct {
+	BlockSize                    uint16 `json:"block_size"`
+	BlockNumber                  uint32 `json:"block_number"`
+	PriorityOperations           uint64 `json:"priority_operations"`
+	PendingOnchainOperationsHash string `json:"pending_onchain_operations_hash"`
+	Timestamp                    string `json:"timestamp"`
+	StateRoot                    string `json:"state_root"`
+	Commitment                   string `json:"commitment"`
+}
+
+// ExitPackage contains the inputs to exit the assets and the nfts of an account on L1 in the
+// desert mode, the proofs are against the state root of the last verified block. The exit
+// function is not in the rollup contract yet, so the calldata of the exits is not encoded, it
+// has to be assembled from the package.
+type ExitPackage struct {
+	// The calldata of activateDesertMode, which should be sent first if the desert mode is
+	// not activated yet.
+	ActivateDesertModeCalldata string               `json:"activate_desert_mode_calldata"`
+	StoredBlockInfo            *StoredBlockInfo     `json:"stored_block_info"`
+	Assets                     []*merkle.AssetProof `json:"assets"`
+	Nfts                       []*merkle.NftProof   `json:"nfts"`
+}
+
+type generator struct {
+	ctx             *svc.ServiceContext
+	height          int64
+	storedBlockInfo *StoredBlockInfo
+	calldata        string
+
+	accountTree bsmt.SparseMerkleTree
+	nftTree     bsmt.SparseMerkleTree
+}
+
+// Exodus rebuilds the trees at the last verified block from the history tables, and
+// generates the exit packages selected by the options.
+func Exodus(configFile string, options Options) error {
+	var c config.Config
+	conf.MustLoad(configFile, &c)
+	logx.MustSetup(c.LogConf)
+	logx.DisableStat()
+	ctx := svc.NewServiceContext(c)
+
+	g, err := newGenerator(ctx)
+	if err != nil {
+		return err
+	}
+
+	if options.All {
+		err = g.generateAll(options.Output)
+		if err != nil {
+			return err
+		}
+		logx.Info(exitCalldataNote)
+		return nil
+	}
+	var exitPackage *ExitPackage
+	switch {
+	case options.AccountIndex >= 0:
+		exitPackage, err = g.generateAccount(options.AccountIndex, options.AssetId)
+	case options.NftIndex >= 0:
+		exitPackage, err = g.generateNft(options.NftIndex)
+	default:
+		return fmt.Errorf("either an account, a nft or all the accounts should be specified")
+	}
+	if err != nil {
+		return err
+	}
+	err = writePackage(exitPackage, options.Output)
+	if err != nil {
+		return err
+	}
+	logx.Info(exitCalldataNote)
+	return nil
+}
+
+func newGenerator(ctx *svc.ServiceContext) (*generator, error) {
+	height, err := ctx.BlockModel.GetLatestVerifiedHeight()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the last verified height: %v", err)
+	}
+	verifiedBlock, err := ctx.BlockModel.GetBlockByHeightWithoutTx(height)
+	if err != nil {
+		return nil, err
+	}
+	contractAbi, err := abi.JSON(strings.NewReader(zkbnb.ZkBNBMetaData.ABI))
+	if err != nil {
+		return nil, err
+	}
+	calldata, err := contractAbi.Pack("activateDesertMode")
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the account tree is rebuilt with the asset roots of the accounts, the asset tree of
+	// an account is built when its package is generated, and checked against its asset root.
+	logx.Infof("rebuilding the trees at height %d", height)
+	accountTree, err := buildAccountTree(ctx, height)
+	if err != nil {
+		return nil, err
+	}
+	treeCtx := &tree.Context{
+		Name:   "exodus",
+		Driver: tree.MemoryDB,
+		Reload: true,
+	}
+	err = tree.SetupTreeDB(treeCtx)
+	if err != nil {
+		return nil, err
+	}
+	nftTree, err := tree.InitNftTree(ctx.NftHistoryModel, height, treeCtx)
+	if err != nil {
+		return nil, err
+	}
+	stateRoot := tree.ComputeStateRootHash(accountTree.Root(), nftTree.Root())
+	if common.Bytes2Hex(stateRoot) != verifiedBlock.StateRoot {
+		return nil, fmt.Errorf("state root mismatch at height %d, expected %s, got %s",
+			height, verifiedBlock.StateRoot, common.Bytes2Hex(stateRoot))
+	}
+
+	info := chain.ConstructStoredBlockInfo(verifiedBlock)
+	return &generator{
+		ctx:    ctx,
+		height: height,
+		storedBlockInfo: &StoredBlockInfo{
+			BlockSize:                    info.BlockSize,
+			BlockNumber:                  info.BlockNumber,
+			PriorityOperations:           info.PriorityOperations,
+			PendingOnchainOperationsHash: common.Bytes2Hex(info.PendingOnchainOperationsHash[:]),
+			Timestamp:                    info.Timestamp.String(),
+			StateRoot:                    common.Bytes2Hex(info.StateRoot[:]),
+			Commitment:                   common.Bytes2Hex(info.Commitment[:]),
+		},
+		calldata:    hexutil.Encode(calldata),
+		accountTree: accountTree,
+		nftTree:     nftTree,
+	}, nil
+}
+
+// buildAccountTree builds the account tree at the height with the asset roots in the account
+// histories, which are covered by the state root checked against the verified block.
+func buildAccountTree(ctx *svc.ServiceContext, height int64) (bsmt.SparseMerkleTree, error) {
+	accountTree, err := bsmt.NewBASSparseMerkleTree(bsmt.NewHasher(mimc.NewMiMC()),
+		memory.NewMemoryDB(), tree.AccountTreeHeight, tree.NilAccountNodeHash,
+		bsmt.InitializeVersion(bsmt.Version(height)))
+	if err != nil {
+		return nil, err
+	}
+	accountNum, err := ctx.AccountHistoryModel.GetValidAccountCount(height)
+	if err != nil {
+		return nil, err
+	}
+	for offset := 0; offset < int(accountNum); offset += batchSize {
+		_, histories, err := ctx.AccountHistoryModel.GetValidAccounts(height, batchSize, offset)
+		if err != nil {
+			return nil, err
+		}
+		for _, history := range histories {
+			accountInfo, err := ctx.AccountModel.GetAccountByIndex(history.AccountIndex)
+			if err != nil {
+				return nil, err
+			}
+			hashVal, err := tree.AccountToNode(accountInfo.AccountNameHash, accountInfo.PublicKey,
+				history.Nonce, history.CollectionNonce, common.FromHex(history.AssetRoot))
+			if err != nil {
+				return nil, err
+			}
+			err = accountTree.Set(uint64(history.AccountIndex), hashVal)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	_, err = accountTree.Commit(nil)
+	if err != nil {
+		return nil, err
+	}
+	return accountTree, nil
+}
+
+func (g *generator) newPackage() *ExitPackage {
+	return &ExitPackage{
+		ActivateDesertModeCalldata: g.calldata,
+		StoredBlockInfo:            g.storedBlockInfo,
+		Assets:                     make([]*merkle.AssetProof, 0),
+		Nfts:                       make([]*merkle.NftProof, 0),
+	}
+}
+
+// generateAccount generates the package of the asset of the account, or the package of all
+// the assets with balances and the nfts owned by the account if the asset is not specified.
+func (g *generator) generateAccount(accountIndex, assetId int64) (*ExitPackage, error) {
+	accountInfo, err := g.getAccount(accountIndex)
+	if err != nil {
+		return nil, err
+	}
+	assetTree, err := buildAssetTree(accountInfo)
+	if err != nil {
+		return nil, err
+	}
+	exitPackage := g.newPackage()
+	if assetId >= 0 {
+		assetProof, err := g.assetProof(accountInfo, assetTree, assetId)
+		if err != nil {
+			return nil, err
+		}
+		exitPackage.Assets = append(exitPackage.Assets, assetProof)
+		return exitPackage, nil
+	}
+
+	for id, asset := range accountInfo.AssetInfo {
+		if asset.Balance == nil || asset.Balance.Sign() == 0 {
+			continue
+		}
+		assetProof, err := g.assetProof(accountInfo, assetTree, id)
+		if err != nil {
+			return nil, err
+		}
+		exitPackage.Assets = append(exitPackage.Assets, assetProof)
+	}
+
+	nftNum, err := g.ctx.NftHistoryModel.GetNftsCountByAccountIndexAtHeight(accountIndex, g.height)
+	if err != nil {
+		return nil, err
+	}
+	for offset := int64(0); offset < nftNum; offset += batchSize {
+		nfts, err := g.ctx.NftHistoryModel.GetNftsByAccountIndexAtHeight(accountIndex, g.height, batchSize, offset)
+		if err != nil {
+			return nil, err
+		}
+		for _, nft := range nfts {
+			nftProof, err := g.nftProof(nft.NftIndex)
+			if err != nil {
+				return nil, err
+			}
+			exitPackage.Nfts = append(exitPackage.Nfts, nftProof)
+		}
+	}
+	return exitPackage, nil
+}
+
+func (g *generator) generateNft(nftIndex int64) (*ExitPackage, error) {
+	nftProof, err := g.nftProof(nftIndex)
+	if err != nil {
+		return nil, err
+	}
+	exitPackage := g.newPackage()
+	exitPackage.Nfts = append(exitPackage.Nfts, nftProof)
+	return exitPackage, nil
+}
+
+// generateAll writes the package of each account into account-<index>.json of the output
+// directory, the accounts with nothing to exit are skipped.
+func (g *generator) generateAll(output string) error {
+	if output == "" {
+		return fmt.Errorf("the output directory should be specified in the batch mode")
+	}
+	err := os.MkdirAll(output, 0755)
+	if err != nil {
+		return err
+	}
+	accountNum, err := g.ctx.AccountHistoryModel.GetValidAccountCount(g.height)
+	if err != nil {
+		return err
+	}
+	generated := 0
+	for offset := 0; offset < int(accountNum); offset += batchSize {
+		_, histories, err := g.ctx.AccountHistoryModel.GetValidAccounts(g.height, batchSize, offset)
+		if err != nil {
+			return err
+		}
+		for _, history := range histories {
+			exitPackage, err := g.generateAccount(history.AccountIndex, -1)
+			if err != nil {
+				return fmt.Errorf("unable to generate the package of account %d: %v", history.AccountIndex, err)
+			}
+			if len(exitPackage.Assets) == 0 && len(exitPackage.Nfts) == 0 {
+				continue
+			}
+			err = writePackage(exitPackage, filepath.Join(output, fmt.Sprintf("account-%d.json", history.AccountIndex)))
+			if err != nil {
+				return err
+			}
+			generated++
+		}
+	}
+	logx.Infof("generated the exit packages of %d accounts at height %d", generated, g.height)
+	return nil
+}
+
+// getAccount restores the account from its latest snapshot at the verified height.
+func (g *generator) getAccount(accountIndex int64) (*types.AccountInfo, error) {
+	accountHistory, err := g.ctx.AccountHistoryModel.GetLatestAccountHistory(accountIndex, g.height+1)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get account %d at height %d: %v", accountIndex, g.height, err)
+	}
+	accountInfo, err := g.ctx.AccountModel.GetAccountByIndex(accountIndex)
+	if err != nil {
+		return nil, err
+	}
+	return chain.ToFormatAccountInfo(&account.Account{
+		AccountIndex:    accountIndex,
+		AccountName:     accountInfo.AccountName,
+		PublicKey:       accountInfo.PublicKey,
+		AccountNameHash: accountInfo.AccountNameHash,
+		L1Address:       accountInfo.L1Address,
+		Nonce:           accountHistory.Nonce,
+		CollectionNonce: accountHistory.CollectionNonce,
+		AssetInfo:       accountHistory.AssetInfo,
+		AssetRoot:       accountHistory.AssetRoot,
+	})
+}
+
+// buildAssetTree builds the asset tree of the account, the root of which must be the asset root
+// of the account in the account tree.
+func buildAssetTree(accountInfo *types.AccountInfo) (bsmt.SparseMerkleTree, error) {
+	assetTree, err := tree.NewMemAccountAssetTree()
+	if err != nil {
+		return nil, err
+	}
+	for assetId, asset := range accountInfo.AssetInfo {
+		hashVal, err := tree.AssetToNode(asset.Balance.String(), asset.OfferCanceledOrFinalized.String())
+		if err != nil {
+			return nil, err
+		}
+		err = assetTree.Set(uint64(assetId), hashVal)
+		if err != nil {
+			return nil, err
+		}
+	}
+	_, err = assetTree.Commit(nil)
+	if err != nil {
+		return nil, err
+	}
+	if common.Bytes2Hex(assetTree.Root()) != accountInfo.AssetRoot {
+		return nil, fmt.Errorf("asset root mismatch of account %d, expected %s, got %s",
+			accountInfo.AccountIndex, accountInfo.AssetRoot, common.Bytes2Hex(assetTree.Root()))
+	}
+	return assetTree, nil
+}
+
+func (g *generator) assetProof(accountInfo *types.AccountInfo, assetTree bsmt.SparseMerkleTree,
+	assetId int64) (*merkle.AssetProof, error) {
+	accountSiblings, err := g.accountTree.GetProof(uint64(accountInfo.AccountIndex))
+	if err != nil {
+		return nil, err
+	}
+	assetSiblings, err := assetTree.GetProof(uint64(assetId))
+	if err != nil {
+		return nil, err
+	}
+
+	assetProof := &merkle.AssetProof{
+		AccountProof: &merkle.AccountProof{
+			BlockHeight:     g.height,
+			StateRoot:       g.storedBlockInfo.StateRoot,
+			NftRoot:         common.Bytes2Hex(g.nftTree.Root()),
+			AccountIndex:    accountInfo.AccountIndex,
+			AccountNameHash: accountInfo.AccountNameHash,
+			AccountPk:       accountInfo.PublicKey,
+			Nonce:           accountInfo.Nonce,
+			CollectionNonce: accountInfo.CollectionNonce,
+			AssetRoot:       common.Bytes2Hex(assetTree.Root()),
+			Siblings:        merkle.FormatSiblings(accountSiblings),
+		},
+		AssetId:                  assetId,
+		Balance:                  types.ZeroBigInt.String(),
+		OfferCanceledOrFinalized: types.ZeroBigInt.String(),
+		Siblings:                 merkle.FormatSiblings(assetSiblings),
+	}
+	if asset, ok := accountInfo.AssetInfo[assetId]; ok {
+		assetProof.Balance = asset.Balance.String()
+		assetProof.OfferCanceledOrFinalized = asset.OfferCanceledOrFinalized.String()
+	}
+	err = merkle.VerifyAssetProof(assetProof)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proof of asset %d of account %d: %v", assetId, accountInfo.AccountIndex, err)
+	}
+	return assetProof, nil
+}
+
+func (g *generator) nftProof(nftIndex int64) (*merkle.NftProof, error) {
+	nft, err := g.ctx.NftHistoryModel.GetLatestNftHistory(nftIndex, g.height+1)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get nft %d at height %d: %v", nftIndex, g.height, err)
+	}
+	siblings, err := g.nftTree.GetProof(uint64(nftIndex))
+	if err != nil {
+		return nil, err
+	}
+	nftProof := &merkle.NftProof{
+		BlockHeight:         g.height,
+		StateRoot:           g.storedBlockInfo.StateRoot,
+		AccountRoot:         common.Bytes2Hex(g.accountTree.Root()),
+		NftIndex:            nftIndex,
+		CreatorAccountIndex: nft.CreatorAccountIndex,
+		OwnerAccountIndex:   nft.OwnerAccountIndex,
+		NftContentHash:      nft.NftContentHash,
+		NftL1Address:        nft.NftL1Address,
+		NftL1TokenId:        nft.NftL1TokenId,
+		CreatorTreasuryRate: nft.CreatorTreasuryRate,
+		CollectionId:        nft.CollectionId,
+		Siblings:            merkle.FormatSiblings(siblings),
+	}
+	err = merkle.VerifyNftProof(nftProof)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proof of nft %d: %v", nftIndex, err)
+	}
+	return nftProof, nil
+}
+
+func writePackage(exitPackage *ExitPackage, output string) error {
+	content, err := json.MarshalIndent(exitPackage, "", "  ")
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		fmt.Println(string(content))
+		return nil
+	}
+	return os.WriteFile(output, content, 0644)
+}
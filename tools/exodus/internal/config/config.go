@@ -0,0 +1,12 @@
+package config
+
+import (
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type Config struct {
+	Postgres struct {
+		DataSource string
+	}
+	LogConf logx.LogConf
+}
@@ -0,0 +1,109 @@
+package gasassets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/conf"
+	"github.com/zeromicro/go-zero/core/logx"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/zkbnb/common/prove"
+	"github.com/bnb-chain/zkbnb/dao/asset"
+	"github.com/bnb-chain/zkbnb/dao/dbcache"
+	"github.com/bnb-chain/zkbnb/dao/sysconfig"
+	"github.com/bnb-chain/zkbnb/service/prover/config"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+// AddGasAssets appends the new version of the gas assets to the SysGasAssets sysconfig with
+// the config of the provers, which must have the circuit keys of the version, otherwise the
+// blocks committed with it can't be proved. The committer uses the version from the next
+// proposed block, and the gas assets cached for the apiserver are dropped.
+func AddGasAssets(configFile string, version int64, assetIds []int64) error {
+	var c config.Config
+	conf.MustLoad(configFile, &c)
+	logx.MustSetup(c.LogConf)
+	logx.DisableStat()
+
+	err := checkKeys(c, version)
+	if err != nil {
+		return err
+	}
+
+	db, err := gorm.Open(postgres.Open(c.Postgres.DataSource))
+	if err != nil {
+		return err
+	}
+	assetModel := asset.NewAssetModel(db)
+	for _, assetId := range assetIds {
+		l2Asset, err := assetModel.GetAssetById(assetId)
+		if err != nil {
+			return fmt.Errorf("failed to get asset %d: %v", assetId, err)
+		}
+		if l2Asset.Status != asset.StatusActive {
+			return fmt.Errorf("asset %d is not active", assetId)
+		}
+	}
+
+	sysConfigModel := sysconfig.NewSysConfigModel(db)
+	err = db.Transaction(func(tx *gorm.DB) error {
+		cfgGasAssets, err := sysConfigModel.GetSysConfigForUpdateInTransact(tx, types.SysGasAssets)
+		if err != nil {
+			return fmt.Errorf("failed to get gas assets: %v", err)
+		}
+		cfgGasAssets.Value, err = types.AppendGasAssets(cfgGasAssets.Value, &types.GasAssets{
+			Version:  version,
+			AssetIds: assetIds,
+		})
+		if err != nil {
+			return err
+		}
+		return sysConfigModel.UpdateSysConfigsInTransact(tx, []*sysconfig.SysConfig{cfgGasAssets})
+	})
+	if err != nil {
+		return err
+	}
+	logx.Infof("gas assets version %d is appended, asset ids: %v", version, assetIds)
+
+	redisCache := dbcache.NewRedisCache(c.CacheRedis[0].Host, c.CacheRedis[0].Pass, 15*time.Minute)
+	defer redisCache.Close()
+	err = redisCache.Delete(context.Background(), dbcache.GasAssetsKey)
+	if err != nil {
+		return fmt.Errorf("gas assets version %d is appended, but failed to drop the cached gas assets: %v", version, err)
+	}
+	return nil
+}
+
+// checkKeys checks that the provers are configured with the keys of the version for all the
+// block sizes, and the verifying keys could be loaded.
+func checkKeys(c config.Config, version int64) error {
+	for _, keyPath := range append([]config.KeyPath{c.KeyPath}, c.ExtraKeyPaths...) {
+		keyVersion := keyPath.GasAssetsVersion
+		if keyVersion == 0 {
+			keyVersion = types.InitialGasAssetsVersion
+		}
+		if keyVersion != version {
+			continue
+		}
+
+		blockSizes := c.BlockConfig.OptionalBlockSizes
+		if len(keyPath.ProvingKeyPath) != len(blockSizes) || len(keyPath.VerifyingKeyPath) != len(blockSizes) {
+			return fmt.Errorf("the keys of gas assets version %d don't match the block sizes %v", version, blockSizes)
+		}
+		for i := range blockSizes {
+			if _, err := os.Stat(keyPath.ProvingKeyPath[i]); err != nil {
+				return fmt.Errorf("invalid proving key of gas assets version %d: %v", version, err)
+			}
+			if _, err := prove.LoadVerifyingKey(keyPath.VerifyingKeyPath[i]); err != nil {
+				return fmt.Errorf("invalid verifying key %s of gas assets version %d: %v",
+					keyPath.VerifyingKeyPath[i], version, err)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("no keys of gas assets version %d in the prover config", version)
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb/tree"
+)
+
+type Config struct {
+	Postgres struct {
+		DataSource string
+	}
+	ChainConfig struct {
+		NetworkRPCSysConfigName string
+		StartL1BlockHeight      int64
+		MaxHandledBlocksCount   int64
+		// The file of the commitBlocks calldata in hex, one tx per line in the L1 order, the
+		// blocks are read from it instead of the L1 node if it's set.
+		//nolint:staticcheck
+		DumpFile string `json:",optional"`
+	}
+	TreeDB struct {
+		Driver tree.Driver
+		//nolint:staticcheck
+		LevelDBOption tree.LevelDBOption `json:",optional"`
+		//nolint:staticcheck
+		RedisDBOption      tree.RedisDBOption `json:",optional"`
+		AssetTreeCacheSize int
+	}
+	LogConf logx.LogConf
+}
+
+func (c Config) Validate() {
+	if c.ChainConfig.DumpFile == "" && (c.ChainConfig.StartL1BlockHeight <= 0 || c.ChainConfig.MaxHandledBlocksCount <= 0) {
+		panic("invalid chain config")
+	}
+}
@@ -0,0 +1,45 @@
+package svc
+
+import (
+	"github.com/zeromicro/go-zero/core/logx"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/zkbnb/dao/account"
+	"github.com/bnb-chain/zkbnb/dao/block"
+	"github.com/bnb-chain/zkbnb/dao/compressedblock"
+	"github.com/bnb-chain/zkbnb/dao/nft"
+	"github.com/bnb-chain/zkbnb/dao/sysconfig"
+	"github.com/bnb-chain/zkbnb/tools/reconstruction/internal/config"
+)
+
+type ServiceContext struct {
+	Config config.Config
+	DB     *gorm.DB
+
+	AccountModel         account.AccountModel
+	AccountHistoryModel  account.AccountHistoryModel
+	NftModel             nft.L2NftModel
+	NftHistoryModel      nft.L2NftHistoryModel
+	BlockModel           block.BlockModel
+	CompressedBlockModel compressedblock.CompressedBlockModel
+	SysConfigModel       sysconfig.SysConfigModel
+}
+
+func NewServiceContext(c config.Config) *ServiceContext {
+	db, err := gorm.Open(postgres.Open(c.Postgres.DataSource))
+	if err != nil {
+		logx.Must(err)
+	}
+	return &ServiceContext{
+		Config:               c,
+		DB:                   db,
+		AccountModel:         account.NewAccountModel(db),
+		AccountHistoryModel:  account.NewAccountHistoryModel(db),
+		NftModel:             nft.NewL2NftModel(db),
+		NftHistoryModel:      nft.NewL2NftHistoryModel(db),
+		BlockModel:           block.NewBlockModel(db),
+		CompressedBlockModel: compressedblock.NewCompressedBlockModel(db),
+		SysConfigModel:       sysconfig.NewSysConfigModel(db),
+	}
+}
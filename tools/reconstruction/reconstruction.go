@@ -0,0 +1,285 @@
+package reconstruction
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/panjf2000/ants/v2"
+	"github.com/zeromicro/go-zero/core/conf"
+	"github.com/zeromicro/go-zero/core/logx"
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/zkbnb-eth-rpc/rpc"
+	"github.com/bnb-chain/zkbnb/common/chain"
+	"github.com/bnb-chain/zkbnb/dao/block"
+	"github.com/bnb-chain/zkbnb/dao/compressedblock"
+	"github.com/bnb-chain/zkbnb/tools/reconstruction/internal/config"
+	"github.com/bnb-chain/zkbnb/tools/reconstruction/internal/svc"
+	"github.com/bnb-chain/zkbnb/tree"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+const (
+	// The trees are rebuilt for the committer, which continues from the replayed state.
+	treeName            = "committer"
+	defaultTaskPoolSize = 1000
+)
+
+// Reconstruct rebuilds the L2 state from the blocks committed on L1 only. The blocks are
+// decoded from the commitBlocks calldata, either from the L1 node or from an archive dump,
+// and their txs are replayed into the database and the trees initialized by the
+// dbinitializer, the state root of every block is checked against the committed one.
+//
+// The txs themselves are not restored, since the pub data doesn't contain the signatures,
+// the nonces and the other fields to rebuild them.
+func Reconstruct(configFile string) error {
+	var c config.Config
+	conf.MustLoad(configFile, &c)
+	c.Validate()
+	logx.MustSetup(c.LogConf)
+	logx.DisableStat()
+	ctx := svc.NewServiceContext(c)
+
+	genesisBlock, err := checkFreshDatabase(ctx)
+	if err != nil {
+		return err
+	}
+
+	blocks, err := getCommittedBlocks(ctx)
+	if err != nil {
+		return err
+	}
+	logx.Infof("%d committed blocks are found", len(blocks))
+
+	treeCtx := &tree.Context{
+		Name:          treeName,
+		Driver:        c.TreeDB.Driver,
+		LevelDBOption: &c.TreeDB.LevelDBOption,
+		RedisDBOption: &c.TreeDB.RedisDBOption,
+	}
+	err = tree.SetupTreeDB(treeCtx)
+	if err != nil {
+		return fmt.Errorf("init tree database failed: %v", err)
+	}
+	accountTree, assetTrees, err := tree.InitAccountTree(ctx.AccountModel, ctx.AccountHistoryModel,
+		genesisBlock.BlockHeight, treeCtx, c.TreeDB.AssetTreeCacheSize)
+	if err != nil {
+		return fmt.Errorf("init account tree failed: %v", err)
+	}
+	nftTree, err := tree.InitNftTree(ctx.NftHistoryModel, genesisBlock.BlockHeight, treeCtx)
+	if err != nil {
+		return fmt.Errorf("init nft tree failed: %v", err)
+	}
+	s := newState(accountTree, assetTrees, nftTree)
+	if common.Bytes2Hex(s.stateRoot()) != genesisBlock.StateRoot {
+		return fmt.Errorf("the tree database is not empty, the state root is %s", common.Bytes2Hex(s.stateRoot()))
+	}
+
+	pool, err := ants.NewPool(defaultTaskPoolSize)
+	if err != nil {
+		return err
+	}
+	defer pool.Release()
+
+	verifiedHeight := genesisBlock.BlockHeight
+	for _, committedBlock := range blocks {
+		if committedBlock.VerifiedTxHash != "" {
+			verifiedHeight = int64(committedBlock.BlockNumber)
+		}
+	}
+	for _, committedBlock := range blocks {
+		err = replayBlock(ctx, pool, s, committedBlock, verifiedHeight)
+		if err != nil {
+			return fmt.Errorf("replay block %d failed: %v", committedBlock.BlockNumber, err)
+		}
+		logx.Infof("block %d is replayed, state root: %s", committedBlock.BlockNumber, common.Bytes2Hex(committedBlock.NewStateRoot[:]))
+	}
+	logx.Infof("the state is reconstructed to block %d, %d accounts and %d nfts", len(blocks), len(s.accounts), len(s.nfts))
+	return nil
+}
+
+// checkFreshDatabase makes sure that the database only contains the genesis block, which is
+// created by the dbinitializer.
+func checkFreshDatabase(ctx *svc.ServiceContext) (*block.Block, error) {
+	height, err := ctx.BlockModel.GetCurrentBlockHeight()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the current height: %v", err)
+	}
+	accountCount, err := ctx.AccountModel.GetAccountsTotalCount()
+	if err != nil && err != types.DbErrNotFound {
+		return nil, fmt.Errorf("unable to get the accounts count: %v", err)
+	}
+	if height != 0 || accountCount != 0 {
+		return nil, fmt.Errorf("the database is not fresh, it should be initialized by the dbinitializer only")
+	}
+	return ctx.BlockModel.GetBlockByHeightWithoutTx(0)
+}
+
+func getCommittedBlocks(ctx *svc.ServiceContext) ([]*CommittedBlock, error) {
+	c := ctx.Config.ChainConfig
+	if c.DumpFile != "" {
+		return readCommittedBlocks(c.DumpFile)
+	}
+	zkbnbAddressConfig, err := ctx.SysConfigModel.GetSysConfigByName(types.ZkBNBContract)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the rollup contract from sysconfig: %v", err)
+	}
+	networkRpc, err := ctx.SysConfigModel.GetSysConfigByName(c.NetworkRPCSysConfigName)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the network rpc from sysconfig: %v", err)
+	}
+	cli, err := rpc.NewClient(networkRpc.Value)
+	if err != nil {
+		return nil, err
+	}
+	return fetchCommittedBlocks(cli, zkbnbAddressConfig.Value, c.StartL1BlockHeight, c.MaxHandledBlocksCount)
+}
+
+// replayBlock applies the txs of the block to the state and checks the new state root, the
+// block and the changed states are stored in one db transaction.
+func replayBlock(ctx *svc.ServiceContext, pool *ants.Pool, s *state, committedBlock *CommittedBlock, verifiedHeight int64) error {
+	height := int64(committedBlock.BlockNumber)
+	pubData := committedBlock.PublicData
+	if len(pubData)%chain.TxPubDataBytesSize != 0 {
+		return fmt.Errorf("invalid block pub data size %d", len(pubData))
+	}
+
+	oldStateRoot := s.stateRoot()
+	s.resetBlock(height)
+	for offset := 0; offset < len(pubData); offset += chain.TxPubDataBytesSize {
+		txPubData := pubData[offset : offset+chain.TxPubDataBytesSize]
+		if int(txPubData[0]) == types.TxTypeEmpty {
+			continue
+		}
+		txInfo, err := chain.ParseTxPubData(txPubData)
+		if err != nil {
+			return err
+		}
+		err = s.applyTx(txInfo, txPubData, uint32(offset))
+		if err != nil {
+			return err
+		}
+	}
+	err := checkPubDataOffsets(s.pubDataOffsets, committedBlock.PublicDataOffsets)
+	if err != nil {
+		return err
+	}
+
+	err = s.updateTrees()
+	if err != nil {
+		return err
+	}
+	newStateRoot := s.stateRoot()
+	if !bytes.Equal(newStateRoot, committedBlock.NewStateRoot[:]) {
+		return fmt.Errorf("state root mismatch, expected %s, got %s",
+			common.Bytes2Hex(committedBlock.NewStateRoot[:]), common.Bytes2Hex(newStateRoot))
+	}
+	pruneVersion := verifiedHeight
+	if pruneVersion > height {
+		pruneVersion = height
+	}
+	err = tree.CommitTrees(pool, uint64(pruneVersion), s.accountTree, s.assetTrees, s.nftTree)
+	if err != nil {
+		return err
+	}
+
+	timestamp := committedBlock.Timestamp.Int64()
+	newBlock := &block.Block{
+		Model: gorm.Model{
+			CreatedAt: time.UnixMilli(timestamp),
+		},
+		BlockSize: committedBlock.BlockSize,
+		BlockCommitment: chain.CreateBlockCommitment(height, timestamp, oldStateRoot, newStateRoot,
+			pubData, int64(len(s.pubDataOffsets))),
+		BlockHeight:                  height,
+		StateRoot:                    common.Bytes2Hex(newStateRoot),
+		PriorityOperations:           s.priorityOperations,
+		PendingOnChainOperationsHash: common.Bytes2Hex(s.pendingOnChainOperationsHash),
+		CommittedTxHash:              committedBlock.CommittedTxHash,
+		VerifiedTxHash:               committedBlock.VerifiedTxHash,
+		BlockStatus:                  block.StatusCommitted,
+	}
+	if height <= verifiedHeight {
+		newBlock.BlockStatus = block.StatusVerifiedAndExecuted
+	}
+	if len(s.pendingOnChainOperationsPubData) > 0 {
+		onChainOperationsPubDataBytes, err := json.Marshal(s.pendingOnChainOperationsPubData)
+		if err != nil {
+			return fmt.Errorf("marshal pending onChain operation pubData failed: %v", err)
+		}
+		newBlock.PendingOnChainOperationsPubData = string(onChainOperationsPubDataBytes)
+	}
+	offsetBytes, err := json.Marshal(s.pubDataOffsets)
+	if err != nil {
+		return fmt.Errorf("marshal pubData offset failed: %v", err)
+	}
+	newCompressedBlock := &compressedblock.CompressedBlock{
+		BlockSize:         committedBlock.BlockSize,
+		BlockHeight:       height,
+		StateRoot:         newBlock.StateRoot,
+		PublicData:        common.Bytes2Hex(pubData),
+		Timestamp:         timestamp,
+		PublicDataOffsets: string(offsetBytes),
+	}
+
+	pendingAccounts, pendingAccountHistories, err := s.pendingAccounts()
+	if err != nil {
+		return err
+	}
+	pendingNfts, pendingNftHistories := s.pendingNfts()
+	err = ctx.DB.Transaction(func(dbTx *gorm.DB) error {
+		err := ctx.BlockModel.CreateBlockInTransact(dbTx, newBlock)
+		if err != nil {
+			return err
+		}
+		err = ctx.CompressedBlockModel.CreateCompressedBlockInTransact(dbTx, newCompressedBlock)
+		if err != nil {
+			return err
+		}
+		if len(pendingAccounts) > 0 {
+			err = ctx.AccountModel.UpdateAccountsInTransact(dbTx, pendingAccounts)
+			if err != nil {
+				return err
+			}
+			err = ctx.AccountHistoryModel.CreateAccountHistoriesInTransact(dbTx, pendingAccountHistories)
+			if err != nil {
+				return err
+			}
+		}
+		if len(pendingNfts) > 0 {
+			err = ctx.NftModel.UpdateNftsInTransact(dbTx, pendingNfts)
+			if err != nil {
+				return err
+			}
+			err = ctx.NftHistoryModel.CreateNftHistoriesInTransact(dbTx, pendingNftHistories)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// keep the ids of the created accounts, so that they are updated in the later blocks
+	for _, pendingAccount := range pendingAccounts {
+		s.accounts[pendingAccount.AccountIndex].AccountId = pendingAccount.ID
+	}
+	return nil
+}
+
+func checkPubDataOffsets(offsets, committedOffsets []uint32) error {
+	if len(offsets) != len(committedOffsets) {
+		return fmt.Errorf("pub data offsets mismatch, expected %v, got %v", committedOffsets, offsets)
+	}
+	for i := range offsets {
+		if offsets[i] != committedOffsets[i] {
+			return fmt.Errorf("pub data offsets mismatch, expected %v, got %v", committedOffsets, offsets)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package reconstruction
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/zeromicro/go-zero/core/logx"
+
+	zkbnb "github.com/bnb-chain/zkbnb-eth-rpc/core"
+	"github.com/bnb-chain/zkbnb-eth-rpc/rpc"
+)
+
+const (
+	eventNameBlockCommit       = "BlockCommit"
+	eventNameBlockVerification = "BlockVerification"
+	eventNameBlocksRevert      = "BlocksRevert"
+	methodNameCommitBlocks     = "commitBlocks"
+)
+
+var (
+	zkbnbContractAbi, _ = abi.JSON(strings.NewReader(zkbnb.ZkBNBMetaData.ABI))
+
+	blockCommitSigHash       = crypto.Keccak256Hash([]byte("BlockCommit(uint32)"))
+	blockVerificationSigHash = crypto.Keccak256Hash([]byte("BlockVerification(uint32)"))
+	blocksRevertSigHash      = crypto.Keccak256Hash([]byte("BlocksRevert(uint32,uint32)"))
+)
+
+// CommittedBlock is a block committed on L1, which is taken from the calldata of the
+// commitBlocks tx.
+type CommittedBlock struct {
+	zkbnb.OldZkBNBCommitBlockInfo
+	CommittedTxHash string
+	VerifiedTxHash  string
+}
+
+// fetchCommittedBlocks scans the events of the rollup contract from the start height to the
+// latest L1 block, and decodes the blocks from the calldata of the txs committing them. The
+// blocks reverted on L1 are dropped, so only the blocks in the canonical chain are returned.
+func fetchCommittedBlocks(cli *rpc.ProviderClient, zkbnbContract string, startHeight, batchSize int64) ([]*CommittedBlock, error) {
+	latestHeight, err := cli.GetHeight()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the l1 height: %v", err)
+	}
+
+	committedTxHashes := make(map[int64]string)
+	verifiedTxHashes := make(map[int64]string)
+	for from := startHeight; from <= int64(latestHeight); from += batchSize {
+		to := from + batchSize - 1
+		if to > int64(latestHeight) {
+			to = int64(latestHeight)
+		}
+		logx.Infof("scanning l1 blocks from %d to %d", from, to)
+		logs, err := cli.FilterLogs(context.Background(), ethereum.FilterQuery{
+			FromBlock: big.NewInt(from),
+			ToBlock:   big.NewInt(to),
+			Addresses: []common.Address{common.HexToAddress(zkbnbContract)},
+			Topics:    [][]common.Hash{{blockCommitSigHash, blockVerificationSigHash, blocksRevertSigHash}},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to get contract logs: %v", err)
+		}
+		for _, vlog := range logs {
+			switch vlog.Topics[0] {
+			case blockCommitSigHash:
+				var event zkbnb.ZkBNBBlockCommit
+				if err := zkbnbContractAbi.UnpackIntoInterface(&event, eventNameBlockCommit, vlog.Data); err != nil {
+					return nil, fmt.Errorf("failed to unpack ZkBNBBlockCommit event, err: %v", err)
+				}
+				committedTxHashes[int64(event.BlockNumber)] = vlog.TxHash.Hex()
+			case blockVerificationSigHash:
+				var event zkbnb.ZkBNBBlockVerification
+				if err := zkbnbContractAbi.UnpackIntoInterface(&event, eventNameBlockVerification, vlog.Data); err != nil {
+					return nil, fmt.Errorf("failed to unpack ZkBNBBlockVerification event, err: %v", err)
+				}
+				verifiedTxHashes[int64(event.BlockNumber)] = vlog.TxHash.Hex()
+			case blocksRevertSigHash:
+				var event zkbnb.ZkBNBBlocksRevert
+				if err := zkbnbContractAbi.UnpackIntoInterface(&event, eventNameBlocksRevert, vlog.Data); err != nil {
+					return nil, fmt.Errorf("failed to unpack ZkBNBBlocksRevert event, err: %v", err)
+				}
+				for height := range committedTxHashes {
+					if height > int64(event.TotalBlocksCommitted) {
+						delete(committedTxHashes, height)
+					}
+				}
+			}
+		}
+	}
+
+	// A commitBlocks tx commits several blocks, so the calldata is decoded once per tx.
+	heightsByTxHash := make(map[string][]int64)
+	for height, txHash := range committedTxHashes {
+		heightsByTxHash[txHash] = append(heightsByTxHash[txHash], height)
+	}
+	blocks := make([]*CommittedBlock, 0, len(committedTxHashes))
+	for txHash, heights := range heightsByTxHash {
+		tx, _, err := cli.GetTransactionByHash(txHash)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get the commit tx %s: %v", txHash, err)
+		}
+		commitBlocksInfo, err := decodeCommitBlocks(tx.Data())
+		if err != nil {
+			return nil, fmt.Errorf("invalid calldata of the commit tx %s: %v", txHash, err)
+		}
+		for _, height := range heights {
+			info, ok := commitBlocksInfo[height]
+			if !ok {
+				return nil, fmt.Errorf("block %d is not in the commit tx %s", height, txHash)
+			}
+			blocks = append(blocks, &CommittedBlock{
+				OldZkBNBCommitBlockInfo: info,
+				CommittedTxHash:         txHash,
+				VerifiedTxHash:          verifiedTxHashes[height],
+			})
+		}
+	}
+	return sortCommittedBlocks(blocks)
+}
+
+// readCommittedBlocks reads the blocks from the dump of the commitBlocks calldata, a block
+// committed again after a revert overrides the reverted one as the txs are in the L1 order.
+func readCommittedBlocks(dumpFile string) ([]*CommittedBlock, error) {
+	file, err := os.Open(dumpFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	committedBlocks := make(map[int64]*CommittedBlock)
+	scanner := bufio.NewScanner(file)
+	// the calldata of a full block is far larger than the default token size
+	scanner.Buffer(make([]byte, 0, 1024*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		calldata := strings.TrimSpace(scanner.Text())
+		if calldata == "" {
+			continue
+		}
+		commitBlocksInfo, err := decodeCommitBlocks(common.FromHex(calldata))
+		if err != nil {
+			return nil, fmt.Errorf("invalid calldata at line %d: %v", line, err)
+		}
+		for height, info := range commitBlocksInfo {
+			committedBlocks[height] = &CommittedBlock{OldZkBNBCommitBlockInfo: info}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	blocks := make([]*CommittedBlock, 0, len(committedBlocks))
+	for _, committedBlock := range committedBlocks {
+		blocks = append(blocks, committedBlock)
+	}
+	return sortCommittedBlocks(blocks)
+}
+
+func decodeCommitBlocks(calldata []byte) (map[int64]zkbnb.OldZkBNBCommitBlockInfo, error) {
+	if len(calldata) < 4 {
+		return nil, fmt.Errorf("calldata too short")
+	}
+	method, err := zkbnbContractAbi.MethodById(calldata[:4])
+	if err != nil {
+		return nil, err
+	}
+	if method.Name != methodNameCommitBlocks {
+		return nil, fmt.Errorf("unexpected method %s", method.Name)
+	}
+	args, err := method.Inputs.Unpack(calldata[4:])
+	if err != nil {
+		return nil, err
+	}
+	if len(args) != 2 {
+		return nil, fmt.Errorf("unexpected arguments count %d", len(args))
+	}
+	newBlocksInfo := *abi.ConvertType(args[1], new([]zkbnb.OldZkBNBCommitBlockInfo)).(*[]zkbnb.OldZkBNBCommitBlockInfo)
+	blocks := make(map[int64]zkbnb.OldZkBNBCommitBlockInfo, len(newBlocksInfo))
+	for _, info := range newBlocksInfo {
+		blocks[int64(info.BlockNumber)] = info
+	}
+	return blocks, nil
+}
+
+// sortCommittedBlocks sorts the blocks by height, which must be consecutive from the first
+// block after the genesis block.
+func sortCommittedBlocks(blocks []*CommittedBlock) ([]*CommittedBlock, error) {
+	sort.Slice(blocks, func(i, j int) bool {
+		return blocks[i].BlockNumber < blocks[j].BlockNumber
+	})
+	for i, committedBlock := range blocks {
+		if int64(committedBlock.BlockNumber) != int64(i+1) {
+			return nil, fmt.Errorf("block %d is missing, the l1 blocks should be scanned since the rollup contract is deployed", i+1)
+		}
+	}
+	return blocks, nil
+}
@@ -0,0 +1,446 @@
+package reconstruction
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/bnb-chain/zkbnb-crypto/ffmath"
+	"github.com/bnb-chain/zkbnb-crypto/wasm/txtypes"
+	"github.com/ethereum/go-ethereum/common"
+
+	bsmt "github.com/bnb-chain/zkbnb-smt"
+	common2 "github.com/bnb-chain/zkbnb/common"
+	"github.com/bnb-chain/zkbnb/common/chain"
+	"github.com/bnb-chain/zkbnb/core/executor"
+	"github.com/bnb-chain/zkbnb/dao/account"
+	"github.com/bnb-chain/zkbnb/dao/nft"
+	"github.com/bnb-chain/zkbnb/tree"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+// state is the L2 state replayed from the pub data, the accounts and the nfts are kept in
+// memory, and the changes of the block being replayed are tracked to update the trees and
+// the database.
+type state struct {
+	accountTree bsmt.SparseMerkleTree
+	assetTrees  *tree.AssetTreeCache
+	nftTree     bsmt.SparseMerkleTree
+
+	accounts map[int64]*types.AccountInfo
+	nfts     map[int64]*nft.L2Nft
+
+	height        int64
+	dirtyAccounts map[int64]map[int64]bool
+	dirtyNfts     map[int64]bool
+
+	priorityOperations              int64
+	pendingOnChainOperationsHash    []byte
+	pendingOnChainOperationsPubData [][]byte
+	pubDataOffsets                  []uint32
+}
+
+func newState(accountTree bsmt.SparseMerkleTree, assetTrees *tree.AssetTreeCache, nftTree bsmt.SparseMerkleTree) *state {
+	return &state{
+		accountTree: accountTree,
+		assetTrees:  assetTrees,
+		nftTree:     nftTree,
+		accounts:    make(map[int64]*types.AccountInfo),
+		nfts:        make(map[int64]*nft.L2Nft),
+	}
+}
+
+// resetBlock starts replaying the block at the height.
+func (s *state) resetBlock(height int64) {
+	s.height = height
+	s.dirtyAccounts = make(map[int64]map[int64]bool)
+	s.dirtyNfts = make(map[int64]bool)
+	s.priorityOperations = 0
+	s.pendingOnChainOperationsHash = common.FromHex(types.EmptyStringKeccak)
+	s.pendingOnChainOperationsPubData = make([][]byte, 0)
+	s.pubDataOffsets = make([]uint32, 0)
+}
+
+func (s *state) stateRoot() []byte {
+	return tree.ComputeStateRootHash(s.accountTree.Root(), s.nftTree.Root())
+}
+
+func (s *state) getAccount(accountIndex int64) (*types.AccountInfo, error) {
+	accountInfo, ok := s.accounts[accountIndex]
+	if !ok {
+		return nil, fmt.Errorf("account %d is not registered", accountIndex)
+	}
+	if s.dirtyAccounts[accountIndex] == nil {
+		s.dirtyAccounts[accountIndex] = make(map[int64]bool)
+	}
+	return accountInfo, nil
+}
+
+// getAsset returns the asset of the account and marks it dirty, the asset is created with
+// zero balance if the account doesn't have it yet.
+func (s *state) getAsset(accountIndex, assetId int64) (*types.AccountAsset, error) {
+	accountInfo, err := s.getAccount(accountIndex)
+	if err != nil {
+		return nil, err
+	}
+	asset, ok := accountInfo.AssetInfo[assetId]
+	if !ok {
+		asset = &types.AccountAsset{
+			AssetId:                  assetId,
+			Balance:                  types.ZeroBigInt,
+			OfferCanceledOrFinalized: types.ZeroBigInt,
+		}
+		accountInfo.AssetInfo[assetId] = asset
+	}
+	s.dirtyAccounts[accountIndex][assetId] = true
+	return asset, nil
+}
+
+func (s *state) addBalance(accountIndex, assetId int64, delta *big.Int) error {
+	asset, err := s.getAsset(accountIndex, assetId)
+	if err != nil {
+		return err
+	}
+	asset.Balance = ffmath.Add(asset.Balance, delta)
+	return nil
+}
+
+func (s *state) subBalance(accountIndex, assetId int64, delta *big.Int) error {
+	asset, err := s.getAsset(accountIndex, assetId)
+	if err != nil {
+		return err
+	}
+	asset.Balance = ffmath.Sub(asset.Balance, delta)
+	if asset.Balance.Sign() < 0 {
+		return fmt.Errorf("balance of asset %d of account %d is negative", assetId, accountIndex)
+	}
+	return nil
+}
+
+// payGas charges the gas fee and increases the nonce of the account.
+func (s *state) payGas(accountIndex, gasAccountIndex, gasFeeAssetId int64, gasFeeAssetAmount *big.Int) error {
+	err := s.subBalance(accountIndex, gasFeeAssetId, gasFeeAssetAmount)
+	if err != nil {
+		return err
+	}
+	err = s.addBalance(gasAccountIndex, gasFeeAssetId, gasFeeAssetAmount)
+	if err != nil {
+		return err
+	}
+	s.accounts[accountIndex].Nonce++
+	return nil
+}
+
+// finalizeOffer marks the offer finalized or canceled in the account.
+func (s *state) finalizeOffer(accountIndex, offerId int64) error {
+	asset, err := s.getAsset(accountIndex, offerId/executor.OfferPerAsset)
+	if err != nil {
+		return err
+	}
+	asset.OfferCanceledOrFinalized = new(big.Int).SetBit(asset.OfferCanceledOrFinalized, int(offerId%executor.OfferPerAsset), 1)
+	return nil
+}
+
+func (s *state) getNft(nftIndex int64) (*nft.L2Nft, error) {
+	l2Nft, ok := s.nfts[nftIndex]
+	if !ok {
+		return nil, fmt.Errorf("nft %d doesn't exist", nftIndex)
+	}
+	return l2Nft, nil
+}
+
+func (s *state) setNft(l2Nft *nft.L2Nft) {
+	if oldNft, ok := s.nfts[l2Nft.NftIndex]; ok {
+		l2Nft.Model = oldNft.Model
+	}
+	s.nfts[l2Nft.NftIndex] = l2Nft
+	s.dirtyNfts[l2Nft.NftIndex] = true
+}
+
+func (s *state) emptyNft(nftIndex int64) {
+	emptyNftInfo := types.EmptyNftInfo(nftIndex)
+	s.setNft(&nft.L2Nft{
+		NftIndex:            emptyNftInfo.NftIndex,
+		CreatorAccountIndex: emptyNftInfo.CreatorAccountIndex,
+		OwnerAccountIndex:   emptyNftInfo.OwnerAccountIndex,
+		NftContentHash:      emptyNftInfo.NftContentHash,
+		NftL1Address:        emptyNftInfo.NftL1Address,
+		NftL1TokenId:        emptyNftInfo.NftL1TokenId,
+		CreatorTreasuryRate: emptyNftInfo.CreatorTreasuryRate,
+		CollectionId:        emptyNftInfo.CollectionId,
+	})
+}
+
+// applyTx applies the tx decoded from the pub data, the pub data of the tx is at the offset
+// of the block pub data.
+func (s *state) applyTx(txInfo txtypes.TxInfo, pubData []byte, offset uint32) error {
+	var err error
+	switch txInfo := txInfo.(type) {
+	case *txtypes.RegisterZnsTxInfo:
+		err = s.applyRegisterZns(txInfo)
+		s.priorityOperations++
+		s.pubDataOffsets = append(s.pubDataOffsets, offset)
+	case *txtypes.DepositTxInfo:
+		err = s.addBalance(txInfo.AccountIndex, txInfo.AssetId, txInfo.AssetAmount)
+		s.priorityOperations++
+		s.pubDataOffsets = append(s.pubDataOffsets, offset)
+	case *txtypes.DepositNftTxInfo:
+		s.setNft(&nft.L2Nft{
+			NftIndex:            txInfo.NftIndex,
+			CreatorAccountIndex: txInfo.CreatorAccountIndex,
+			OwnerAccountIndex:   txInfo.AccountIndex,
+			NftContentHash:      common.Bytes2Hex(txInfo.NftContentHash),
+			NftL1Address:        txInfo.NftL1Address,
+			NftL1TokenId:        txInfo.NftL1TokenId.String(),
+			CreatorTreasuryRate: txInfo.CreatorTreasuryRate,
+			CollectionId:        txInfo.CollectionId,
+		})
+		s.priorityOperations++
+		s.pubDataOffsets = append(s.pubDataOffsets, offset)
+	case *txtypes.TransferTxInfo:
+		err = s.applyTransfer(txInfo)
+	case *txtypes.WithdrawTxInfo:
+		err = s.applyWithdraw(txInfo)
+		s.addOnChainOperation(pubData, offset)
+	case *txtypes.CreateCollectionTxInfo:
+		err = s.payGas(txInfo.AccountIndex, txInfo.GasAccountIndex, txInfo.GasFeeAssetId, txInfo.GasFeeAssetAmount)
+		if err == nil {
+			s.accounts[txInfo.AccountIndex].CollectionNonce++
+		}
+	case *txtypes.MintNftTxInfo:
+		err = s.payGas(txInfo.CreatorAccountIndex, txInfo.GasAccountIndex, txInfo.GasFeeAssetId, txInfo.GasFeeAssetAmount)
+		s.setNft(&nft.L2Nft{
+			NftIndex:            txInfo.NftIndex,
+			CreatorAccountIndex: txInfo.CreatorAccountIndex,
+			OwnerAccountIndex:   txInfo.ToAccountIndex,
+			NftContentHash:      txInfo.NftContentHash,
+			NftL1Address:        types.EmptyL1Address,
+			NftL1TokenId:        types.EmptyL1TokenId,
+			CreatorTreasuryRate: txInfo.CreatorTreasuryRate,
+			CollectionId:        txInfo.NftCollectionId,
+		})
+	case *txtypes.TransferNftTxInfo:
+		err = s.applyTransferNft(txInfo)
+	case *txtypes.AtomicMatchTxInfo:
+		err = s.applyAtomicMatch(txInfo)
+	case *txtypes.CancelOfferTxInfo:
+		err = s.payGas(txInfo.AccountIndex, txInfo.GasAccountIndex, txInfo.GasFeeAssetId, txInfo.GasFeeAssetAmount)
+		if err == nil {
+			err = s.finalizeOffer(txInfo.AccountIndex, txInfo.OfferId)
+		}
+	case *txtypes.WithdrawNftTxInfo:
+		err = s.payGas(txInfo.AccountIndex, txInfo.GasAccountIndex, txInfo.GasFeeAssetId, txInfo.GasFeeAssetAmount)
+		s.emptyNft(txInfo.NftIndex)
+		s.addOnChainOperation(pubData, offset)
+	case *txtypes.FullExitTxInfo:
+		err = s.subBalance(txInfo.AccountIndex, txInfo.AssetId, txInfo.AssetAmount)
+		s.priorityOperations++
+		s.addOnChainOperation(pubData, offset)
+	case *txtypes.FullExitNftTxInfo:
+		// The nft is exited only if it's owned by the account, otherwise an empty nft is
+		// exited and the state is not changed.
+		if l2Nft, ok := s.nfts[txInfo.NftIndex]; ok && l2Nft.OwnerAccountIndex == txInfo.AccountIndex {
+			s.emptyNft(txInfo.NftIndex)
+		}
+		s.priorityOperations++
+		s.addOnChainOperation(pubData, offset)
+	default:
+		return fmt.Errorf("unsupported tx type %d", txInfo.GetTxType())
+	}
+	return err
+}
+
+func (s *state) addOnChainOperation(pubData []byte, offset uint32) {
+	s.pubDataOffsets = append(s.pubDataOffsets, offset)
+	s.pendingOnChainOperationsPubData = append(s.pendingOnChainOperationsPubData, pubData)
+	s.pendingOnChainOperationsHash = common2.ConcatKeccakHash(s.pendingOnChainOperationsHash, pubData)
+}
+
+func (s *state) applyRegisterZns(txInfo *txtypes.RegisterZnsTxInfo) error {
+	if _, ok := s.accounts[txInfo.AccountIndex]; ok {
+		return fmt.Errorf("account %d is registered already", txInfo.AccountIndex)
+	}
+	s.accounts[txInfo.AccountIndex] = &types.AccountInfo{
+		AccountIndex:    txInfo.AccountIndex,
+		AccountName:     txInfo.AccountName,
+		PublicKey:       txInfo.PubKey,
+		AccountNameHash: common.Bytes2Hex(txInfo.AccountNameHash),
+		Nonce:           types.EmptyNonce,
+		CollectionNonce: types.EmptyCollectionNonce,
+		AssetInfo:       make(map[int64]*types.AccountAsset),
+		AssetRoot:       common.Bytes2Hex(tree.NilAccountAssetRoot),
+		Status:          account.AccountStatusConfirmed,
+	}
+	s.dirtyAccounts[txInfo.AccountIndex] = make(map[int64]bool)
+	s.assetTrees.UpdateCache(txInfo.AccountIndex, s.height)
+	return nil
+}
+
+func (s *state) applyTransfer(txInfo *txtypes.TransferTxInfo) error {
+	err := s.payGas(txInfo.FromAccountIndex, txInfo.GasAccountIndex, txInfo.GasFeeAssetId, txInfo.GasFeeAssetAmount)
+	if err != nil {
+		return err
+	}
+	err = s.subBalance(txInfo.FromAccountIndex, txInfo.AssetId, txInfo.AssetAmount)
+	if err != nil {
+		return err
+	}
+	return s.addBalance(txInfo.ToAccountIndex, txInfo.AssetId, txInfo.AssetAmount)
+}
+
+func (s *state) applyWithdraw(txInfo *txtypes.WithdrawTxInfo) error {
+	err := s.payGas(txInfo.FromAccountIndex, txInfo.GasAccountIndex, txInfo.GasFeeAssetId, txInfo.GasFeeAssetAmount)
+	if err != nil {
+		return err
+	}
+	return s.subBalance(txInfo.FromAccountIndex, txInfo.AssetId, txInfo.AssetAmount)
+}
+
+func (s *state) applyTransferNft(txInfo *txtypes.TransferNftTxInfo) error {
+	l2Nft, err := s.getNft(txInfo.NftIndex)
+	if err != nil {
+		return err
+	}
+	if _, err = s.getAccount(txInfo.ToAccountIndex); err != nil {
+		return err
+	}
+	err = s.payGas(txInfo.FromAccountIndex, txInfo.GasAccountIndex, txInfo.GasFeeAssetId, txInfo.GasFeeAssetAmount)
+	if err != nil {
+		return err
+	}
+	newNft := *l2Nft
+	newNft.OwnerAccountIndex = txInfo.ToAccountIndex
+	s.setNft(&newNft)
+	return nil
+}
+
+func (s *state) applyAtomicMatch(txInfo *txtypes.AtomicMatchTxInfo) error {
+	l2Nft, err := s.getNft(txInfo.BuyOffer.NftIndex)
+	if err != nil {
+		return err
+	}
+	err = s.payGas(txInfo.AccountIndex, txInfo.GasAccountIndex, txInfo.GasFeeAssetId, txInfo.GasFeeAssetAmount)
+	if err != nil {
+		return err
+	}
+
+	assetId := txInfo.BuyOffer.AssetId
+	err = s.subBalance(txInfo.BuyOffer.AccountIndex, assetId, txInfo.BuyOffer.AssetAmount)
+	if err != nil {
+		return err
+	}
+	sellAmount := ffmath.Sub(txInfo.BuyOffer.AssetAmount, ffmath.Add(txInfo.TreasuryAmount, txInfo.CreatorAmount))
+	err = s.addBalance(txInfo.SellOffer.AccountIndex, assetId, sellAmount)
+	if err != nil {
+		return err
+	}
+	err = s.addBalance(l2Nft.CreatorAccountIndex, assetId, txInfo.CreatorAmount)
+	if err != nil {
+		return err
+	}
+	err = s.addBalance(txInfo.GasAccountIndex, assetId, txInfo.TreasuryAmount)
+	if err != nil {
+		return err
+	}
+
+	err = s.finalizeOffer(txInfo.BuyOffer.AccountIndex, txInfo.BuyOffer.OfferId)
+	if err != nil {
+		return err
+	}
+	err = s.finalizeOffer(txInfo.SellOffer.AccountIndex, txInfo.SellOffer.OfferId)
+	if err != nil {
+		return err
+	}
+
+	newNft := *l2Nft
+	newNft.OwnerAccountIndex = txInfo.BuyOffer.AccountIndex
+	s.setNft(&newNft)
+	return nil
+}
+
+// updateTrees updates the leaves of the changed accounts, assets and nfts, the trees are
+// committed after the block is checked.
+func (s *state) updateTrees() error {
+	for accountIndex, assets := range s.dirtyAccounts {
+		accountInfo := s.accounts[accountIndex]
+		assetTree := s.assetTrees.Get(accountIndex)
+		for assetId := range assets {
+			asset := accountInfo.AssetInfo[assetId]
+			assetLeaf, err := tree.ComputeAccountAssetLeafHash(asset.Balance.String(), asset.OfferCanceledOrFinalized.String())
+			if err != nil {
+				return fmt.Errorf("compute new account asset leaf failed: %v", err)
+			}
+			err = assetTree.Set(uint64(assetId), assetLeaf)
+			if err != nil {
+				return fmt.Errorf("update asset tree failed: %v", err)
+			}
+		}
+		accountInfo.AssetRoot = common.Bytes2Hex(assetTree.Root())
+		accountLeaf, err := tree.ComputeAccountLeafHash(accountInfo.AccountNameHash, accountInfo.PublicKey,
+			accountInfo.Nonce, accountInfo.CollectionNonce, assetTree.Root())
+		if err != nil {
+			return fmt.Errorf("unable to compute account leaf: %v", err)
+		}
+		err = s.accountTree.Set(uint64(accountIndex), accountLeaf)
+		if err != nil {
+			return fmt.Errorf("unable to update account tree: %v", err)
+		}
+	}
+
+	for nftIndex := range s.dirtyNfts {
+		l2Nft := s.nfts[nftIndex]
+		nftLeaf, err := tree.ComputeNftAssetLeafHash(l2Nft.CreatorAccountIndex, l2Nft.OwnerAccountIndex,
+			l2Nft.NftContentHash, l2Nft.NftL1Address, l2Nft.NftL1TokenId, l2Nft.CreatorTreasuryRate, l2Nft.CollectionId)
+		if err != nil {
+			return fmt.Errorf("unable to compute nft leaf: %v", err)
+		}
+		err = s.nftTree.Set(uint64(nftIndex), nftLeaf)
+		if err != nil {
+			return fmt.Errorf("unable to update nft tree: %v", err)
+		}
+	}
+	return nil
+}
+
+// pendingAccounts returns the changed accounts and their histories at the block.
+func (s *state) pendingAccounts() ([]*account.Account, []*account.AccountHistory, error) {
+	pendingAccounts := make([]*account.Account, 0, len(s.dirtyAccounts))
+	pendingAccountHistories := make([]*account.AccountHistory, 0, len(s.dirtyAccounts))
+	for accountIndex := range s.dirtyAccounts {
+		newAccount, err := chain.FromFormatAccountInfo(s.accounts[accountIndex])
+		if err != nil {
+			return nil, nil, err
+		}
+		pendingAccounts = append(pendingAccounts, newAccount)
+		pendingAccountHistories = append(pendingAccountHistories, &account.AccountHistory{
+			AccountIndex:    newAccount.AccountIndex,
+			Nonce:           newAccount.Nonce,
+			CollectionNonce: newAccount.CollectionNonce,
+			AssetInfo:       newAccount.AssetInfo,
+			AssetRoot:       newAccount.AssetRoot,
+			L2BlockHeight:   s.height,
+		})
+	}
+	return pendingAccounts, pendingAccountHistories, nil
+}
+
+// pendingNfts returns the changed nfts and their histories at the block.
+func (s *state) pendingNfts() ([]*nft.L2Nft, []*nft.L2NftHistory) {
+	pendingNfts := make([]*nft.L2Nft, 0, len(s.dirtyNfts))
+	pendingNftHistories := make([]*nft.L2NftHistory, 0, len(s.dirtyNfts))
+	for nftIndex := range s.dirtyNfts {
+		l2Nft := s.nfts[nftIndex]
+		pendingNfts = append(pendingNfts, l2Nft)
+		pendingNftHistories = append(pendingNftHistories, &nft.L2NftHistory{
+			NftIndex:            l2Nft.NftIndex,
+			CreatorAccountIndex: l2Nft.CreatorAccountIndex,
+			OwnerAccountIndex:   l2Nft.OwnerAccountIndex,
+			NftContentHash:      l2Nft.NftContentHash,
+			NftL1Address:        l2Nft.NftL1Address,
+			NftL1TokenId:        l2Nft.NftL1TokenId,
+			CreatorTreasuryRate: l2Nft.CreatorTreasuryRate,
+			CollectionId:        l2Nft.CollectionId,
+			L2BlockHeight:       s.height,
+		})
+	}
+	return pendingNfts, pendingNftHistories
+}
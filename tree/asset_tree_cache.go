@@ -40,6 +40,14 @@ func (c *AssetTreeCache) UpdateCache(accountNumber, latestBlock int64) {
 	c.mainLock.Unlock()
 }
 
+// Rolls back the cache to the given block number and latest account index after blocks are reverted
+func (c *AssetTreeCache) RollBack(accountNumber, latestBlock int64) {
+	c.mainLock.Lock()
+	c.nextAccountNumber = accountNumber
+	c.blockNumber = latestBlock
+	c.mainLock.Unlock()
+}
+
 // Returns index of next account
 func (c *AssetTreeCache) GetNextAccountIndex() int64 {
 	c.mainLock.RLock()
@@ -19,6 +19,7 @@ package tree
 
 import (
 	"bytes"
+	"encoding/json"
 	"math/big"
 
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
@@ -30,6 +31,8 @@ import (
 	"github.com/bnb-chain/zkbnb-crypto/ffmath"
 	bsmt "github.com/bnb-chain/zkbnb-smt"
 	common2 "github.com/bnb-chain/zkbnb/common"
+	"github.com/bnb-chain/zkbnb/dao/account"
+	"github.com/bnb-chain/zkbnb/types"
 )
 
 func EmptyAccountNodeHash() []byte {
@@ -230,6 +233,109 @@ func RollBackTrees(
 	return nil
 }
 
+// RollBackTreesToHeight reverts the committed trees to the state of the given block height.
+// The account and nft trees are rolled back to the version of the height, while the asset
+// trees are not versioned by block height, so the given assets of the given accounts are
+// reset to their values at the height from the account histories.
+func RollBackTreesToHeight(
+	pool *ants.Pool,
+	blockHeight int64,
+	accountIndexes []int64,
+	assetIds []int64,
+	accountHistoryModel account.AccountHistoryModel,
+	accountTree bsmt.SparseMerkleTree,
+	assetTrees *AssetTreeCache,
+	nftTree bsmt.SparseMerkleTree) error {
+
+	ver := bsmt.Version(blockHeight)
+	if accountTree.LatestVersion() > ver && !accountTree.IsEmpty() {
+		err := accountTree.Rollback(ver)
+		if err != nil {
+			return errors.Wrapf(err, "unable to rollback account tree, ver: %d", ver)
+		}
+	}
+	if nftTree.LatestVersion() > ver && !nftTree.IsEmpty() {
+		err := nftTree.Rollback(ver)
+		if err != nil {
+			return errors.Wrapf(err, "unable to rollback nft tree, ver: %d", ver)
+		}
+	}
+
+	totalTask := len(accountIndexes)
+	errChan := make(chan error, totalTask)
+	defer close(errChan)
+
+	for _, accountIndex := range accountIndexes {
+		err := func(i int64) error {
+			return pool.Submit(func() {
+				err := resetAssetTree(accountHistoryModel, blockHeight, i, assetIds, assetTrees.Get(i))
+				if err != nil {
+					errChan <- errors.Wrapf(err, "unable to reset asset tree [%d], height: %d", i, blockHeight)
+					return
+				}
+				errChan <- nil
+			})
+		}(accountIndex)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i < totalTask; i++ {
+		err := <-errChan
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func resetAssetTree(
+	accountHistoryModel account.AccountHistoryModel,
+	blockHeight int64,
+	accountIndex int64,
+	assetIds []int64,
+	assetTree bsmt.SparseMerkleTree) error {
+
+	assets := make(map[int64]*types.AccountAsset)
+	accountHistory, err := accountHistoryModel.GetLatestAccountHistory(accountIndex, blockHeight+1)
+	if err != nil && err != types.DbErrNotFound {
+		return err
+	}
+	if err == nil {
+		err = json.Unmarshal([]byte(accountHistory.AssetInfo), &assets)
+		if err != nil {
+			return types.JsonErrUnmarshal
+		}
+	}
+
+	for _, assetId := range assetIds {
+		hashVal := NilAccountAssetNodeHash
+		if asset, ok := assets[assetId]; ok {
+			hashVal, err = AssetToNode(asset.Balance.String(), asset.OfferCanceledOrFinalized.String())
+			if err != nil {
+				return err
+			}
+		}
+		err = assetTree.Set(uint64(assetId), hashVal)
+		if err != nil {
+			return err
+		}
+	}
+
+	version := assetTree.LatestVersion()
+	_, err = assetTree.Commit(&version)
+	if err != nil {
+		return err
+	}
+
+	if accountHistory != nil && common.Bytes2Hex(assetTree.Root()) != accountHistory.AssetRoot {
+		return errors.New("asset root mismatch after reset")
+	}
+	return nil
+}
+
 func ComputeAccountLeafHash(
 	accountNameHash string,
 	pk string,
@@ -15,6 +15,7 @@ var (
 	DbErrSqlOperation                = errors.New("unknown sql operation error")
 	DbErrFailToCreateBlock           = errors.New("fail to create block")
 	DbErrFailToUpdateBlock           = errors.New("fail to update block")
+	DbErrFailToCreateTx              = errors.New("fail to create tx")
 	DbErrFailToUpdateTx              = errors.New("fail to update tx")
 	DbErrFailToCreateCompressedBlock = errors.New("fail to create compressed block")
 	DbErrFailToCreateProof           = errors.New("fail to create proof")
@@ -32,11 +33,17 @@ var (
 	DbErrFailToCreatePoolTx          = errors.New("fail to create pool tx")
 	DbErrFailToUpdatePoolTx          = errors.New("fail to update pool tx")
 	DbErrFailToDeletePoolTx          = errors.New("fail to delete pool tx")
+	DbErrFailToReplacePoolTx         = errors.New("fail to replace pool tx")
 	DbErrFailToCreateNft             = errors.New("fail to create nft")
 	DbErrFailToUpdateNft             = errors.New("fail to update nft")
 	DbErrFailToCreateNftHistory      = errors.New("fail to create nft history")
 	DbErrFailToCreatePriorityRequest = errors.New("fail to create priority request")
 	DbErrFailToUpdatePriorityRequest = errors.New("fail to update priority request")
+	DbErrFailToCreateRollback        = errors.New("fail to create rollback")
+	DbErrFailToUpdateRollback        = errors.New("fail to update rollback")
+	DbErrFailToCreateOffer           = errors.New("fail to create offer")
+	DbErrFailToCreateGasFee          = errors.New("fail to create gas fee")
+	DbErrFailToCreateWithdrawal      = errors.New("fail to create withdrawal")
 
 	JsonErrUnmarshal = errors.New("json.Unmarshal err")
 	JsonErrMarshal   = errors.New("json.Marshal err")
@@ -53,6 +60,9 @@ var (
 	AppErrInvalidGasAsset = New(25003, "invalid gas asset")
 	AppErrInvalidTxType   = New(25004, "invalid tx type")
 	AppErrTooManyTxs      = New(25005, "too many pending txs")
+	AppErrTxUnderpriced   = New(25006, "replacement tx underpriced")
+	AppErrInvalidOffer    = New(25007, "invalid offer: ")
+	AppErrNoMerkleProof   = New(25008, "merkle proof is not available")
 	AppErrNotFound        = New(29404, "not found")
 	AppErrInternal        = New(29500, "internal server error")
 )
@@ -0,0 +1,119 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const (
+	InitialGasAssetsVersion = 1
+)
+
+var (
+	// InitialGasAssetIds are the gas assets of the genesis, BNB and the first BEP20 asset.
+	InitialGasAssetIds = []int64{0, 1}
+)
+
+// GasAssets is a version of the gas asset set, which is stored in the SysGasAssets sysconfig
+// with all the previous versions. The gas assets are compiled into the circuits, so every
+// block records the version it's committed with, and is proved by the circuit of the version.
+type GasAssets struct {
+	Version  int64
+	AssetIds []int64
+}
+
+func (g *GasAssets) Contains(assetId int64) bool {
+	for _, gasAssetId := range g.AssetIds {
+		if gasAssetId == assetId {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseGasAssetsVersions parses the value of the SysGasAssets sysconfig, the versions are
+// in ascending order and the last one is in effect for the new blocks.
+func ParseGasAssetsVersions(value string) (versions []*GasAssets, err error) {
+	err = json.Unmarshal([]byte(value), &versions)
+	if err != nil {
+		return nil, JsonErrUnmarshal
+	}
+	if len(versions) == 0 {
+		return nil, errors.New("no gas assets version")
+	}
+	for i := 1; i < len(versions); i++ {
+		if versions[i].Version <= versions[i-1].Version {
+			return nil, errors.New("gas assets versions are not sorted")
+		}
+	}
+	return versions, nil
+}
+
+// ParseGasAssets parses the value of the SysGasAssets sysconfig and returns the given version,
+// the latest version is returned if the version is NilGasAssetsVersion.
+func ParseGasAssets(value string, version int64) (*GasAssets, error) {
+	versions, err := ParseGasAssetsVersions(value)
+	if err != nil {
+		return nil, err
+	}
+	if version == NilGasAssetsVersion {
+		return versions[len(versions)-1], nil
+	}
+	for _, gasAssets := range versions {
+		if gasAssets.Version == version {
+			return gasAssets, nil
+		}
+	}
+	return nil, fmt.Errorf("gas assets version %d not found", version)
+}
+
+// AppendGasAssets validates the new version of the gas assets and appends it to the value of
+// the SysGasAssets sysconfig. The version must be above the latest one, since the versions of
+// the committed blocks can't be changed.
+func AppendGasAssets(value string, gasAssets *GasAssets) (string, error) {
+	versions, err := ParseGasAssetsVersions(value)
+	if err != nil {
+		return "", err
+	}
+	latest := versions[len(versions)-1]
+	if gasAssets.Version <= latest.Version {
+		return "", fmt.Errorf("gas assets version %d is not above the latest version %d", gasAssets.Version, latest.Version)
+	}
+	if len(gasAssets.AssetIds) == 0 {
+		return "", errors.New("no gas asset")
+	}
+	seen := make(map[int64]bool, len(gasAssets.AssetIds))
+	for _, assetId := range gasAssets.AssetIds {
+		if assetId < 0 {
+			return "", fmt.Errorf("invalid gas asset %d", assetId)
+		}
+		if seen[assetId] {
+			return "", fmt.Errorf("duplicated gas asset %d", assetId)
+		}
+		seen[assetId] = true
+	}
+
+	newValue, err := json.Marshal(append(versions, gasAssets))
+	if err != nil {
+		return "", JsonErrMarshal
+	}
+	return string(newValue), nil
+}
@@ -0,0 +1,35 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendGasAssets(t *testing.T) {
+	value := `[{"Version":1,"AssetIds":[0,1]}]`
+
+	newValue, err := AppendGasAssets(value, &GasAssets{Version: 2, AssetIds: []int64{0, 1, 2}})
+	require.NoError(t, err)
+	assert.Equal(t, `[{"Version":1,"AssetIds":[0,1]},{"Version":2,"AssetIds":[0,1,2]}]`, newValue)
+	gasAssets, err := ParseGasAssets(newValue, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{0, 1}, gasAssets.AssetIds)
+	gasAssets, err = ParseGasAssets(newValue, NilGasAssetsVersion)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), gasAssets.Version)
+
+	for _, invalid := range []*GasAssets{
+		{Version: 1, AssetIds: []int64{0, 1, 2}},
+		{Version: 0, AssetIds: []int64{0}},
+		{Version: 2},
+		{Version: 2, AssetIds: []int64{0, -1}},
+		{Version: 2, AssetIds: []int64{0, 1, 0}},
+	} {
+		_, err = AppendGasAssets(value, invalid)
+		assert.Error(t, err, "version %d, asset ids %v", invalid.Version, invalid.AssetIds)
+	}
+	_, err = AppendGasAssets(`[]`, &GasAssets{Version: 1, AssetIds: []int64{0}})
+	assert.Error(t, err)
+}